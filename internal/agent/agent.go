@@ -17,6 +17,7 @@ import (
 	"log/slog"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -36,6 +37,8 @@ import (
 	"github.com/charmbracelet/crush/internal/agentstatus"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/hooks"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/permission"
 	"github.com/charmbracelet/crush/internal/session"
@@ -61,6 +64,16 @@ var titlePrompt []byte
 //go:embed templates/summary.md
 var summaryPrompt []byte
 
+// worktreeInstructions is appended to the system prompt of sessions that run
+// in their own git worktree.
+const worktreeInstructions = `
+
+<worktree>
+This session runs in an isolated git worktree at %s. Treat it as the working
+directory: relative paths resolve against it and commands run in it. Do not
+modify files outside of it.
+</worktree>`
+
 // Used to remove <think> tags from generated titles.
 var thinkTagRegex = regexp.MustCompile(`<think>.*?</think>`)
 
@@ -78,6 +91,19 @@ type SessionAgentCall struct {
 	TopK             *int64
 	FrequencyPenalty *float64
 	PresencePenalty  *float64
+	// MaxSteps stops the run with ErrBudgetExceeded once the agent has
+	// taken this many steps. 0 means no limit.
+	MaxSteps int
+	// MaxCostUSD stops the run with ErrBudgetExceeded once the run has cost
+	// this much. 0 means no limit.
+	MaxCostUSD float64
+	// Model, when set, is used instead of the agent's large model.
+	Model *Model
+	// Resume runs the session's last turn again instead of starting a new
+	// one. The prompt is taken from the last user message and the steps
+	// recorded after it are kept, so another model can pick up a turn that
+	// failed.
+	Resume bool
 }
 
 type SessionAgent interface {
@@ -93,8 +119,11 @@ type SessionAgent interface {
 	QueuedPrompts(sessionID string) int
 	QueuedPromptsList(sessionID string) []string
 	ClearQueue(sessionID string)
-	Summarize(context.Context, string, fantasy.ProviderOptions) error
+	// Summarize summarizes the history of the session, paying special
+	// attention to the instructions when they aren't empty.
+	Summarize(ctx context.Context, sessionID string, opts fantasy.ProviderOptions, instructions string) error
 	Model() Model
+	SmallModel() Model
 }
 
 type Model struct {
@@ -113,8 +142,12 @@ type sessionAgent struct {
 	isSubAgent           bool
 	sessions             session.Service
 	messages             message.Service
+	history              history.Service
+	hooks                *hooks.Runner
 	disableAutoSummarize bool
 	isYolo               bool
+	compaction           config.Compaction
+	summaryPrompt        string
 
 	statusReporter *StatusReporter
 
@@ -133,6 +166,18 @@ type SessionAgentOptions struct {
 	Sessions             session.Service
 	Messages             message.Service
 	Tools                []fantasy.AgentTool
+	// History, when set, is used to checkpoint the session's files at every
+	// user message so turns can be reverted.
+	History history.Service
+	// Hooks, when set, runs the session_start, user_prompt_submit and stop
+	// hooks. Sub-agents don't run them.
+	Hooks *hooks.Runner
+	// Compaction configures how the history is compacted when it gets close
+	// to the context window of the model.
+	Compaction config.Compaction
+	// SummaryPrompt, when set, replaces the system prompt the history is
+	// summarized with.
+	SummaryPrompt string
 }
 
 func NewSessionAgent(
@@ -146,16 +191,20 @@ func NewSessionAgent(
 		isSubAgent:           opts.IsSubAgent,
 		sessions:             opts.Sessions,
 		messages:             opts.Messages,
+		history:              opts.History,
+		hooks:                opts.Hooks,
 		disableAutoSummarize: opts.DisableAutoSummarize,
 		tools:                csync.NewSliceFrom(opts.Tools),
 		isYolo:               opts.IsYolo,
+		compaction:           opts.Compaction,
+		summaryPrompt:        cmp.Or(opts.SummaryPrompt, string(summaryPrompt)),
 		messageQueue:         csync.NewMap[string, []SessionAgentCall](),
 		activeRequests:       csync.NewMap[string, context.CancelFunc](),
 	}
 }
 
 func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy.AgentResult, error) {
-	if call.Prompt == "" && !message.ContainsTextAttachment(call.Attachments) {
+	if call.Prompt == "" && !message.ContainsTextAttachment(call.Attachments) && !call.Resume {
 		return nil, ErrEmptyPrompt
 	}
 	if call.SessionID == "" {
@@ -176,6 +225,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	// Copy mutable fields under lock to avoid races with SetTools/SetModels.
 	agentTools := a.tools.Copy()
 	largeModel := a.largeModel.Get()
+	if call.Model != nil {
+		largeModel = *call.Model
+	}
 	systemPrompt := a.systemPrompt.Get()
 	promptPrefix := a.systemPromptPrefix.Get()
 	var instructions strings.Builder
@@ -194,6 +246,20 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		systemPrompt += "\n\n<mcp-instructions>\n" + s + "\n</mcp-instructions>"
 	}
 
+	currentSession, err := a.sessions.Get(ctx, call.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Root the tools in the session's worktree, if it has one. Sub-agents
+	// inherit the working directory of their parent through the context.
+	if currentSession.WorktreePath != "" {
+		ctx = context.WithValue(ctx, tools.WorkingDirContextKey, currentSession.WorktreePath)
+	}
+	if workingDir := tools.GetWorkingDirFromContext(ctx); workingDir != "" {
+		systemPrompt += fmt.Sprintf(worktreeInstructions, workingDir)
+	}
+
 	// Start telemetry span for agent run.
 	ctx, span := telemetry.StartSpan(ctx, telemetry.SpanAgentRun,
 		trace.WithAttributes(
@@ -213,22 +279,45 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		largeModel.Model,
 		fantasy.WithSystemPrompt(systemPrompt),
 		fantasy.WithTools(agentTools...),
+		// Models retry according to their provider's policy.
+		fantasy.WithMaxRetries(0),
 	)
 
 	sessionLock := sync.Mutex{}
-	currentSession, err := a.sessions.Get(ctx, call.SessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get session: %w", err)
-	}
-
 	msgs, err := a.getSessionMessages(ctx, currentSession)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get session messages: %w", err)
 	}
 
+	var resumed []message.Message
+	if call.Resume {
+		var userMsg message.Message
+		msgs, userMsg, resumed, err = splitLastTurn(msgs)
+		if err != nil {
+			return nil, err
+		}
+		resumed, err = a.dropFailedSteps(ctx, resumed)
+		if err != nil {
+			return nil, err
+		}
+		call.Prompt = userMsg.Content().Text
+		call.Attachments = userMsg.Attachments()
+	}
+
+	prompt := message.PromptWithTextAttachments(call.Prompt, call.Attachments)
+	if !call.Resume {
+		hookContext, err := a.runPromptHooks(ctx, call, len(msgs) == 0)
+		if err != nil {
+			return nil, err
+		}
+		if hookContext != "" {
+			prompt += "\n\n" + formatHookContext(hookContext)
+		}
+	}
+
 	var wg sync.WaitGroup
 	// Generate title if first message.
-	if len(msgs) == 0 {
+	if len(msgs) == 0 && !call.Resume {
 		titleCtx := ctx // Copy to avoid race with ctx reassignment below.
 		wg.Go(func() {
 			a.generateTitle(titleCtx, call.SessionID, call.Prompt)
@@ -237,9 +326,10 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	defer wg.Wait()
 
 	// Add the user message to the session.
-	_, err = a.createUserMessage(ctx, call)
-	if err != nil {
-		return nil, err
+	if !call.Resume {
+		if _, err := a.createUserMessage(ctx, call); err != nil {
+			return nil, err
+		}
 	}
 
 	// Add the session to the context.
@@ -252,14 +342,17 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	defer a.activeRequests.Del(call.SessionID)
 
 	history, files := a.preparePrompt(msgs, call.Attachments...)
+	resumedSteps := historyMessages(resumed)
 
 	startTime := time.Now()
 	a.eventPromptSent(call.SessionID)
 
 	var currentAssistant *message.Message
 	var shouldSummarize bool
+	var budgetExceeded string
+	startCost := currentSession.Cost
 	result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
-		Prompt:           message.PromptWithTextAttachments(call.Prompt, call.Attachments),
+		Prompt:           prompt,
 		Files:            files,
 		Messages:         history,
 		ProviderOptions:  call.ProviderOptions,
@@ -271,6 +364,9 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		FrequencyPenalty: call.FrequencyPenalty,
 		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
 			prepared.Messages = options.Messages
+			if options.StepNumber == 0 {
+				prepared.Messages = append(prepared.Messages, resumedSteps...)
+			}
 			for i := range prepared.Messages {
 				prepared.Messages[i].ProviderOptions = nil
 			}
@@ -278,11 +374,22 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			queuedCalls, _ := a.messageQueue.Get(call.SessionID)
 			a.messageQueue.Del(call.SessionID)
 			for _, queued := range queuedCalls {
+				hookContext, hookErr := a.runPromptHooks(callContext, queued, false)
+				if errors.Is(hookErr, ErrPromptBlocked) {
+					slog.Info("Dropped queued prompt", "session_id", call.SessionID, "reason", hookErr)
+					continue
+				}
+				if hookErr != nil {
+					return callContext, prepared, hookErr
+				}
 				userMessage, createErr := a.createUserMessage(callContext, queued)
 				if createErr != nil {
 					return callContext, prepared, createErr
 				}
 				prepared.Messages = append(prepared.Messages, userMessage.ToAIMessage()...)
+				if hookContext != "" {
+					prepared.Messages = append(prepared.Messages, fantasy.NewUserMessage(formatHookContext(hookContext)))
+				}
 			}
 
 			prepared.Messages = a.workaroundProviderMediaLimitations(prepared.Messages, largeModel)
@@ -372,9 +479,6 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 			currentAssistant.AddToolCall(toolCall)
 			return a.messages.Update(genCtx, *currentAssistant)
 		},
-		OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
-			// TODO: implement
-		},
 		OnToolCall: func(tc fantasy.ToolCallContent) error {
 			// Report tool start to status reporter.
 			if a.statusReporter != nil {
@@ -458,18 +562,17 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				cw := int64(largeModel.CatwalkCfg.ContextWindow)
 				tokens := currentSession.CompletionTokens + currentSession.PromptTokens
 				remaining := cw - tokens
-				var threshold int64
-				if cw > largeContextWindowThreshold {
-					threshold = largeContextWindowBuffer
-				} else {
-					threshold = int64(float64(cw) * smallContextWindowRatio)
-				}
+				threshold := compactionThreshold(cw, a.compaction.Threshold)
 				if (remaining <= threshold) && !a.disableAutoSummarize {
 					shouldSummarize = true
 					return true
 				}
 				return false
 			},
+			func(steps []fantasy.StepResult) bool {
+				budgetExceeded = checkBudget(call, steps, currentSession.Cost-startCost)
+				return budgetExceeded != ""
+			},
 		},
 	})
 
@@ -580,9 +683,17 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		return nil, err
 	}
 
+	if budgetExceeded != "" {
+		currentAssistant.AddFinish(message.FinishReasonError, "Budget exceeded", stringext.Capitalize(budgetExceeded))
+		if updateErr := a.messages.Update(ctx, *currentAssistant); updateErr != nil {
+			return nil, updateErr
+		}
+		return result, fmt.Errorf("%w: %s", ErrBudgetExceeded, budgetExceeded)
+	}
+
 	if shouldSummarize {
 		a.activeRequests.Del(call.SessionID)
-		if summarizeErr := a.Summarize(genCtx, call.SessionID, call.ProviderOptions); summarizeErr != nil {
+		if summarizeErr := a.compact(genCtx, call.SessionID, call.ProviderOptions); summarizeErr != nil {
 			return nil, summarizeErr
 		}
 		// If the agent wasn't done...
@@ -601,6 +712,8 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	a.activeRequests.Del(call.SessionID)
 	cancel()
 
+	a.runStopHooks(ctx, call.SessionID)
+
 	queuedMessages, ok := a.messageQueue.Get(call.SessionID)
 	if !ok || len(queuedMessages) == 0 {
 		return result, err
@@ -611,7 +724,84 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	return a.Run(ctx, firstQueuedMessage)
 }
 
-func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fantasy.ProviderOptions) error {
+// checkBudget returns why the run should stop if it's over its step or cost
+// budget, and an empty string otherwise. A run that's about to end anyway is
+// never over budget.
+func checkBudget(call SessionAgentCall, steps []fantasy.StepResult, spent float64) string {
+	// Only a step that asked for tool calls would be followed by another.
+	if len(steps) == 0 || steps[len(steps)-1].FinishReason != fantasy.FinishReasonToolCalls {
+		return ""
+	}
+	if call.MaxSteps > 0 && len(steps) >= call.MaxSteps {
+		return fmt.Sprintf("reached the limit of %d steps", call.MaxSteps)
+	}
+	if call.MaxCostUSD > 0 && spent >= call.MaxCostUSD {
+		return fmt.Sprintf("spent $%.2f of the $%.2f limit", spent, call.MaxCostUSD)
+	}
+	return ""
+}
+
+// runPromptHooks runs the session_start hooks for the first prompt of a
+// session and the user_prompt_submit hooks for every prompt, returning the
+// context they add. It fails with ErrPromptBlocked when a hook blocks the
+// prompt.
+func (a *sessionAgent) runPromptHooks(ctx context.Context, call SessionAgentCall, first bool) (string, error) {
+	if a.isSubAgent {
+		return "", nil
+	}
+	input := hooks.Input{
+		SessionID:  call.SessionID,
+		WorkingDir: tools.GetWorkingDirFromContext(ctx),
+	}
+
+	var contexts []string
+	if first {
+		input.Event = hooks.SessionStart
+		result, err := a.hooks.Run(ctx, input)
+		if err != nil {
+			return "", err
+		}
+		if result.Context != "" {
+			contexts = append(contexts, result.Context)
+		}
+	}
+
+	input.Event = hooks.UserPromptSubmit
+	input.Prompt = call.Prompt
+	result, err := a.hooks.Run(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	if result.Blocked {
+		return "", fmt.Errorf("%w: %s", ErrPromptBlocked, result.Reason)
+	}
+	if result.Context != "" {
+		contexts = append(contexts, result.Context)
+	}
+	return strings.Join(contexts, "\n\n"), nil
+}
+
+// formatHookContext marks context added by hooks for the model.
+func formatHookContext(hookContext string) string {
+	return "<hook_context>\n" + hookContext + "\n</hook_context>"
+}
+
+// runStopHooks runs the stop hooks once the agent is done responding.
+func (a *sessionAgent) runStopHooks(ctx context.Context, sessionID string) {
+	if a.isSubAgent {
+		return
+	}
+	_, err := a.hooks.Run(ctx, hooks.Input{
+		Event:      hooks.Stop,
+		SessionID:  sessionID,
+		WorkingDir: tools.GetWorkingDirFromContext(ctx),
+	})
+	if err != nil {
+		slog.Warn("Failed to run stop hooks", "session_id", sessionID, "error", err)
+	}
+}
+
+func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fantasy.ProviderOptions, instructions string) error {
 	if a.IsSessionBusy(sessionID) {
 		return ErrSessionBusy
 	}
@@ -633,6 +823,14 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		return nil
 	}
 
+	// Keep the latest turns verbatim if there are older ones to summarize.
+	var kept []message.Message
+	if a.compaction.Strategy == config.CompactionSummarizeOldest {
+		if start := keptTurnsStart(msgs, a.compaction.KeepTurns); start > 0 {
+			msgs, kept = msgs[:start], msgs[start:]
+		}
+	}
+
 	aiMsgs, _ := a.preparePrompt(msgs)
 
 	genCtx, cancel := context.WithCancel(ctx)
@@ -641,7 +839,8 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	defer cancel()
 
 	agent := fantasy.NewAgent(largeModel.Model,
-		fantasy.WithSystemPrompt(string(summaryPrompt)),
+		fantasy.WithSystemPrompt(a.summaryPrompt),
+		fantasy.WithMaxRetries(0),
 	)
 	summaryMessage, err := a.messages.Create(ctx, sessionID, message.CreateMessageParams{
 		Role:             message.Assistant,
@@ -653,7 +852,7 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		return err
 	}
 
-	summaryPromptText := buildSummaryPrompt(currentSession.Todos)
+	summaryPromptText := buildSummaryPrompt(currentSession.Todos, instructions)
 
 	resp, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
 		Prompt:          summaryPromptText,
@@ -718,6 +917,12 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	// Just in case, get just the last usage info.
 	usage := resp.Response.Usage
 	currentSession.SummaryMessageID = summaryMessage.ID
+	if len(kept) > 0 {
+		currentSession.SummaryKeptMessageID = kept[0].ID
+	} else {
+		currentSession.SummaryKeptMessageID = ""
+		currentSession.ElidedMessageID = ""
+	}
 	currentSession.CompletionTokens = usage.OutputTokens
 	currentSession.PromptTokens = 0
 	_, err = a.sessions.Save(genCtx, currentSession)
@@ -752,6 +957,11 @@ func (a *sessionAgent) createUserMessage(ctx context.Context, call SessionAgentC
 	if err != nil {
 		return message.Message{}, fmt.Errorf("failed to create user message: %w", err)
 	}
+	if a.history != nil {
+		if err := a.history.Checkpoint(ctx, call.SessionID, msg.ID); err != nil {
+			slog.Warn("Failed to create checkpoint", "session_id", call.SessionID, "message_id", msg.ID, "error", err)
+		}
+	}
 	return msg, nil
 }
 
@@ -766,6 +976,27 @@ If not, please feel free to ignore. Again do not mention this message to the use
 			),
 		))
 	}
+	history = append(history, historyMessages(msgs)...)
+
+	var files []fantasy.FilePart
+	for _, attachment := range attachments {
+		if attachment.IsText() {
+			continue
+		}
+		files = append(files, fantasy.FilePart{
+			Filename:  attachment.FileName,
+			Data:      attachment.Content,
+			MediaType: attachment.MimeType,
+		})
+	}
+
+	return history, files
+}
+
+// historyMessages converts the session's messages to the model's, leaving
+// out empty ones.
+func historyMessages(msgs []message.Message) []fantasy.Message {
+	var history []fantasy.Message
 	for _, m := range msgs {
 		if len(m.Parts) == 0 {
 			continue
@@ -777,20 +1008,53 @@ If not, please feel free to ignore. Again do not mention this message to the use
 		}
 		history = append(history, m.ToAIMessage()...)
 	}
+	return history
+}
 
-	var files []fantasy.FilePart
-	for _, attachment := range attachments {
-		if attachment.IsText() {
-			continue
+// splitLastTurn splits the session's messages around the last user message,
+// returning the messages before it, the message itself and the steps after
+// it.
+func splitLastTurn(msgs []message.Message) ([]message.Message, message.Message, []message.Message, error) {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == message.User {
+			return msgs[:i], msgs[i], msgs[i+1:], nil
 		}
-		files = append(files, fantasy.FilePart{
-			Filename:  attachment.FileName,
-			Data:      attachment.Content,
-			MediaType: attachment.MimeType,
-		})
 	}
+	return nil, message.Message{}, nil, ErrNothingToResume
+}
 
-	return history, files
+// dropFailedSteps deletes the assistant messages of the steps that failed,
+// which can hold partial text or reasoning, along with the results recorded
+// for their tool calls, so that resuming doesn't replay them. It returns the
+// remaining steps.
+func (a *sessionAgent) dropFailedSteps(ctx context.Context, steps []message.Message) ([]message.Message, error) {
+	failedCalls := make(map[string]bool)
+	var kept []message.Message
+	for _, step := range steps {
+		var failed bool
+		switch step.Role {
+		case message.Assistant:
+			failed = step.FinishReason() == message.FinishReasonError
+			if failed {
+				for _, tc := range step.ToolCalls() {
+					failedCalls[tc.ID] = true
+				}
+			}
+		case message.Tool:
+			results := step.ToolResults()
+			failed = len(results) > 0 && !slices.ContainsFunc(results, func(tr message.ToolResult) bool {
+				return !failedCalls[tr.ToolCallID]
+			})
+		}
+		if !failed {
+			kept = append(kept, step)
+			continue
+		}
+		if err := a.messages.Delete(ctx, step.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete failed step: %w", err)
+		}
+	}
+	return kept, nil
 }
 
 func (a *sessionAgent) getSessionMessages(ctx context.Context, session session.Session) ([]message.Message, error) {
@@ -799,20 +1063,7 @@ func (a *sessionAgent) getSessionMessages(ctx context.Context, session session.S
 		return nil, fmt.Errorf("failed to list messages: %w", err)
 	}
 
-	if session.SummaryMessageID != "" {
-		summaryMsgIndex := -1
-		for i, msg := range msgs {
-			if msg.ID == session.SummaryMessageID {
-				summaryMsgIndex = i
-				break
-			}
-		}
-		if summaryMsgIndex != -1 {
-			msgs = msgs[summaryMsgIndex:]
-			msgs[0].Role = message.User
-		}
-	}
-	return msgs, nil
+	return compactedHistory(msgs, session), nil
 }
 
 // generateTitle generates a session titled based on the initial prompt.
@@ -834,6 +1085,7 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, user
 		return fantasy.NewAgent(m,
 			fantasy.WithSystemPrompt(string(p)+"\n /no_think"),
 			fantasy.WithMaxOutputTokens(tok),
+			fantasy.WithMaxRetries(0),
 		)
 	}
 
@@ -1074,6 +1326,10 @@ func (a *sessionAgent) Model() Model {
 	return a.largeModel.Get()
 }
 
+func (a *sessionAgent) SmallModel() Model {
+	return a.smallModel.Get()
+}
+
 // convertToToolResult converts a fantasy tool result to a message tool result.
 func (a *sessionAgent) convertToToolResult(result fantasy.ToolResultContent) message.ToolResult {
 	baseResult := message.ToolResult{
@@ -1197,7 +1453,7 @@ func (a *sessionAgent) workaroundProviderMediaLimitations(messages []fantasy.Mes
 }
 
 // buildSummaryPrompt constructs the prompt text for session summarization.
-func buildSummaryPrompt(todos []session.Todo) string {
+func buildSummaryPrompt(todos []session.Todo, instructions string) string {
 	var sb strings.Builder
 	sb.WriteString("Provide a detailed summary of our conversation above.")
 	if len(todos) > 0 {
@@ -1208,5 +1464,10 @@ func buildSummaryPrompt(todos []session.Todo) string {
 		sb.WriteString("\nInclude these tasks and their statuses in your summary. ")
 		sb.WriteString("Instruct the resuming assistant to use the `todos` tool to continue tracking progress on these tasks.")
 	}
+	if instructions != "" {
+		sb.WriteString("\n\n## Focus\n\n")
+		sb.WriteString("Pay special attention to the following and preserve all of its details in your summary:\n\n")
+		sb.WriteString(instructions)
+	}
 	return sb.String()
 }
@@ -71,7 +71,8 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 			if !ok {
 				return fantasy.ToolResponse{}, errors.New("model provider not configured")
 			}
-			result, err := agent.Run(ctx, SessionAgentCall{
+			taskCfg := c.cfg.Agents[config.AgentTask]
+			result, runErr := agent.Run(ctx, SessionAgentCall{
 				SessionID:        session.ID,
 				Prompt:           params.Prompt,
 				MaxOutputTokens:  maxTokens,
@@ -81,8 +82,10 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 				TopK:             model.ModelCfg.TopK,
 				FrequencyPenalty: model.ModelCfg.FrequencyPenalty,
 				PresencePenalty:  model.ModelCfg.PresencePenalty,
+				MaxSteps:         taskCfg.MaxSteps,
+				MaxCostUSD:       taskCfg.MaxCostUSD,
 			})
-			if err != nil {
+			if runErr != nil && !errors.Is(runErr, ErrBudgetExceeded) {
 				return fantasy.NewTextErrorResponse("error generating response"), nil
 			}
 			updatedSession, err := c.sessions.Get(ctx, session.ID)
@@ -100,6 +103,24 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error saving parent session: %s", err)
 			}
-			return fantasy.NewTextResponse(result.Response.Content.Text()), nil
+			return subAgentResponse(result, runErr), nil
 		}), nil
 }
+
+// subAgentResponse builds the tool response for a finished sub-agent run.
+// When the run was stopped by its budget, the parent is told so along with
+// whatever the sub-agent had to say up to that point.
+func subAgentResponse(result *fantasy.AgentResult, runErr error) fantasy.ToolResponse {
+	var text string
+	if result != nil {
+		text = result.Response.Content.Text()
+	}
+	if runErr == nil {
+		return fantasy.NewTextResponse(text)
+	}
+	msg := fmt.Sprintf("The agent was stopped before finishing its task: %s.", runErr)
+	if text != "" {
+		msg += " Its last response was:\n\n" + text
+	}
+	return fantasy.NewTextErrorResponse(msg)
+}
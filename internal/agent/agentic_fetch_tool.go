@@ -83,6 +83,7 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				permission.CreatePermissionRequest{
 					SessionID:   validationResult.SessionID,
 					Path:        c.cfg.WorkingDir(),
+					URL:         params.URL,
 					ToolCallID:  call.ID,
 					ToolName:    tools.AgenticFetchToolName,
 					Action:      "fetch",
@@ -161,7 +162,11 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 			}
 
 			webFetchTool := tools.NewWebFetchTool(tmpDir, client)
-			webSearchTool := tools.NewWebSearchTool(client)
+			searchProvider, err := tools.NewWebSearchProvider(c.cfg.Tools.WebSearch, client, c.cfg.Resolver())
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			webSearchTool := tools.NewWebSearchTool(searchProvider)
 			fetchTools := []fantasy.AgentTool{
 				webFetchTool,
 				webSearchTool,
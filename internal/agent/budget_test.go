@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"fmt"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBudget(t *testing.T) {
+	t.Parallel()
+
+	toolSteps := func(n int) []fantasy.StepResult {
+		steps := make([]fantasy.StepResult, n)
+		for i := range steps {
+			steps[i].FinishReason = fantasy.FinishReasonToolCalls
+		}
+		return steps
+	}
+
+	tests := []struct {
+		name  string
+		call  SessionAgentCall
+		steps []fantasy.StepResult
+		spent float64
+		want  string
+	}{
+		{
+			name:  "no budget",
+			steps: toolSteps(100),
+			spent: 100,
+		},
+		{
+			name:  "under step limit",
+			call:  SessionAgentCall{MaxSteps: 3},
+			steps: toolSteps(2),
+		},
+		{
+			name:  "step limit reached",
+			call:  SessionAgentCall{MaxSteps: 3},
+			steps: toolSteps(3),
+			want:  "reached the limit of 3 steps",
+		},
+		{
+			name:  "finished on the last step",
+			call:  SessionAgentCall{MaxSteps: 3},
+			steps: append(toolSteps(2), fantasy.StepResult{Response: fantasy.Response{FinishReason: fantasy.FinishReasonStop}}),
+		},
+		{
+			name:  "under cost limit",
+			call:  SessionAgentCall{MaxCostUSD: 1},
+			steps: toolSteps(1),
+			spent: 0.5,
+		},
+		{
+			name:  "cost limit reached",
+			call:  SessionAgentCall{MaxCostUSD: 1},
+			steps: toolSteps(1),
+			spent: 1.25,
+			want:  "spent $1.25 of the $1.00 limit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, checkBudget(tt.call, tt.steps, tt.spent))
+		})
+	}
+}
+
+func TestSubAgentResponse(t *testing.T) {
+	t.Parallel()
+
+	result := &fantasy.AgentResult{
+		Response: fantasy.Response{Content: fantasy.ResponseContent{fantasy.TextContent{Text: "halfway there"}}},
+	}
+
+	resp := subAgentResponse(result, nil)
+	require.False(t, resp.IsError)
+	require.Equal(t, "halfway there", resp.Content)
+
+	resp = subAgentResponse(result, fmt.Errorf("%w: reached the limit of 5 steps", ErrBudgetExceeded))
+	require.True(t, resp.IsError)
+	require.Equal(t, "The agent was stopped before finishing its task: agent budget exceeded: reached the limit of 5 steps. Its last response was:\n\nhalfway there", resp.Content)
+}
@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+const (
+	// defaultKeepTurns is the number of latest turns the summarize_oldest
+	// and elide_tool_results strategies keep verbatim by default.
+	defaultKeepTurns = 2
+	// minElidedResultSize is the size of the smallest tool result worth
+	// eliding.
+	minElidedResultSize = 1024
+)
+
+// compactionThreshold returns how many tokens may be left in a context
+// window of contextWindow tokens before the history is compacted. percent is
+// the percentage of the window in use that triggers the compaction; when it's
+// 0, large windows keep a fixed buffer and small ones a ratio of the window.
+func compactionThreshold(contextWindow int64, percent int) int64 {
+	if percent > 0 {
+		return contextWindow * int64(100-min(percent, 100)) / 100
+	}
+	if contextWindow > largeContextWindowThreshold {
+		return largeContextWindowBuffer
+	}
+	return int64(float64(contextWindow) * smallContextWindowRatio)
+}
+
+// keptTurnsStart returns the index of the first message of the latest turns
+// of msgs, or 0 when there's nothing but a summary before them.
+func keptTurnsStart(msgs []message.Message, turns int) int {
+	if turns <= 0 {
+		turns = defaultKeepTurns
+	}
+	for i := len(msgs) - 1; i > 0; i-- {
+		if msgs[i].Role != message.User || msgs[i].IsSummaryMessage {
+			continue
+		}
+		turns--
+		if turns > 0 {
+			continue
+		}
+		if i == 1 && msgs[0].IsSummaryMessage {
+			return 0
+		}
+		return i
+	}
+	return 0
+}
+
+// compactedHistory returns the history of the session in msgs: its summary
+// followed by the messages kept verbatim and the ones after the summary, with
+// the tool results up to the elided message elided.
+func compactedHistory(msgs []message.Message, s session.Session) []message.Message {
+	if summary := messageIndex(msgs, s.SummaryMessageID); summary != -1 {
+		history := []message.Message{msgs[summary]}
+		history[0].Role = message.User
+		if kept := messageIndex(msgs[:summary], s.SummaryKeptMessageID); kept != -1 {
+			for _, msg := range msgs[kept:summary] {
+				// Previous summaries are part of the new one.
+				if !msg.IsSummaryMessage {
+					history = append(history, msg)
+				}
+			}
+		}
+		msgs = append(history, msgs[summary+1:]...)
+	}
+	if elided := messageIndex(msgs, s.ElidedMessageID); elided != -1 {
+		elideToolResults(msgs[:elided+1])
+	}
+	return msgs
+}
+
+func messageIndex(msgs []message.Message, id string) int {
+	if id == "" {
+		return -1
+	}
+	return slices.IndexFunc(msgs, func(msg message.Message) bool {
+		return msg.ID == id
+	})
+}
+
+// elidable reports whether the tool result is worth eliding.
+func elidable(result message.ToolResult) bool {
+	return !result.IsError && result.Data == "" && len(result.Content) >= minElidedResultSize
+}
+
+func hasElidable(msgs []message.Message) bool {
+	for _, msg := range msgs {
+		if msg.Role == message.Tool && slices.ContainsFunc(msg.ToolResults(), elidable) {
+			return true
+		}
+	}
+	return false
+}
+
+// elideToolResults replaces the content of the tool results of msgs worth
+// eliding with a pointer to their output in the output cache.
+func elideToolResults(msgs []message.Message) {
+	for i, msg := range msgs {
+		if msg.Role != message.Tool {
+			continue
+		}
+		parts := slices.Clone(msg.Parts)
+		for j, part := range parts {
+			result, ok := part.(message.ToolResult)
+			if !ok || !elidable(result) {
+				continue
+			}
+			result.Content = fmt.Sprintf("[The output of this tool call was elided to save context. Use output_head/output_tail/output_grep with tool_call_id=%q to explore it]", result.ToolCallID)
+			result.Metadata = ""
+			parts[j] = result
+		}
+		msgs[i].Parts = parts
+	}
+}
+
+// compact compacts the history of the session with the agent's strategy.
+func (a *sessionAgent) compact(ctx context.Context, sessionID string, opts fantasy.ProviderOptions) error {
+	if a.compaction.Strategy == config.CompactionElideToolResults {
+		elided, err := a.elide(ctx, sessionID)
+		if err != nil || elided {
+			return err
+		}
+	}
+	return a.Summarize(ctx, sessionID, opts, "")
+}
+
+// elide elides the tool results of the session but the ones of its latest
+// turns, or all of them when the latest turns are all there is to elide. It
+// reports false when there's nothing left to elide.
+func (a *sessionAgent) elide(ctx context.Context, sessionID string) (bool, error) {
+	currentSession, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get session: %w", err)
+	}
+	msgs, err := a.getSessionMessages(ctx, currentSession)
+	if err != nil {
+		return false, err
+	}
+
+	last := -1
+	if start := keptTurnsStart(msgs, a.compaction.KeepTurns); start > 0 && hasElidable(msgs[:start]) {
+		last = start - 1
+	} else if hasElidable(msgs) {
+		last = len(msgs) - 1
+	}
+	if last == -1 {
+		return false, nil
+	}
+
+	// Make sure the outputs can still be explored. Truncated ones are
+	// already in the cache, in full.
+	cache := tools.GetOutputCache()
+	for _, msg := range msgs[:last+1] {
+		if msg.Role != message.Tool {
+			continue
+		}
+		for _, result := range msg.ToolResults() {
+			if !elidable(result) {
+				continue
+			}
+			if _, ok := cache.Get(sessionID, result.ToolCallID); !ok {
+				cache.Store(sessionID, result.ToolCallID, result.Content)
+			}
+		}
+	}
+
+	currentSession.ElidedMessageID = msgs[last].ID
+	_, err = a.sessions.Save(ctx, currentSession)
+	return true, err
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactionThreshold(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, int64(20_000), compactionThreshold(1_000_000, 0))
+	require.Equal(t, int64(25_600), compactionThreshold(128_000, 0))
+	require.Equal(t, int64(200_000), compactionThreshold(1_000_000, 80))
+	require.Equal(t, int64(0), compactionThreshold(128_000, 150))
+}
+
+func userMsg(id string) message.Message {
+	return message.Message{ID: id, Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: id}}}
+}
+
+func assistantMsg(id string) message.Message {
+	return message.Message{ID: id, Role: message.Assistant, Parts: []message.ContentPart{message.TextContent{Text: id}}}
+}
+
+func summaryMsg(id string) message.Message {
+	return message.Message{ID: id, Role: message.Assistant, IsSummaryMessage: true, Parts: []message.ContentPart{message.TextContent{Text: id}}}
+}
+
+func toolMsg(id, content string) message.Message {
+	return message.Message{ID: id, Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: id + "-call", Content: content}}}
+}
+
+func messageIDs(msgs []message.Message) []string {
+	ids := make([]string, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg.ID
+	}
+	return ids
+}
+
+func TestKeptTurnsStart(t *testing.T) {
+	t.Parallel()
+
+	msgs := []message.Message{
+		userMsg("u1"), assistantMsg("a1"),
+		userMsg("u2"), assistantMsg("a2"),
+		userMsg("u3"), assistantMsg("a3"),
+	}
+	require.Equal(t, 2, keptTurnsStart(msgs, 0))
+	require.Equal(t, 4, keptTurnsStart(msgs, 1))
+	require.Equal(t, 0, keptTurnsStart(msgs, 3))
+
+	summarized := append([]message.Message{summaryMsg("s")}, msgs[2:]...)
+	summarized[0].Role = message.User
+	require.Equal(t, 0, keptTurnsStart(summarized, 2))
+	require.Equal(t, 3, keptTurnsStart(summarized, 1))
+}
+
+func TestCompactedHistory(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("x", minElidedResultSize)
+	msgs := func() []message.Message {
+		return []message.Message{
+			userMsg("u1"), assistantMsg("a1"), toolMsg("t1", large),
+			summaryMsg("s1"),
+			userMsg("u2"), assistantMsg("a2"), toolMsg("t2", large), toolMsg("t3", "small"),
+			summaryMsg("s2"),
+			userMsg("u3"), assistantMsg("a3"), toolMsg("t4", large),
+		}
+	}
+
+	t.Run("no compaction", func(t *testing.T) {
+		t.Parallel()
+		history := compactedHistory(msgs(), session.Session{})
+		require.Len(t, history, 12)
+	})
+
+	t.Run("summary", func(t *testing.T) {
+		t.Parallel()
+		history := compactedHistory(msgs(), session.Session{SummaryMessageID: "s2"})
+		require.Equal(t, []string{"s2", "u3", "a3", "t4"}, messageIDs(history))
+		require.Equal(t, message.User, history[0].Role)
+	})
+
+	t.Run("summary of the oldest turns", func(t *testing.T) {
+		t.Parallel()
+		history := compactedHistory(msgs(), session.Session{SummaryMessageID: "s2", SummaryKeptMessageID: "u2"})
+		require.Equal(t, []string{"s2", "u2", "a2", "t2", "t3", "u3", "a3", "t4"}, messageIDs(history))
+
+		history = compactedHistory(msgs(), session.Session{SummaryMessageID: "s2", SummaryKeptMessageID: "u1"})
+		require.Equal(t, []string{"s2", "u1", "a1", "t1", "u2", "a2", "t2", "t3", "u3", "a3", "t4"}, messageIDs(history))
+	})
+
+	t.Run("elided tool results", func(t *testing.T) {
+		t.Parallel()
+		original := msgs()
+		history := compactedHistory(original, session.Session{SummaryMessageID: "s2", SummaryKeptMessageID: "u2", ElidedMessageID: "t3"})
+		require.Contains(t, history[3].ToolResults()[0].Content, `tool_call_id="t2-call"`)
+		require.Equal(t, "small", history[4].ToolResults()[0].Content)
+		require.Equal(t, large, history[7].ToolResults()[0].Content)
+		require.Equal(t, large, original[6].ToolResults()[0].Content)
+	})
+
+	t.Run("elided message summarized", func(t *testing.T) {
+		t.Parallel()
+		history := compactedHistory(msgs(), session.Session{SummaryMessageID: "s2", ElidedMessageID: "t2"})
+		require.Equal(t, large, history[3].ToolResults()[0].Content)
+	})
+}
+
+func TestHasElidable(t *testing.T) {
+	t.Parallel()
+
+	large := strings.Repeat("x", minElidedResultSize)
+	require.True(t, hasElidable([]message.Message{userMsg("u"), toolMsg("t", large)}))
+	require.False(t, hasElidable([]message.Message{userMsg("u"), toolMsg("t", "small")}))
+
+	failed := toolMsg("t", large)
+	failed.Parts = []message.ContentPart{message.ToolResult{ToolCallID: "t-call", Content: large, IsError: true}}
+	require.False(t, hasElidable([]message.Message{failed}))
+
+	msgs := []message.Message{toolMsg("t", large)}
+	elideToolResults(msgs)
+	require.False(t, hasElidable(msgs))
+}
+
+func TestBuildSummaryPromptInstructions(t *testing.T) {
+	t.Parallel()
+
+	require.NotContains(t, buildSummaryPrompt(nil, ""), "## Focus")
+	prompt := buildSummaryPrompt(nil, "the parser refactoring")
+	require.Contains(t, prompt, "## Focus")
+	require.True(t, strings.HasSuffix(prompt, "the parser refactoring"))
+}
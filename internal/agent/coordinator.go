@@ -20,15 +20,23 @@ import (
 	"github.com/charmbracelet/crush/internal/agent/hyper"
 	"github.com/charmbracelet/crush/internal/agent/prompt"
 	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/agentstatus"
+	"github.com/charmbracelet/crush/internal/codeindex"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/filepathext"
 	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/hooks"
 	"github.com/charmbracelet/crush/internal/log"
 	"github.com/charmbracelet/crush/internal/lsp"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/oauth/copilot"
 	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
 	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/skills"
 	"golang.org/x/sync/errgroup"
 
 	"charm.land/fantasy/providers/anthropic"
@@ -53,9 +61,17 @@ type Coordinator interface {
 	QueuedPrompts(sessionID string) int
 	QueuedPromptsList(sessionID string) []string
 	ClearQueue(sessionID string)
-	Summarize(context.Context, string) error
+	// Summarize summarizes the history of the session, paying special
+	// attention to the instructions when they aren't empty.
+	Summarize(ctx context.Context, sessionID string, instructions string) error
 	Model() Model
 	UpdateModels(ctx context.Context) error
+	// UpdateSystemPrompt builds the system prompt again, for changes to the
+	// config it depends on, like the enabled skills.
+	UpdateSystemPrompt(ctx context.Context) error
+	// Sample generates a message with the small model for a sampling request
+	// of an MCP.
+	Sample(ctx context.Context, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error)
 
 	// Status reporting.
 	SetStatusReporter(reporter *StatusReporter)
@@ -68,9 +84,11 @@ type coordinator struct {
 	permissions permission.Service
 	history     history.Service
 	lspClients  *csync.Map[string, *lsp.Client]
+	hooks       *hooks.Runner
 
 	currentAgent SessionAgent
 	agents       map[string]SessionAgent
+	systemPrompt *prompt.Prompt
 
 	// Status reporter for agent status reporting.
 	statusReporter *StatusReporter
@@ -94,6 +112,7 @@ func NewCoordinator(
 		permissions: permissions,
 		history:     history,
 		lspClients:  lspClients,
+		hooks:       hooks.NewRunner(cfg.Hooks, cfg.WorkingDir()),
 		agents:      make(map[string]SessionAgent),
 	}
 
@@ -112,6 +131,7 @@ func NewCoordinator(
 	if err != nil {
 		return nil, err
 	}
+	c.systemPrompt = prompt
 	c.currentAgent = agent
 	c.agents[config.AgentCoder] = agent
 	return c, nil
@@ -129,10 +149,6 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 	}
 
 	model := c.currentAgent.Model()
-	maxTokens := model.CatwalkCfg.DefaultMaxTokens
-	if model.ModelCfg.MaxTokens != 0 {
-		maxTokens = model.ModelCfg.MaxTokens
-	}
 
 	if !model.CatwalkCfg.SupportsImages && attachments != nil {
 		// filter out image attachments
@@ -150,8 +166,6 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		return nil, errors.New("model provider not configured")
 	}
 
-	mergedOptions, temp, topP, topK, freqPenalty, presPenalty := mergeCallOptions(model, providerCfg)
-
 	if providerCfg.OAuthToken != nil && providerCfg.OAuthToken.IsExpired() {
 		slog.Info("Token needs to be refreshed", "provider", providerCfg.ID)
 		if err := c.refreshOAuth2Token(ctx, providerCfg); err != nil {
@@ -159,19 +173,15 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		}
 	}
 
+	// Read the budget on every run, so it can be changed after the agent
+	// was built.
+	agentCfg := c.cfg.Agents[config.AgentCoder]
+
 	run := func() (*fantasy.AgentResult, error) {
-		return c.currentAgent.Run(ctx, SessionAgentCall{
-			SessionID:        sessionID,
-			Prompt:           prompt,
-			Attachments:      attachments,
-			MaxOutputTokens:  maxTokens,
-			ProviderOptions:  mergedOptions,
-			Temperature:      temp,
-			TopP:             topP,
-			TopK:             topK,
-			FrequencyPenalty: freqPenalty,
-			PresencePenalty:  presPenalty,
-		})
+		call := newAgentCall(sessionID, model, providerCfg, agentCfg)
+		call.Prompt = prompt
+		call.Attachments = attachments
+		return c.currentAgent.Run(ctx, call)
 	}
 	result, originalErr := run()
 
@@ -194,9 +204,78 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		}
 	}
 
+	if ctx.Err() == nil && shouldFallback(originalErr) && len(model.ModelCfg.Fallbacks) > 0 {
+		return c.runFallbacks(ctx, sessionID, model, agentCfg, originalErr)
+	}
 	return result, originalErr
 }
 
+// runFallbacks resumes a turn that failed with err on the model's fallbacks,
+// in order, until one of them gets through it or fails with an error that
+// isn't worth falling back on.
+func (c *coordinator) runFallbacks(ctx context.Context, sessionID string, model Model, agentCfg config.Agent, err error) (*fantasy.AgentResult, error) {
+	failed := model
+	for _, fallback := range model.ModelCfg.Fallbacks {
+		providerCfg, ok := c.cfg.Providers.Get(fallback.Provider)
+		if !ok {
+			slog.Warn("Skipping fallback model with unconfigured provider", "provider", fallback.Provider, "model", fallback.Model)
+			continue
+		}
+		fallbackModel, buildErr := c.buildModel(ctx, providerCfg, config.SelectedModel{Model: fallback.Model, Provider: fallback.Provider}, false)
+		if buildErr != nil {
+			slog.Warn("Skipping fallback model", "provider", fallback.Provider, "model", fallback.Model, "error", buildErr)
+			continue
+		}
+		if providerCfg.OAuthToken != nil && providerCfg.OAuthToken.IsExpired() {
+			if refreshErr := c.refreshOAuth2Token(ctx, providerCfg); refreshErr != nil {
+				slog.Warn("Skipping fallback model", "provider", fallback.Provider, "model", fallback.Model, "error", refreshErr)
+				continue
+			}
+		}
+
+		reason := fallbackReason(err)
+		slog.Warn("Falling back to another model", "from", failed.ModelCfg.Model, "to", fallback.Model, "reason", reason)
+		fallbackBroker.Publish(pubsub.CreatedEvent, FallbackEvent{
+			SessionID: sessionID,
+			From:      failed.CatwalkCfg.Name,
+			To:        fallbackModel.CatwalkCfg.Name,
+			Reason:    reason,
+		})
+
+		call := newAgentCall(sessionID, fallbackModel, providerCfg, agentCfg)
+		call.Model = &fallbackModel
+		call.Resume = true
+		var result *fantasy.AgentResult
+		result, err = c.currentAgent.Run(ctx, call)
+		if ctx.Err() != nil || !shouldFallback(err) {
+			return result, err
+		}
+		failed = fallbackModel
+	}
+	return nil, err
+}
+
+// newAgentCall returns a call to the model with its configured options.
+func newAgentCall(sessionID string, model Model, providerCfg config.ProviderConfig, agentCfg config.Agent) SessionAgentCall {
+	maxTokens := model.CatwalkCfg.DefaultMaxTokens
+	if model.ModelCfg.MaxTokens != 0 {
+		maxTokens = model.ModelCfg.MaxTokens
+	}
+	mergedOptions, temp, topP, topK, freqPenalty, presPenalty := mergeCallOptions(model, providerCfg)
+	return SessionAgentCall{
+		SessionID:        sessionID,
+		MaxOutputTokens:  maxTokens,
+		ProviderOptions:  mergedOptions,
+		Temperature:      temp,
+		TopP:             topP,
+		TopK:             topK,
+		FrequencyPenalty: freqPenalty,
+		PresencePenalty:  presPenalty,
+		MaxSteps:         agentCfg.MaxSteps,
+		MaxCostUSD:       agentCfg.MaxCostUSD,
+	}
+}
+
 func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.ProviderOptions {
 	options := fantasy.ProviderOptions{}
 
@@ -317,6 +396,20 @@ func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.
 	return options
 }
 
+// readSummaryPrompt reads the custom summary prompt of the compaction, if
+// any.
+func (c *coordinator) readSummaryPrompt(compaction config.Compaction) (string, error) {
+	if compaction.SummaryPrompt == "" {
+		return "", nil
+	}
+	path := filepathext.SmartJoin(c.cfg.WorkingDir(), home.Long(compaction.SummaryPrompt))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read summary prompt: %w", err)
+	}
+	return string(content), nil
+}
+
 func mergeCallOptions(model Model, cfg config.ProviderConfig) (fantasy.ProviderOptions, *float64, *float64, *int64, *float64, *float64) {
 	modelOptions := getProviderOptions(model, cfg)
 	temp := cmp.Or(model.ModelCfg.Temperature, model.CatwalkCfg.Options.Temperature)
@@ -333,6 +426,11 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 		return nil, err
 	}
 
+	summaryPrompt, err := c.readSummaryPrompt(agent.Compaction)
+	if err != nil {
+		return nil, err
+	}
+
 	largeProviderCfg, _ := c.cfg.Providers.Get(large.ModelCfg.Provider)
 	result := NewSessionAgent(SessionAgentOptions{
 		large,
@@ -345,6 +443,10 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 		c.sessions,
 		c.messages,
 		nil,
+		c.history,
+		c.hooks,
+		agent.Compaction,
+		summaryPrompt,
 	})
 
 	c.readyWg.Go(func() error {
@@ -405,8 +507,18 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		}
 	}
 
+	embedder, err := codeindex.NewEmbedder(c.cfg)
+	if err != nil {
+		slog.Warn("Code search falls back to lexical search", "error", err)
+	}
+
+	enabledSkills := c.enabledSkills()
+	if len(enabledSkills) > 0 {
+		allTools = append(allTools, tools.NewSkillTool(enabledSkills))
+	}
+
 	allTools = append(allTools,
-		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName, c.cfg.Options.AllowUnsafeCommands),
+		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName, c.cfg.Options.AllowUnsafeCommands, c.cfg.Options.Sandbox.Shell(c.cfg.WorkingDir(), c.cfg.Options.DataDirectory)),
 		tools.NewJobOutputTool(),
 		tools.NewJobKillTool(),
 		tools.NewOutputHeadTool(),
@@ -418,15 +530,33 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), nil),
 		tools.NewGlobTool(c.cfg.WorkingDir()),
 		tools.NewGrepTool(c.cfg.WorkingDir()),
+		tools.NewCodeSearchTool(c.cfg.WorkingDir(), c.cfg.Options.DataDirectory, embedder),
 		tools.NewLsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.Ls),
 		tools.NewSourcegraphTool(nil),
 		tools.NewTodosTool(c.sessions),
-		tools.NewViewTool(c.lspClients, c.permissions, c.cfg.WorkingDir(), c.cfg.Options.SkillsPaths...),
+		tools.NewViewTool(c.lspClients, c.permissions, c.cfg.WorkingDir(), c.cfg.SkillsDirs()...),
 		tools.NewWriteTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
 	)
 
 	if len(c.cfg.LSP) > 0 {
-		allTools = append(allTools, tools.NewDiagnosticsTool(c.lspClients), tools.NewReferencesTool(c.lspClients), tools.NewLSPRestartTool(c.lspClients))
+		allTools = append(allTools,
+			tools.NewDiagnosticsTool(c.lspClients),
+			tools.NewReferencesTool(c.lspClients),
+			tools.NewDefinitionTool(c.lspClients),
+			tools.NewImplementationTool(c.lspClients),
+			tools.NewHoverTool(c.lspClients),
+			tools.NewSymbolsTool(c.lspClients),
+			tools.NewRenameTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+			tools.NewCodeActionTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+			tools.NewLSPRestartTool(c.lspClients),
+		)
+	}
+
+	if len(c.cfg.MCP) > 0 {
+		allTools = append(allTools,
+			tools.NewMCPListResourcesTool(agent.AllowedMCP),
+			tools.NewMCPReadResourceTool(agent.AllowedMCP),
+		)
 	}
 
 	var filteredTools []fantasy.AgentTool
@@ -436,6 +566,12 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		}
 	}
 
+	// Skill scripts run commands, so they're only available to agents that
+	// can run them with bash too.
+	if slices.Contains(agent.AllowedTools, tools.SkillToolName) && slices.Contains(agent.AllowedTools, tools.BashToolName) {
+		filteredTools = append(filteredTools, tools.NewSkillScriptTools(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.AllowUnsafeCommands, c.cfg.Options.Sandbox.Shell(c.cfg.WorkingDir(), c.cfg.Options.DataDirectory), enabledSkills)...)
+	}
+
 	for _, tool := range tools.GetMCPTools(c.permissions, c.cfg.WorkingDir()) {
 		if agent.AllowedMCP == nil {
 			// No MCP restrictions
@@ -461,7 +597,18 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	slices.SortFunc(filteredTools, func(a, b fantasy.AgentTool) int {
 		return strings.Compare(a.Info().Name, b.Info().Name)
 	})
-	return tools.WrapAllWithCaching(filteredTools), nil
+	return tools.WrapAllWithHooks(tools.WrapAllWithDenials(tools.WrapAllWithCaching(filteredTools)), c.hooks), nil
+}
+
+// enabledSkills returns the valid skills in the skills paths that aren't
+// disabled.
+func (c *coordinator) enabledSkills() []*skills.Skill {
+	if len(c.cfg.Options.SkillsPaths) == 0 {
+		return nil
+	}
+	return slices.DeleteFunc(skills.Discover(c.cfg.SkillsDirs()), func(skill *skills.Skill) bool {
+		return !c.cfg.SkillEnabled(skill.Name)
+	})
 }
 
 // TODO: when we support multiple agents we need to change this so that we pass in the agent specific model config
@@ -480,7 +627,7 @@ func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Mo
 		return Model{}, Model{}, errors.New("large model provider not configured")
 	}
 
-	largeProvider, err := c.buildProvider(largeProviderCfg, largeModelCfg, isSubAgent)
+	large, err := c.buildModel(ctx, largeProviderCfg, largeModelCfg, isSubAgent)
 	if err != nil {
 		return Model{}, Model{}, err
 	}
@@ -495,57 +642,67 @@ func (c *coordinator) buildAgentModels(ctx context.Context, isSubAgent bool) (Mo
 		return Model{}, Model{}, err
 	}
 
-	var largeCatwalkModel *catwalk.Model
 	var smallCatwalkModel *catwalk.Model
-
-	for _, m := range largeProviderCfg.Models {
-		if m.ID == largeModelCfg.Model {
-			largeCatwalkModel = &m
-		}
-	}
 	for _, m := range smallProviderCfg.Models {
 		if m.ID == smallModelCfg.Model {
 			smallCatwalkModel = &m
 		}
 	}
 
-	if largeCatwalkModel == nil {
-		return Model{}, Model{}, errors.New("large model not found in provider config")
-	}
-
 	if smallCatwalkModel == nil {
 		return Model{}, Model{}, errors.New("small model not found in provider config")
 	}
 
-	largeModelID := largeModelCfg.Model
 	smallModelID := smallModelCfg.Model
-
-	if largeModelCfg.Provider == openrouter.Name && isExactoSupported(largeModelID) {
-		largeModelID += ":exacto"
-	}
-
 	if smallModelCfg.Provider == openrouter.Name && isExactoSupported(smallModelID) {
 		smallModelID += ":exacto"
 	}
 
-	largeModel, err := largeProvider.LanguageModel(ctx, largeModelID)
+	smallModel, err := smallProvider.LanguageModel(ctx, smallModelID)
 	if err != nil {
 		return Model{}, Model{}, err
 	}
-	smallModel, err := smallProvider.LanguageModel(ctx, smallModelID)
+
+	smallModel = newRetryModel(smallModel, smallProviderCfg, c.reportRetry)
+
+	return large, Model{
+		Model:      smallModel,
+		CatwalkCfg: *smallCatwalkModel,
+		ModelCfg:   smallModelCfg,
+	}, nil
+}
+
+// buildModel builds the model selected by modelCfg from its provider.
+func (c *coordinator) buildModel(ctx context.Context, providerCfg config.ProviderConfig, modelCfg config.SelectedModel, isSubAgent bool) (Model, error) {
+	provider, err := c.buildProvider(providerCfg, modelCfg, isSubAgent)
 	if err != nil {
-		return Model{}, Model{}, err
+		return Model{}, err
+	}
+
+	var catwalkModel *catwalk.Model
+	for _, m := range providerCfg.Models {
+		if m.ID == modelCfg.Model {
+			catwalkModel = &m
+		}
+	}
+	if catwalkModel == nil {
+		return Model{}, fmt.Errorf("model %q not found in provider config", modelCfg.Model)
 	}
 
+	modelID := modelCfg.Model
+	if modelCfg.Provider == openrouter.Name && isExactoSupported(modelID) {
+		modelID += ":exacto"
+	}
+
+	model, err := provider.LanguageModel(ctx, modelID)
+	if err != nil {
+		return Model{}, err
+	}
 	return Model{
-			Model:      largeModel,
-			CatwalkCfg: *largeCatwalkModel,
-			ModelCfg:   largeModelCfg,
-		}, Model{
-			Model:      smallModel,
-			CatwalkCfg: *smallCatwalkModel,
-			ModelCfg:   smallModelCfg,
-		}, nil
+		Model:      newRetryModel(model, providerCfg, c.reportRetry),
+		CatwalkCfg: *catwalkModel,
+		ModelCfg:   modelCfg,
+	}, nil
 }
 
 func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string) (fantasy.Provider, error) {
@@ -822,6 +979,24 @@ func (c *coordinator) Model() Model {
 	return c.currentAgent.Model()
 }
 
+// reportRetry publishes a retry event and reports the agent as waiting
+// while it backs off.
+func (c *coordinator) reportRetry(eventType pubsub.EventType, event RetryEvent) {
+	if eventType == pubsub.UpdatedEvent {
+		slog.Warn("Retrying provider request", "provider", event.Provider, "attempt", event.Attempt, "delay", event.Delay, "error", event.Error)
+	}
+	retryBroker.Publish(eventType, event)
+
+	if c.statusReporter == nil {
+		return
+	}
+	if eventType == pubsub.UpdatedEvent {
+		c.statusReporter.SetStatus(agentstatus.StatusWaiting)
+	} else {
+		c.statusReporter.SetStatus(agentstatus.StatusWorking)
+	}
+}
+
 // SetStatusReporter sets the status reporter for agent status reporting.
 func (c *coordinator) SetStatusReporter(reporter *StatusReporter) {
 	c.statusReporter = reporter
@@ -852,6 +1027,16 @@ func (c *coordinator) UpdateModels(ctx context.Context) error {
 	return nil
 }
 
+func (c *coordinator) UpdateSystemPrompt(ctx context.Context) error {
+	model := c.currentAgent.Model()
+	systemPrompt, err := c.systemPrompt.Build(ctx, model.Model.Provider(), model.Model.Model(), *c.cfg)
+	if err != nil {
+		return err
+	}
+	c.currentAgent.SetSystemPrompt(systemPrompt)
+	return nil
+}
+
 func (c *coordinator) QueuedPrompts(sessionID string) int {
 	return c.currentAgent.QueuedPrompts(sessionID)
 }
@@ -860,12 +1045,12 @@ func (c *coordinator) QueuedPromptsList(sessionID string) []string {
 	return c.currentAgent.QueuedPromptsList(sessionID)
 }
 
-func (c *coordinator) Summarize(ctx context.Context, sessionID string) error {
+func (c *coordinator) Summarize(ctx context.Context, sessionID string, instructions string) error {
 	providerCfg, ok := c.cfg.Providers.Get(c.currentAgent.Model().ModelCfg.Provider)
 	if !ok {
 		return errors.New("model provider not configured")
 	}
-	return c.currentAgent.Summarize(ctx, sessionID, getProviderOptions(c.currentAgent.Model(), providerCfg))
+	return c.currentAgent.Summarize(ctx, sessionID, getProviderOptions(c.currentAgent.Model(), providerCfg), instructions)
 }
 
 func (c *coordinator) isUnauthorized(err error) bool {
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/hyper"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// FallbackEvent reports that a session's turn was handed to a fallback model
+// after its model failed.
+type FallbackEvent struct {
+	SessionID string
+	// From and To are the names of the model that failed and the one taking
+	// over.
+	From string
+	To   string
+	// Reason is why the model failed.
+	Reason string
+}
+
+var fallbackBroker = pubsub.NewBroker[FallbackEvent]()
+
+// SubscribeFallbackEvents returns a channel for model fallback events.
+func SubscribeFallbackEvents(ctx context.Context) <-chan pubsub.Event[FallbackEvent] {
+	return fallbackBroker.Subscribe(ctx)
+}
+
+// shouldFallback reports whether a run that failed with err should be handed
+// to a fallback model: the provider is out of credits, rate limited,
+// overloaded or failing.
+func shouldFallback(err error) bool {
+	if errors.Is(err, hyper.ErrNoCredits) {
+		return true
+	}
+	var providerErr *fantasy.ProviderError
+	if !errors.As(err, &providerErr) {
+		return false
+	}
+	return providerErr.StatusCode == http.StatusTooManyRequests || providerErr.StatusCode >= http.StatusInternalServerError
+}
+
+// fallbackReason describes the error that made the model fall back.
+func fallbackReason(err error) string {
+	if errors.Is(err, hyper.ErrNoCredits) {
+		return "out of credits"
+	}
+	var providerErr *fantasy.ProviderError
+	if errors.As(err, &providerErr) {
+		return cmp.Or(providerErr.Title, fantasy.ErrorTitleForStatusCode(providerErr.StatusCode))
+	}
+	return err.Error()
+}
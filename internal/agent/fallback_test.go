@@ -0,0 +1,216 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/agent/hyper"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "no error"},
+		{name: "canceled", err: context.Canceled},
+		{name: "other error", err: errors.New("boom")},
+		{name: "bad request", err: &fantasy.ProviderError{StatusCode: 400}},
+		{name: "unauthorized", err: &fantasy.ProviderError{StatusCode: 401}},
+		{name: "rate limited", err: &fantasy.ProviderError{StatusCode: 429}, want: true},
+		{name: "server error", err: &fantasy.ProviderError{StatusCode: 500}, want: true},
+		{name: "overloaded", err: &fantasy.ProviderError{StatusCode: 529}, want: true},
+		{name: "no credits", err: fmt.Errorf("stream: %w", hyper.ErrNoCredits), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, shouldFallback(tt.err))
+		})
+	}
+}
+
+// scriptedModel answers every request with the same text, or fails with err,
+// after streaming the text when there is one.
+type scriptedModel struct {
+	fantasy.LanguageModel
+	name    string
+	text    string
+	err     error
+	prompts []fantasy.Prompt
+}
+
+func (m *scriptedModel) Provider() string { return m.name }
+func (m *scriptedModel) Model() string    { return m.name }
+
+func (m *scriptedModel) Generate(_ context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	m.prompts = append(m.prompts, call.Prompt)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &fantasy.Response{
+		Content:      fantasy.ResponseContent{fantasy.TextContent{Text: m.text}},
+		FinishReason: fantasy.FinishReasonStop,
+	}, nil
+}
+
+func (m *scriptedModel) Stream(_ context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	m.prompts = append(m.prompts, call.Prompt)
+	return func(yield func(fantasy.StreamPart) bool) {
+		if m.err != nil {
+			if m.text != "" {
+				for _, part := range []fantasy.StreamPart{
+					{Type: fantasy.StreamPartTypeTextStart, ID: "0"},
+					{Type: fantasy.StreamPartTypeTextDelta, ID: "0", Delta: m.text},
+				} {
+					if !yield(part) {
+						return
+					}
+				}
+			}
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: m.err})
+			return
+		}
+		for _, part := range []fantasy.StreamPart{
+			{Type: fantasy.StreamPartTypeTextStart, ID: "0"},
+			{Type: fantasy.StreamPartTypeTextDelta, ID: "0", Delta: m.text},
+			{Type: fantasy.StreamPartTypeTextEnd, ID: "0"},
+			{Type: fantasy.StreamPartTypeFinish, FinishReason: fantasy.FinishReasonStop},
+		} {
+			if !yield(part) {
+				return
+			}
+		}
+	}, nil
+}
+
+func TestResumeOnFallbackModel(t *testing.T) {
+	env := testEnv(t)
+
+	primary := &scriptedModel{name: "primary", err: &fantasy.ProviderError{StatusCode: 503}}
+	small := &scriptedModel{name: "small", text: "Title"}
+	agent := testSessionAgent(env, primary, small, "You are a test agent.")
+
+	sess, err := env.sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+
+	_, err = agent.Run(t.Context(), SessionAgentCall{
+		SessionID:       sess.ID,
+		Prompt:          "Say hello",
+		MaxOutputTokens: 100,
+	})
+	require.True(t, shouldFallback(err))
+
+	fallback := &scriptedModel{name: "fallback", text: "Hello!"}
+	_, err = agent.Run(t.Context(), SessionAgentCall{
+		SessionID:       sess.ID,
+		MaxOutputTokens: 100,
+		Model: &Model{
+			Model:      fallback,
+			CatwalkCfg: catwalk.Model{ContextWindow: 200000, DefaultMaxTokens: 10000},
+			ModelCfg:   config.SelectedModel{Provider: "backup", Model: "fallback"},
+		},
+		Resume: true,
+	})
+	require.NoError(t, err)
+
+	// The prompt is sent to the fallback as the last user message, once.
+	require.Len(t, fallback.prompts, 1)
+	var userTexts []string
+	for _, msg := range fallback.prompts[0] {
+		if msg.Role != fantasy.MessageRoleUser {
+			continue
+		}
+		for _, part := range msg.Content {
+			if text, ok := part.(fantasy.TextPart); ok && text.Text == "Say hello" {
+				userTexts = append(userTexts, text.Text)
+			}
+		}
+	}
+	require.Equal(t, []string{"Say hello"}, userTexts)
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	var roles []message.MessageRole
+	for _, msg := range msgs {
+		roles = append(roles, msg.Role)
+	}
+	// The assistant message of the failed model is dropped.
+	require.Equal(t, []message.MessageRole{message.User, message.Assistant}, roles)
+
+	// Each turn records the model that produced it.
+	last := msgs[len(msgs)-1]
+	require.Equal(t, "Hello!", last.Content().Text)
+	require.Equal(t, "backup", last.Provider)
+	require.Equal(t, "fallback", last.Model)
+}
+
+func TestResumeAfterMidStreamFailure(t *testing.T) {
+	env := testEnv(t)
+
+	primary := &scriptedModel{name: "primary", text: "Partial answ", err: &fantasy.ProviderError{StatusCode: 503}}
+	small := &scriptedModel{name: "small", text: "Title"}
+	agent := testSessionAgent(env, primary, small, "You are a test agent.")
+
+	sess, err := env.sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+
+	_, err = agent.Run(t.Context(), SessionAgentCall{
+		SessionID:       sess.ID,
+		Prompt:          "Say hello",
+		MaxOutputTokens: 100,
+	})
+	require.True(t, shouldFallback(err))
+
+	msgs, err := env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, "Partial answ", msgs[1].Content().Text)
+
+	fallback := &scriptedModel{name: "fallback", text: "Hello!"}
+	_, err = agent.Run(t.Context(), SessionAgentCall{
+		SessionID:       sess.ID,
+		MaxOutputTokens: 100,
+		Model: &Model{
+			Model:      fallback,
+			CatwalkCfg: catwalk.Model{ContextWindow: 200000, DefaultMaxTokens: 10000},
+			ModelCfg:   config.SelectedModel{Provider: "backup", Model: "fallback"},
+		},
+		Resume: true,
+	})
+	require.NoError(t, err)
+
+	// The partial answer isn't replayed to the fallback.
+	require.Len(t, fallback.prompts, 1)
+	for _, msg := range fallback.prompts[0] {
+		require.NotEqual(t, fantasy.MessageRoleAssistant, msg.Role)
+	}
+
+	msgs, err = env.messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Equal(t, message.User, msgs[0].Role)
+	require.Equal(t, "Hello!", msgs[1].Content().Text)
+}
+
+func TestResumeWithoutTurn(t *testing.T) {
+	env := testEnv(t)
+	model := &scriptedModel{name: "model", text: "Hello!"}
+	agent := testSessionAgent(env, model, model, "You are a test agent.")
+
+	sess, err := env.sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+
+	_, err = agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Resume: true})
+	require.ErrorIs(t, err, ErrNothingToResume)
+}
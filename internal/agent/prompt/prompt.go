@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"text/template"
 	"time"
@@ -167,11 +168,10 @@ func (p *Prompt) promptData(ctx context.Context, provider, model string, cfg con
 	// Discover and load skills metadata.
 	var availSkillXML string
 	if len(cfg.Options.SkillsPaths) > 0 {
-		expandedPaths := make([]string, 0, len(cfg.Options.SkillsPaths))
-		for _, pth := range cfg.Options.SkillsPaths {
-			expandedPaths = append(expandedPaths, expandPath(pth, cfg))
-		}
-		if discoveredSkills := skills.Discover(expandedPaths); len(discoveredSkills) > 0 {
+		discoveredSkills := slices.DeleteFunc(skills.Discover(cfg.SkillsDirs()), func(skill *skills.Skill) bool {
+			return !cfg.SkillEnabled(skill.Name)
+		})
+		if len(discoveredSkills) > 0 {
 			availSkillXML = skills.ToPromptXML(discoveredSkills)
 		}
 	}
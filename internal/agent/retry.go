@@ -0,0 +1,230 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+const (
+	defaultRetryMaxAttempts = 5
+	defaultRetryMaxBackoff  = 60 * time.Second
+	retryInitialBackoff     = 2 * time.Second
+)
+
+// defaultRetryStatusCodes are the HTTP status codes retried when the provider
+// doesn't configure its own: timeouts, conflicts, rate limits, server errors
+// and Anthropic's "overloaded".
+var defaultRetryStatusCodes = []int{408, 409, 429, 500, 502, 503, 504, 529}
+
+// RetryEvent reports a failed provider request. An [pubsub.UpdatedEvent] is
+// published before waiting to retry, and a [pubsub.DeletedEvent] once the
+// request went through or was given up on.
+type RetryEvent struct {
+	SessionID string
+	Provider  string
+	// Attempt is the attempt that failed, starting at 1.
+	Attempt     int
+	MaxAttempts int
+	// Delay is how long until the next attempt.
+	Delay time.Duration
+	Error *fantasy.ProviderError
+}
+
+var retryBroker = pubsub.NewBroker[RetryEvent]()
+
+// SubscribeRetryEvents returns a channel for provider retry events.
+func SubscribeRetryEvents(ctx context.Context) <-chan pubsub.Event[RetryEvent] {
+	return retryBroker.Subscribe(ctx)
+}
+
+// retryPolicy decides which failed requests are retried and when.
+type retryPolicy struct {
+	maxAttempts int
+	maxBackoff  time.Duration
+	statusCodes []int
+}
+
+func newRetryPolicy(cfg *config.RetryPolicy) retryPolicy {
+	policy := retryPolicy{
+		maxAttempts: defaultRetryMaxAttempts,
+		maxBackoff:  defaultRetryMaxBackoff,
+		statusCodes: defaultRetryStatusCodes,
+	}
+	if cfg == nil {
+		return policy
+	}
+	if cfg.MaxAttempts > 0 {
+		policy.maxAttempts = cfg.MaxAttempts
+	}
+	if cfg.MaxBackoff > 0 {
+		policy.maxBackoff = time.Duration(cfg.MaxBackoff) * time.Second
+	}
+	if len(cfg.StatusCodes) > 0 {
+		policy.statusCodes = cfg.StatusCodes
+	}
+	return policy
+}
+
+// backoff returns how long to wait before retrying the given failed attempt,
+// and false if it shouldn't be retried. The delay doubles with every attempt
+// unless the provider asked for a specific one, and never exceeds the
+// policy's maximum.
+func (p retryPolicy) backoff(attempt int, err error) (time.Duration, bool) {
+	var providerErr *fantasy.ProviderError
+	if attempt >= p.maxAttempts || !errors.As(err, &providerErr) || !slices.Contains(p.statusCodes, providerErr.StatusCode) {
+		return 0, false
+	}
+	delay := retryInitialBackoff << (attempt - 1)
+	if after, ok := retryAfter(providerErr.ResponseHeaders); ok {
+		delay = after
+	}
+	return min(delay, p.maxBackoff), true
+}
+
+// retryAfter returns the delay requested by the retry-after-ms or
+// retry-after response headers.
+func retryAfter(headers map[string]string) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(headers["retry-after-ms"], 64); err == nil && ms >= 0 {
+		return time.Duration(ms * float64(time.Millisecond)), true
+	}
+	value := headers["retry-after"]
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if t, err := time.Parse(time.RFC1123, value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// retryModel is a language model that retries failed requests according to
+// its provider's retry policy and reports every retry. Streams are only
+// retried if they fail before producing any content.
+type retryModel struct {
+	fantasy.LanguageModel
+	provider string
+	policy   retryPolicy
+	// onRetry is called with an update before waiting to retry, and with a
+	// delete once a request that was retried is over.
+	onRetry func(pubsub.EventType, RetryEvent)
+}
+
+func newRetryModel(model fantasy.LanguageModel, providerCfg config.ProviderConfig, onRetry func(pubsub.EventType, RetryEvent)) *retryModel {
+	return &retryModel{
+		LanguageModel: model,
+		provider:      providerCfg.ID,
+		policy:        newRetryPolicy(providerCfg.Retry),
+		onRetry:       onRetry,
+	}
+}
+
+// Generate implements fantasy.LanguageModel.
+func (m *retryModel) Generate(ctx context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	var retried bool
+	defer m.finish(ctx, &retried)
+	for attempt := 1; ; attempt++ {
+		resp, err := m.LanguageModel.Generate(ctx, call)
+		if err == nil || !m.retry(ctx, attempt, err, &retried) {
+			return resp, err
+		}
+	}
+}
+
+// Stream implements fantasy.LanguageModel.
+func (m *retryModel) Stream(ctx context.Context, call fantasy.Call) (fantasy.StreamResponse, error) {
+	return func(yield func(fantasy.StreamPart) bool) {
+		var retried bool
+		defer m.finish(ctx, &retried)
+		for attempt := 1; ; attempt++ {
+			err := m.stream(ctx, call, yield)
+			if err == nil {
+				return
+			}
+			if !m.retry(ctx, attempt, err, &retried) {
+				yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: err})
+				return
+			}
+		}
+	}, nil
+}
+
+// stream runs one attempt, forwarding its parts and holding back warnings
+// until content arrives. If the attempt fails before any content, the error
+// is returned instead of forwarded so the attempt can be retried.
+func (m *retryModel) stream(ctx context.Context, call fantasy.Call, yield func(fantasy.StreamPart) bool) error {
+	stream, err := m.LanguageModel.Stream(ctx, call)
+	if err != nil {
+		return err
+	}
+	var pending []fantasy.StreamPart
+	started := false
+	for part := range stream {
+		if !started {
+			switch part.Type {
+			case fantasy.StreamPartTypeWarnings:
+				pending = append(pending, part)
+				continue
+			case fantasy.StreamPartTypeError:
+				return part.Error
+			}
+			started = true
+			for _, p := range pending {
+				if !yield(p) {
+					return nil
+				}
+			}
+		}
+		if !yield(part) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// retry reports the failed attempt and waits before the next one. It
+// returns false if the error isn't retried or the context is done first.
+func (m *retryModel) retry(ctx context.Context, attempt int, err error, retried *bool) bool {
+	delay, ok := m.policy.backoff(attempt, err)
+	if !ok {
+		return false
+	}
+	*retried = true
+	var providerErr *fantasy.ProviderError
+	errors.As(err, &providerErr)
+	m.onRetry(pubsub.UpdatedEvent, RetryEvent{
+		SessionID:   tools.GetSessionFromContext(ctx),
+		Provider:    m.provider,
+		Attempt:     attempt,
+		MaxAttempts: m.policy.maxAttempts,
+		Delay:       delay,
+		Error:       providerErr,
+	})
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// finish reports the end of a request that was retried.
+func (m *retryModel) finish(ctx context.Context, retried *bool) {
+	if !*retried {
+		return
+	}
+	m.onRetry(pubsub.DeletedEvent, RetryEvent{
+		SessionID: tools.GetSessionFromContext(ctx),
+		Provider:  m.provider,
+	})
+}
@@ -0,0 +1,240 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	rateLimited := &fantasy.ProviderError{StatusCode: 429}
+
+	t.Run("doubles per attempt", func(t *testing.T) {
+		t.Parallel()
+		policy := newRetryPolicy(nil)
+		for attempt, want := range []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second} {
+			delay, ok := policy.backoff(attempt+1, rateLimited)
+			require.True(t, ok)
+			require.Equal(t, want, delay)
+		}
+	})
+
+	t.Run("capped by max backoff", func(t *testing.T) {
+		t.Parallel()
+		policy := newRetryPolicy(&config.RetryPolicy{MaxAttempts: 10, MaxBackoff: 5})
+		delay, ok := policy.backoff(4, rateLimited)
+		require.True(t, ok)
+		require.Equal(t, 5*time.Second, delay)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		t.Parallel()
+		policy := newRetryPolicy(&config.RetryPolicy{MaxAttempts: 2})
+		_, ok := policy.backoff(1, rateLimited)
+		require.True(t, ok)
+		_, ok = policy.backoff(2, rateLimited)
+		require.False(t, ok)
+	})
+
+	t.Run("only configured status codes", func(t *testing.T) {
+		t.Parallel()
+		policy := newRetryPolicy(&config.RetryPolicy{StatusCodes: []int{503}})
+		_, ok := policy.backoff(1, rateLimited)
+		require.False(t, ok)
+		_, ok = policy.backoff(1, &fantasy.ProviderError{StatusCode: 503})
+		require.True(t, ok)
+	})
+
+	t.Run("not provider errors", func(t *testing.T) {
+		t.Parallel()
+		_, ok := newRetryPolicy(nil).backoff(1, errors.New("boom"))
+		require.False(t, ok)
+	})
+
+	t.Run("respects retry after", func(t *testing.T) {
+		t.Parallel()
+		delay, ok := newRetryPolicy(nil).backoff(1, &fantasy.ProviderError{
+			StatusCode:      429,
+			ResponseHeaders: map[string]string{"retry-after": "7"},
+		})
+		require.True(t, ok)
+		require.Equal(t, 7*time.Second, delay)
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    time.Duration
+		ok      bool
+	}{
+		{name: "none"},
+		{name: "milliseconds", headers: map[string]string{"retry-after-ms": "1500"}, want: 1500 * time.Millisecond, ok: true},
+		{name: "seconds", headers: map[string]string{"retry-after": "3"}, want: 3 * time.Second, ok: true},
+		{name: "milliseconds win", headers: map[string]string{"retry-after-ms": "10", "retry-after": "3"}, want: 10 * time.Millisecond, ok: true},
+		{name: "past date", headers: map[string]string{"retry-after": "Mon, 02 Jan 2006 15:04:05 GMT"}, ok: true},
+		{name: "garbage", headers: map[string]string{"retry-after": "soon"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := retryAfter(tt.headers)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// flakyModel fails the first failures requests with a rate limit error.
+type flakyModel struct {
+	fantasy.LanguageModel
+	failures int
+	calls    int
+	// midStream makes streams fail after producing content.
+	midStream bool
+}
+
+func (m *flakyModel) err() error {
+	return &fantasy.ProviderError{
+		StatusCode:      429,
+		ResponseHeaders: map[string]string{"retry-after-ms": "0"},
+	}
+}
+
+func (m *flakyModel) Generate(context.Context, fantasy.Call) (*fantasy.Response, error) {
+	m.calls++
+	if m.calls <= m.failures {
+		return nil, m.err()
+	}
+	return &fantasy.Response{Content: fantasy.ResponseContent{fantasy.TextContent{Text: "ok"}}}, nil
+}
+
+func (m *flakyModel) Stream(context.Context, fantasy.Call) (fantasy.StreamResponse, error) {
+	m.calls++
+	failing := m.calls <= m.failures
+	return func(yield func(fantasy.StreamPart) bool) {
+		if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeWarnings}) {
+			return
+		}
+		if failing && !m.midStream {
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: m.err()})
+			return
+		}
+		if !yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeTextDelta, Delta: "ok"}) {
+			return
+		}
+		if failing {
+			yield(fantasy.StreamPart{Type: fantasy.StreamPartTypeError, Error: m.err()})
+		}
+	}, nil
+}
+
+type retryRecorder struct {
+	types  []pubsub.EventType
+	events []RetryEvent
+}
+
+func (r *retryRecorder) record(eventType pubsub.EventType, event RetryEvent) {
+	r.types = append(r.types, eventType)
+	r.events = append(r.events, event)
+}
+
+func newTestRetryModel(model fantasy.LanguageModel, policy *config.RetryPolicy, recorder *retryRecorder) *retryModel {
+	return newRetryModel(model, config.ProviderConfig{ID: "test", Retry: policy}, recorder.record)
+}
+
+func TestRetryModelGenerate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("retries until success", func(t *testing.T) {
+		t.Parallel()
+		var recorder retryRecorder
+		flaky := &flakyModel{failures: 2}
+		resp, err := newTestRetryModel(flaky, nil, &recorder).Generate(t.Context(), fantasy.Call{})
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp.Content.Text())
+		require.Equal(t, 3, flaky.calls)
+		require.Equal(t, []pubsub.EventType{pubsub.UpdatedEvent, pubsub.UpdatedEvent, pubsub.DeletedEvent}, recorder.types)
+		require.Equal(t, 2, recorder.events[1].Attempt)
+		require.Equal(t, "test", recorder.events[1].Provider)
+		require.Equal(t, 429, recorder.events[1].Error.StatusCode)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		t.Parallel()
+		var recorder retryRecorder
+		flaky := &flakyModel{failures: 5}
+		_, err := newTestRetryModel(flaky, &config.RetryPolicy{MaxAttempts: 2}, &recorder).Generate(t.Context(), fantasy.Call{})
+		var providerErr *fantasy.ProviderError
+		require.ErrorAs(t, err, &providerErr)
+		require.Equal(t, 2, flaky.calls)
+		require.Equal(t, []pubsub.EventType{pubsub.UpdatedEvent, pubsub.DeletedEvent}, recorder.types)
+	})
+
+	t.Run("no events without retries", func(t *testing.T) {
+		t.Parallel()
+		var recorder retryRecorder
+		_, err := newTestRetryModel(&flakyModel{}, nil, &recorder).Generate(t.Context(), fantasy.Call{})
+		require.NoError(t, err)
+		require.Empty(t, recorder.types)
+	})
+}
+
+func TestRetryModelStream(t *testing.T) {
+	t.Parallel()
+
+	collect := func(t *testing.T, model fantasy.LanguageModel) []fantasy.StreamPart {
+		t.Helper()
+		stream, err := model.Stream(t.Context(), fantasy.Call{})
+		require.NoError(t, err)
+		var parts []fantasy.StreamPart
+		for part := range stream {
+			parts = append(parts, part)
+		}
+		return parts
+	}
+
+	t.Run("retries before content", func(t *testing.T) {
+		t.Parallel()
+		var recorder retryRecorder
+		flaky := &flakyModel{failures: 1}
+		parts := collect(t, newTestRetryModel(flaky, nil, &recorder))
+		require.Equal(t, 2, flaky.calls)
+		require.Len(t, parts, 2)
+		require.Equal(t, fantasy.StreamPartTypeWarnings, parts[0].Type)
+		require.Equal(t, fantasy.StreamPartTypeTextDelta, parts[1].Type)
+		require.Equal(t, []pubsub.EventType{pubsub.UpdatedEvent, pubsub.DeletedEvent}, recorder.types)
+	})
+
+	t.Run("does not retry after content", func(t *testing.T) {
+		t.Parallel()
+		var recorder retryRecorder
+		flaky := &flakyModel{failures: 1, midStream: true}
+		parts := collect(t, newTestRetryModel(flaky, nil, &recorder))
+		require.Equal(t, 1, flaky.calls)
+		require.Equal(t, fantasy.StreamPartTypeError, parts[len(parts)-1].Type)
+		require.Empty(t, recorder.types)
+	})
+
+	t.Run("reports the last error", func(t *testing.T) {
+		t.Parallel()
+		var recorder retryRecorder
+		flaky := &flakyModel{failures: 5}
+		parts := collect(t, newTestRetryModel(flaky, &config.RetryPolicy{MaxAttempts: 3}, &recorder))
+		require.Equal(t, 3, flaky.calls)
+		require.Len(t, parts, 1)
+		require.Equal(t, fantasy.StreamPartTypeError, parts[0].Type)
+		require.Equal(t, []pubsub.EventType{pubsub.UpdatedEvent, pubsub.UpdatedEvent, pubsub.DeletedEvent}, recorder.types)
+	})
+}
@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+)
+
+// Sample implements [Coordinator]. The model preferences of the request are
+// ignored: it always uses the small model of the current agent. The context
+// inclusion is ignored too, the request only has its own messages.
+func (c *coordinator) Sample(ctx context.Context, params *mcp.CreateMessageParams) (*mcp.CreateMessageResult, error) {
+	model := c.currentAgent.SmallModel()
+	if model.Model == nil {
+		return nil, errors.New("small model not available")
+	}
+
+	prompt, err := samplingPrompt(params)
+	if err != nil {
+		return nil, err
+	}
+	if providerCfg, ok := c.cfg.Providers.Get(model.ModelCfg.Provider); ok && providerCfg.SystemPromptPrefix != "" {
+		prompt = append(fantasy.Prompt{fantasy.NewSystemMessage(providerCfg.SystemPromptPrefix)}, prompt...)
+	}
+
+	maxTokens := params.MaxTokens
+	if limit := model.CatwalkCfg.DefaultMaxTokens; limit > 0 && (maxTokens <= 0 || maxTokens > limit) {
+		maxTokens = limit
+	}
+	call := fantasy.Call{
+		Prompt:          prompt,
+		MaxOutputTokens: &maxTokens,
+	}
+	if params.Temperature != 0 {
+		call.Temperature = &params.Temperature
+	}
+
+	resp, err := model.Model.Generate(ctx, call)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate message: %w", err)
+	}
+
+	stopReason := "endTurn"
+	if resp.FinishReason == fantasy.FinishReasonLength {
+		stopReason = "maxTokens"
+	}
+	return &mcp.CreateMessageResult{
+		Content:    &mcp.TextContent{Text: resp.Content.Text()},
+		Model:      model.ModelCfg.Model,
+		Role:       "assistant",
+		StopReason: stopReason,
+	}, nil
+}
+
+// samplingPrompt converts the system prompt and the messages of a sampling
+// request to a prompt.
+func samplingPrompt(params *mcp.CreateMessageParams) (fantasy.Prompt, error) {
+	var prompt fantasy.Prompt
+	if params.SystemPrompt != "" {
+		prompt = append(prompt, fantasy.NewSystemMessage(params.SystemPrompt))
+	}
+	for _, msg := range params.Messages {
+		var part fantasy.MessagePart
+		switch content := msg.Content.(type) {
+		case *mcp.TextContent:
+			part = fantasy.TextPart{Text: content.Text}
+		case *mcp.ImageContent:
+			part = fantasy.FilePart{Data: content.Data, MediaType: content.MIMEType}
+		case *mcp.AudioContent:
+			part = fantasy.FilePart{Data: content.Data, MediaType: content.MIMEType}
+		default:
+			return nil, fmt.Errorf("unsupported sampling content %T", msg.Content)
+		}
+
+		role := fantasy.MessageRoleUser
+		if msg.Role == "assistant" {
+			role = fantasy.MessageRoleAssistant
+		}
+		// Consecutive contents of the same role make up a single message.
+		if n := len(prompt); n > 0 && prompt[n-1].Role == role {
+			prompt[n-1].Content = append(prompt[n-1].Content, part)
+			continue
+		}
+		prompt = append(prompt, fantasy.Message{
+			Role:    role,
+			Content: []fantasy.MessagePart{part},
+		})
+	}
+	if len(prompt) == 0 {
+		return nil, errors.New("the sampling request has no messages")
+	}
+	return prompt, nil
+}
@@ -96,7 +96,7 @@ func (c *coordinator) subagentTool(ctx context.Context) (fantasy.AgentTool, erro
 				return fantasy.ToolResponse{}, errors.New("model provider not configured")
 			}
 
-			result, err := agent.Run(ctx, SessionAgentCall{
+			result, runErr := agent.Run(ctx, SessionAgentCall{
 				SessionID:        session.ID,
 				Prompt:           params.Prompt,
 				MaxOutputTokens:  maxTokens,
@@ -106,8 +106,9 @@ func (c *coordinator) subagentTool(ctx context.Context) (fantasy.AgentTool, erro
 				TopK:             model.ModelCfg.TopK,
 				FrequencyPenalty: model.ModelCfg.FrequencyPenalty,
 				PresencePenalty:  model.ModelCfg.PresencePenalty,
+				MaxSteps:         selectedSubagent.MaxSteps,
 			})
-			if err != nil {
+			if runErr != nil && !errors.Is(runErr, ErrBudgetExceeded) {
 				return fantasy.NewTextErrorResponse("error generating response"), nil
 			}
 
@@ -127,7 +128,7 @@ func (c *coordinator) subagentTool(ctx context.Context) (fantasy.AgentTool, erro
 				return fantasy.ToolResponse{}, fmt.Errorf("error saving parent session: %s", err)
 			}
 
-			return fantasy.NewTextResponse(result.Response.Content.Text()), nil
+			return subAgentResponse(result, runErr), nil
 		}), nil
 }
 
@@ -144,6 +145,7 @@ func (c *coordinator) buildSubagentAgent(ctx context.Context, sa *subagent.Subag
 		Description:  sa.Description,
 		Model:        config.SelectedModelTypeLarge,
 		AllowedTools: config.AllToolNames(), // All tools by default
+		Compaction:   c.cfg.Options.Compaction[sa.Name],
 	}
 
 	// If tools are explicitly restricted in the subagent spec, use those instead.
@@ -163,6 +165,11 @@ func (c *coordinator) buildSubagentAgent(ctx context.Context, sa *subagent.Subag
 		return nil, err
 	}
 
+	summaryPrompt, err := c.readSummaryPrompt(agentCfg.Compaction)
+	if err != nil {
+		return nil, err
+	}
+
 	largeProviderCfg, _ := c.cfg.Providers.Get(large.ModelCfg.Provider)
 	result := NewSessionAgent(SessionAgentOptions{
 		large,
@@ -175,6 +182,10 @@ func (c *coordinator) buildSubagentAgent(ctx context.Context, sa *subagent.Subag
 		c.sessions,
 		c.messages,
 		nil,
+		nil,
+		nil,
+		agentCfg.Compaction,
+		summaryPrompt,
 	})
 
 	// Build system prompt.
@@ -196,11 +207,17 @@ func (c *coordinator) buildSubagentAgent(ctx context.Context, sa *subagent.Subag
 
 // createSubagentPermissions creates a permission service for a subagent.
 // If yolo_mode is true, all requests are auto-approved.
-// Otherwise, allowed_tools are auto-approved and others bubble up.
+// Otherwise, allowed_tools are auto-approved and others bubble up. Permission
+// rules apply either way.
 func (c *coordinator) createSubagentPermissions(sa *subagent.Subagent) permission.Service {
+	var rules []permission.Rule
+	if c.cfg.Permissions != nil {
+		rules = c.cfg.Permissions.Rules
+	}
+
 	if sa.YoloMode {
 		// Auto-approve everything.
-		return permission.NewPermissionService(c.cfg.WorkingDir(), true, nil)
+		return permission.NewPermissionService(c.cfg.WorkingDir(), true, nil, rules, nil)
 	}
 
 	// Use subagent's allowed_tools, falling back to parent's allowed_tools.
@@ -212,7 +229,7 @@ func (c *coordinator) createSubagentPermissions(sa *subagent.Subagent) permissio
 	// Create a permission service that delegates non-allowed tools to the parent.
 	// For now, we create a separate service that shares the allowed tools list.
 	// Permission requests for tools not in allowed_tools will prompt the user.
-	return permission.NewPermissionService(c.cfg.WorkingDir(), c.permissions.SkipRequests(), allowedTools)
+	return permission.NewPermissionService(c.cfg.WorkingDir(), c.permissions.SkipRequests(), allowedTools, rules, nil)
 }
 
 // buildSubagentTools builds tools for a subagent with custom permissions.
@@ -228,7 +245,7 @@ func (c *coordinator) buildSubagentTools(ctx context.Context, agent config.Agent
 	}
 
 	allTools = append(allTools,
-		tools.NewBashTool(permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName, c.cfg.Options.AllowUnsafeCommands),
+		tools.NewBashTool(permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName, c.cfg.Options.AllowUnsafeCommands, c.cfg.Options.Sandbox.Shell(c.cfg.WorkingDir(), c.cfg.Options.DataDirectory)),
 		tools.NewJobOutputTool(),
 		tools.NewJobKillTool(),
 		tools.NewDownloadTool(permissions, c.cfg.WorkingDir(), nil),
@@ -279,7 +296,7 @@ func (c *coordinator) buildSubagentTools(ctx context.Context, agent config.Agent
 		return strings.Compare(a.Info().Name, b.Info().Name)
 	})
 
-	return filteredTools, nil
+	return tools.WrapAllWithHooks(tools.WrapAllWithDenials(filteredTools), c.hooks), nil
 }
 
 // subagentPrompt creates a prompt for a user-defined subagent.
@@ -375,9 +375,9 @@ Diagnostics (lint/typecheck) included in tool output.
 {{.AvailSkillXML}}
 
 <skills_usage>
-When a user task matches a skill's description, read the skill's SKILL.md file to get full instructions.
-Skills are activated by reading their location path. Follow the skill's instructions to complete the task.
-If a skill mentions scripts, references, or assets, they are placed in the same folder as the skill itself (e.g., scripts/, references/, assets/ subdirectories within the skill's folder).
+When a user task matches a skill's description, load the skill with the `skill` tool to get its full instructions, then follow them to complete the task.
+Loading a skill also lists its resources (e.g., scripts/, references/, assets/ in the skill's folder), which you can read with the `view` tool when the instructions mention them.
+Scripts a skill declares are available as `skill_<skill>_<script>` tools; prefer them over running the scripts with bash.
 </skills_usage>
 {{end}}
 
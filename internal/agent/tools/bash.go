@@ -66,6 +66,7 @@ type bashDescriptionData struct {
 	MaxOutputLength int
 	Attribution     config.Attribution
 	ModelName       string
+	Sandbox         *shell.Sandbox
 }
 
 var bannedCommands = []string{
@@ -154,7 +155,7 @@ func filterBannedCommands(banned, allowed []string) []string {
 	return result
 }
 
-func bashDescription(attribution *config.Attribution, modelName string, allowedUnsafe []string) string {
+func bashDescription(attribution *config.Attribution, modelName string, allowedUnsafe []string, sandbox *shell.Sandbox) string {
 	effectiveBanned := filterBannedCommands(bannedCommands, allowedUnsafe)
 	bannedCommandsStr := strings.Join(effectiveBanned, ", ")
 	var out bytes.Buffer
@@ -163,6 +164,7 @@ func bashDescription(attribution *config.Attribution, modelName string, allowedU
 		MaxOutputLength: MaxOutputLength,
 		Attribution:     *attribution,
 		ModelName:       modelName,
+		Sandbox:         sandbox,
 	}); err != nil {
 		// this should never happen.
 		panic("failed to execute bash description template: " + err.Error())
@@ -203,10 +205,12 @@ func blockFuncs(allowedUnsafe []string) []shell.BlockFunc {
 	}
 }
 
-func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string, allowedUnsafe []string) fantasy.AgentTool {
+// NewBashTool creates the bash tool. Commands run in the sandbox when it
+// isn't nil.
+func NewBashTool(permissions permission.Service, workingDir string, attribution *config.Attribution, modelName string, allowedUnsafe []string, sandbox *shell.Sandbox) fantasy.AgentTool {
 	return fantasy.NewAgentTool(
 		BashToolName,
-		string(bashDescription(attribution, modelName, allowedUnsafe)),
+		string(bashDescription(attribution, modelName, allowedUnsafe, sandbox)),
 		func(ctx context.Context, params BashParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
 			// Start telemetry span for tool execution.
 			ctx, span := telemetry.StartSpan(ctx, telemetry.SpanToolExecute)
@@ -221,7 +225,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			}
 
 			// Determine working directory
-			execWorkingDir := cmp.Or(params.WorkingDir, workingDir)
+			execWorkingDir := cmp.Or(params.WorkingDir, GetWorkingDirFromContext(ctx), workingDir)
 
 			isSafeReadOnly := false
 			cmdLower := strings.ToLower(params.Command)
@@ -239,24 +243,26 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			if sessionID == "" {
 				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for executing shell command")
 			}
-			if !isSafeReadOnly {
-				p, err := permissions.Request(ctx,
-					permission.CreatePermissionRequest{
-						SessionID:   sessionID,
-						Path:        execWorkingDir,
-						ToolCallID:  call.ID,
-						ToolName:    BashToolName,
-						Action:      "execute",
-						Description: fmt.Sprintf("Execute command: %s", params.Command),
-						Params:      BashPermissionsParams(params),
-					},
-				)
-				if err != nil {
-					return fantasy.ToolResponse{}, err
-				}
-				if !p {
-					return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
-				}
+			// Safe read-only commands run without a prompt, but still go
+			// through the permission rules.
+			p, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        execWorkingDir,
+					Command:     params.Command,
+					ToolCallID:  call.ID,
+					ToolName:    BashToolName,
+					Action:      "execute",
+					Description: fmt.Sprintf("Execute command: %s", params.Command),
+					Params:      BashPermissionsParams(params),
+					Safe:        isSafeReadOnly,
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !p {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 			}
 
 			// If explicitly requested as background, start immediately with detached context
@@ -265,7 +271,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 				bgManager := shell.GetBackgroundShellManager()
 				bgManager.Cleanup()
 				// Use background context so it continues after tool returns
-				bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(allowedUnsafe), params.Command, params.Description)
+				bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(allowedUnsafe), sandbox, params.Command, params.Description)
 				if err != nil {
 					return fantasy.ToolResponse{}, fmt.Errorf("error starting background shell: %w", err)
 				}
@@ -320,7 +326,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 			// Start with detached context so it can survive if moved to background
 			bgManager := shell.GetBackgroundShellManager()
 			bgManager.Cleanup()
-			bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(allowedUnsafe), params.Command, params.Description)
+			bgShell, err := bgManager.Start(context.Background(), execWorkingDir, blockFuncs(allowedUnsafe), sandbox, params.Command, params.Description)
 			if err != nil {
 				return fantasy.ToolResponse{}, fmt.Errorf("error starting shell: %w", err)
 			}
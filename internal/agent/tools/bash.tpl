@@ -15,7 +15,11 @@ Common shell builtins and core utils available on Windows.
 6. Return Result: Include errors, metadata with <cwd></cwd> tags
 </execution_steps>
 
-<usage_notes>
+{{ if .Sandbox }}<sandbox>
+Commands run in a sandbox: only the working directory and the temporary directory{{ if gt (len .Sandbox.Writable) 1 }} and other configured paths{{ end }} are writable{{ if not .Sandbox.Network }}, and network access is blocked{{ end }}. Don't try to work around it; tell the user when a task needs more access.
+</sandbox>
+
+{{ end }}<usage_notes>
 - Command required, working_dir optional (defaults to current directory)
 - IMPORTANT: Use Grep/Glob/Agent tools instead of 'find'/'grep'. Use View/LS tools instead of 'cat'/'head'/'tail'/'ls'
 - Chain with ';' or '&&', avoid newlines except in quoted strings
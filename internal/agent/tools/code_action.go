@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type CodeActionParams struct {
+	FilePath string `json:"file_path" description:"The file to get the code actions for"`
+	Line     int    `json:"line" description:"The line number (1-based) to get the code actions for, usually the line of a diagnostic"`
+	Apply    int    `json:"apply,omitempty" description:"The number of the code action to apply, from a previous listing. Omit to list the code actions."`
+}
+
+const CodeActionToolName = "lsp_code_action"
+
+//go:embed code_action.md
+var codeActionDescription []byte
+
+func NewCodeActionTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		CodeActionToolName,
+		string(codeActionDescription),
+		func(ctx context.Context, params CodeActionParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.FilePath == "" || params.Line < 1 {
+				return fantasy.NewTextErrorResponse("file_path and line are required"), nil
+			}
+			if lspClients.Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+			workingDir := cmp.Or(GetWorkingDirFromContext(ctx), workingDir)
+			path := filepathext.SmartJoin(workingDir, params.FilePath)
+
+			client := lspClientFor(lspClients, path)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP client handles %s", path)), nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to read file: %s", err)), nil
+			}
+			lines := strings.Split(string(content), "\n")
+			if params.Line > len(lines) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("line %d is past the end of %s", params.Line, path)), nil
+			}
+			if !client.IsFileOpen(path) {
+				// Opening the file makes the server compute its diagnostics.
+				notifyLSPs(ctx, lspClients, path)
+			}
+
+			line := uint32(params.Line - 1) //nolint:gosec
+			rng := fileRange(lines[params.Line-1])
+			rng.Start.Line = line
+			rng.End.Line = line
+
+			var diagnostics []protocol.Diagnostic
+			for _, diagnostic := range client.GetFileDiagnostics(protocol.URIFromPath(path)) {
+				if diagnostic.Range.Start.Line <= line && line <= diagnostic.Range.End.Line {
+					diagnostics = append(diagnostics, diagnostic)
+				}
+			}
+
+			var actions []protocol.CodeAction
+			found, err := client.CodeActions(ctx, path, rng, diagnostics)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			for _, action := range found {
+				if action.Disabled == nil {
+					actions = append(actions, action)
+				}
+			}
+
+			if params.Apply == 0 {
+				return fantasy.NewTextResponse(listCodeActions(path, params.Line, diagnostics, actions)), nil
+			}
+			if params.Apply > len(actions) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("there's no code action %d, list the code actions again", params.Apply)), nil
+			}
+
+			action, err := client.ResolveCodeAction(ctx, actions[params.Apply-1])
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if action.Edit == nil {
+				if action.Command != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("the code action '%s' runs a server command, which isn't supported", action.Title)), nil
+				}
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("the code action '%s' doesn't change any file", action.Title)), nil
+			}
+
+			edited, err := workspaceEditFiles(*action.Edit)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(edited) == 0 {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("the code action '%s' doesn't change any file", action.Title)), nil
+			}
+
+			description := fmt.Sprintf("Apply '%s'", action.Title)
+			metadata, err := applyEditedFiles(ctx, lspClients, permissions, files, call, CodeActionToolName, description, edited, workingDir)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+
+			text := fmt.Sprintf("<result>\nApplied '%s' to %d file(s):\n%s\n</result>\n", action.Title, len(edited), strings.Join(metadata.Files, "\n"))
+			text += getDiagnostics(path, lspClients)
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), metadata), nil
+		})
+}
+
+// listCodeActions lists the diagnostics on the line and the numbered actions
+// the server offers for it.
+func listCodeActions(path string, line int, diagnostics []protocol.Diagnostic, actions []protocol.CodeAction) string {
+	var output strings.Builder
+	if len(diagnostics) > 0 {
+		fmt.Fprintf(&output, "Diagnostics on line %d of %s:\n", line, path)
+		for _, diagnostic := range diagnostics {
+			fmt.Fprintf(&output, "- %s\n", diagnostic.Message)
+		}
+		output.WriteString("\n")
+	}
+	if len(actions) == 0 {
+		fmt.Fprintf(&output, "No code actions available for line %d of %s", line, path)
+		return output.String()
+	}
+
+	fmt.Fprintf(&output, "Found %d code action(s) for line %d of %s:\n", len(actions), line, path)
+	for i, action := range actions {
+		fmt.Fprintf(&output, "%d. %s", i+1, action.Title)
+		if action.Kind != "" {
+			fmt.Fprintf(&output, " (%s)", action.Kind)
+		}
+		output.WriteString("\n")
+	}
+	output.WriteString("\nPass the number of an action as apply to apply it.")
+	return output.String()
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/codeindex"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+type CodeSearchParams struct {
+	Query string `json:"query" description:"What to search for: a natural language description, identifiers or keywords"`
+	Path  string `json:"path,omitempty" description:"The directory to search in. Defaults to the current working directory."`
+	Limit int    `json:"limit,omitempty" description:"The maximum number of results to return (defaults to 10, max 50)"`
+}
+
+type CodeSearchResponseMetadata struct {
+	NumberOfResults int `json:"number_of_results"`
+}
+
+const (
+	CodeSearchToolName = "code_search"
+	maxCodeSearchLimit = 50
+)
+
+//go:embed code_search.md
+var codeSearchDescription []byte
+
+// NewCodeSearchTool creates the code_search tool. The index of the project
+// is kept in the index directory of dataDir; embedder may be nil to only
+// search lexically.
+func NewCodeSearchTool(workingDir, dataDir string, embedder codeindex.Embedder) fantasy.AgentTool {
+	var mu sync.Mutex
+	indexes := make(map[string]*codeindex.Index)
+	openIndex := func(root string) (*codeindex.Index, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if idx, ok := indexes[root]; ok {
+			return idx, nil
+		}
+		dir := codeindex.Dir(dataDir)
+		if root != workingDir {
+			// Sessions in worktrees get an index of their own, keyed on
+			// the path of the worktree since their names can collide.
+			sum := sha256.Sum256([]byte(root))
+			name := filepath.Base(root) + "-" + hex.EncodeToString(sum[:4])
+			dir = filepath.Join(dir, "worktrees", name)
+		}
+		idx, err := codeindex.Open(root, dir, embedder)
+		if err != nil {
+			return nil, err
+		}
+		indexes[root] = idx
+		return idx, nil
+	}
+
+	return fantasy.NewAgentTool(
+		CodeSearchToolName,
+		string(codeSearchDescription),
+		func(ctx context.Context, params CodeSearchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			root := cmp.Or(GetWorkingDirFromContext(ctx), workingDir)
+
+			if strings.TrimSpace(params.Query) == "" {
+				return fantasy.NewTextErrorResponse("query is required"), nil
+			}
+
+			var prefix string
+			if params.Path != "" {
+				searchPath, err := fsext.Expand(params.Path)
+				if err != nil {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("error expanding path: %v", err)), nil
+				}
+				rel, err := filepath.Rel(root, filepathext.SmartJoin(root, searchPath))
+				if err != nil || strings.HasPrefix(rel, "..") {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("path %s is outside of the working directory", params.Path)), nil
+				}
+				if rel != "." {
+					prefix = filepath.ToSlash(rel)
+				}
+			}
+
+			idx, err := openIndex(root)
+			if err != nil {
+				return fantasy.ToolResponse{}, fmt.Errorf("error opening code index: %w", err)
+			}
+			if err := idx.Sync(ctx); err != nil {
+				if errors.Is(err, context.Canceled) {
+					return fantasy.ToolResponse{}, err
+				}
+				slog.Warn("Failed to update code index", "error", err)
+			}
+
+			results, err := idx.Search(ctx, params.Query, min(params.Limit, maxCodeSearchLimit), prefix)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			var output string
+			if len(results) == 0 {
+				output = "No results found"
+			} else {
+				output = formatCodeSearchResults(results)
+			}
+
+			return fantasy.WithResponseMetadata(
+				fantasy.NewTextResponse(output),
+				CodeSearchResponseMetadata{
+					NumberOfResults: len(results),
+				},
+			), nil
+		})
+}
+
+func formatCodeSearchResults(results []codeindex.Result) string {
+	var sb strings.Builder
+	for i, result := range results {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "%s:%d-%d", result.Path, result.StartLine, result.EndLine)
+		if len(result.Symbols) > 0 {
+			fmt.Fprintf(&sb, " (%s)", strings.Join(result.Symbols, ", "))
+		}
+		sb.WriteString("\n")
+		sb.WriteString(addLineNumbers(result.Text, result.StartLine))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,24 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+)
+
+const DefinitionToolName = "lsp_definition"
+
+//go:embed definition.md
+var definitionDescription []byte
+
+func NewDefinitionTool(lspClients *csync.Map[string, *lsp.Client]) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		DefinitionToolName,
+		string(definitionDescription),
+		func(ctx context.Context, params LSPSymbolParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			return locationsResponse(ctx, lspClients, params, "definition", (*lsp.Client).Definition), nil
+		})
+}
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"context"
+	"errors"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// DenialTool wraps an AgentTool so that calls denied by a permission rule are
+// reported to the model as failed calls, instead of ending the turn like a
+// denial by the user.
+type DenialTool struct {
+	tool fantasy.AgentTool
+}
+
+// WrapAllWithDenials wraps all tools in the slice to report rule denials.
+func WrapAllWithDenials(tools []fantasy.AgentTool) []fantasy.AgentTool {
+	wrapped := make([]fantasy.AgentTool, len(tools))
+	for i, tool := range tools {
+		wrapped[i] = &DenialTool{tool: tool}
+	}
+	return wrapped
+}
+
+// Info returns the tool info from the wrapped tool.
+func (t *DenialTool) Info() fantasy.ToolInfo {
+	return t.tool.Info()
+}
+
+// Run executes the wrapped tool.
+func (t *DenialTool) Run(ctx context.Context, params fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	resp, err := t.tool.Run(ctx, params)
+	if errors.Is(err, permission.ErrDeniedByRule) {
+		return fantasy.NewTextErrorResponse(err.Error() + " in the user's configuration; don't retry it"), nil
+	}
+	return resp, err
+}
+
+// ProviderOptions returns the provider options from the wrapped tool.
+func (t *DenialTool) ProviderOptions() fantasy.ProviderOptions {
+	return t.tool.ProviderOptions()
+}
+
+// SetProviderOptions sets provider options on the wrapped tool.
+func (t *DenialTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	t.tool.SetProviderOptions(opts)
+}
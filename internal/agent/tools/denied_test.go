@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"fmt"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDenialTool(t *testing.T) {
+	t.Parallel()
+
+	t.Run("reports rule denials", func(t *testing.T) {
+		t.Parallel()
+		tool := WrapAllWithDenials([]fantasy.AgentTool{&mockTool{
+			err: fmt.Errorf("%w #2", permission.ErrDeniedByRule),
+		}})[0]
+		resp, err := tool.Run(t.Context(), fantasy.ToolCall{})
+		require.NoError(t, err)
+		require.True(t, resp.IsError)
+		require.Contains(t, resp.Content, "permission denied by rule #2")
+	})
+
+	t.Run("keeps user denials", func(t *testing.T) {
+		t.Parallel()
+		tool := WrapAllWithDenials([]fantasy.AgentTool{&mockTool{
+			err: permission.ErrorPermissionDenied,
+		}})[0]
+		_, err := tool.Run(t.Context(), fantasy.ToolCall{})
+		require.ErrorIs(t, err, permission.ErrorPermissionDenied)
+	})
+}
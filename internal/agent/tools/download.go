@@ -49,6 +49,8 @@ func NewDownloadTool(permissions permission.Service, workingDir string, client *
 		DownloadToolName,
 		string(downloadDescription),
 		func(ctx context.Context, params DownloadParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := cmp.Or(GetWorkingDirFromContext(ctx), workingDir)
+
 			if params.URL == "" {
 				return fantasy.NewTextErrorResponse("URL parameter is required"), nil
 			}
@@ -74,6 +76,8 @@ func NewDownloadTool(permissions permission.Service, workingDir string, client *
 				permission.CreatePermissionRequest{
 					SessionID:   sessionID,
 					Path:        filePath,
+					FilePath:    filePath,
+					URL:         params.URL,
 					ToolName:    DownloadToolName,
 					Action:      "download",
 					Description: fmt.Sprintf("Download file from URL: %s to %s", params.URL, filePath),
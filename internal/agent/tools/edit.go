@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
 	"fmt"
@@ -65,6 +66,8 @@ func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 		EditToolName,
 		string(editDescription),
 		func(ctx context.Context, params EditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := cmp.Or(GetWorkingDirFromContext(ctx), workingDir)
+
 			// Start telemetry span for tool execution.
 			ctx, span := telemetry.StartSpan(ctx, telemetry.SpanToolExecute)
 			span.SetAttributes(telemetry.AttrToolName.String(EditToolName))
@@ -101,6 +104,7 @@ func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 				return response, nil
 			}
 
+			formatOnWrite(ctx, lspClients, files, params.FilePath)
 			notifyLSPs(ctx, lspClients, params.FilePath)
 
 			text := fmt.Sprintf("<result>\n%s\n</result>\n", response.Content)
@@ -140,6 +144,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
+			FilePath:    filePath,
 			ToolCallID:  call.ID,
 			ToolName:    EditToolName,
 			Action:      "write",
@@ -164,7 +169,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 	}
 
 	// File can't be in the history so we create a new file history
-	_, err = edit.files.Create(edit.ctx, sessionID, filePath, "")
+	_, err = edit.files.CreateNew(edit.ctx, sessionID, filePath)
 	if err != nil {
 		// Log error but don't fail the operation
 		return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -261,6 +266,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
+			FilePath:    filePath,
 			ToolCallID:  call.ID,
 			ToolName:    EditToolName,
 			Action:      "write",
@@ -393,6 +399,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
+			FilePath:    filePath,
 			ToolCallID:  call.ID,
 			ToolName:    EditToolName,
 			Action:      "write",
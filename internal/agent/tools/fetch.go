@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
 	"fmt"
@@ -58,7 +59,8 @@ func NewFetchTool(permissions permission.Service, workingDir string, client *htt
 			p, err := permissions.Request(ctx,
 				permission.CreatePermissionRequest{
 					SessionID:   sessionID,
-					Path:        workingDir,
+					Path:        cmp.Or(GetWorkingDirFromContext(ctx), workingDir),
+					URL:         params.URL,
 					ToolCallID:  call.ID,
 					ToolName:    FetchToolName,
 					Action:      "fetch",
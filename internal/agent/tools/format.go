@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// formatOnWrite organizes the imports of the file and formats it with the LSP
+// server handling it, when the server is configured to, and stores the result
+// as a new version of the file. Failures are only logged since the write they
+// follow already succeeded.
+func formatOnWrite(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], files history.Service, path string) {
+	client := lspClientFor(lspClients, path)
+	if client == nil {
+		return
+	}
+	cfg := client.Config()
+	if !cfg.OrganizeImports && !cfg.FormatOnWrite {
+		return
+	}
+
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if err := client.OpenFileOnDemand(ctx, path); err != nil {
+		slog.Warn("Failed to open file for formatting", "file", path, "error", err)
+		return
+	}
+	// The server may still have the content from before the write.
+	_ = client.NotifyChange(ctx, path)
+
+	if cfg.OrganizeImports {
+		if err := organizeImports(ctx, client, path); err != nil {
+			slog.Warn("Failed to organize imports", "file", path, "error", err)
+		}
+	}
+	if cfg.FormatOnWrite {
+		if err := formatFile(ctx, client, path); err != nil {
+			slog.Warn("Failed to format file", "file", path, "error", err)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) == string(original) {
+		return
+	}
+	sessionID := GetSessionFromContext(ctx)
+	newContent, _ := fsext.ToUnixLineEndings(string(content))
+	if _, err := files.CreateVersion(ctx, sessionID, path, newContent); err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+	filetracker.RecordWrite(path)
+	filetracker.RecordRead(path)
+}
+
+// organizeImports applies the first organize imports action the server
+// offers for the file.
+func organizeImports(ctx context.Context, client *lsp.Client, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	actions, err := client.CodeActions(ctx, path, fileRange(string(content)), nil, protocol.SourceOrganizeImports)
+	if err != nil {
+		return err
+	}
+	for _, action := range actions {
+		action, err = client.ResolveCodeAction(ctx, action)
+		if err != nil {
+			return err
+		}
+		if action.Edit == nil {
+			continue
+		}
+		edited, err := workspaceEditFiles(*action.Edit)
+		if err != nil {
+			return err
+		}
+		for _, file := range edited {
+			if file.path != path {
+				continue
+			}
+			newContent := file.newContent
+			if file.crlf {
+				newContent, _ = fsext.ToWindowsLineEndings(newContent)
+			}
+			return writeAndNotify(ctx, client, path, []byte(newContent))
+		}
+		return nil
+	}
+	return nil
+}
+
+// formatFile applies the formatting edits the server returns for the file.
+func formatFile(ctx context.Context, client *lsp.Client, path string) error {
+	edits, err := client.Format(ctx, path)
+	if err != nil || len(edits) == 0 {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	newContent, err := util.ApplyTextEdits(content, edits)
+	if err != nil {
+		return fmt.Errorf("failed to apply formatting: %w", err)
+	}
+	return writeAndNotify(ctx, client, path, newContent)
+}
+
+func writeAndNotify(ctx context.Context, client *lsp.Client, path string, content []byte) error {
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return client.NotifyChange(ctx, path)
+}
+
+// fileRange returns the range covering the whole content. LSP characters
+// count UTF-16 code units.
+func fileRange(content string) protocol.Range {
+	lines := strings.Split(content, "\n")
+	last := lines[len(lines)-1]
+	return protocol.Range{
+		End: protocol.Position{
+			Line:      uint32(len(lines) - 1),                  //nolint:gosec
+			Character: uint32(len(utf16.Encode([]rune(last)))), //nolint:gosec
+		},
+	}
+}
@@ -3,6 +3,7 @@ package tools
 import (
 	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	_ "embed"
 	"encoding/json"
@@ -117,6 +118,8 @@ func NewGrepTool(workingDir string) fantasy.AgentTool {
 		GrepToolName,
 		string(grepDescription),
 		func(ctx context.Context, params GrepParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := cmp.Or(GetWorkingDirFromContext(ctx), workingDir)
+
 			if params.Pattern == "" {
 				return fantasy.NewTextErrorResponse("pattern is required"), nil
 			}
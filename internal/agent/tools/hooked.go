@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/hooks"
+)
+
+// HookedTool wraps an AgentTool to run the pre_tool_use and post_tool_use
+// hooks around its calls.
+type HookedTool struct {
+	tool  fantasy.AgentTool
+	hooks *hooks.Runner
+}
+
+// WrapWithHooks wraps a tool to run the hooks matching it. Tools no hook
+// matches are returned unwrapped.
+func WrapWithHooks(tool fantasy.AgentTool, runner *hooks.Runner) fantasy.AgentTool {
+	name := tool.Info().Name
+	if !runner.Has(hooks.PreToolUse, name) && !runner.Has(hooks.PostToolUse, name) {
+		return tool
+	}
+	return &HookedTool{tool: tool, hooks: runner}
+}
+
+// WrapAllWithHooks wraps all tools in the slice with hooks.
+func WrapAllWithHooks(tools []fantasy.AgentTool, runner *hooks.Runner) []fantasy.AgentTool {
+	wrapped := make([]fantasy.AgentTool, len(tools))
+	for i, tool := range tools {
+		wrapped[i] = WrapWithHooks(tool, runner)
+	}
+	return wrapped
+}
+
+// Info returns the tool info from the wrapped tool.
+func (t *HookedTool) Info() fantasy.ToolInfo {
+	return t.tool.Info()
+}
+
+// Run executes the wrapped tool between its hooks. A pre_tool_use hook may
+// block the call or rewrite its input, in which case the result tells the
+// agent the input that was used; post_tool_use hooks may add to the result.
+func (t *HookedTool) Run(ctx context.Context, params fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	input := hooks.Input{
+		SessionID:  GetSessionFromContext(ctx),
+		WorkingDir: GetWorkingDirFromContext(ctx),
+		ToolName:   t.tool.Info().Name,
+		ToolCallID: params.ID,
+		ToolInput:  toolInput(params.Input),
+	}
+
+	input.Event = hooks.PreToolUse
+	pre, err := t.hooks.Run(ctx, input)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if pre.Blocked {
+		return fantasy.NewTextErrorResponse(withHookContext("Tool call blocked by hook: "+pre.Reason, pre.Context)), nil
+	}
+	preContext := pre.Context
+	if pre.ToolInput != "" {
+		params.Input = pre.ToolInput
+		input.ToolInput = toolInput(pre.ToolInput)
+		// The stored tool call keeps the input the agent sent.
+		preContext = strings.TrimSpace("Tool input rewritten by hook to: " + pre.ToolInput + "\n" + preContext)
+	}
+
+	resp, err := t.tool.Run(ctx, params)
+	if err != nil {
+		return resp, err
+	}
+	resp.Content = withHookContext(resp.Content, preContext)
+
+	input.Event = hooks.PostToolUse
+	input.ToolResponse = &hooks.ToolResponse{Content: resp.Content, IsError: resp.IsError}
+	post, err := t.hooks.Run(ctx, input)
+	if err != nil {
+		return resp, err
+	}
+	if post.Blocked {
+		resp.Content = withHookContext(resp.Content, "Hook feedback: "+post.Reason)
+	}
+	resp.Content = withHookContext(resp.Content, post.Context)
+	return resp, nil
+}
+
+// ProviderOptions returns the provider options from the wrapped tool.
+func (t *HookedTool) ProviderOptions() fantasy.ProviderOptions {
+	return t.tool.ProviderOptions()
+}
+
+// SetProviderOptions sets provider options on the wrapped tool.
+func (t *HookedTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	t.tool.SetProviderOptions(opts)
+}
+
+// toolInput returns the raw tool input as JSON, quoting it when the model
+// sent something that isn't valid JSON.
+func toolInput(input string) json.RawMessage {
+	if json.Valid([]byte(input)) {
+		return json.RawMessage(input)
+	}
+	data, _ := json.Marshal(input)
+	return data
+}
+
+func withHookContext(content, hookContext string) string {
+	if hookContext == "" {
+		return content
+	}
+	if content == "" {
+		return hookContext
+	}
+	return fmt.Sprintf("%s\n\n<hook_context>\n%s\n</hook_context>", content, hookContext)
+}
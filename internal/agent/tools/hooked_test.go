@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/hooks"
+	"github.com/stretchr/testify/require"
+)
+
+// echoTool responds with the input it was called with.
+type echoTool struct {
+	mockTool
+	calls int
+}
+
+func (e *echoTool) Run(ctx context.Context, params fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	e.calls++
+	return fantasy.NewTextResponse(params.Input), nil
+}
+
+func TestHookedTool_NoMatchingHooks(t *testing.T) {
+	t.Parallel()
+
+	tool := &echoTool{}
+	runner := hooks.NewRunner(config.Hooks{
+		PreToolUse: []config.Hook{{Matcher: "bash", Command: "exit 2"}},
+	}, t.TempDir())
+
+	require.Same(t, tool, WrapWithHooks(tool, runner))
+}
+
+func TestHookedTool_Block(t *testing.T) {
+	t.Parallel()
+
+	tool := &echoTool{}
+	wrapped := WrapWithHooks(tool, hooks.NewRunner(config.Hooks{
+		PreToolUse: []config.Hook{{Matcher: "mock_*", Command: "echo 'not today' >&2; exit 2"}},
+	}, t.TempDir()))
+
+	resp, err := wrapped.Run(t.Context(), fantasy.ToolCall{ID: "call-1", Input: `{}`})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Equal(t, "Tool call blocked by hook: not today", resp.Content)
+	require.Zero(t, tool.calls)
+}
+
+func TestHookedTool_RewriteAndContext(t *testing.T) {
+	t.Parallel()
+
+	tool := &echoTool{}
+	wrapped := WrapWithHooks(tool, hooks.NewRunner(config.Hooks{
+		PreToolUse:  []config.Hook{{Command: `echo '{"tool_input":{"path":"b.go"}}'`}},
+		PostToolUse: []config.Hook{{Matcher: "mock_tool", Command: "echo formatted"}},
+	}, t.TempDir()))
+
+	resp, err := wrapped.Run(t.Context(), fantasy.ToolCall{ID: "call-1", Input: `{"path":"a.go"}`})
+	require.NoError(t, err)
+	require.False(t, resp.IsError)
+	require.Equal(t, 1, tool.calls)
+	require.Equal(t, "{\"path\":\"b.go\"}\n\n<hook_context>\nTool input rewritten by hook to: {\"path\":\"b.go\"}\n</hook_context>\n\n<hook_context>\nformatted\n</hook_context>", resp.Content)
+}
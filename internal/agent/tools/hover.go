@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+)
+
+const HoverToolName = "lsp_hover"
+
+//go:embed hover.md
+var hoverDescription []byte
+
+func NewHoverTool(lspClients *csync.Map[string, *lsp.Client]) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		HoverToolName,
+		string(hoverDescription),
+		func(ctx context.Context, params LSPSymbolParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Symbol == "" {
+				return fantasy.NewTextErrorResponse("symbol is required"), nil
+			}
+			if lspClients.Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+
+			workingDir := cmp.Or(GetWorkingDirFromContext(ctx), ".")
+			var output string
+			err := visitSymbol(ctx, lspClients, params, workingDir, func(client *lsp.Client, path string, line, character int) (bool, error) {
+				hover, err := client.Hover(ctx, path, line, character)
+				if err != nil {
+					return false, err
+				}
+				if hover == nil || strings.TrimSpace(hover.Contents.Value) == "" {
+					return false, nil
+				}
+				output = fmt.Sprintf("%s:%d:%d\n\n%s", path, line, character, strings.TrimSpace(hover.Contents.Value))
+				return true, nil
+			})
+			switch {
+			case errors.Is(err, errSymbolNotFound):
+				return fantasy.NewTextResponse(fmt.Sprintf("Symbol '%s' not found", params.Symbol)), nil
+			case output != "":
+				return fantasy.NewTextResponse(output), nil
+			case err != nil:
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			return fantasy.NewTextResponse(fmt.Sprintf("No hover information found for symbol '%s'", params.Symbol)), nil
+		})
+}
@@ -0,0 +1,24 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/lsp"
+)
+
+const ImplementationToolName = "lsp_implementation"
+
+//go:embed implementation.md
+var implementationDescription []byte
+
+func NewImplementationTool(lspClients *csync.Map[string, *lsp.Client]) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		ImplementationToolName,
+		string(implementationDescription),
+		func(ctx context.Context, params LSPSymbolParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			return locationsResponse(ctx, lspClients, params, "implementation", (*lsp.Client).Implementation), nil
+		})
+}
@@ -60,6 +60,8 @@ func NewLsTool(permissions permission.Service, workingDir string, lsConfig confi
 		LSToolName,
 		string(lsDescription),
 		func(ctx context.Context, params LSParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := cmp.Or(GetWorkingDirFromContext(ctx), workingDir)
+
 			searchPath, err := fsext.Expand(cmp.Or(params.Path, workingDir))
 			if err != nil {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("error expanding path: %v", err)), nil
@@ -90,6 +92,7 @@ func NewLsTool(permissions permission.Service, workingDir string, lsConfig confi
 					permission.CreatePermissionRequest{
 						SessionID:   sessionID,
 						Path:        absSearchPath,
+						FilePath:    absSearchPath,
 						ToolCallID:  call.ID,
 						ToolName:    LSToolName,
 						Action:      "list",
@@ -0,0 +1,362 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/diff"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/lsp/util"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// LSPSymbolParams locates a symbol for the LSP tools that act on one.
+type LSPSymbolParams struct {
+	Symbol   string `json:"symbol" description:"The symbol name (e.g., function name, variable name, type name)"`
+	FilePath string `json:"file_path,omitempty" description:"The file or directory where the symbol appears. Defaults to the current working directory."`
+	Line     int    `json:"line,omitempty" description:"The line number (1-based) where the symbol appears in file_path"`
+}
+
+var errSymbolNotFound = errors.New("symbol not found")
+
+// lspClientFor returns the client handling the file, or nil.
+func lspClientFor(lspClients *csync.Map[string, *lsp.Client], path string) *lsp.Client {
+	for client := range lspClients.Seq() {
+		if client.HandlesFile(path) {
+			return client
+		}
+	}
+	return nil
+}
+
+// visitSymbol calls visit with the client and position of each place the
+// symbol appears, until it reports it's done. Places the servers find no
+// identifier at, like comments, are skipped.
+func visitSymbol(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], params LSPSymbolParams, workingDir string, visit func(client *lsp.Client, path string, line, character int) (bool, error)) error {
+	matches, err := symbolMatches(ctx, params, workingDir)
+	if err != nil {
+		return fmt.Errorf("failed to search for symbol: %w", err)
+	}
+	if len(matches) == 0 {
+		return errSymbolNotFound
+	}
+
+	var errs error
+	for _, match := range matches {
+		path, err := filepath.Abs(match.path)
+		if err != nil {
+			continue
+		}
+		client := lspClientFor(lspClients, path)
+		if client == nil {
+			continue
+		}
+		done, err := visit(client, path, match.lineNum, match.charNum+getSymbolOffset(params.Symbol))
+		if err != nil {
+			if !strings.Contains(err.Error(), "no identifier found") {
+				errs = errors.Join(errs, err)
+			}
+			continue
+		}
+		if done {
+			return nil
+		}
+	}
+	return errs
+}
+
+// symbolMatches returns the places the symbol appears as a whole identifier:
+// on the line of the file, anywhere in the file, or anywhere in the
+// directory.
+func symbolMatches(ctx context.Context, params LSPSymbolParams, workingDir string) ([]grepMatch, error) {
+	path := filepathext.SmartJoin(workingDir, params.FilePath)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	pattern := symbolPattern(params.Symbol)
+	if info.IsDir() {
+		matches, _, err := searchFiles(ctx, pattern, path, "", 100)
+		return matches, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	re := regexp.MustCompile(pattern)
+	var matches []grepMatch
+	for i, text := range strings.Split(string(content), "\n") {
+		if params.Line > 0 && i+1 != params.Line {
+			continue
+		}
+		if loc := re.FindStringIndex(text); loc != nil {
+			matches = append(matches, grepMatch{path: path, lineNum: i + 1, charNum: loc[0] + 1, lineText: text})
+		}
+	}
+	return matches, nil
+}
+
+// symbolPattern returns the regular expression matching the symbol, but not
+// as part of a longer identifier, like Foo in FooBar or myFoo.
+func symbolPattern(symbol string) string {
+	pattern := regexp.QuoteMeta(symbol)
+	if symbol == "" {
+		return pattern
+	}
+	if isIdentifierByte(symbol[0]) {
+		pattern = `\b` + pattern
+	}
+	if isIdentifierByte(symbol[len(symbol)-1]) {
+		pattern += `\b`
+	}
+	return pattern
+}
+
+func isIdentifierByte(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// formatLocations lists the locations with the line of code at each.
+func formatLocations(locations []protocol.Location) string {
+	var output strings.Builder
+	files := map[string][]string{}
+	for _, loc := range locations {
+		path, err := loc.URI.Path()
+		if err != nil {
+			continue
+		}
+		line := int(loc.Range.Start.Line)
+		fmt.Fprintf(&output, "%s:%d:%d\n", path, line+1, loc.Range.Start.Character+1)
+
+		lines, ok := files[path]
+		if !ok {
+			content, _ := os.ReadFile(path)
+			lines = strings.Split(string(content), "\n")
+			files[path] = lines
+		}
+		if line < len(lines) {
+			if text := strings.TrimSpace(lines[line]); text != "" {
+				fmt.Fprintf(&output, "  %s\n", text)
+			}
+		}
+	}
+	return output.String()
+}
+
+// symbolKindNames are the names of the LSP symbol kinds, which start at one.
+var symbolKindNames = []string{
+	"", "File", "Module", "Namespace", "Package", "Class", "Method", "Property",
+	"Field", "Constructor", "Enum", "Interface", "Function", "Variable",
+	"Constant", "String", "Number", "Boolean", "Array", "Object", "Key", "Null",
+	"EnumMember", "Struct", "Event", "Operator", "TypeParameter",
+}
+
+func symbolKindName(kind protocol.SymbolKind) string {
+	if int(kind) < len(symbolKindNames) && kind > 0 {
+		return symbolKindNames[kind]
+	}
+	return "Symbol"
+}
+
+// locationsResponse answers with the locations the request finds for the
+// symbol, like its definition. what names them in the response.
+func locationsResponse(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], params LSPSymbolParams, what string, request func(c *lsp.Client, ctx context.Context, path string, line, character int) ([]protocol.Location, error)) fantasy.ToolResponse {
+	if params.Symbol == "" {
+		return fantasy.NewTextErrorResponse("symbol is required")
+	}
+	if lspClients.Len() == 0 {
+		return fantasy.NewTextErrorResponse("no LSP clients available")
+	}
+
+	workingDir := cmp.Or(GetWorkingDirFromContext(ctx), ".")
+	var locations []protocol.Location
+	err := visitSymbol(ctx, lspClients, params, workingDir, func(client *lsp.Client, path string, line, character int) (bool, error) {
+		found, err := request(client, ctx, path, line, character)
+		if err != nil {
+			return false, err
+		}
+		locations = append(locations, found...)
+		return false, nil
+	})
+	if errors.Is(err, errSymbolNotFound) {
+		return fantasy.NewTextResponse(fmt.Sprintf("Symbol '%s' not found", params.Symbol))
+	}
+	if len(locations) > 0 {
+		locations = cleanupLocations(locations)
+		return fantasy.NewTextResponse(fmt.Sprintf("Found %d %s(s) of '%s':\n\n%s", len(locations), what, params.Symbol, formatLocations(locations)))
+	}
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error())
+	}
+	return fantasy.NewTextResponse(fmt.Sprintf("No %s found for symbol '%s'", what, params.Symbol))
+}
+
+// WorkspaceEditResponseMetadata describes the changes of the tools applying
+// LSP workspace edits.
+type WorkspaceEditResponseMetadata struct {
+	Files     []string `json:"files"`
+	Additions int      `json:"additions"`
+	Removals  int      `json:"removals"`
+}
+
+// editedFile is a file a workspace edit changes.
+type editedFile struct {
+	path       string
+	oldContent string
+	newContent string
+	crlf       bool
+}
+
+// applyEditedFiles asks for permission to change each file, then writes them
+// all and records them in the file history. Asking for every file before
+// changing any leaves the code as it was when one is denied.
+func applyEditedFiles(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, call fantasy.ToolCall, toolName, description string, edited []editedFile, workingDir string) (WorkspaceEditResponseMetadata, error) {
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return WorkspaceEditResponseMetadata{}, fmt.Errorf("session ID is required for editing files")
+	}
+
+	var metadata WorkspaceEditResponseMetadata
+	for _, file := range edited {
+		_, additions, removals := diff.GenerateDiff(
+			file.oldContent,
+			file.newContent,
+			strings.TrimPrefix(file.path, workingDir),
+		)
+		metadata.Files = append(metadata.Files, file.path)
+		metadata.Additions += additions
+		metadata.Removals += removals
+
+		p, err := permissions.Request(ctx,
+			permission.CreatePermissionRequest{
+				SessionID:   sessionID,
+				Path:        fsext.PathOrPrefix(file.path, workingDir),
+				FilePath:    file.path,
+				ToolCallID:  call.ID,
+				ToolName:    toolName,
+				Action:      "write",
+				Description: fmt.Sprintf("%s in %s", description, file.path),
+				Params: EditPermissionsParams{
+					FilePath:   file.path,
+					OldContent: file.oldContent,
+					NewContent: file.newContent,
+				},
+			},
+		)
+		if err != nil {
+			return WorkspaceEditResponseMetadata{}, err
+		}
+		if !p {
+			return WorkspaceEditResponseMetadata{}, permission.ErrorPermissionDenied
+		}
+	}
+
+	for _, file := range edited {
+		if err := writeEditedFile(ctx, files, sessionID, file); err != nil {
+			return WorkspaceEditResponseMetadata{}, err
+		}
+		notifyLSPs(ctx, lspClients, file.path)
+	}
+	return metadata, nil
+}
+
+// workspaceEditFiles returns the files the edit changes with their new
+// content. Edits that create, rename or delete files aren't supported.
+func workspaceEditFiles(edit protocol.WorkspaceEdit) ([]editedFile, error) {
+	edits := map[protocol.DocumentURI][]protocol.TextEdit{}
+	for uri, textEdits := range edit.Changes {
+		edits[uri] = append(edits[uri], textEdits...)
+	}
+	for _, change := range edit.DocumentChanges {
+		if change.TextDocumentEdit == nil {
+			return nil, errors.New("the edit creates, renames or deletes files, which isn't supported")
+		}
+		uri := change.TextDocumentEdit.TextDocument.URI
+		for _, e := range change.TextDocumentEdit.Edits {
+			textEdit, err := e.AsTextEdit()
+			if err != nil {
+				return nil, err
+			}
+			edits[uri] = append(edits[uri], textEdit)
+		}
+	}
+
+	var edited []editedFile
+	for uri, textEdits := range edits {
+		path, err := uri.Path()
+		if err != nil {
+			return nil, fmt.Errorf("invalid URI: %w", err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		newContent, err := util.ApplyTextEdits(content, textEdits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to edit %s: %w", path, err)
+		}
+		oldText, crlf := fsext.ToUnixLineEndings(string(content))
+		newText, _ := fsext.ToUnixLineEndings(string(newContent))
+		if oldText == newText {
+			continue
+		}
+		edited = append(edited, editedFile{path: path, oldContent: oldText, newContent: newText, crlf: crlf})
+	}
+	slices.SortFunc(edited, func(a, b editedFile) int {
+		return strings.Compare(a.path, b.path)
+	})
+	return edited, nil
+}
+
+// writeEditedFile writes the new content of the file and records it in the
+// file history, like the edit tool.
+func writeEditedFile(ctx context.Context, files history.Service, sessionID string, file editedFile) error {
+	newContent := file.newContent
+	if file.crlf {
+		newContent, _ = fsext.ToWindowsLineEndings(newContent)
+	}
+	if err := os.WriteFile(file.path, []byte(newContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	// Check if file exists in history
+	existing, err := files.GetByPathAndSession(ctx, file.path, sessionID)
+	if err != nil {
+		_, err = files.Create(ctx, sessionID, file.path, file.oldContent)
+		if err != nil {
+			return fmt.Errorf("error creating file history: %w", err)
+		}
+	}
+	if existing.Content != file.oldContent {
+		// User manually changed the content; store an intermediate version
+		_, err = files.CreateVersion(ctx, sessionID, file.path, file.oldContent)
+		if err != nil {
+			slog.Error("Error creating file history version", "error", err)
+		}
+	}
+	// Store the new version
+	_, err = files.CreateVersion(ctx, sessionID, file.path, file.newContent)
+	if err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+
+	filetracker.RecordWrite(file.path)
+	filetracker.RecordRead(file.path)
+	return nil
+}
@@ -0,0 +1,166 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolMatches(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	content := "package main\n\nfunc helper() {}\n\nfunc main() {\n\thelper()\n}\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	t.Run("line of file", func(t *testing.T) {
+		t.Parallel()
+		matches, err := symbolMatches(t.Context(), LSPSymbolParams{Symbol: "helper", FilePath: "main.go", Line: 6}, dir)
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		require.Equal(t, path, matches[0].path)
+		require.Equal(t, 6, matches[0].lineNum)
+		require.Equal(t, 2, matches[0].charNum)
+	})
+
+	t.Run("whole file", func(t *testing.T) {
+		t.Parallel()
+		matches, err := symbolMatches(t.Context(), LSPSymbolParams{Symbol: "helper", FilePath: path}, dir)
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+		require.Equal(t, 3, matches[0].lineNum)
+		require.Equal(t, 6, matches[0].charNum)
+		require.Equal(t, 6, matches[1].lineNum)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+		_, err := symbolMatches(t.Context(), LSPSymbolParams{Symbol: "helper", FilePath: "other.go"}, dir)
+		require.Error(t, err)
+	})
+
+	t.Run("whole identifiers only", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "foo.go")
+		content := "package main\n\nvar myFoo, FooBar = 1, 2\n\nfunc Foo() { _ = FooBar + myFoo }\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		matches, err := symbolMatches(t.Context(), LSPSymbolParams{Symbol: "Foo", FilePath: path}, dir)
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		require.Equal(t, 5, matches[0].lineNum)
+		require.Equal(t, 6, matches[0].charNum)
+
+		matches, err = symbolMatches(t.Context(), LSPSymbolParams{Symbol: "Foo"}, dir)
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		require.Equal(t, 5, matches[0].lineNum)
+	})
+}
+
+func TestSymbolPattern(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, `\bFoo\b`, symbolPattern("Foo"))
+	require.Equal(t, `\bfoo\.Bar\b`, symbolPattern("foo.Bar"))
+	require.Equal(t, `\boperator\+`, symbolPattern("operator+"))
+}
+
+func TestRangeText(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\n// 🚀 Foo\nfunc Foo() {}\n"), 0o644))
+
+	rng := func(line, start, end uint32) protocol.Range {
+		return protocol.Range{
+			Start: protocol.Position{Line: line, Character: start},
+			End:   protocol.Position{Line: line, Character: end},
+		}
+	}
+	require.Equal(t, "Foo", rangeText(path, rng(3, 5, 8)))
+	require.Equal(t, "Foo", rangeText(path, rng(2, 6, 9)))
+	require.Empty(t, rangeText(path, rng(3, 5, 80)))
+	require.Empty(t, rangeText(path, protocol.Range{End: protocol.Position{Line: 1}}))
+}
+
+func TestWorkspaceEditFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.go")
+	b := filepath.Join(dir, "b.go")
+	require.NoError(t, os.WriteFile(a, []byte("func old() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(b, []byte("x := old()\r\ny := old()\r\n"), 0o644))
+
+	rename := func(line, character uint32) protocol.TextEdit {
+		return protocol.TextEdit{
+			Range: protocol.Range{
+				Start: protocol.Position{Line: line, Character: character},
+				End:   protocol.Position{Line: line, Character: character + 3},
+			},
+			NewText: "renamed",
+		}
+	}
+
+	t.Run("changes and document changes", func(t *testing.T) {
+		t.Parallel()
+		renamed, err := workspaceEditFiles(protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				protocol.URIFromPath(a): {rename(0, 5)},
+			},
+			DocumentChanges: []protocol.DocumentChange{{
+				TextDocumentEdit: &protocol.TextDocumentEdit{
+					TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+						TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(b)},
+					},
+					Edits: []protocol.Or_TextDocumentEdit_edits_Elem{
+						{Value: rename(0, 5)},
+						{Value: rename(1, 5)},
+					},
+				},
+			}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []editedFile{
+			{path: a, oldContent: "func old() {}\n", newContent: "func renamed() {}\n"},
+			{path: b, oldContent: "x := old()\ny := old()\n", newContent: "x := renamed()\ny := renamed()\n", crlf: true},
+		}, renamed)
+
+		// Nothing is written until the rename is applied.
+		content, err := os.ReadFile(a)
+		require.NoError(t, err)
+		require.Equal(t, "func old() {}\n", string(content))
+	})
+
+	t.Run("file operations", func(t *testing.T) {
+		t.Parallel()
+		_, err := workspaceEditFiles(protocol.WorkspaceEdit{
+			DocumentChanges: []protocol.DocumentChange{{
+				RenameFile: &protocol.RenameFile{
+					OldURI: protocol.URIFromPath(a),
+					NewURI: protocol.URIFromPath(filepath.Join(dir, "c.go")),
+				},
+			}},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestFileRange(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, protocol.Range{}, fileRange(""))
+	require.Equal(t, protocol.Range{
+		End: protocol.Position{Line: 3, Character: 0},
+	}, fileRange("package main\n\nfunc main() {}\n"))
+	// Characters count UTF-16 code units, so the emoji counts twice.
+	require.Equal(t, protocol.Range{
+		End: protocol.Position{Line: 1, Character: 4},
+	}, fileRange("a\nb😀c"))
+}
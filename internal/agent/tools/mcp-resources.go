@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+)
+
+type MCPListResourcesParams struct {
+	MCPName string `json:"mcp_name,omitempty" description:"The name of the MCP server to list the resources of. Lists the resources of all servers when omitted."`
+}
+
+type MCPReadResourceParams struct {
+	MCPName string `json:"mcp_name,omitempty" description:"The name of the MCP server the resource belongs to. Required for URIs built from resource templates."`
+	URI     string `json:"uri" description:"The URI of the resource to read"`
+}
+
+const (
+	MCPListResourcesToolName = "mcp_list_resources"
+	MCPReadResourceToolName  = "mcp_read_resource"
+)
+
+//go:embed mcp_list_resources.md
+var mcpListResourcesDescription []byte
+
+//go:embed mcp_read_resource.md
+var mcpReadResourceDescription []byte
+
+// NewMCPListResourcesTool lists the resources of the MCP servers in
+// allowedMCP, or of all of them when it's nil.
+func NewMCPListResourcesTool(allowedMCP map[string][]string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		MCPListResourcesToolName,
+		string(mcpListResourcesDescription),
+		func(ctx context.Context, params MCPListResourcesParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			resources := map[string][]*mcp.Resource{}
+			for name, list := range mcp.Resources() {
+				resources[name] = list
+			}
+			templates := map[string][]*mcp.ResourceTemplate{}
+			for name, list := range mcp.ResourceTemplates() {
+				templates[name] = list
+			}
+
+			var names []string
+			for name := range mcp.GetStates() {
+				if params.MCPName != "" && name != params.MCPName {
+					continue
+				}
+				if !mcpAllowed(allowedMCP, name) {
+					continue
+				}
+				if len(resources[name]) > 0 || len(templates[name]) > 0 {
+					names = append(names, name)
+				}
+			}
+			slices.Sort(names)
+
+			if len(names) == 0 {
+				if params.MCPName != "" {
+					return fantasy.NewTextResponse(fmt.Sprintf("MCP server '%s' has no resources", params.MCPName)), nil
+				}
+				return fantasy.NewTextResponse("No MCP resources available"), nil
+			}
+
+			var output strings.Builder
+			for i, name := range names {
+				if i > 0 {
+					output.WriteString("\n")
+				}
+				fmt.Fprintf(&output, "<mcp name=%q>\n", name)
+				for _, resource := range resources[name] {
+					writeResourceLine(&output, resource.URI, cmp.Or(resource.Title, resource.Name), resource.Description, resource.MIMEType)
+				}
+				if len(templates[name]) > 0 {
+					output.WriteString("Templates:\n")
+					for _, template := range templates[name] {
+						writeResourceLine(&output, template.URITemplate, cmp.Or(template.Title, template.Name), template.Description, template.MIMEType)
+					}
+				}
+				output.WriteString("</mcp>\n")
+			}
+			return fantasy.NewTextResponse(output.String()), nil
+		})
+}
+
+func writeResourceLine(output *strings.Builder, uri, name, description, mimeType string) {
+	fmt.Fprintf(output, "- %s", uri)
+	if name != "" {
+		fmt.Fprintf(output, " (%s)", name)
+	}
+	if mimeType != "" {
+		fmt.Fprintf(output, " [%s]", mimeType)
+	}
+	if description != "" {
+		fmt.Fprintf(output, ": %s", strings.Join(strings.Fields(description), " "))
+	}
+	output.WriteString("\n")
+}
+
+// NewMCPReadResourceTool reads resources of the MCP servers in allowedMCP, or
+// of all of them when it's nil.
+func NewMCPReadResourceTool(allowedMCP map[string][]string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		MCPReadResourceToolName,
+		string(mcpReadResourceDescription),
+		func(ctx context.Context, params MCPReadResourceParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.URI == "" {
+				return fantasy.NewTextErrorResponse("uri is required"), nil
+			}
+
+			name := params.MCPName
+			if name == "" {
+				name = resourceMCP(params.URI)
+				if name == "" {
+					return fantasy.NewTextErrorResponse(fmt.Sprintf("no MCP server lists the resource %s, pass mcp_name to read it", params.URI)), nil
+				}
+			}
+			if !mcpAllowed(allowedMCP, name) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("MCP server '%s' is not available", name)), nil
+			}
+
+			contents, err := mcp.ReadResource(ctx, name, params.URI)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(contents) == 0 {
+				return fantasy.NewTextResponse(fmt.Sprintf("Resource %s is empty", params.URI)), nil
+			}
+
+			var texts []string
+			for _, content := range contents {
+				switch {
+				case content.Blob == nil:
+					texts = append(texts, content.Text)
+				case strings.HasPrefix(content.MIMEType, "image/") && len(contents) == 1:
+					if !GetSupportsImagesFromContext(ctx) {
+						modelName := GetModelNameFromContext(ctx)
+						return fantasy.NewTextErrorResponse(fmt.Sprintf("This model (%s) does not support image data.", modelName)), nil
+					}
+					return fantasy.NewImageResponse(content.Blob, content.MIMEType), nil
+				default:
+					texts = append(texts, fmt.Sprintf("[%s: %d bytes of %s data]", content.URI, len(content.Blob), cmp.Or(content.MIMEType, "binary")))
+				}
+			}
+			return fantasy.NewTextResponse(strings.Join(texts, "\n\n")), nil
+		})
+}
+
+// resourceMCP returns the name of the MCP server listing the resource, or an
+// empty string.
+func resourceMCP(uri string) string {
+	for name, resources := range mcp.Resources() {
+		for _, resource := range resources {
+			if resource.URI == uri {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// mcpAllowed reports whether an agent allowing allowedMCP may use the MCP
+// server. A nil map allows every server.
+func mcpAllowed(allowedMCP map[string][]string, name string) bool {
+	if allowedMCP == nil {
+		return true
+	}
+	_, ok := allowedMCP[name]
+	return ok
+}
@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/oauth/mcpauth"
+)
+
+var (
+	// oauthCredentials holds the current OAuth credentials of the MCPs, which
+	// replace the configured ones once refreshed.
+	oauthCredentials = csync.NewMap[string, *mcpauth.Credentials]()
+	refreshMu        sync.Mutex
+
+	// unauthorized holds the MCPs whose requests were rejected for lack of
+	// authorization.
+	unauthorized = csync.NewMap[string, struct{}]()
+)
+
+// errNeedsAuth is the error of the MCPs in [StateNeedsAuth].
+func errNeedsAuth(name string) error {
+	return fmt.Errorf("authorization required, run `crush login mcp %s`", name)
+}
+
+// accessToken returns the OAuth access token for the MCP, refreshing it when
+// it expired. It returns an empty string when the MCP has no OAuth
+// credentials, or credentials issued for another URL, as a project can
+// configure another server under the name of one the user logged in to.
+func accessToken(ctx context.Context, name string, m config.MCPConfig) (string, error) {
+	if m.OAuth == nil {
+		return "", nil
+	}
+	if !m.OAuth.For(m.URL) {
+		slog.Warn("Not sending MCP OAuth token issued for another URL", "name", name, "url", m.URL)
+		return "", nil
+	}
+	creds := oauthCredentials.GetOrSet(name, func() *mcpauth.Credentials {
+		return m.OAuth
+	})
+	if !creds.For(m.URL) {
+		creds = m.OAuth
+		oauthCredentials.Set(name, creds)
+	}
+	if !creds.Expired() {
+		return creds.Token.AccessToken, nil
+	}
+
+	refreshMu.Lock()
+	defer refreshMu.Unlock()
+	// Another request may have refreshed it already.
+	if creds, _ = oauthCredentials.Get(name); !creds.Expired() {
+		return creds.Token.AccessToken, nil
+	}
+
+	refreshed, err := mcpauth.Refresh(ctx, creds)
+	if err != nil {
+		unauthorized.Set(name, struct{}{})
+		return "", fmt.Errorf("failed to refresh OAuth token: %w", err)
+	}
+	oauthCredentials.Set(name, refreshed)
+	if err := config.Get().SetConfigField(fmt.Sprintf("mcp.%s.oauth", name), refreshed); err != nil {
+		slog.Warn("Failed to persist refreshed MCP OAuth token", "name", name, "error", err)
+	}
+	slog.Info("Successfully refreshed MCP OAuth token", "name", name)
+	return refreshed.Token.AccessToken, nil
+}
+
+// authRoundTripper sets the configured headers and the OAuth token of the MCP
+// on its requests, and records the rejected ones.
+type authRoundTripper struct {
+	name    string
+	mcp     config.MCPConfig
+	headers map[string]string
+}
+
+func (rt authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	for k, v := range rt.headers {
+		req.Header.Set(k, v)
+	}
+	if req.Header.Get("Authorization") == "" {
+		token, err := accessToken(req.Context(), rt.name, rt.mcp)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		unauthorized.Set(rt.name, struct{}{})
+	}
+	return resp, err
+}
+
+// connectErr returns the state and the error of an MCP that failed to
+// connect, telling apart the ones that need authorization.
+func connectErr(name string, err error) (State, error) {
+	if _, ok := unauthorized.Take(name); ok {
+		return StateNeedsAuth, errNeedsAuth(name)
+	}
+	return StateError, err
+}
@@ -0,0 +1,33 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/oauth"
+	"github.com/charmbracelet/crush/internal/oauth/mcpauth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessTokenChecksServerURL(t *testing.T) {
+	t.Parallel()
+
+	creds := &mcpauth.Credentials{
+		ServerURL: "https://mcp.example.com/mcp",
+		Token:     &oauth.Token{AccessToken: "secret"},
+	}
+
+	token, err := accessToken(t.Context(), "test-access-token-other-url", config.MCPConfig{
+		URL:   "https://attacker.example.com/mcp",
+		OAuth: creds,
+	})
+	require.NoError(t, err)
+	require.Empty(t, token)
+
+	token, err = accessToken(t.Context(), "test-access-token-same-url", config.MCPConfig{
+		URL:   "https://mcp.example.com/mcp",
+		OAuth: creds,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "secret", token)
+}
@@ -0,0 +1,240 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Elicitation actions, as defined by the protocol.
+const (
+	ElicitationAccept  = "accept"
+	ElicitationDecline = "decline"
+	ElicitationCancel  = "cancel"
+)
+
+// ElicitationRequest is a request of an MCP for input from the user, to be
+// answered with [RespondElicitation].
+type ElicitationRequest struct {
+	ID      string
+	MCPName string
+	Message string
+	Fields  []ElicitationField
+}
+
+// ElicitationField is a field of the form the user fills to answer an
+// elicitation request.
+type ElicitationField struct {
+	Name        string
+	Title       string
+	Description string
+	// Type is one of "string", "number", "integer" or "boolean".
+	Type     string
+	Enum     []string
+	Default  string
+	Required bool
+}
+
+// Label returns the name to show for the field.
+func (f ElicitationField) Label() string {
+	if f.Title != "" {
+		return f.Title
+	}
+	return f.Name
+}
+
+// Hint returns the text to show in the empty input of the field.
+func (f ElicitationField) Hint() string {
+	switch {
+	case len(f.Enum) > 0:
+		return "One of " + strings.Join(f.Enum, ", ")
+	case f.Type == "boolean":
+		return "true or false"
+	case f.Description != "":
+		return f.Description
+	default:
+		return "Enter " + f.Label()
+	}
+}
+
+// Value converts the text the user entered to the value of the field. It
+// returns nil for an empty optional field.
+func (f ElicitationField) Value(text string) (any, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		if f.Required {
+			return nil, fmt.Errorf("%s is required", f.Label())
+		}
+		return nil, nil
+	}
+	if len(f.Enum) > 0 && !slices.Contains(f.Enum, text) {
+		return nil, fmt.Errorf("%s must be one of %s", f.Label(), strings.Join(f.Enum, ", "))
+	}
+	switch f.Type {
+	case "number":
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", f.Label())
+		}
+		return v, nil
+	case "integer":
+		v, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer", f.Label())
+		}
+		return v, nil
+	case "boolean":
+		v, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", f.Label())
+		}
+		return v, nil
+	default:
+		return text, nil
+	}
+}
+
+// Content converts the texts the user entered for the fields, by name, to
+// the content of the answer.
+func (r ElicitationRequest) Content(values map[string]string) (map[string]any, error) {
+	content := map[string]any{}
+	for _, field := range r.Fields {
+		v, err := field.Value(values[field.Name])
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			content[field.Name] = v
+		}
+	}
+	return content, nil
+}
+
+// ElicitationResponse is the answer of the user to an elicitation request.
+type ElicitationResponse struct {
+	Action  string
+	Content map[string]any
+}
+
+var (
+	elicitations        = pubsub.NewBroker[ElicitationRequest]()
+	pendingElicitations = csync.NewMap[string, chan ElicitationResponse]()
+	interactive         = csync.NewValue(false)
+)
+
+// SubscribeElicitations returns a channel for the elicitation requests of the
+// MCPs.
+func SubscribeElicitations(ctx context.Context) <-chan pubsub.Event[ElicitationRequest] {
+	return elicitations.Subscribe(ctx)
+}
+
+// SetInteractive sets whether a user can answer elicitation requests. When
+// nobody can, elicitation requests fail right away.
+func SetInteractive(v bool) {
+	interactive.Set(v)
+}
+
+// RespondElicitation answers the elicitation request with the given ID.
+func RespondElicitation(id string, response ElicitationResponse) {
+	ch, ok := pendingElicitations.Take(id)
+	if !ok {
+		return
+	}
+	ch <- response
+}
+
+// elicit asks the user to fill the form the MCP requests and waits for the
+// answer.
+func elicit(ctx context.Context, name string, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+	if !interactive.Get() {
+		return nil, errors.New("crush is running non-interactively and can't ask the user for input")
+	}
+	if req.Params.Mode == "url" {
+		return nil, errors.New("URL elicitation isn't supported")
+	}
+	fields, err := elicitationFields(req.Params.RequestedSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	request := ElicitationRequest{
+		ID:      uuid.NewString(),
+		MCPName: name,
+		Message: req.Params.Message,
+		Fields:  fields,
+	}
+	ch := make(chan ElicitationResponse, 1)
+	pendingElicitations.Set(request.ID, ch)
+	defer pendingElicitations.Del(request.ID)
+	elicitations.Publish(pubsub.CreatedEvent, request)
+
+	select {
+	case response := <-ch:
+		result := &mcp.ElicitResult{Action: response.Action}
+		if response.Action == ElicitationAccept {
+			result.Content = response.Content
+		}
+		return result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type elicitationSchema struct {
+	Properties map[string]struct {
+		Type        string `json:"type"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Enum        []any  `json:"enum"`
+		Default     any    `json:"default"`
+	} `json:"properties"`
+	Required []string `json:"required"`
+}
+
+// elicitationFields returns the fields of the form described by the
+// requested schema, sorted by name. The protocol limits the schema to an
+// object with primitive properties.
+func elicitationFields(schema any) ([]ElicitationField, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+	var parsed elicitationSchema
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid requested schema: %w", err)
+	}
+
+	fields := make([]ElicitationField, 0, len(parsed.Properties))
+	for name, prop := range parsed.Properties {
+		field := ElicitationField{
+			Name:        name,
+			Title:       prop.Title,
+			Description: prop.Description,
+			Type:        prop.Type,
+			Required:    slices.Contains(parsed.Required, name),
+		}
+		for _, v := range prop.Enum {
+			field.Enum = append(field.Enum, fmt.Sprint(v))
+		}
+		if prop.Default != nil {
+			field.Default = fmt.Sprint(prop.Default)
+		}
+		fields = append(fields, field)
+	}
+	slices.SortFunc(fields, func(a, b ElicitationField) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return fields, nil
+}
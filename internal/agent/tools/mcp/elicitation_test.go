@@ -0,0 +1,78 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestElicitationFields(t *testing.T) {
+	t.Parallel()
+
+	fields, err := elicitationFields(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "title": "Name", "description": "Your name"},
+			"count": map[string]any{"type": "integer", "default": 3},
+			"level": map[string]any{"type": "string", "enum": []any{"low", "high"}},
+		},
+		"required": []any{"name"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []ElicitationField{
+		{Name: "count", Type: "integer", Default: "3"},
+		{Name: "level", Type: "string", Enum: []string{"low", "high"}},
+		{Name: "name", Title: "Name", Description: "Your name", Type: "string", Required: true},
+	}, fields)
+
+	fields, err = elicitationFields(nil)
+	require.NoError(t, err)
+	require.Empty(t, fields)
+}
+
+func TestElicitationRequestContent(t *testing.T) {
+	t.Parallel()
+
+	request := ElicitationRequest{
+		Fields: []ElicitationField{
+			{Name: "name", Type: "string", Required: true},
+			{Name: "count", Type: "integer"},
+			{Name: "ratio", Type: "number"},
+			{Name: "confirm", Type: "boolean"},
+			{Name: "level", Type: "string", Enum: []string{"low", "high"}},
+		},
+	}
+
+	content, err := request.Content(map[string]string{
+		"name":    " crush ",
+		"count":   "3",
+		"ratio":   "0.5",
+		"confirm": "true",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"name":    "crush",
+		"count":   int64(3),
+		"ratio":   0.5,
+		"confirm": true,
+	}, content)
+
+	for name, values := range map[string]map[string]string{
+		"missing required": {"count": "3"},
+		"invalid integer":  {"name": "crush", "count": "three"},
+		"invalid boolean":  {"name": "crush", "confirm": "maybe"},
+		"invalid enum":     {"name": "crush", "level": "medium"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := request.Content(values)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestFileURI(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "file:///home/user/my%20project", fileURI("/home/user/my project"))
+}
@@ -41,6 +41,9 @@ const (
 	StateStarting
 	StateConnected
 	StateError
+	// StateNeedsAuth is the state of remote MCPs that reject Crush until the
+	// user authorizes it.
+	StateNeedsAuth
 )
 
 func (s State) String() string {
@@ -53,6 +56,8 @@ func (s State) String() string {
 		return "connected"
 	case StateError:
 		return "error"
+	case StateNeedsAuth:
+		return "needs auth"
 	default:
 		return "unknown"
 	}
@@ -65,6 +70,8 @@ const (
 	EventStateChanged EventType = iota
 	EventToolsListChanged
 	EventPromptsListChanged
+	EventResourcesListChanged
+	EventResourceUpdated
 )
 
 // Event represents an event in the MCP system
@@ -74,12 +81,15 @@ type Event struct {
 	State  State
 	Error  error
 	Counts Counts
+	// URI is the resource an [EventResourceUpdated] is about.
+	URI string
 }
 
 // Counts number of available tools, prompts, etc.
 type Counts struct {
-	Tools   int
-	Prompts int
+	Tools     int
+	Prompts   int
+	Resources int
 }
 
 // ClientInfo holds information about an MCP client's state
@@ -130,11 +140,16 @@ func Close() error {
 	case <-time.After(5 * time.Second):
 	}
 	broker.Shutdown()
+	elicitations.Shutdown()
 	return nil
 }
 
 // Initialize initializes MCP clients based on the provided configuration.
-func Initialize(ctx context.Context, permissions permission.Service, cfg *config.Config) {
+func Initialize(ctx context.Context, p permission.Service, cfg *config.Config) {
+	permissions.Set(p)
+	workingDir.Set(cfg.WorkingDir())
+	AddRoots(cfg.WorkingDir())
+
 	var wg sync.WaitGroup
 	// Initialize states for all configured MCPs
 	for name, m := range cfg.MCP {
@@ -188,13 +203,23 @@ func Initialize(ctx context.Context, permissions permission.Service, cfg *config
 				return
 			}
 
+			resources, templates, err := getResources(ctx, session)
+			if err != nil {
+				slog.Error("error listing resources", "error", err)
+				updateState(name, StateError, err, nil, Counts{})
+				session.Close()
+				return
+			}
+
 			toolCount := updateTools(name, tools)
 			updatePrompts(name, prompts)
+			updateResources(name, resources, templates)
 			sessions.Set(name, session)
 
 			updateState(name, StateConnected, nil, session, Counts{
-				Tools:   toolCount,
-				Prompts: len(prompts),
+				Tools:     toolCount,
+				Prompts:   len(prompts),
+				Resources: len(resources) + len(templates),
 			})
 		}(name, m)
 	}
@@ -254,7 +279,7 @@ func updateState(name string, state State, err error, client *mcp.ClientSession,
 	switch state {
 	case StateConnected:
 		info.ConnectedAt = time.Now()
-	case StateError:
+	case StateError, StateNeedsAuth:
 		sessions.Del(name)
 	}
 	states.Set(name, info)
@@ -274,7 +299,8 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 	mcpCtx, cancel := context.WithCancel(ctx)
 	cancelTimer := time.AfterFunc(timeout, cancel)
 
-	transport, err := createTransport(mcpCtx, m, resolver)
+	unauthorized.Del(name)
+	transport, err := createTransport(mcpCtx, name, m, resolver)
 	if err != nil {
 		updateState(name, StateError, err, nil, Counts{})
 		slog.Error("error creating mcp client", "error", err, "name", name)
@@ -302,23 +328,46 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 					Name: name,
 				})
 			},
+			ResourceListChangedHandler: func(context.Context, *mcp.ResourceListChangedRequest) {
+				broker.Publish(pubsub.UpdatedEvent, Event{
+					Type: EventResourcesListChanged,
+					Name: name,
+				})
+			},
+			ResourceUpdatedHandler: func(_ context.Context, req *mcp.ResourceUpdatedNotificationRequest) {
+				broker.Publish(pubsub.UpdatedEvent, Event{
+					Type: EventResourceUpdated,
+					Name: name,
+					URI:  req.Params.URI,
+				})
+			},
 			LoggingMessageHandler: func(_ context.Context, req *mcp.LoggingMessageRequest) {
 				slog.Info("MCP log", "name", name, "data", req.Params.Data)
 			},
+			CreateMessageHandler: func(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+				return createMessage(ctx, name, req)
+			},
+			ElicitationHandler: func(ctx context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+				return elicit(ctx, name, req)
+			},
 		},
 	)
+	client.AddRoots(currentRoots()...)
+	clients.Set(name, client)
 
 	session, err := client.Connect(mcpCtx, transport, nil)
 	if err != nil {
 		err = maybeStdioErr(err, transport)
-		updateState(name, StateError, maybeTimeoutErr(err, timeout), nil, Counts{})
 		slog.Error("MCP client failed to initialize", "error", err, "name", name)
+		state, err := connectErr(name, maybeTimeoutErr(err, timeout))
+		updateState(name, state, err, nil, Counts{})
 		cancel()
 		cancelTimer.Stop()
 		return nil, err
 	}
 
 	cancelTimer.Stop()
+	resubscribe(ctx, name, session)
 	slog.Info("MCP client initialized", "name", name)
 	return session, nil
 }
@@ -351,7 +400,7 @@ func maybeTimeoutErr(err error, timeout time.Duration) error {
 	return err
 }
 
-func createTransport(ctx context.Context, m config.MCPConfig, resolver config.VariableResolver) (mcp.Transport, error) {
+func createTransport(ctx context.Context, name string, m config.MCPConfig, resolver config.VariableResolver) (mcp.Transport, error) {
 	switch m.Type {
 	case config.MCPStdio:
 		command, err := resolver.ResolveValue(m.Command)
@@ -371,7 +420,9 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 			return nil, fmt.Errorf("mcp http config requires a non-empty 'url' field")
 		}
 		client := &http.Client{
-			Transport: &headerRoundTripper{
+			Transport: &authRoundTripper{
+				name:    name,
+				mcp:     m,
 				headers: m.ResolvedHeaders(),
 			},
 		}
@@ -384,7 +435,9 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 			return nil, fmt.Errorf("mcp sse config requires a non-empty 'url' field")
 		}
 		client := &http.Client{
-			Transport: &headerRoundTripper{
+			Transport: &authRoundTripper{
+				name:    name,
+				mcp:     m,
 				headers: m.ResolvedHeaders(),
 			},
 		}
@@ -397,17 +450,6 @@ func createTransport(ctx context.Context, m config.MCPConfig, resolver config.Va
 	}
 }
 
-type headerRoundTripper struct {
-	headers map[string]string
-}
-
-func (rt headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
-	for k, v := range rt.headers {
-		req.Header.Set(k, v)
-	}
-	return http.DefaultTransport.RoundTrip(req)
-}
-
 func mcpTimeout(m config.MCPConfig) time.Duration {
 	return time.Duration(cmp.Or(m.Timeout, 15)) * time.Second
 }
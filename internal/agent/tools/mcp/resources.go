@@ -0,0 +1,175 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type (
+	Resource         = mcp.Resource
+	ResourceTemplate = mcp.ResourceTemplate
+	ResourceContents = mcp.ResourceContents
+)
+
+var (
+	allResources         = csync.NewMap[string, []*Resource]()
+	allResourceTemplates = csync.NewMap[string, []*ResourceTemplate]()
+
+	// subscriptions holds the URIs of the resources read from each MCP, so
+	// createSession can subscribe to them again when it reconnects.
+	subscriptions = csync.NewMap[string, *csync.Map[string, struct{}]]()
+)
+
+// Resources returns all available MCP resources.
+func Resources() iter.Seq2[string, []*Resource] {
+	return allResources.Seq2()
+}
+
+// ResourceTemplates returns all available MCP resource templates.
+func ResourceTemplates() iter.Seq2[string, []*ResourceTemplate] {
+	return allResourceTemplates.Seq2()
+}
+
+// ReadResource reads the contents of the resource with the given URI. When the
+// MCP supports it, Crush subscribes to the resource to be notified of its
+// changes.
+func ReadResource(ctx context.Context, clientName, uri string) ([]*ResourceContents, error) {
+	c, err := getOrRenewClient(ctx, clientName)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		return nil, err
+	}
+	subscribe(ctx, clientName, c, uri)
+	return result.Contents, nil
+}
+
+// ResourceAttachment converts the contents of a resource to an attachment for
+// the prompt. Text contents are joined and attached as text, so they become
+// part of the prompt; otherwise the first binary content is attached. It
+// reports false when the resource has no contents.
+func ResourceAttachment(uri, name, mimeType string, contents []*ResourceContents) (message.Attachment, bool) {
+	attachment := message.Attachment{
+		FilePath: uri,
+		FileName: cmp.Or(name, path.Base(uri)),
+	}
+	var texts []string
+	for _, content := range contents {
+		if content.Blob == nil {
+			texts = append(texts, content.Text)
+			continue
+		}
+		if attachment.Content == nil {
+			attachment.Content = content.Blob
+			attachment.MimeType = cmp.Or(content.MIMEType, mimeType, http.DetectContentType(content.Blob))
+		}
+	}
+	if len(texts) > 0 {
+		attachment.Content = []byte(strings.Join(texts, "\n\n"))
+		attachment.MimeType = "text/plain"
+	}
+	return attachment, attachment.Content != nil
+}
+
+// RefreshResources gets the updated list of resources and resource templates
+// from the MCP and updates the global state.
+func RefreshResources(ctx context.Context, name string) {
+	session, ok := sessions.Get(name)
+	if !ok {
+		slog.Warn("refresh resources: no session", "name", name)
+		return
+	}
+
+	resources, templates, err := getResources(ctx, session)
+	if err != nil {
+		updateState(name, StateError, err, nil, Counts{})
+		return
+	}
+
+	updateResources(name, resources, templates)
+
+	prev, _ := states.Get(name)
+	prev.Counts.Resources = len(resources) + len(templates)
+	updateState(name, StateConnected, nil, session, prev.Counts)
+}
+
+func getResources(ctx context.Context, c *mcp.ClientSession) ([]*Resource, []*ResourceTemplate, error) {
+	if c.InitializeResult().Capabilities.Resources == nil {
+		return nil, nil, nil
+	}
+	var resources []*Resource
+	for resource, err := range c.Resources(ctx, nil) {
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, resource)
+	}
+	var templates []*ResourceTemplate
+	for template, err := range c.ResourceTemplates(ctx, nil) {
+		if err != nil {
+			return nil, nil, err
+		}
+		templates = append(templates, template)
+	}
+	return resources, templates, nil
+}
+
+// updateResources updates the global resource and resource template maps.
+func updateResources(mcpName string, resources []*Resource, templates []*ResourceTemplate) {
+	if len(resources) == 0 {
+		allResources.Del(mcpName)
+	} else {
+		allResources.Set(mcpName, resources)
+	}
+	if len(templates) == 0 {
+		allResourceTemplates.Del(mcpName)
+	} else {
+		allResourceTemplates.Set(mcpName, templates)
+	}
+}
+
+// subscribe subscribes to the updates of the resource, if the MCP supports it
+// and it isn't subscribed already.
+func subscribe(ctx context.Context, name string, c *mcp.ClientSession, uri string) {
+	caps := c.InitializeResult().Capabilities.Resources
+	if caps == nil || !caps.Subscribe {
+		return
+	}
+	uris := subscriptions.GetOrSet(name, func() *csync.Map[string, struct{}] {
+		return csync.NewMap[string, struct{}]()
+	})
+	if _, ok := uris.Get(uri); ok {
+		return
+	}
+	if err := c.Subscribe(ctx, &mcp.SubscribeParams{URI: uri}); err != nil {
+		slog.Warn("Failed to subscribe to MCP resource", "name", name, "uri", uri, "error", err)
+		return
+	}
+	uris.Set(uri, struct{}{})
+}
+
+// resubscribe subscribes the new session of the MCP to the resources the
+// previous one was subscribed to.
+func resubscribe(ctx context.Context, name string, c *mcp.ClientSession) {
+	uris, ok := subscriptions.Get(name)
+	if !ok {
+		return
+	}
+	for uri := range uris.Seq2() {
+		if err := c.Subscribe(ctx, &mcp.SubscribeParams{URI: uri}); err != nil {
+			slog.Warn("Failed to subscribe to MCP resource", "name", name, "uri", uri, "error", err)
+			uris.Del(uri)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceAttachment(t *testing.T) {
+	t.Parallel()
+
+	t.Run("text", func(t *testing.T) {
+		t.Parallel()
+		attachment, ok := ResourceAttachment("docs://guide/intro.md", "", "text/markdown", []*ResourceContents{
+			{URI: "docs://guide/intro.md", MIMEType: "text/markdown", Text: "# Intro"},
+			{URI: "docs://guide/intro.md#usage", MIMEType: "application/json", Text: `{"usage":true}`},
+		})
+		require.True(t, ok)
+		require.Equal(t, "docs://guide/intro.md", attachment.FilePath)
+		require.Equal(t, "intro.md", attachment.FileName)
+		require.Equal(t, "text/plain", attachment.MimeType)
+		require.Equal(t, "# Intro\n\n{\"usage\":true}", string(attachment.Content))
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		t.Parallel()
+		attachment, ok := ResourceAttachment("schemas://logo", "Logo", "", []*ResourceContents{
+			{URI: "schemas://logo", MIMEType: "image/png", Blob: []byte{0x89, 'P', 'N', 'G'}},
+		})
+		require.True(t, ok)
+		require.Equal(t, "Logo", attachment.FileName)
+		require.Equal(t, "image/png", attachment.MimeType)
+		require.True(t, attachment.IsImage())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		_, ok := ResourceAttachment("tickets://1", "", "", nil)
+		require.False(t, ok)
+	})
+}
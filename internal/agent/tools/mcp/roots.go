@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var (
+	// clients holds the client of each MCP, so roots added after it connects
+	// are announced to it.
+	clients = csync.NewMap[string, *mcp.Client]()
+	roots   = csync.NewMap[string, struct{}]()
+)
+
+// AddRoots adds the directories to the roots Crush exposes to the MCPs, such
+// as the directory of a new worktree, and notifies the connected ones.
+func AddRoots(paths ...string) {
+	var added []*mcp.Root
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if _, ok := roots.Get(path); ok {
+			continue
+		}
+		roots.Set(path, struct{}{})
+		added = append(added, newRoot(path))
+	}
+	if len(added) == 0 {
+		return
+	}
+	for client := range clients.Seq() {
+		client.AddRoots(added...)
+	}
+}
+
+// currentRoots returns the roots to add to a new client.
+func currentRoots() []*mcp.Root {
+	var paths []string
+	for path := range roots.Seq2() {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	result := make([]*mcp.Root, 0, len(paths))
+	for _, path := range paths {
+		result = append(result, newRoot(path))
+	}
+	return result
+}
+
+func newRoot(path string) *mcp.Root {
+	return &mcp.Root{
+		URI:  fileURI(path),
+		Name: filepath.Base(path),
+	}
+}
+
+// fileURI returns the file URI of the absolute path.
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		// Windows paths start with the drive letter.
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
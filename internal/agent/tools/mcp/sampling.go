@@ -0,0 +1,153 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type (
+	CreateMessageParams = mcp.CreateMessageParams
+	CreateMessageResult = mcp.CreateMessageResult
+	TextContent         = mcp.TextContent
+	ImageContent        = mcp.ImageContent
+	AudioContent        = mcp.AudioContent
+)
+
+// SamplingFunc generates the message an MCP asks for with a sampling
+// request.
+type SamplingFunc func(ctx context.Context, params *CreateMessageParams) (*CreateMessageResult, error)
+
+var (
+	sampler = csync.NewValue[SamplingFunc](nil)
+
+	// runningCalls holds the session of each running tool call. Sampling
+	// requests happen while a tool runs, so they're approved in, and
+	// attributed to, the session of the running calls of the MCP.
+	runningCalls = csync.NewMap[toolCall, string]()
+	lastCallID   atomic.Uint64
+
+	permissions = csync.NewValue[permission.Service](nil)
+	workingDir  = csync.NewValue("")
+)
+
+// toolCall identifies a running tool call of an MCP.
+type toolCall struct {
+	mcp string
+	id  uint64
+}
+
+// startCall records the session of a tool call of the MCP, until the
+// returned function is called when the call is done.
+func startCall(name, sessionID string) func() {
+	call := toolCall{mcp: name, id: lastCallID.Add(1)}
+	runningCalls.Set(call, sessionID)
+	return func() { runningCalls.Del(call) }
+}
+
+// callerSession returns the session of the running tool calls of the MCP.
+// Requests don't tell which call they come from, so it fails when calls of
+// several sessions run.
+func callerSession(name string) (string, error) {
+	var sessionID string
+	var found bool
+	for call, id := range runningCalls.Seq2() {
+		if call.mcp != name {
+			continue
+		}
+		if found && id != sessionID {
+			return "", errors.New("sampling isn't supported while tools of the server run in several sessions")
+		}
+		sessionID, found = id, true
+	}
+	if !found {
+		return "", errors.New("sampling is only supported while one of the tools of the server runs")
+	}
+	return sessionID, nil
+}
+
+// SetSampler sets the function that generates the messages of the sampling
+// requests. Until it's set, sampling requests fail.
+func SetSampler(fn SamplingFunc) {
+	sampler.Set(fn)
+}
+
+// createMessage handles a sampling request of the MCP, once the user
+// approves it.
+func createMessage(ctx context.Context, name string, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	sample := sampler.Get()
+	if sample == nil {
+		return nil, errors.New("no model is available for sampling")
+	}
+	sessionID, err := callerSession(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if p := permissions.Get(); p != nil {
+		params, err := json.Marshal(samplingPermissionParams(req.Params))
+		if err != nil {
+			return nil, err
+		}
+		granted, err := p.Request(ctx, permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        workingDir.Get(),
+			MCP:         name,
+			ToolName:    fmt.Sprintf("mcp_%s_sampling", name),
+			Action:      "sample",
+			Description: fmt.Sprintf("generate a message for %s with the small model:", name),
+			Params:      string(params),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !granted {
+			return nil, errors.New("the user denied the sampling request")
+		}
+	}
+
+	return sample(ctx, req.Params)
+}
+
+type samplingMessage struct {
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+type samplingParams struct {
+	SystemPrompt string            `json:"system_prompt,omitempty"`
+	Messages     []samplingMessage `json:"messages"`
+	MaxTokens    int64             `json:"max_tokens"`
+}
+
+// samplingPermissionParams returns what the user approves of a sampling
+// request. Non-text contents are replaced by their type.
+func samplingPermissionParams(params *mcp.CreateMessageParams) samplingParams {
+	result := samplingParams{
+		SystemPrompt: params.SystemPrompt,
+		MaxTokens:    params.MaxTokens,
+	}
+	for _, msg := range params.Messages {
+		var text string
+		switch content := msg.Content.(type) {
+		case *mcp.TextContent:
+			text = content.Text
+		case *mcp.ImageContent:
+			text = fmt.Sprintf("[image: %s]", content.MIMEType)
+		case *mcp.AudioContent:
+			text = fmt.Sprintf("[audio: %s]", content.MIMEType)
+		}
+		result.Messages = append(result.Messages, samplingMessage{
+			Role: string(msg.Role),
+			Text: strings.TrimSpace(text),
+		})
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallerSession(t *testing.T) {
+	t.Parallel()
+
+	const name = "test-caller-session"
+	_, err := callerSession(name)
+	require.ErrorContains(t, err, "only supported while one of the tools of the server runs")
+
+	doneA := startCall(name, "session-a")
+	doneA2 := startCall(name, "session-a")
+	sessionID, err := callerSession(name)
+	require.NoError(t, err)
+	require.Equal(t, "session-a", sessionID)
+
+	doneB := startCall(name, "session-b")
+	_, err = callerSession(name)
+	require.ErrorContains(t, err, "several sessions")
+
+	doneA()
+	doneA2()
+	sessionID, err = callerSession(name)
+	require.NoError(t, err)
+	require.Equal(t, "session-b", sessionID)
+
+	doneB()
+	_, err = callerSession(name)
+	require.Error(t, err)
+}
@@ -31,12 +31,15 @@ func Tools() iter.Seq2[string, []*Tool] {
 	return allTools.Seq2()
 }
 
-// RunTool runs an MCP tool with the given input parameters.
-func RunTool(ctx context.Context, name, toolName string, input string) (ToolResult, error) {
+// RunTool runs an MCP tool with the given input parameters on behalf of the
+// session.
+func RunTool(ctx context.Context, sessionID, name, toolName string, input string) (ToolResult, error) {
 	var args map[string]any
 	if err := json.Unmarshal([]byte(input), &args); err != nil {
 		return ToolResult{}, fmt.Errorf("error parsing parameters: %s", err)
 	}
+	done := startCall(name, sessionID)
+	defer done()
 
 	c, err := getOrRenewClient(ctx, name)
 	if err != nil {
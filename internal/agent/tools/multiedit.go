@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
 	"fmt"
@@ -63,6 +64,8 @@ func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions pe
 		MultiEditToolName,
 		string(multieditDescription),
 		func(ctx context.Context, params MultiEditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := cmp.Or(GetWorkingDirFromContext(ctx), workingDir)
+
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
@@ -97,6 +100,7 @@ func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions pe
 				return response, nil
 			}
 
+			formatOnWrite(ctx, lspClients, files, params.FilePath)
 			// Notify LSP clients about the change
 			notifyLSPs(ctx, lspClients, params.FilePath)
 
@@ -176,6 +180,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	p, err := edit.permissions.Request(edit.ctx, permission.CreatePermissionRequest{
 		SessionID:   sessionID,
 		Path:        fsext.PathOrPrefix(params.FilePath, edit.workingDir),
+		FilePath:    params.FilePath,
 		ToolCallID:  call.ID,
 		ToolName:    MultiEditToolName,
 		Action:      "write",
@@ -200,7 +205,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	}
 
 	// Update file history
-	_, err = edit.files.Create(edit.ctx, sessionID, params.FilePath, "")
+	_, err = edit.files.CreateNew(edit.ctx, sessionID, params.FilePath)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
 	}
@@ -320,6 +325,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	p, err := edit.permissions.Request(edit.ctx, permission.CreatePermissionRequest{
 		SessionID:   sessionID,
 		Path:        fsext.PathOrPrefix(params.FilePath, edit.workingDir),
+		FilePath:    params.FilePath,
 		ToolCallID:  call.ID,
 		ToolName:    MultiEditToolName,
 		Action:      "write",
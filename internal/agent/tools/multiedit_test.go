@@ -29,6 +29,8 @@ func (m *mockPermissionService) Deny(req permission.PermissionRequest) {}
 
 func (m *mockPermissionService) GrantPersistent(req permission.PermissionRequest) {}
 
+func (m *mockPermissionService) GrantForProject(req permission.PermissionRequest) {}
+
 func (m *mockPermissionService) AutoApproveSession(sessionID string) {}
 
 func (m *mockPermissionService) SetSkipRequests(skip bool) {}
@@ -49,6 +51,10 @@ func (m *mockHistoryService) Create(ctx context.Context, sessionID, path, conten
 	return history.File{Path: path, Content: content}, nil
 }
 
+func (m *mockHistoryService) CreateNew(ctx context.Context, sessionID, path string) (history.File, error) {
+	return history.File{Path: path, IsNew: true}, nil
+}
+
 func (m *mockHistoryService) CreateVersion(ctx context.Context, sessionID, path, content string) (history.File, error) {
 	return history.File{}, nil
 }
@@ -77,6 +83,14 @@ func (m *mockHistoryService) DeleteSessionFiles(ctx context.Context, sessionID s
 	return nil
 }
 
+func (m *mockHistoryService) Checkpoint(ctx context.Context, sessionID, messageID string) error {
+	return nil
+}
+
+func (m *mockHistoryService) Revert(ctx context.Context, sessionID, messageID string) ([]string, error) {
+	return nil, nil
+}
+
 func TestApplyEditToContentPartialSuccess(t *testing.T) {
 	t.Parallel()
 
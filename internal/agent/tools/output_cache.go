@@ -1,6 +1,8 @@
 package tools
 
 import (
+	"context"
+	"log/slog"
 	"regexp"
 	"strings"
 	"sync"
@@ -8,10 +10,19 @@ import (
 )
 
 // OutputCache stores tool outputs for later retrieval via head/tail/grep operations.
-// Outputs are stored per-session and expire after a retention period.
+// Outputs are stored per-session and expire from memory after a retention
+// period. When the cache has a store, outputs are also persisted to it and
+// loaded back on demand, so they outlive the process.
 type OutputCache struct {
 	mu      sync.RWMutex
 	entries map[string]*outputEntry // keyed by "sessionID:toolCallID"
+	store   OutputStore
+}
+
+// OutputStore persists cached outputs.
+type OutputStore interface {
+	Save(ctx context.Context, sessionID, toolCallID, output string) error
+	Get(ctx context.Context, sessionID, toolCallID string) (string, bool, error)
 }
 
 type outputEntry struct {
@@ -44,26 +55,68 @@ func GetOutputCache() *OutputCache {
 	return globalOutputCache
 }
 
-// Store saves tool output for later retrieval.
-func (c *OutputCache) Store(sessionID, toolCallID, output string) {
+// SetStore sets the store outputs are persisted to.
+func (c *OutputCache) SetStore(store OutputStore) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.store = store
+}
 
+// Store saves tool output for later retrieval.
+func (c *OutputCache) Store(sessionID, toolCallID, output string) {
+	c.mu.Lock()
 	key := sessionID + ":" + toolCallID
-	c.entries[key] = &outputEntry{
+	c.entries[key] = newOutputEntry(output)
+	store := c.store
+	c.mu.Unlock()
+
+	if store == nil {
+		return
+	}
+	// Persist even if the tool call that produced the output is canceled.
+	if err := store.Save(context.Background(), sessionID, toolCallID, output); err != nil {
+		slog.Warn("Failed to persist tool output", "session_id", sessionID, "tool_call_id", toolCallID, "error", err)
+	}
+}
+
+func newOutputEntry(output string) *outputEntry {
+	return &outputEntry{
 		output:    output,
 		lines:     strings.Split(output, "\n"),
 		createdAt: time.Now(),
 	}
 }
 
-// Get retrieves raw output for a tool call.
-func (c *OutputCache) Get(sessionID, toolCallID string) (string, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
+// entry returns the cached output of a tool call, loading it from the store
+// if it's not in memory.
+func (c *OutputCache) entry(sessionID, toolCallID string) (*outputEntry, bool) {
 	key := sessionID + ":" + toolCallID
+	c.mu.RLock()
 	entry, ok := c.entries[key]
+	store := c.store
+	c.mu.RUnlock()
+	if ok || store == nil {
+		return entry, ok
+	}
+
+	output, ok, err := store.Get(context.Background(), sessionID, toolCallID)
+	if err != nil {
+		slog.Warn("Failed to load tool output", "session_id", sessionID, "tool_call_id", toolCallID, "error", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	entry = newOutputEntry(output)
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.mu.Unlock()
+	return entry, true
+}
+
+// Get retrieves raw output for a tool call.
+func (c *OutputCache) Get(sessionID, toolCallID string) (string, bool) {
+	entry, ok := c.entry(sessionID, toolCallID)
 	if !ok {
 		return "", false
 	}
@@ -72,11 +125,7 @@ func (c *OutputCache) Get(sessionID, toolCallID string) (string, bool) {
 
 // Head returns the first n lines of the cached output.
 func (c *OutputCache) Head(sessionID, toolCallID string, n, offset int) (result string, totalLines int, hasMore bool, ok bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	key := sessionID + ":" + toolCallID
-	entry, exists := c.entries[key]
+	entry, exists := c.entry(sessionID, toolCallID)
 	if !exists {
 		return "", 0, false, false
 	}
@@ -98,11 +147,7 @@ func (c *OutputCache) Head(sessionID, toolCallID string, n, offset int) (result
 
 // Tail returns the last n lines of the cached output.
 func (c *OutputCache) Tail(sessionID, toolCallID string, n, offset int) (result string, totalLines int, hasMore bool, ok bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	key := sessionID + ":" + toolCallID
-	entry, exists := c.entries[key]
+	entry, exists := c.entry(sessionID, toolCallID)
 	if !exists {
 		return "", 0, false, false
 	}
@@ -133,11 +178,7 @@ type GrepResult struct {
 
 // Grep searches for a pattern in the cached output.
 func (c *OutputCache) Grep(sessionID, toolCallID, pattern string, contextLines int) (matches []GrepResult, totalLines int, ok bool, err error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	key := sessionID + ":" + toolCallID
-	entry, exists := c.entries[key]
+	entry, exists := c.entry(sessionID, toolCallID)
 	if !exists {
 		return nil, 0, false, nil
 	}
@@ -168,18 +209,14 @@ func (c *OutputCache) Grep(sessionID, toolCallID, pattern string, contextLines i
 
 // TotalLines returns the total number of lines in the cached output.
 func (c *OutputCache) TotalLines(sessionID, toolCallID string) (int, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	key := sessionID + ":" + toolCallID
-	entry, ok := c.entries[key]
+	entry, ok := c.entry(sessionID, toolCallID)
 	if !ok {
 		return 0, false
 	}
 	return len(entry.lines), true
 }
 
-// Cleanup removes expired entries from the cache.
+// Cleanup removes expired entries from memory. Persisted outputs are kept.
 func (c *OutputCache) Cleanup() int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -197,7 +234,7 @@ func (c *OutputCache) Cleanup() int {
 	return removed
 }
 
-// Clear removes all entries for a session.
+// Clear removes all entries for a session from memory.
 func (c *OutputCache) Clear(sessionID string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
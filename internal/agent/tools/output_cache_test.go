@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -195,3 +196,43 @@ func TestTailOutput_LargeOutput(t *testing.T) {
 	resultLines := strings.Split(result, "\n")
 	require.Len(t, resultLines, DefaultOutputLines)
 }
+
+type memoryOutputStore map[string]string
+
+func (s memoryOutputStore) Save(_ context.Context, sessionID, toolCallID, output string) error {
+	s[sessionID+":"+toolCallID] = output
+	return nil
+}
+
+func (s memoryOutputStore) Get(_ context.Context, sessionID, toolCallID string) (string, bool, error) {
+	output, ok := s[sessionID+":"+toolCallID]
+	return output, ok, nil
+}
+
+func TestOutputCache_Store(t *testing.T) {
+	t.Parallel()
+
+	store := memoryOutputStore{}
+	cache := &OutputCache{
+		entries: make(map[string]*outputEntry),
+		store:   store,
+	}
+
+	content := "line1\nline2\nline3"
+	cache.Store("test-session", "tool-123", content)
+	require.Equal(t, content, store["test-session:tool-123"])
+
+	// A new cache, like after a restart, loads the output from the store.
+	restarted := &OutputCache{
+		entries: make(map[string]*outputEntry),
+		store:   store,
+	}
+	result, total, hasMore, ok := restarted.Head("test-session", "tool-123", 2, 0)
+	require.True(t, ok)
+	require.Equal(t, 3, total)
+	require.True(t, hasMore)
+	require.Equal(t, "line1\nline2", result)
+
+	_, ok = restarted.Get("other-session", "tool-123")
+	require.False(t, ok)
+}
@@ -78,7 +78,7 @@ func NewOutputHeadTool() fantasy.AgentTool {
 			cache := GetOutputCache()
 			result, totalLines, hasMore, ok := cache.Head(sessionID, params.ToolCallID, lines, params.Offset)
 			if !ok {
-				return fantasy.NewTextErrorResponse(fmt.Sprintf("No cached output found for tool call ID: %s. Output cache expires after %v.", params.ToolCallID, OutputCacheRetention)), nil
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("No cached output found for tool call ID: %s.", params.ToolCallID)), nil
 			}
 
 			linesShown := len(strings.Split(result, "\n"))
@@ -132,7 +132,7 @@ func NewOutputTailTool() fantasy.AgentTool {
 			cache := GetOutputCache()
 			result, totalLines, hasMore, ok := cache.Tail(sessionID, params.ToolCallID, lines, params.Offset)
 			if !ok {
-				return fantasy.NewTextErrorResponse(fmt.Sprintf("No cached output found for tool call ID: %s. Output cache expires after %v.", params.ToolCallID, OutputCacheRetention)), nil
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("No cached output found for tool call ID: %s.", params.ToolCallID)), nil
 			}
 
 			linesShown := len(strings.Split(result, "\n"))
@@ -191,7 +191,7 @@ func NewOutputGrepTool() fantasy.AgentTool {
 				return fantasy.NewTextErrorResponse(fmt.Sprintf("Invalid regex pattern: %v", err)), nil
 			}
 			if !ok {
-				return fantasy.NewTextErrorResponse(fmt.Sprintf("No cached output found for tool call ID: %s. Output cache expires after %v.", params.ToolCallID, OutputCacheRetention)), nil
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("No cached output found for tool call ID: %s.", params.ToolCallID)), nil
 			}
 
 			var output strings.Builder
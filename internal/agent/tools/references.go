@@ -47,7 +47,7 @@ func NewReferencesTool(lspClients *csync.Map[string, *lsp.Client]) fantasy.Agent
 				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
 			}
 
-			workingDir := cmp.Or(params.Path, ".")
+			workingDir := cmp.Or(params.Path, GetWorkingDirFromContext(ctx), ".")
 
 			matches, _, err := searchFiles(ctx, regexp.QuoteMeta(params.Symbol), workingDir, "", 100)
 			if err != nil {
@@ -97,14 +97,7 @@ func find(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], symbo
 		return nil, fmt.Errorf("failed to get absolute path: %s", err)
 	}
 
-	var client *lsp.Client
-	for c := range lspClients.Seq() {
-		if c.HandlesFile(absPath) {
-			client = c
-			break
-		}
-	}
-
+	client := lspClientFor(lspClients, absPath)
 	if client == nil {
 		slog.Warn("No LSP clients to handle", "path", match.path)
 		return nil, nil
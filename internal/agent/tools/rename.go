@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf16"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type RenameParams struct {
+	Symbol   string `json:"symbol" description:"The current name of the symbol to rename"`
+	NewName  string `json:"new_name" description:"The new name of the symbol"`
+	FilePath string `json:"file_path,omitempty" description:"The file or directory where the symbol appears. Defaults to the current working directory."`
+	Line     int    `json:"line,omitempty" description:"The line number (1-based) where the symbol appears in file_path"`
+}
+
+const RenameToolName = "lsp_rename"
+
+//go:embed rename.md
+var renameDescription []byte
+
+func NewRenameTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RenameToolName,
+		string(renameDescription),
+		func(ctx context.Context, params RenameParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Symbol == "" || params.NewName == "" {
+				return fantasy.NewTextErrorResponse("symbol and new_name are required"), nil
+			}
+			if lspClients.Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+			sessionID := GetSessionFromContext(ctx)
+			if sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for renaming symbols")
+			}
+			workingDir := cmp.Or(GetWorkingDirFromContext(ctx), workingDir)
+
+			var edit *protocol.WorkspaceEdit
+			symbol := LSPSymbolParams{Symbol: params.Symbol, FilePath: params.FilePath, Line: params.Line}
+			name := params.Symbol[getSymbolOffset(params.Symbol):]
+			err := visitSymbol(ctx, lspClients, symbol, workingDir, func(client *lsp.Client, path string, line, character int) (bool, error) {
+				// Make sure the server renames the symbol, not a longer
+				// identifier or something else around the position.
+				rng, err := client.PrepareRename(ctx, path, line, character)
+				switch {
+				case errors.Is(err, errors.ErrUnsupported):
+				case err != nil:
+					return false, err
+				case rng == nil || rangeText(path, *rng) != name:
+					return false, nil
+				}
+
+				result, err := client.Rename(ctx, path, line, character, params.NewName)
+				if err != nil {
+					return false, err
+				}
+				edit = result
+				return edit != nil, nil
+			})
+			if errors.Is(err, errSymbolNotFound) {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Symbol '%s' not found", params.Symbol)), nil
+			}
+			if edit == nil {
+				if err != nil {
+					return fantasy.NewTextErrorResponse(err.Error()), nil
+				}
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("Symbol '%s' can't be renamed", params.Symbol)), nil
+			}
+
+			edited, err := workspaceEditFiles(*edit)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(edited) == 0 {
+				return fantasy.NewTextErrorResponse("the rename doesn't change any file"), nil
+			}
+
+			description := fmt.Sprintf("Rename %s to %s", params.Symbol, params.NewName)
+			metadata, err := applyEditedFiles(ctx, lspClients, permissions, files, call, RenameToolName, description, edited, workingDir)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+
+			text := fmt.Sprintf("<result>\nRenamed %s to %s in %d file(s):\n%s\n</result>\n", params.Symbol, params.NewName, len(edited), strings.Join(metadata.Files, "\n"))
+			text += getDiagnostics(edited[0].path, lspClients)
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), metadata), nil
+		})
+}
+
+// rangeText returns the text of the file in the range, when it's on a single
+// line.
+func rangeText(path string, rng protocol.Range) string {
+	if rng.Start.Line != rng.End.Line {
+		return ""
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(string(content), "\n")
+	if int(rng.Start.Line) >= len(lines) {
+		return ""
+	}
+	// Characters are counted in UTF-16 code units.
+	line := utf16.Encode([]rune(lines[rng.Start.Line]))
+	start, end := int(rng.Start.Character), int(rng.End.Character)
+	if start > end || end > len(line) {
+		return ""
+	}
+	return string(utf16.Decode(line[start:end]))
+}
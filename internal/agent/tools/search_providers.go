@@ -0,0 +1,238 @@
+package tools
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// WebSearchProvider searches the web for the web_search tool.
+type WebSearchProvider interface {
+	Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error)
+}
+
+// NewWebSearchProvider creates the web search provider configured in
+// searchConfig, resolving its API key and headers with resolver.
+func NewWebSearchProvider(searchConfig config.ToolWebSearch, client *http.Client, resolver config.VariableResolver) (WebSearchProvider, error) {
+	headers := make(map[string]string, len(searchConfig.Headers))
+	for k, v := range searchConfig.Headers {
+		value, err := resolver.ResolveValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve web search header %s: %w", k, err)
+		}
+		headers[k] = value
+	}
+	apiKey, err := resolver.ResolveValue(searchConfig.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve web search API key: %w", err)
+	}
+
+	switch provider := cmp.Or(searchConfig.Provider, config.WebSearchDuckDuckGo); provider {
+	case config.WebSearchDuckDuckGo:
+		return &duckDuckGoProvider{client: client}, nil
+	case config.WebSearchSearXNG:
+		if searchConfig.URL == "" {
+			return nil, fmt.Errorf("the searxng web search provider requires a url")
+		}
+		return &jsonSearchProvider{
+			client:   client,
+			method:   http.MethodGet,
+			url:      strings.TrimSuffix(searchConfig.URL, "/") + "/search?q={query}&format=json",
+			headers:  headers,
+			response: config.WebSearchResponse{Snippet: "content"},
+		}, nil
+	case config.WebSearchBrave:
+		if apiKey == "" {
+			return nil, fmt.Errorf("the brave web search provider requires an api_key")
+		}
+		headers["X-Subscription-Token"] = apiKey
+		return &jsonSearchProvider{
+			client:   client,
+			method:   http.MethodGet,
+			url:      cmp.Or(searchConfig.URL, "https://api.search.brave.com/res/v1/web/search"),
+			params:   map[string]string{"q": "{query}", "count": "{max_results}"},
+			headers:  headers,
+			response: config.WebSearchResponse{Results: "web.results", Snippet: "description"},
+		}, nil
+	case config.WebSearchTavily:
+		if apiKey == "" {
+			return nil, fmt.Errorf("the tavily web search provider requires an api_key")
+		}
+		headers["Authorization"] = "Bearer " + apiKey
+		return &jsonSearchProvider{
+			client:   client,
+			method:   http.MethodPost,
+			url:      cmp.Or(searchConfig.URL, "https://api.tavily.com/search"),
+			headers:  headers,
+			body:     `{"query": "{query}", "max_results": {max_results}}`,
+			response: config.WebSearchResponse{Snippet: "content"},
+		}, nil
+	case config.WebSearchHTTP:
+		if searchConfig.URL == "" {
+			return nil, fmt.Errorf("the http web search provider requires a url")
+		}
+		return &jsonSearchProvider{
+			client:   client,
+			method:   cmp.Or(strings.ToUpper(searchConfig.Method), http.MethodGet),
+			url:      searchConfig.URL,
+			headers:  headers,
+			body:     searchConfig.Body,
+			response: searchConfig.Response,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown web search provider: %s", provider)
+	}
+}
+
+// duckDuckGoProvider scrapes the lite version of DuckDuckGo.
+type duckDuckGoProvider struct {
+	client *http.Client
+}
+
+func (p *duckDuckGoProvider) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	maybeDelaySearch()
+	return searchDuckDuckGo(ctx, p.client, query, maxResults)
+}
+
+// jsonSearchProvider queries a search API that answers with JSON. The
+// {query} and {max_results} placeholders of the URL, the query parameters
+// and the body are replaced, escaped for each. The params are added to the
+// query of the URL, keeping the parameters it already has.
+type jsonSearchProvider struct {
+	client   *http.Client
+	method   string
+	url      string
+	params   map[string]string
+	headers  map[string]string
+	body     string
+	response config.WebSearchResponse
+}
+
+func (p *jsonSearchProvider) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	limit := strconv.Itoa(maxResults)
+	searchURL := strings.NewReplacer("{query}", url.QueryEscape(query), "{max_results}", limit).Replace(p.url)
+	if len(p.params) > 0 {
+		u, err := url.Parse(searchURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid search url: %w", err)
+		}
+		values := u.Query()
+		replacer := strings.NewReplacer("{query}", query, "{max_results}", limit)
+		for k, v := range p.params {
+			values.Set(k, replacer.Replace(v))
+		}
+		u.RawQuery = values.Encode()
+		searchURL = u.String()
+	}
+
+	var body io.Reader
+	if p.body != "" {
+		// Escape the query as the contents of a JSON string.
+		quoted, _ := json.Marshal(query)
+		escaped := string(quoted[1 : len(quoted)-1])
+		body = strings.NewReader(strings.NewReplacer("{query}", escaped, "{max_results}", limit).Replace(p.body))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, p.method, searchURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("User-Agent", "crush/1.0")
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search failed with status code: %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return parseJSONSearchResults(doc, p.response, maxResults)
+}
+
+// parseJSONSearchResults finds the results in a JSON response.
+func parseJSONSearchResults(doc any, response config.WebSearchResponse, maxResults int) ([]SearchResult, error) {
+	path := cmp.Or(response.Results, "results")
+	items, ok := jsonPath(doc, path).([]any)
+	if !ok {
+		return nil, fmt.Errorf("no array of results at %q in the response", path)
+	}
+
+	var results []SearchResult
+	for _, item := range items {
+		if len(results) >= maxResults {
+			break
+		}
+		link := jsonString(item, cmp.Or(response.URL, "url"))
+		if link == "" {
+			continue
+		}
+		results = append(results, SearchResult{
+			Title:    jsonString(item, cmp.Or(response.Title, "title")),
+			Link:     link,
+			Snippet:  jsonString(item, cmp.Or(response.Snippet, "snippet")),
+			Position: len(results) + 1,
+		})
+	}
+	return results, nil
+}
+
+// jsonPath returns the value at the dot-separated path, or nil. The path "."
+// is the value itself.
+func jsonPath(v any, path string) any {
+	if path == "." {
+		return v
+	}
+	for key := range strings.SplitSeq(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func jsonString(v any, path string) string {
+	switch value := jsonPath(v, path).(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
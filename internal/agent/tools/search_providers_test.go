@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebSearchProvider(t *testing.T) {
+	t.Parallel()
+
+	resolver := config.NewEnvironmentVariableResolver(env.NewFromMap(map[string]string{"SEARCH_KEY": "secret"}))
+
+	t.Run("defaults to duckduckgo", func(t *testing.T) {
+		t.Parallel()
+		provider, err := NewWebSearchProvider(config.ToolWebSearch{}, http.DefaultClient, resolver)
+		require.NoError(t, err)
+		require.IsType(t, &duckDuckGoProvider{}, provider)
+	})
+
+	t.Run("requires settings", func(t *testing.T) {
+		t.Parallel()
+		for _, cfg := range []config.ToolWebSearch{
+			{Provider: config.WebSearchSearXNG},
+			{Provider: config.WebSearchBrave},
+			{Provider: config.WebSearchTavily},
+			{Provider: config.WebSearchHTTP},
+			{Provider: "bing"},
+		} {
+			_, err := NewWebSearchProvider(cfg, http.DefaultClient, resolver)
+			require.Error(t, err, cfg.Provider)
+		}
+	})
+
+	t.Run("brave", func(t *testing.T) {
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Subscription-Token") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			query := r.URL.Query()
+			if query.Get("q") != "go generics" || query.Get("count") != "2" || query.Get("country") != "fr" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = io.WriteString(w, `{"web": {"results": [
+				{"title": "Generics", "url": "https://go.dev/doc/tutorial/generics", "description": "Tutorial"},
+				{"title": "Spec", "url": "https://go.dev/ref/spec", "description": "Type parameters"},
+				{"title": "Blog", "url": "https://go.dev/blog/intro-generics", "description": "Intro"}
+			]}}`)
+		}))
+		t.Cleanup(server.Close)
+
+		provider, err := NewWebSearchProvider(config.ToolWebSearch{
+			Provider: config.WebSearchBrave,
+			URL:      server.URL + "?country=fr",
+			APIKey:   "$SEARCH_KEY",
+		}, server.Client(), resolver)
+		require.NoError(t, err)
+
+		results, err := provider.Search(t.Context(), "go generics", 2)
+		require.NoError(t, err)
+		require.Equal(t, []SearchResult{
+			{Title: "Generics", Link: "https://go.dev/doc/tutorial/generics", Snippet: "Tutorial", Position: 1},
+			{Title: "Spec", Link: "https://go.dev/ref/spec", Snippet: "Type parameters", Position: 2},
+		}, results)
+	})
+
+	t.Run("tavily", func(t *testing.T) {
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				Query      string `json:"query"`
+				MaxResults int    `json:"max_results"`
+			}
+			if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Query != `say "hi"` || body.MaxResults != 5 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = io.WriteString(w, `{"results": [{"title": "Hi", "url": "https://example.com", "content": "Hello"}]}`)
+		}))
+		t.Cleanup(server.Close)
+
+		provider, err := NewWebSearchProvider(config.ToolWebSearch{
+			Provider: config.WebSearchTavily,
+			URL:      server.URL,
+			APIKey:   "$SEARCH_KEY",
+		}, server.Client(), resolver)
+		require.NoError(t, err)
+
+		results, err := provider.Search(t.Context(), `say "hi"`, 5)
+		require.NoError(t, err)
+		require.Equal(t, []SearchResult{
+			{Title: "Hi", Link: "https://example.com", Snippet: "Hello", Position: 1},
+		}, results)
+	})
+
+	t.Run("http template", func(t *testing.T) {
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("term") != "crush" || r.Header.Get("X-Team") != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = io.WriteString(w, `[
+				{"doc": {"name": "Crush", "link": "https://internal/crush"}, "highlights": ["The glamorous agent"]},
+				{"doc": {"name": "No link"}}
+			]`)
+		}))
+		t.Cleanup(server.Close)
+
+		provider, err := NewWebSearchProvider(config.ToolWebSearch{
+			Provider: config.WebSearchHTTP,
+			URL:      server.URL + "/search?term={query}&limit={max_results}",
+			Headers:  map[string]string{"X-Team": "$SEARCH_KEY"},
+			Response: config.WebSearchResponse{
+				Results: ".",
+				Title:   "doc.name",
+				URL:     "doc.link",
+				Snippet: "highlights.0",
+			},
+		}, server.Client(), resolver)
+		require.NoError(t, err)
+
+		results, err := provider.Search(t.Context(), "crush", 10)
+		require.NoError(t, err)
+		require.Equal(t, []SearchResult{
+			{Title: "Crush", Link: "https://internal/crush", Snippet: "The glamorous agent", Position: 1},
+		}, results)
+	})
+
+	t.Run("missing results", func(t *testing.T) {
+		t.Parallel()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{"hits": []}`)
+		}))
+		t.Cleanup(server.Close)
+
+		provider, err := NewWebSearchProvider(config.ToolWebSearch{
+			Provider: config.WebSearchSearXNG,
+			URL:      server.URL,
+		}, server.Client(), resolver)
+		require.NoError(t, err)
+
+		_, err = provider.Search(t.Context(), "crush", 10)
+		require.ErrorContains(t, err, `no array of results at "results"`)
+	})
+}
@@ -0,0 +1,304 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/shell"
+	"github.com/charmbracelet/crush/internal/skills"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+const (
+	SkillToolName = "skill"
+	// maxSkillResources is the number of files of a skill listed when it's
+	// loaded.
+	maxSkillResources = 100
+)
+
+//go:embed skill.md
+var skillDescription []byte
+
+type SkillParams struct {
+	Name string `json:"name" description:"The name of the skill to load"`
+}
+
+// NewSkillTool returns the tool that loads the skills, so only their names
+// and descriptions have to be in the system prompt.
+func NewSkillTool(available []*skills.Skill) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		SkillToolName,
+		string(skillDescription),
+		func(ctx context.Context, params SkillParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Name == "" {
+				return fantasy.NewTextErrorResponse("name is required"), nil
+			}
+			i := slices.IndexFunc(available, func(s *skills.Skill) bool {
+				return s.Name == params.Name
+			})
+			if i < 0 {
+				names := make([]string, len(available))
+				for j, s := range available {
+					names[j] = s.Name
+				}
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("skill %q not found, available skills: %s", params.Name, strings.Join(names, ", "))), nil
+			}
+			return fantasy.NewTextResponse(loadSkill(available[i])), nil
+		})
+}
+
+// loadSkill returns the instructions, resources and scripts of the skill.
+func loadSkill(skill *skills.Skill) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<skill name=%q directory=%q>\n", skill.Name, filepath.ToSlash(skill.Path))
+	fmt.Fprintf(&sb, "<instructions>\n%s\n</instructions>\n", skill.Instructions)
+
+	if resources, more := skillResources(skill.Path); len(resources) > 0 {
+		sb.WriteString("<resources>\n")
+		for _, resource := range resources {
+			sb.WriteString(resource + "\n")
+		}
+		if more > 0 {
+			fmt.Fprintf(&sb, "(%d more files)\n", more)
+		}
+		sb.WriteString("</resources>\n")
+	}
+
+	if len(skill.Scripts) > 0 {
+		sb.WriteString("<scripts>\n")
+		for _, script := range skill.Scripts {
+			fmt.Fprintf(&sb, "- %s: %s\n", skills.ScriptToolName(skill.Name, script.Name), script.Description)
+		}
+		sb.WriteString("</scripts>\n")
+	}
+	sb.WriteString("</skill>")
+	return sb.String()
+}
+
+// skillResources returns the paths of the files of the skill in dir, relative
+// to it, and how many more there are than the ones returned.
+func skillResources(dir string) ([]string, int) {
+	var resources []string
+	var more int
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || path == filepath.Join(dir, skills.SkillFileName) {
+			return nil
+		}
+		if len(resources) == maxSkillResources {
+			more++
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err == nil {
+			resources = append(resources, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return resources, more
+}
+
+// NewSkillScriptTools returns the tools that run the scripts of the skills.
+func NewSkillScriptTools(permissions permission.Service, workingDir string, allowedUnsafe []string, sandbox *shell.Sandbox, available []*skills.Skill) []fantasy.AgentTool {
+	var result []fantasy.AgentTool
+	for _, skill := range available {
+		for _, script := range skill.Scripts {
+			result = append(result, &SkillScriptTool{
+				skill:         skill,
+				script:        script,
+				permissions:   permissions,
+				workingDir:    workingDir,
+				allowedUnsafe: allowedUnsafe,
+				sandbox:       sandbox,
+			})
+		}
+	}
+	return result
+}
+
+// SkillScriptTool is a tool that runs a script of a skill.
+type SkillScriptTool struct {
+	skill           *skills.Skill
+	script          skills.Script
+	permissions     permission.Service
+	workingDir      string
+	allowedUnsafe   []string
+	sandbox         *shell.Sandbox
+	providerOptions fantasy.ProviderOptions
+}
+
+var _ fantasy.AgentTool = (*SkillScriptTool)(nil)
+
+func (t *SkillScriptTool) SetProviderOptions(opts fantasy.ProviderOptions) {
+	t.providerOptions = opts
+}
+
+func (t *SkillScriptTool) ProviderOptions() fantasy.ProviderOptions {
+	return t.providerOptions
+}
+
+func (t *SkillScriptTool) Name() string {
+	return skills.ScriptToolName(t.skill.Name, t.script.Name)
+}
+
+func (t *SkillScriptTool) Info() fantasy.ToolInfo {
+	parameters := make(map[string]any, len(t.script.Parameters))
+	required := make([]string, 0)
+	for _, p := range t.script.Parameters {
+		schema := map[string]any{
+			"type": cmp.Or(p.Type, skills.ParameterString),
+		}
+		if p.Description != "" {
+			schema["description"] = p.Description
+		}
+		if len(p.Enum) > 0 {
+			schema["enum"] = p.Enum
+		}
+		parameters[p.Name] = schema
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+
+	return fantasy.ToolInfo{
+		Name:        t.Name(),
+		Description: fmt.Sprintf("%s\n\nA script of the %s skill, load the skill first. It runs in %s, pass it absolute paths.", t.script.Description, t.skill.Name, filepath.ToSlash(t.skill.Path)),
+		Parameters:  parameters,
+		Required:    required,
+	}
+}
+
+func (t *SkillScriptTool) Run(ctx context.Context, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+	sessionID := GetSessionFromContext(ctx)
+	if sessionID == "" {
+		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for running a skill script")
+	}
+
+	input := make(map[string]any)
+	if strings.TrimSpace(call.Input) != "" {
+		dec := json.NewDecoder(strings.NewReader(call.Input))
+		dec.UseNumber()
+		if err := dec.Decode(&input); err != nil {
+			return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid parameters: %s", err)), nil
+		}
+	}
+	command, err := scriptCommand(t.script, input)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error()), nil
+	}
+
+	p, err := t.permissions.Request(ctx,
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			ToolCallID:  call.ID,
+			Path:        t.skill.Path,
+			Command:     command,
+			ToolName:    t.Name(),
+			Action:      "execute",
+			Description: fmt.Sprintf("Run the %s script of the %s skill: %s", t.script.Name, t.skill.Name, command),
+			Params:      input,
+		},
+	)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if !p {
+		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	}
+
+	sh := shell.NewShell(&shell.Options{
+		WorkingDir: t.skill.Path,
+		Env: append(os.Environ(),
+			"CRUSH_SKILL_DIR="+t.skill.Path,
+			"CRUSH_WORKING_DIR="+cmp.Or(GetWorkingDirFromContext(ctx), t.workingDir),
+		),
+		BlockFuncs: blockFuncs(t.allowedUnsafe),
+		Sandbox:    t.sandbox,
+	})
+	stdout, stderr, execErr := sh.Exec(ctx, command)
+	if shell.ExitCode(execErr) == 0 && !shell.IsInterrupt(execErr) && execErr != nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("error running script: %s", execErr)), nil
+	}
+
+	output := formatOutput(stdout, stderr, execErr)
+	if output == "" {
+		return fantasy.NewTextResponse(BashNoOutput), nil
+	}
+	return fantasy.NewTextResponse(output), nil
+}
+
+// scriptCommand returns the command line of the script called with input:
+// its command followed by the arguments as --name value flags, and boolean
+// ones as --name when true.
+func scriptCommand(script skills.Script, input map[string]any) (string, error) {
+	var sb strings.Builder
+	sb.WriteString(script.Command)
+	for _, p := range script.Parameters {
+		v, ok := input[p.Name]
+		if !ok || v == nil {
+			if p.Required {
+				return "", fmt.Errorf("parameter %q is required", p.Name)
+			}
+			continue
+		}
+
+		var value string
+		switch cmp.Or(p.Type, skills.ParameterString) {
+		case skills.ParameterBoolean:
+			b, ok := v.(bool)
+			if !ok {
+				return "", fmt.Errorf("parameter %q must be a boolean", p.Name)
+			}
+			if b {
+				sb.WriteString(" --" + p.Name)
+			}
+			continue
+		case skills.ParameterInteger:
+			n, ok := v.(json.Number)
+			if _, err := n.Int64(); !ok || err != nil {
+				return "", fmt.Errorf("parameter %q must be an integer", p.Name)
+			}
+			value = n.String()
+		case skills.ParameterNumber:
+			n, ok := v.(json.Number)
+			if _, err := n.Float64(); !ok || err != nil {
+				return "", fmt.Errorf("parameter %q must be a number", p.Name)
+			}
+			value = n.String()
+		default:
+			s, ok := v.(string)
+			if !ok {
+				return "", fmt.Errorf("parameter %q must be a string", p.Name)
+			}
+			if len(p.Enum) > 0 && !slices.Contains(p.Enum, s) {
+				return "", fmt.Errorf("parameter %q must be one of %s", p.Name, strings.Join(p.Enum, ", "))
+			}
+			value = s
+		}
+
+		quoted, err := syntax.Quote(value, syntax.LangPOSIX)
+		if err != nil {
+			return "", fmt.Errorf("parameter %q: %w", p.Name, err)
+		}
+		sb.WriteString(" --" + p.Name + " " + quoted)
+	}
+	return sb.String(), nil
+}
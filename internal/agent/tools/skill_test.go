@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/skills"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkillTool(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "scripts"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "SKILL.md"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "scripts", "extract.py"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), nil, 0o644))
+
+	skill := &skills.Skill{
+		Name:         "pdf",
+		Description:  "Works with PDF files.",
+		Instructions: "Run the extract script.",
+		Path:         dir,
+		Scripts: []skills.Script{
+			{Name: "extract", Description: "Extracts the text of a PDF file.", Command: "python3 scripts/extract.py"},
+		},
+	}
+	tool := NewSkillTool([]*skills.Skill{skill})
+
+	resp, err := tool.Run(t.Context(), fantasy.ToolCall{ID: "1", Name: SkillToolName, Input: `{"name":"pdf"}`})
+	require.NoError(t, err)
+	require.False(t, resp.IsError)
+	require.Contains(t, resp.Content, "<instructions>\nRun the extract script.\n</instructions>")
+	require.Contains(t, resp.Content, "<resources>\nscripts/extract.py\n</resources>")
+	require.Contains(t, resp.Content, "- skill_pdf_extract: Extracts the text of a PDF file.")
+	require.NotContains(t, resp.Content, ".hidden")
+
+	resp, err = tool.Run(t.Context(), fantasy.ToolCall{ID: "2", Name: SkillToolName, Input: `{"name":"docx"}`})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, "available skills: pdf")
+}
+
+func TestScriptCommand(t *testing.T) {
+	t.Parallel()
+
+	script := skills.Script{
+		Command: "python3 scripts/extract.py",
+		Parameters: []skills.Parameter{
+			{Name: "file", Required: true},
+			{Name: "format", Enum: []string{"text", "markdown"}},
+			{Name: "pages", Type: skills.ParameterInteger},
+			{Name: "verbose", Type: skills.ParameterBoolean},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "required only",
+			input: `{"file": "/tmp/a.pdf"}`,
+			want:  "python3 scripts/extract.py --file /tmp/a.pdf",
+		},
+		{
+			name:  "all parameters",
+			input: `{"verbose": true, "pages": 3, "format": "markdown", "file": "/tmp/my file.pdf"}`,
+			want:  "python3 scripts/extract.py --file '/tmp/my file.pdf' --format markdown --pages 3 --verbose",
+		},
+		{
+			name:  "false boolean",
+			input: `{"file": "a.pdf", "verbose": false}`,
+			want:  "python3 scripts/extract.py --file a.pdf",
+		},
+		{
+			name:  "quoted value",
+			input: `{"file": "$(rm -rf /)"}`,
+			want:  "python3 scripts/extract.py --file '$(rm -rf /)'",
+		},
+		{
+			name:    "missing required",
+			input:   `{}`,
+			wantErr: `parameter "file" is required`,
+		},
+		{
+			name:    "not in enum",
+			input:   `{"file": "a.pdf", "format": "html"}`,
+			wantErr: `parameter "format" must be one of text, markdown`,
+		},
+		{
+			name:    "invalid integer",
+			input:   `{"file": "a.pdf", "pages": 1.5}`,
+			wantErr: `parameter "pages" must be an integer`,
+		},
+		{
+			name:    "invalid string",
+			input:   `{"file": 1}`,
+			wantErr: `parameter "file" must be a string`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var input map[string]any
+			dec := json.NewDecoder(strings.NewReader(tt.input))
+			dec.UseNumber()
+			require.NoError(t, dec.Decode(&input))
+
+			got, err := scriptCommand(script, input)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSkillScriptTool(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	skill := &skills.Skill{
+		Name: "greet",
+		Path: dir,
+		Scripts: []skills.Script{{
+			Name:        "hello",
+			Description: "Says hello.",
+			Command:     `echo "$PWD" "$CRUSH_WORKING_DIR"`,
+			Parameters: []skills.Parameter{
+				{Name: "name", Description: "Who to greet.", Required: true},
+			},
+		}},
+	}
+	permissions := &mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()}
+	tools := NewSkillScriptTools(permissions, "/project", nil, nil, []*skills.Skill{skill})
+	require.Len(t, tools, 1)
+
+	info := tools[0].Info()
+	require.Equal(t, "skill_greet_hello", info.Name)
+	require.Equal(t, []string{"name"}, info.Required)
+	require.Equal(t, map[string]any{"type": "string", "description": "Who to greet."}, info.Parameters["name"])
+
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	resp, err := tools[0].Run(ctx, fantasy.ToolCall{ID: "1", Name: info.Name, Input: `{"name": "world"}`})
+	require.NoError(t, err)
+	require.False(t, resp.IsError)
+	require.Equal(t, dir+" /project --name world\n", resp.Content)
+
+	resp, err = tools[0].Run(ctx, fantasy.ToolCall{ID: "2", Name: info.Name, Input: `{}`})
+	require.NoError(t, err)
+	require.True(t, resp.IsError)
+	require.Contains(t, resp.Content, `parameter "name" is required`)
+}
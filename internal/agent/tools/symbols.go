@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"charm.land/fantasy"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/filepathext"
+	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type SymbolsParams struct {
+	Query    string `json:"query,omitempty" description:"The name or part of the name of the symbols to search the workspace for"`
+	FilePath string `json:"file_path,omitempty" description:"The file to list the symbols of, instead of searching the workspace"`
+}
+
+const SymbolsToolName = "lsp_symbols"
+
+// maxWorkspaceSymbols limits how many symbols a workspace search returns.
+const maxWorkspaceSymbols = 100
+
+//go:embed symbols.md
+var symbolsDescription []byte
+
+func NewSymbolsTool(lspClients *csync.Map[string, *lsp.Client]) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		SymbolsToolName,
+		string(symbolsDescription),
+		func(ctx context.Context, params SymbolsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.Query == "" && params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("either query or file_path is required"), nil
+			}
+			if lspClients.Len() == 0 {
+				return fantasy.NewTextErrorResponse("no LSP clients available"), nil
+			}
+
+			if params.FilePath != "" {
+				workingDir := cmp.Or(GetWorkingDirFromContext(ctx), ".")
+				return documentSymbols(ctx, lspClients, filepathext.SmartJoin(workingDir, params.FilePath)), nil
+			}
+			return workspaceSymbols(ctx, lspClients, params.Query), nil
+		})
+}
+
+func documentSymbols(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], path string) fantasy.ToolResponse {
+	client := lspClientFor(lspClients, path)
+	if client == nil {
+		return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP client handles %s", path))
+	}
+	symbols, err := client.DocumentSymbols(ctx, path)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error())
+	}
+	if len(symbols) == 0 {
+		return fantasy.NewTextResponse(fmt.Sprintf("No symbols found in %s", path))
+	}
+
+	var output strings.Builder
+	fmt.Fprintf(&output, "Symbols in %s:\n\n", path)
+	for _, symbol := range symbols {
+		switch s := symbol.(type) {
+		case *protocol.DocumentSymbol:
+			writeDocumentSymbol(&output, *s, 0)
+		case *protocol.SymbolInformation:
+			fmt.Fprintf(&output, "%s %s - Line %d\n", symbolKindName(s.Kind), s.Name, s.Location.Range.Start.Line+1)
+		}
+	}
+	return fantasy.NewTextResponse(output.String())
+}
+
+// writeDocumentSymbol writes the symbol and its children, indented by depth.
+func writeDocumentSymbol(output *strings.Builder, symbol protocol.DocumentSymbol, depth int) {
+	output.WriteString(strings.Repeat("  ", depth))
+	fmt.Fprintf(output, "%s %s", symbolKindName(symbol.Kind), symbol.Name)
+	if symbol.Detail != "" {
+		fmt.Fprintf(output, " (%s)", symbol.Detail)
+	}
+	fmt.Fprintf(output, " - Line %d\n", symbol.SelectionRange.Start.Line+1)
+	for _, child := range symbol.Children {
+		writeDocumentSymbol(output, child, depth+1)
+	}
+}
+
+func workspaceSymbols(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], query string) fantasy.ToolResponse {
+	var lines []string
+	var errs error
+	for client := range lspClients.Seq() {
+		symbols, err := client.WorkspaceSymbols(ctx, query)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+		for _, symbol := range symbols {
+			location := symbol.GetLocation()
+			path, err := location.URI.Path()
+			if err != nil {
+				continue
+			}
+			var kind protocol.SymbolKind
+			switch s := symbol.(type) {
+			case *protocol.WorkspaceSymbol:
+				kind = s.Kind
+			case *protocol.SymbolInformation:
+				kind = s.Kind
+			}
+			lines = append(lines, fmt.Sprintf("%s %s - %s:%d", symbolKindName(kind), symbol.GetName(), path, location.Range.Start.Line+1))
+		}
+	}
+
+	if len(lines) == 0 {
+		if errs != nil {
+			return fantasy.NewTextErrorResponse(errs.Error())
+		}
+		return fantasy.NewTextResponse(fmt.Sprintf("No symbols found matching '%s'", query))
+	}
+
+	header := fmt.Sprintf("Found %d symbol(s) matching '%s'", len(lines), query)
+	if len(lines) > maxWorkspaceSymbols {
+		header += fmt.Sprintf(", showing the first %d", maxWorkspaceSymbols)
+		lines = lines[:maxWorkspaceSymbols]
+	}
+	return fantasy.NewTextResponse(header + ":\n\n" + strings.Join(lines, "\n"))
+}
@@ -9,6 +9,7 @@ type (
 	messageIDContextKey string
 	supportsImagesKey   string
 	modelNameKey        string
+	workingDirKey       string
 )
 
 const (
@@ -20,6 +21,9 @@ const (
 	SupportsImagesContextKey supportsImagesKey = "supports_images"
 	// ModelNameContextKey is the key for the model name in the context.
 	ModelNameContextKey modelNameKey = "model_name"
+	// WorkingDirContextKey is the key for the session's working directory
+	// in the context, set when the session runs in its own worktree.
+	WorkingDirContextKey workingDirKey = "working_dir"
 )
 
 // GetSessionFromContext retrieves the session ID from the context.
@@ -72,3 +76,17 @@ func GetModelNameFromContext(ctx context.Context) string {
 	}
 	return s
 }
+
+// GetWorkingDirFromContext retrieves the session's working directory from the
+// context, if it overrides the project's.
+func GetWorkingDirFromContext(ctx context.Context) string {
+	workingDir := ctx.Value(WorkingDirContextKey)
+	if workingDir == nil {
+		return ""
+	}
+	s, ok := workingDir.(string)
+	if !ok {
+		return ""
+	}
+	return s
+}
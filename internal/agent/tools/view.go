@@ -2,6 +2,7 @@ package tools
 
 import (
 	"bufio"
+	"cmp"
 	"context"
 	_ "embed"
 	"encoding/base64"
@@ -52,6 +53,8 @@ func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 		ViewToolName,
 		string(viewDescription),
 		func(ctx context.Context, params ViewParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := cmp.Or(GetWorkingDirFromContext(ctx), workingDir)
+
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
@@ -75,29 +78,34 @@ func NewViewTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 			isSkillFile := isInSkillsPath(absFilePath, skillsPaths)
 
 			// Request permission for files outside working directory, unless it's a skill file.
-			if isOutsideWorkDir && !isSkillFile {
-				sessionID := GetSessionFromContext(ctx)
-				if sessionID == "" {
-					return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for accessing files outside working directory")
-				}
-
-				granted, err := permissions.Request(ctx,
-					permission.CreatePermissionRequest{
-						SessionID:   sessionID,
-						Path:        absFilePath,
-						ToolCallID:  call.ID,
-						ToolName:    ViewToolName,
-						Action:      "read",
-						Description: fmt.Sprintf("Read file outside working directory: %s", absFilePath),
-						Params:      ViewPermissionsParams(params),
-					},
-				)
-				if err != nil {
-					return fantasy.ToolResponse{}, err
-				}
-				if !granted {
-					return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
-				}
+			// The others are read without a prompt, but still go through the permission rules.
+			safe := !isOutsideWorkDir || isSkillFile
+			sessionID := GetSessionFromContext(ctx)
+			if !safe && sessionID == "" {
+				return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for accessing files outside working directory")
+			}
+			description := fmt.Sprintf("Read file: %s", absFilePath)
+			if isOutsideWorkDir {
+				description = fmt.Sprintf("Read file outside working directory: %s", absFilePath)
+			}
+			granted, err := permissions.Request(ctx,
+				permission.CreatePermissionRequest{
+					SessionID:   sessionID,
+					Path:        absFilePath,
+					FilePath:    absFilePath,
+					ToolCallID:  call.ID,
+					ToolName:    ViewToolName,
+					Action:      "read",
+					Description: description,
+					Params:      ViewPermissionsParams(params),
+					Safe:        safe,
+				},
+			)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if !granted {
+				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
 			}
 
 			// Check if file exists
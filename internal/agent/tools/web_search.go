@@ -13,17 +13,18 @@ import (
 //go:embed web_search.md
 var webSearchToolDescription []byte
 
-// NewWebSearchTool creates a web search tool for sub-agents (no permissions needed).
-func NewWebSearchTool(client *http.Client) fantasy.AgentTool {
-	if client == nil {
-		client = &http.Client{
+// NewWebSearchTool creates a web search tool for sub-agents (no permissions
+// needed). It searches DuckDuckGo when provider is nil.
+func NewWebSearchTool(provider WebSearchProvider) fantasy.AgentTool {
+	if provider == nil {
+		provider = &duckDuckGoProvider{client: &http.Client{
 			Timeout: 30 * time.Second,
 			Transport: &http.Transport{
 				MaxIdleConns:        100,
 				MaxIdleConnsPerHost: 10,
 				IdleConnTimeout:     90 * time.Second,
 			},
-		}
+		}}
 	}
 
 	return fantasy.NewParallelAgentTool(
@@ -42,8 +43,7 @@ func NewWebSearchTool(client *http.Client) fantasy.AgentTool {
 				maxResults = 20
 			}
 
-			maybeDelaySearch()
-			results, err := searchDuckDuckGo(ctx, client, params.Query, maxResults)
+			results, err := provider.Search(ctx, params.Query, maxResults)
 			slog.Debug("Web search completed", "query", params.Query, "results", len(results), "err", err)
 			if err != nil {
 				return fantasy.NewTextErrorResponse("Failed to search: " + err.Error()), nil
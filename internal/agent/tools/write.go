@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"cmp"
 	"context"
 	_ "embed"
 	"fmt"
@@ -49,6 +50,8 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 		WriteToolName,
 		string(writeDescription),
 		func(ctx context.Context, params WriteParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := cmp.Or(GetWorkingDirFromContext(ctx), workingDir)
+
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
@@ -108,6 +111,7 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 				permission.CreatePermissionRequest{
 					SessionID:   sessionID,
 					Path:        fsext.PathOrPrefix(filePath, workingDir),
+					FilePath:    filePath,
 					ToolCallID:  call.ID,
 					ToolName:    WriteToolName,
 					Action:      "write",
@@ -134,7 +138,11 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			// Check if file exists in history
 			file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
 			if err != nil {
-				_, err = files.Create(ctx, sessionID, filePath, oldContent)
+				if fileInfo == nil {
+					_, err = files.CreateNew(ctx, sessionID, filePath)
+				} else {
+					_, err = files.Create(ctx, sessionID, filePath, oldContent)
+				}
 				if err != nil {
 					// Log error but don't fail the operation
 					return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
@@ -156,6 +164,7 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			filetracker.RecordWrite(filePath)
 			filetracker.RecordRead(filePath)
 
+			formatOnWrite(ctx, lspClients, files, filePath)
 			notifyLSPs(ctx, lspClients, params.FilePath)
 
 			result := fmt.Sprintf("File successfully written: %s", filePath)
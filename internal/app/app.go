@@ -3,6 +3,7 @@
 package app
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"errors"
@@ -20,6 +21,7 @@ import (
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
 	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/agentstatus"
 	"github.com/charmbracelet/crush/internal/config"
@@ -35,6 +37,7 @@ import (
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/charmbracelet/crush/internal/telemetry"
+	"github.com/charmbracelet/crush/internal/tooloutput"
 	"github.com/charmbracelet/crush/internal/tui/components/anim"
 	"github.com/charmbracelet/crush/internal/tui/styles"
 	"github.com/charmbracelet/crush/internal/update"
@@ -55,7 +58,11 @@ type App struct {
 	Sessions    session.Service
 	Messages    message.Service
 	History     history.Service
+	ToolOutputs tooloutput.Service
 	Permissions permission.Service
+	// PermissionGrants stores the permissions granted for a session or the
+	// project.
+	PermissionGrants permission.GrantStore
 
 	AgentCoordinator agent.Coordinator
 	StatusReporter   *agentstatus.Reporter
@@ -80,18 +87,24 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 	sessions := session.NewService(q, conn)
 	messages := message.NewService(q)
 	files := history.NewService(q, conn)
+	toolOutputs := tooloutput.NewService(q, conn)
+	permissionGrants := permission.NewGrantStore(q, cfg.Options.DataDirectory, cfg.WorkingDir())
 	skipPermissionsRequests := cfg.Permissions != nil && cfg.Permissions.SkipRequests
 	var allowedTools []string
-	if cfg.Permissions != nil && cfg.Permissions.AllowedTools != nil {
+	var permissionRules []permission.Rule
+	if cfg.Permissions != nil {
 		allowedTools = cfg.Permissions.AllowedTools
+		permissionRules = cfg.Permissions.Rules
 	}
 
 	app := &App{
-		Sessions:    sessions,
-		Messages:    messages,
-		History:     files,
-		Permissions: permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools),
-		LSPClients:  csync.NewMap[string, *lsp.Client](),
+		Sessions:         sessions,
+		Messages:         messages,
+		History:          files,
+		ToolOutputs:      toolOutputs,
+		Permissions:      permission.NewPermissionService(cfg.WorkingDir(), skipPermissionsRequests, allowedTools, permissionRules, permissionGrants),
+		PermissionGrants: permissionGrants,
+		LSPClients:       csync.NewMap[string, *lsp.Client](),
 
 		globalCtx: ctx,
 
@@ -104,6 +117,10 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 
 	app.setupEvents()
 
+	// Persist truncated tool outputs so they can be explored across
+	// restarts.
+	tools.GetOutputCache().SetStore(toolOutputs)
+
 	// Initialize LSP clients in the background.
 	go app.initLSPClients(ctx)
 
@@ -140,19 +157,47 @@ func (app *App) Config() *config.Config {
 	return app.config
 }
 
+// NonInteractiveOptions configures a non-interactive run.
+type NonInteractiveOptions struct {
+	Prompt       string
+	LargeModel   string
+	SmallModel   string
+	Quiet        bool
+	OutputFormat OutputFormat
+
+	// SessionID is the ID of an existing session to continue. When empty, a
+	// new session is created unless Continue is set.
+	SessionID string
+	// Continue continues the most recently updated session.
+	Continue bool
+	// Worktree creates the new session in its own git worktree.
+	Worktree bool
+	// MaxSteps and MaxCostUSD override the configured step and cost budgets
+	// of the coder and task agents when set.
+	MaxSteps   int
+	MaxCostUSD float64
+}
+
 // RunNonInteractive runs the application in non-interactive mode with the
 // given prompt, printing to stdout.
-func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt, largeModel, smallModel string, quiet bool) error {
+func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, opts NonInteractiveOptions) error {
 	slog.Info("Running in non-interactive mode")
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	if largeModel != "" || smallModel != "" {
-		if err := app.overrideModelsForNonInteractive(ctx, largeModel, smallModel); err != nil {
+	prompt := opts.Prompt
+	outputFormat := cmp.Or(opts.OutputFormat, OutputFormatText)
+	quiet := opts.Quiet || outputFormat != OutputFormatText
+
+	if opts.LargeModel != "" || opts.SmallModel != "" {
+		if err := app.overrideModelsForNonInteractive(ctx, opts.LargeModel, opts.SmallModel); err != nil {
 			return fmt.Errorf("failed to override models: %w", err)
 		}
 	}
+	if opts.MaxSteps > 0 || opts.MaxCostUSD > 0 {
+		app.overrideBudgetForNonInteractive(opts.MaxSteps, opts.MaxCostUSD)
+	}
 
 	var (
 		spinner   *format.Spinner
@@ -208,22 +253,20 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 
 	defer stopSpinner()
 
-	const maxPromptLengthForTitle = 100
-	const titlePrefix = "Non-interactive: "
-	var titleSuffix string
-
-	if len(prompt) > maxPromptLengthForTitle {
-		titleSuffix = prompt[:maxPromptLengthForTitle] + "..."
-	} else {
-		titleSuffix = prompt
+	sess, err := app.nonInteractiveSession(ctx, opts)
+	if err != nil {
+		return err
 	}
-	title := titlePrefix + titleSuffix
 
-	sess, err := app.Sessions.Create(ctx, title)
-	if err != nil {
-		return fmt.Errorf("failed to create session for non-interactive mode: %w", err)
+	tracker := newStreamTracker(sess.ID)
+	if opts.SessionID != "" || opts.Continue {
+		// Don't report what happened in previous runs.
+		existing, err := app.Messages.List(ctx, sess.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list session messages: %w", err)
+		}
+		tracker.skip(existing...)
 	}
-	slog.Info("Created session for non-interactive run", "session_id", sess.ID)
 
 	// Automatically approve all permission requests for this non-interactive
 	// session.
@@ -235,29 +278,41 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 	}
 	done := make(chan response, 1)
 
+	messageEvents := app.Messages.Subscribe(ctx)
+	permissionEvents := app.Permissions.SubscribeNotifications(ctx)
+	messageReadBytes := make(map[string]int)
+	startTime := time.Now()
+
 	go func(ctx context.Context, sessionID, prompt string) {
-		result, err := app.AgentCoordinator.Run(ctx, sess.ID, prompt)
+		result, err := app.AgentCoordinator.Run(ctx, sessionID, prompt)
 		if err != nil {
 			done <- response{
 				err: fmt.Errorf("failed to start agent processing stream: %w", err),
 			}
+			return
 		}
 		done <- response{
 			result: result,
 		}
 	}(ctx, sess.ID, prompt)
 
-	messageEvents := app.Messages.Subscribe(ctx)
-	messageReadBytes := make(map[string]int)
-
 	defer func() {
 		if stderrTTY {
 			_, _ = fmt.Fprintf(os.Stderr, ansi.ResetProgressBar)
 		}
 
-		// Always print a newline at the end. If output is a TTY this will
-		// prevent the prompt from overwriting the last line of output.
-		_, _ = fmt.Fprintln(output)
+		// Always print a newline at the end in text mode. If output is a TTY
+		// this will prevent the prompt from overwriting the last line of
+		// output.
+		if outputFormat == OutputFormatText {
+			_, _ = fmt.Fprintln(output)
+			// Print the session ID so scripts can continue it later. It goes
+			// to stderr to keep stdout limited to the response.
+			_, _ = fmt.Fprintf(os.Stderr, "Session: %s\n", sess.ID)
+			if sess.WorktreePath != "" {
+				_, _ = fmt.Fprintf(os.Stderr, "Worktree: %s (%s)\n", sess.WorktreePath, sess.WorktreeBranch)
+			}
+		}
 	}()
 
 	for {
@@ -270,17 +325,44 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 		select {
 		case result := <-done:
 			stopSpinner()
-			if result.err != nil {
-				if errors.Is(result.err, context.Canceled) || errors.Is(result.err, agent.ErrRequestCancelled) {
-					slog.Info("Non-interactive: agent processing cancelled", "session_id", sess.ID)
-					return nil
+			runErr := result.err
+			if errors.Is(runErr, context.Canceled) || errors.Is(runErr, agent.ErrRequestCancelled) {
+				slog.Info("Non-interactive: agent processing cancelled", "session_id", sess.ID)
+				runErr = nil
+			}
+			if outputFormat != OutputFormatText {
+				if err := app.writeRunSummary(ctx, output, outputFormat, tracker, result.err, time.Since(startTime)); err != nil {
+					slog.Error("Non-interactive: failed to write run summary", "error", err)
 				}
-				return fmt.Errorf("agent processing failed: %w", result.err)
+			}
+			if runErr != nil {
+				return fmt.Errorf("agent processing failed: %w", runErr)
 			}
 			return nil
 
+		case event := <-permissionEvents:
+			if outputFormat != OutputFormatStreamJSON {
+				continue
+			}
+			if ev, ok := tracker.permissionEvent(event.Payload); ok {
+				if err := writeJSONLine(output, ev); err != nil {
+					return err
+				}
+			}
+
 		case event := <-messageEvents:
 			msg := event.Payload
+			if outputFormat == OutputFormatStreamJSON {
+				for _, ev := range tracker.events(msg) {
+					if err := writeJSONLine(output, ev); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if outputFormat != OutputFormatText {
+				continue
+			}
 			if msg.SessionID == sess.ID && msg.Role == message.Assistant && len(msg.Parts) > 0 {
 				stopSpinner()
 
@@ -309,6 +391,121 @@ func (app *App) RunNonInteractive(ctx context.Context, output io.Writer, prompt,
 	}
 }
 
+// nonInteractiveSession returns the session a non-interactive run should use:
+// the requested session, the most recent one when continuing, or a new one.
+func (app *App) nonInteractiveSession(ctx context.Context, opts NonInteractiveOptions) (session.Session, error) {
+	switch {
+	case opts.SessionID != "":
+		sess, err := app.Sessions.Get(ctx, opts.SessionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return session.Session{}, fmt.Errorf("session %q not found", opts.SessionID)
+		}
+		if err != nil {
+			return session.Session{}, fmt.Errorf("failed to get session %q: %w", opts.SessionID, err)
+		}
+		slog.Info("Continuing session for non-interactive run", "session_id", sess.ID)
+		app.UseWorktree(sess)
+		return sess, nil
+	case opts.Continue:
+		sessions, err := app.Sessions.List(ctx)
+		if err != nil {
+			return session.Session{}, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		if len(sessions) > 0 {
+			slog.Info("Continuing most recent session for non-interactive run", "session_id", sessions[0].ID)
+			app.UseWorktree(sessions[0])
+			return sessions[0], nil
+		}
+		slog.Info("No session to continue, creating a new one")
+	}
+
+	const maxPromptLengthForTitle = 100
+	const titlePrefix = "Non-interactive: "
+	var titleSuffix string
+
+	if len(opts.Prompt) > maxPromptLengthForTitle {
+		titleSuffix = opts.Prompt[:maxPromptLengthForTitle] + "..."
+	} else {
+		titleSuffix = opts.Prompt
+	}
+	title := titlePrefix + titleSuffix
+
+	if opts.Worktree {
+		sess, err := app.NewWorktreeSession(ctx, title)
+		if err != nil {
+			return session.Session{}, fmt.Errorf("failed to create worktree session for non-interactive mode: %w", err)
+		}
+		return sess, nil
+	}
+
+	sess, err := app.Sessions.Create(ctx, title)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to create session for non-interactive mode: %w", err)
+	}
+	slog.Info("Created session for non-interactive run", "session_id", sess.ID)
+	return sess, nil
+}
+
+// writeRunSummary writes the final record of a json or stream-json run. In
+// stream-json mode it first flushes any parts whose update events were
+// dropped by the message broker.
+func (app *App) writeRunSummary(ctx context.Context, output io.Writer, format OutputFormat, tracker *streamTracker, runErr error, duration time.Duration) error {
+	// The run context may already be cancelled; we still want to report.
+	ctx = context.WithoutCancel(ctx)
+
+	msgs, err := app.Messages.List(ctx, tracker.sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to list session messages: %w", err)
+	}
+
+	summary := RunSummary{
+		Type:       StreamEventResult,
+		SessionID:  tracker.sessionID,
+		Status:     RunStatusSuccess,
+		DurationMS: duration.Milliseconds(),
+	}
+
+	for _, msg := range msgs {
+		if tracker.skipped[msg.ID] {
+			continue
+		}
+		if format == OutputFormatStreamJSON {
+			for _, ev := range tracker.events(msg) {
+				if err := writeJSONLine(output, ev); err != nil {
+					return err
+				}
+			}
+		}
+		if msg.Role != message.Assistant {
+			continue
+		}
+		if text := msg.Content().Text; text != "" {
+			summary.Result = strings.TrimSpace(text)
+		}
+		if reason := msg.FinishReason(); reason != "" {
+			summary.FinishReason = reason
+		}
+	}
+
+	switch {
+	case errors.Is(runErr, context.Canceled) || errors.Is(runErr, agent.ErrRequestCancelled):
+		summary.Status = RunStatusCanceled
+	case runErr != nil:
+		summary.Status = RunStatusError
+		summary.Error = runErr.Error()
+	}
+
+	if sess, err := app.Sessions.Get(ctx, tracker.sessionID); err == nil {
+		summary.Cost = sess.Cost
+		summary.PromptTokens = sess.PromptTokens
+		summary.CompletionTokens = sess.CompletionTokens
+	} else {
+		slog.Warn("Non-interactive: failed to get session for summary", "error", err)
+	}
+
+	return writeJSONLine(output, summary)
+}
+
 func (app *App) UpdateAgentModel(ctx context.Context) error {
 	if app.AgentCoordinator == nil {
 		return fmt.Errorf("agent configuration is missing")
@@ -316,6 +513,28 @@ func (app *App) UpdateAgentModel(ctx context.Context) error {
 	return app.AgentCoordinator.UpdateModels(ctx)
 }
 
+// UpdateAgentSystemPrompt builds the system prompt of the agent again, after
+// the skills were toggled.
+func (app *App) UpdateAgentSystemPrompt(ctx context.Context) error {
+	if app.AgentCoordinator == nil {
+		return fmt.Errorf("agent configuration is missing")
+	}
+	return app.AgentCoordinator.UpdateSystemPrompt(ctx)
+}
+
+// overrideBudgetForNonInteractive overrides the step and cost budgets of the
+// coder and task agents for this run. Zero values keep the configured
+// budget.
+func (app *App) overrideBudgetForNonInteractive(maxSteps int, maxCostUSD float64) {
+	if maxSteps > 0 {
+		app.config.Options.MaxSteps = maxSteps
+	}
+	if maxCostUSD > 0 {
+		app.config.Options.MaxCostUSD = maxCostUSD
+	}
+	app.config.SetupAgents()
+}
+
 // overrideModelsForNonInteractive parses the model strings and temporarily
 // overrides the model configurations, then rebuilds the agent.
 // Format: "model-name" (searches all providers) or "provider/model-name".
@@ -415,7 +634,10 @@ func (app *App) setupEvents() {
 	setupSubscriber(ctx, app.serviceEventsWG, "permissions-notifications", app.Permissions.SubscribeNotifications, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "history", app.History.Subscribe, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "mcp", mcp.SubscribeEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "mcp-elicitations", mcp.SubscribeElicitations, app.events)
 	setupSubscriber(ctx, app.serviceEventsWG, "lsp", SubscribeLSPEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "retries", agent.SubscribeRetryEvents, app.events)
+	setupSubscriber(ctx, app.serviceEventsWG, "fallbacks", agent.SubscribeFallbackEvents, app.events)
 	cleanupFunc := func() error {
 		cancel()
 		app.serviceEventsWG.Wait()
@@ -476,6 +698,7 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 		slog.Error("Failed to create coder agent", "err", err)
 		return err
 	}
+	mcp.SetSampler(app.AgentCoordinator.Sample)
 
 	// Set status reporter if initialized.
 	if app.StatusReporter != nil {
@@ -534,6 +757,9 @@ func (app *App) Subscribe(program *tea.Program) {
 		program.Quit()
 	})
 
+	// The TUI answers the elicitation requests of the MCPs.
+	mcp.SetInteractive(true)
+
 	app.tuiWG.Add(1)
 	tuiCtx, tuiCancel := context.WithCancel(app.globalCtx)
 	app.cleanupFuncs = append(app.cleanupFuncs, func() error {
@@ -559,6 +785,12 @@ func (app *App) Subscribe(program *tea.Program) {
 	}
 }
 
+// Events returns the events of the services that Subscribe sends to the TUI,
+// for the headless modes. Only one consumer may read them.
+func (app *App) Events() <-chan tea.Msg {
+	return app.events
+}
+
 // Shutdown performs a graceful shutdown of the application.
 func (app *App) Shutdown() {
 	start := time.Now()
@@ -5,10 +5,12 @@ import (
 	"log/slog"
 	"os/exec"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/lsp"
+	"github.com/charmbracelet/crush/internal/session"
 	powernapconfig "github.com/charmbracelet/x/powernap/pkg/config"
 )
 
@@ -20,6 +22,7 @@ func (app *App) initLSPClients(ctx context.Context) {
 	manager.LoadDefaults()
 
 	var userConfiguredLSPs []string
+	userConfigs := map[string]config.LSPConfig{}
 	for name, clientConfig := range app.config.LSP {
 		if clientConfig.Disabled {
 			slog.Info("Skipping disabled LSP client", "name", name)
@@ -39,6 +42,7 @@ func (app *App) initLSPClients(ctx context.Context) {
 			}
 		}
 		userConfiguredLSPs = append(userConfiguredLSPs, name)
+		userConfigs[name] = clientConfig
 		manager.AddServer(name, &powernapconfig.ServerConfig{
 			Command:     clientConfig.Command,
 			Args:        clientConfig.Args,
@@ -63,9 +67,15 @@ func (app *App) initLSPClients(ctx context.Context) {
 			slog.Debug("Ignoring non user-define LSP client due to AutoLSP being disabled", "name", name)
 			continue
 		}
+		cfg := toOurConfig(server)
+		if user, ok := userConfigs[name]; ok {
+			// Settings crush handles itself aren't part of powernap's config.
+			cfg.FormatOnWrite = user.FormatOnWrite
+			cfg.OrganizeImports = user.OrganizeImports
+		}
 		go app.createAndStartLSPClient(
-			ctx, name,
-			toOurConfig(server),
+			ctx, name, app.config.WorkingDir(),
+			cfg,
 			slices.Contains(userConfiguredLSPs, name),
 		)
 	}
@@ -83,8 +93,27 @@ func toOurConfig(in *powernapconfig.ServerConfig) config.LSPConfig {
 	}
 }
 
+// StartWorktreeLSPClients starts a copy of each running LSP client rooted in
+// the session's worktree, so diagnostics reflect the files the session's
+// agent actually edits. Clients already started for the worktree are kept.
+func (app *App) StartWorktreeLSPClients(sess session.Session) {
+	if sess.WorktreePath == "" {
+		return
+	}
+	for name, client := range app.LSPClients.Seq2() {
+		if strings.Contains(name, "@") {
+			continue
+		}
+		wtName := name + "@" + sess.WorktreeBranch
+		if _, ok := app.LSPClients.Get(wtName); ok {
+			continue
+		}
+		go app.createAndStartLSPClient(app.globalCtx, wtName, sess.WorktreePath, client.Config(), true)
+	}
+}
+
 // createAndStartLSPClient creates a new LSP client, initializes it, and starts its workspace watcher.
-func (app *App) createAndStartLSPClient(ctx context.Context, name string, config config.LSPConfig, userConfigured bool) {
+func (app *App) createAndStartLSPClient(ctx context.Context, name, workDir string, config config.LSPConfig, userConfigured bool) {
 	if !userConfigured {
 		if _, err := exec.LookPath(config.Command); err != nil {
 			slog.Warn("Default LSP config skipped: server not installed", "name", name, "error", err)
@@ -98,7 +127,7 @@ func (app *App) createAndStartLSPClient(ctx context.Context, name string, config
 	updateLSPState(name, lsp.StateStarting, nil, nil, 0)
 
 	// Create LSP client.
-	lspClient, err := lsp.New(ctx, name, config, app.config.Resolver())
+	lspClient, err := lsp.New(ctx, name, workDir, config, app.config.Resolver())
 	if err != nil {
 		if !userConfigured {
 			slog.Warn("Default LSP config skipped due to error", "name", name, "error", err)
@@ -118,7 +147,7 @@ func (app *App) createAndStartLSPClient(ctx context.Context, name string, config
 	defer cancel()
 
 	// Initialize LSP client.
-	_, err = lspClient.Initialize(initCtx, app.config.WorkingDir())
+	_, err = lspClient.Initialize(initCtx, workDir)
 	if err != nil {
 		slog.Error("LSP client initialization failed", "name", name, "error", err)
 		updateLSPState(name, lsp.StateError, err, lspClient, 0)
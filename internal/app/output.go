@@ -0,0 +1,262 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// OutputFormat controls what a non-interactive run writes to its output.
+type OutputFormat string
+
+const (
+	// OutputFormatText prints the assistant's text as it streams in.
+	OutputFormatText OutputFormat = "text"
+	// OutputFormatJSON prints a single JSON summary record once the run
+	// finishes.
+	OutputFormatJSON OutputFormat = "json"
+	// OutputFormatStreamJSON prints one JSON event per line (NDJSON) for
+	// every message part, followed by a summary record.
+	OutputFormatStreamJSON OutputFormat = "stream-json"
+)
+
+// OutputFormats lists all supported output formats.
+var OutputFormats = []OutputFormat{
+	OutputFormatText,
+	OutputFormatJSON,
+	OutputFormatStreamJSON,
+}
+
+// ParseOutputFormat parses an output format, defaulting to text when empty.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	if s == "" {
+		return OutputFormatText, nil
+	}
+	f := OutputFormat(strings.ToLower(s))
+	if !slices.Contains(OutputFormats, f) {
+		return "", fmt.Errorf("invalid output format %q: must be one of text, json, stream-json", s)
+	}
+	return f, nil
+}
+
+// Stream event types emitted in stream-json mode.
+const (
+	StreamEventText       = "text"
+	StreamEventReasoning  = "reasoning"
+	StreamEventToolCall   = "tool_call"
+	StreamEventToolResult = "tool_result"
+	StreamEventPermission = "permission"
+	StreamEventFinish     = "finish"
+	StreamEventResult     = "result"
+)
+
+// Run statuses reported in the summary record.
+const (
+	RunStatusSuccess  = "success"
+	RunStatusError    = "error"
+	RunStatusCanceled = "canceled"
+)
+
+// StreamEvent is a single NDJSON record emitted in stream-json mode.
+type StreamEvent struct {
+	Type      string `json:"type"`
+	SessionID string `json:"session_id"`
+	MessageID string `json:"message_id,omitempty"`
+
+	// Text holds the delta for text and reasoning events.
+	Text string `json:"text,omitempty"`
+
+	ToolCall   *StreamToolCall   `json:"tool_call,omitempty"`
+	ToolResult *StreamToolResult `json:"tool_result,omitempty"`
+	Permission *StreamPermission `json:"permission,omitempty"`
+	Finish     *StreamFinish     `json:"finish,omitempty"`
+}
+
+// StreamToolCall describes a tool call requested by the model.
+type StreamToolCall struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// StreamToolResult describes the outcome of a tool call.
+type StreamToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	MIMEType   string `json:"mime_type,omitempty"`
+	IsError    bool   `json:"is_error"`
+}
+
+// StreamPermission describes a permission decision for a tool call.
+type StreamPermission struct {
+	ToolCallID string `json:"tool_call_id"`
+	Granted    bool   `json:"granted"`
+}
+
+// StreamFinish describes why an assistant message finished.
+type StreamFinish struct {
+	Reason  message.FinishReason `json:"reason"`
+	Message string               `json:"message,omitempty"`
+	Details string               `json:"details,omitempty"`
+}
+
+// RunSummary is the final record written in json and stream-json modes.
+type RunSummary struct {
+	Type             string               `json:"type"`
+	SessionID        string               `json:"session_id"`
+	Status           string               `json:"status"`
+	Result           string               `json:"result"`
+	Error            string               `json:"error,omitempty"`
+	FinishReason     message.FinishReason `json:"finish_reason,omitempty"`
+	Cost             float64              `json:"cost"`
+	PromptTokens     int64                `json:"prompt_tokens"`
+	CompletionTokens int64                `json:"completion_tokens"`
+	DurationMS       int64                `json:"duration_ms"`
+}
+
+// streamTracker turns message updates into stream events, remembering what
+// was already emitted so that every part is reported exactly once.
+type streamTracker struct {
+	sessionID      string
+	textBytes      map[string]int
+	reasoningBytes map[string]int
+	toolCalls      map[string]bool
+	toolResults    map[string]bool
+	finished       map[string]bool
+	skipped        map[string]bool
+}
+
+func newStreamTracker(sessionID string) *streamTracker {
+	return &streamTracker{
+		sessionID:      sessionID,
+		textBytes:      make(map[string]int),
+		reasoningBytes: make(map[string]int),
+		toolCalls:      make(map[string]bool),
+		toolResults:    make(map[string]bool),
+		finished:       make(map[string]bool),
+		skipped:        make(map[string]bool),
+	}
+}
+
+// skip marks msgs as already reported, e.g. when continuing a session.
+func (t *streamTracker) skip(msgs ...message.Message) {
+	for _, msg := range msgs {
+		t.skipped[msg.ID] = true
+	}
+}
+
+// events returns the events for the parts of msg that have not been emitted
+// yet.
+func (t *streamTracker) events(msg message.Message) []StreamEvent {
+	if msg.SessionID != t.sessionID || t.skipped[msg.ID] {
+		return nil
+	}
+
+	var events []StreamEvent
+	newEvent := func(typ string) StreamEvent {
+		return StreamEvent{Type: typ, SessionID: msg.SessionID, MessageID: msg.ID}
+	}
+
+	switch msg.Role {
+	case message.Assistant:
+		if reasoning := msg.ReasoningContent().Thinking; len(reasoning) > t.reasoningBytes[msg.ID] {
+			ev := newEvent(StreamEventReasoning)
+			ev.Text = reasoning[t.reasoningBytes[msg.ID]:]
+			t.reasoningBytes[msg.ID] = len(reasoning)
+			events = append(events, ev)
+		}
+		if text := msg.Content().Text; len(text) > t.textBytes[msg.ID] {
+			ev := newEvent(StreamEventText)
+			ev.Text = text[t.textBytes[msg.ID]:]
+			t.textBytes[msg.ID] = len(text)
+			events = append(events, ev)
+		}
+		for _, tc := range msg.ToolCalls() {
+			if !tc.Finished || t.toolCalls[tc.ID] {
+				continue
+			}
+			t.toolCalls[tc.ID] = true
+			ev := newEvent(StreamEventToolCall)
+			ev.ToolCall = &StreamToolCall{
+				ID:    tc.ID,
+				Name:  tc.Name,
+				Input: rawJSONOrString(tc.Input),
+			}
+			events = append(events, ev)
+		}
+		if finish := msg.FinishPart(); finish != nil && !t.finished[msg.ID] {
+			t.finished[msg.ID] = true
+			ev := newEvent(StreamEventFinish)
+			ev.Finish = &StreamFinish{
+				Reason:  finish.Reason,
+				Message: finish.Message,
+				Details: finish.Details,
+			}
+			events = append(events, ev)
+		}
+	case message.Tool:
+		for _, tr := range msg.ToolResults() {
+			if t.toolResults[tr.ToolCallID] {
+				continue
+			}
+			t.toolResults[tr.ToolCallID] = true
+			ev := newEvent(StreamEventToolResult)
+			ev.ToolResult = &StreamToolResult{
+				ToolCallID: tr.ToolCallID,
+				Name:       tr.Name,
+				Content:    tr.Content,
+				MIMEType:   tr.MIMEType,
+				IsError:    tr.IsError,
+			}
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+// permissionEvent returns the event for a permission decision, or false if
+// the notification is not a decision (e.g. a request being announced).
+func (t *streamTracker) permissionEvent(n permission.PermissionNotification) (StreamEvent, bool) {
+	if !n.Granted && !n.Denied {
+		return StreamEvent{}, false
+	}
+	return StreamEvent{
+		Type:      StreamEventPermission,
+		SessionID: t.sessionID,
+		Permission: &StreamPermission{
+			ToolCallID: n.ToolCallID,
+			Granted:    n.Granted,
+		},
+	}, true
+}
+
+// writeJSONLine writes v as a single line of JSON.
+func writeJSONLine(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
+
+// rawJSONOrString returns s as raw JSON if it is valid JSON, otherwise as a
+// JSON string. Tool inputs are usually JSON objects, but a cancelled stream
+// may leave them partial.
+func rawJSONOrString(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	if json.Valid([]byte(s)) {
+		return json.RawMessage(s)
+	}
+	data, _ := json.Marshal(s)
+	return data
+}
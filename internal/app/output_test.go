@@ -0,0 +1,132 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		want    OutputFormat
+		wantErr bool
+	}{
+		{in: "", want: OutputFormatText},
+		{in: "text", want: OutputFormatText},
+		{in: "JSON", want: OutputFormatJSON},
+		{in: "stream-json", want: OutputFormatStreamJSON},
+		{in: "yaml", wantErr: true},
+	} {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseOutputFormat(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStreamTrackerEvents(t *testing.T) {
+	tracker := newStreamTracker("sess")
+
+	msg := message.Message{ID: "m1", SessionID: "sess", Role: message.Assistant}
+	msg.AppendReasoningContent("thinking")
+	msg.AppendContent("Hello")
+
+	events := tracker.events(msg)
+	require.Len(t, events, 2)
+	require.Equal(t, StreamEventReasoning, events[0].Type)
+	require.Equal(t, "thinking", events[0].Text)
+	require.Equal(t, StreamEventText, events[1].Type)
+	require.Equal(t, "Hello", events[1].Text)
+
+	// Only the delta is emitted on subsequent updates.
+	msg.AppendContent(", world")
+	msg.AddToolCall(message.ToolCall{ID: "tc1", Name: "bash", Input: `{"command":"ls"}`})
+	events = tracker.events(msg)
+	require.Len(t, events, 1)
+	require.Equal(t, ", world", events[0].Text)
+
+	// Tool calls are emitted once they are finished.
+	msg.FinishToolCall("tc1")
+	msg.AddFinish(message.FinishReasonToolUse, "", "")
+	events = tracker.events(msg)
+	require.Len(t, events, 2)
+	require.Equal(t, StreamEventToolCall, events[0].Type)
+	require.Equal(t, "bash", events[0].ToolCall.Name)
+	require.JSONEq(t, `{"command":"ls"}`, string(events[0].ToolCall.Input))
+	require.Equal(t, StreamEventFinish, events[1].Type)
+	require.Equal(t, message.FinishReasonToolUse, events[1].Finish.Reason)
+
+	// Nothing new, nothing emitted.
+	require.Empty(t, tracker.events(msg))
+
+	result := message.Message{
+		ID:        "m2",
+		SessionID: "sess",
+		Role:      message.Tool,
+		Parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "tc1", Name: "bash", Content: "README.md"},
+			message.Finish{Reason: "stop"},
+		},
+	}
+	events = tracker.events(result)
+	require.Len(t, events, 1)
+	require.Equal(t, StreamEventToolResult, events[0].Type)
+	require.Equal(t, "README.md", events[0].ToolResult.Content)
+	require.Empty(t, tracker.events(result))
+
+	// Messages from other sessions are ignored.
+	other := message.Message{ID: "m3", SessionID: "other", Role: message.Assistant}
+	other.AppendContent("nope")
+	require.Empty(t, tracker.events(other))
+}
+
+func TestStreamTrackerPermissionEvent(t *testing.T) {
+	tracker := newStreamTracker("sess")
+
+	_, ok := tracker.permissionEvent(permission.PermissionNotification{ToolCallID: "tc1"})
+	require.False(t, ok, "pending requests are not decisions")
+
+	ev, ok := tracker.permissionEvent(permission.PermissionNotification{ToolCallID: "tc1", Granted: true})
+	require.True(t, ok)
+	require.Equal(t, StreamEventPermission, ev.Type)
+	require.True(t, ev.Permission.Granted)
+}
+
+func TestWriteJSONLine(t *testing.T) {
+	var b bytes.Buffer
+	ev := StreamEvent{
+		Type:      StreamEventToolCall,
+		SessionID: "sess",
+		ToolCall:  &StreamToolCall{ID: "tc1", Name: "bash", Input: rawJSONOrString("{\"command\":")},
+	}
+	require.NoError(t, writeJSONLine(&b, ev))
+	require.Equal(t, byte('\n'), b.Bytes()[b.Len()-1])
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(b.Bytes(), &decoded))
+	toolCall := decoded["tool_call"].(map[string]any)
+	require.Equal(t, "{\"command\":", toolCall["input"], "partial input is encoded as a string")
+}
+
+func TestStreamTrackerSkip(t *testing.T) {
+	tracker := newStreamTracker("sess")
+
+	previous := message.Message{ID: "m1", SessionID: "sess", Role: message.Assistant}
+	previous.AppendContent("From an earlier run")
+	tracker.skip(previous)
+	require.Empty(t, tracker.events(previous))
+
+	current := message.Message{ID: "m2", SessionID: "sess", Role: message.Assistant}
+	current.AppendContent("New")
+	require.Len(t, tracker.events(current), 1)
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// RevertResult describes a reverted turn.
+type RevertResult struct {
+	// Message is the user message the session was reverted to. It's deleted
+	// along with everything after it, but callers may want to offer its
+	// content for editing.
+	Message message.Message
+	// Files are the paths that were restored or deleted.
+	Files []string
+}
+
+// RevertSession undoes the turn started by the given user message and every
+// turn after it: files the agent changed are restored to their checkpoint
+// and the conversation is truncated before the message. When messageID is
+// empty, the last turn is reverted.
+func RevertSession(ctx context.Context, sessions session.Service, messages message.Service, files history.Service, sessionID, messageID string) (RevertResult, error) {
+	sess, err := sessions.Get(ctx, sessionID)
+	if err != nil {
+		return RevertResult{}, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	msgs, err := messages.List(ctx, sess.ID)
+	if err != nil {
+		return RevertResult{}, fmt.Errorf("failed to list messages: %w", err)
+	}
+	var (
+		target message.Message
+		found  bool
+	)
+	for i := len(msgs) - 1; i >= 0; i-- {
+		msg := msgs[i]
+		if messageID == "" && msg.Role == message.User || msg.ID == messageID {
+			target, found = msg, true
+			break
+		}
+	}
+	switch {
+	case !found && messageID == "":
+		return RevertResult{}, errors.New("session has no turns to revert")
+	case !found:
+		return RevertResult{}, fmt.Errorf("message %q not found in session", messageID)
+	case target.Role != message.User:
+		return RevertResult{}, fmt.Errorf("message %q is not a user message", messageID)
+	}
+
+	paths, err := files.Revert(ctx, sess.ID, target.ID)
+	if err != nil {
+		return RevertResult{}, fmt.Errorf("failed to revert files: %w", err)
+	}
+	if err := messages.Truncate(ctx, sess.ID, target.ID); err != nil {
+		return RevertResult{}, fmt.Errorf("failed to truncate conversation: %w", err)
+	}
+
+	// Forget the summary if it was part of what got truncated.
+	if sess.SummaryMessageID != "" {
+		if _, err := messages.Get(ctx, sess.SummaryMessageID); errors.Is(err, sql.ErrNoRows) {
+			sess.SummaryMessageID = ""
+			if _, err := sessions.Save(ctx, sess); err != nil {
+				return RevertResult{}, fmt.Errorf("failed to update session: %w", err)
+			}
+		}
+	}
+
+	slog.Info("Reverted session", "session_id", sess.ID, "message_id", target.ID, "files", len(paths))
+	return RevertResult{Message: target, Files: paths}, nil
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/worktree"
+)
+
+// NewWorktreeSession creates a session whose tools are rooted in a new git
+// worktree, checked out on its own branch under the data directory.
+func (app *App) NewWorktreeSession(ctx context.Context, title string) (session.Session, error) {
+	sess, err := app.Sessions.Create(ctx, title)
+	if err != nil {
+		return session.Session{}, err
+	}
+
+	wt, err := worktree.Create(ctx, app.config.WorkingDir(), app.config.Options.DataDirectory, worktreeName(sess.ID))
+	if err != nil {
+		if delErr := app.Sessions.Delete(ctx, sess.ID); delErr != nil {
+			slog.Error("Failed to delete session after worktree creation failed", "session_id", sess.ID, "error", delErr)
+		}
+		return session.Session{}, fmt.Errorf("failed to create worktree: %w", err)
+	}
+
+	sess.WorktreePath = wt.Path
+	sess.WorktreeBranch = wt.Branch
+	sess, err = app.Sessions.Save(ctx, sess)
+	if err != nil {
+		return session.Session{}, err
+	}
+	slog.Info("Created worktree session", "session_id", sess.ID, "path", wt.Path, "branch", wt.Branch)
+
+	app.UseWorktree(sess)
+	return sess, nil
+}
+
+// UseWorktree prepares the services of the app for the session's worktree, if
+// it has one: it starts the LSP clients rooted in it and exposes it to the
+// MCPs as a root.
+func (app *App) UseWorktree(sess session.Session) {
+	if sess.WorktreePath == "" {
+		return
+	}
+	app.StartWorktreeLSPClients(sess)
+	mcp.AddRoots(sess.WorktreePath)
+}
+
+// worktreeName returns the name of the worktree and branch for a session.
+func worktreeName(sessionID string) string {
+	const maxLen = 8
+	if len(sessionID) > maxLen {
+		return sessionID[:maxLen]
+	}
+	return sessionID
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/charmbracelet/crush/internal/codeindex"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Build the code search index",
+	Long: `Build or update the index the code_search tool searches, in the data
+directory. Crush builds it on first use and keeps it up to date; prebuild it
+to make the first search fast, for instance in CI.
+
+The index is lexical only, unless an embedding provider is configured in
+tools.code_search.embedding_provider.`,
+	Example: `
+# Build the index of the current project
+crush index
+
+# Build the index of another project
+crush index --cwd /path/to/project
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		debug, _ := cmd.Flags().GetBool("debug")
+
+		cfg, err := config.Init(cwd, dataDir, debug)
+		if err != nil {
+			return err
+		}
+
+		// Cancel on SIGINT or SIGTERM.
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+		defer cancel()
+
+		embedder, err := codeindex.NewEmbedder(cfg)
+		if err != nil {
+			return err
+		}
+		idx, err := codeindex.Open(cfg.WorkingDir(), codeindex.Dir(cfg.Options.DataDirectory), embedder)
+		if err != nil {
+			return err
+		}
+		stats, err := idx.Update(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Indexed %d files in %d chunks (%d updated, %d removed)\n", stats.Files, stats.Chunks, stats.Indexed, stats.Removed)
+		return nil
+	},
+}
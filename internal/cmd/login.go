@@ -14,31 +14,37 @@ import (
 	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/oauth/copilot"
 	"github.com/charmbracelet/crush/internal/oauth/hyper"
+	"github.com/charmbracelet/crush/internal/oauth/mcpauth"
 	"github.com/pkg/browser"
 	"github.com/spf13/cobra"
 )
 
 var loginCmd = &cobra.Command{
 	Aliases: []string{"auth"},
-	Use:     "login [platform]",
+	Use:     "login [platform] [mcp-name]",
 	Short:   "Login Crush to a platform",
 	Long: `Login Crush to a specified platform.
 The platform should be provided as an argument.
-Available platforms are: hyper, copilot.`,
+Available platforms are: hyper, copilot, mcp.
+To authorize Crush with a remote MCP server, pass its name after mcp.`,
 	Example: `
 # Authenticate with Charm Hyper
 crush login
 
 # Authenticate with GitHub Copilot
 crush login copilot
+
+# Authorize Crush with the MCP server named linear
+crush login mcp linear
   `,
 	ValidArgs: []cobra.Completion{
 		"hyper",
 		"copilot",
 		"github",
 		"github-copilot",
+		"mcp",
 	},
-	Args: cobra.MaximumNArgs(1),
+	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		app, err := setupAppWithProgressBar(cmd)
 		if err != nil {
@@ -55,6 +61,11 @@ crush login copilot
 			return loginHyper()
 		case "copilot", "github", "github-copilot":
 			return loginCopilot()
+		case "mcp":
+			if len(args) < 2 {
+				return fmt.Errorf("the name of the MCP server is required")
+			}
+			return loginMCP(args[1])
 		default:
 			return fmt.Errorf("unknown platform: %s", args[0])
 		}
@@ -190,6 +201,42 @@ func loginCopilot() error {
 	return nil
 }
 
+func loginMCP(name string) error {
+	cfg := config.Get()
+	m, ok := cfg.MCP[name]
+	if !ok {
+		return fmt.Errorf("unknown MCP server: %s", name)
+	}
+	if m.Type != config.MCPHttp && m.Type != config.MCPSSE {
+		return fmt.Errorf("MCP server %s doesn't use HTTP, only HTTP and SSE servers support authorization", name)
+	}
+	ctx := getLoginContext()
+
+	fmt.Println("Discovering the authorization server...")
+	creds, err := mcpauth.Authorize(ctx, m.URL, func(authURL string) {
+		fmt.Println()
+		fmt.Println("Open the following URL to authorize Crush:")
+		fmt.Println()
+		fmt.Println(lipgloss.NewStyle().Hyperlink(authURL, "id=mcp").Render(authURL))
+		fmt.Println()
+		if err := browser.OpenURL(authURL); err != nil {
+			fmt.Println("Could not open the URL. You'll need to manually open the URL in your browser.")
+		}
+		fmt.Println("Waiting for authorization...")
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.SetConfigField(fmt.Sprintf("mcp.%s.oauth", name), creds); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Printf("You're now authenticated with %s!\n", name)
+	return nil
+}
+
 func getLoginContext() context.Context {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	go func() {
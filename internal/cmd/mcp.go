@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"cmp"
+	"crypto/rand"
+	"os"
+	"os/signal"
+
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/mcpserver"
+	"github.com/spf13/cobra"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Use Crush over MCP",
+	Long:  "Expose Crush as a Model Context Protocol server, so that editors, other agents and scripts can drive it",
+	Example: `
+# Serve MCP over stdin and stdout
+crush mcp serve
+
+# Serve MCP over streamable HTTP, printing a random token
+crush mcp serve --http localhost:8765
+
+# Serve MCP over streamable HTTP to other machines, with a fixed token
+CRUSH_MCP_TOKEN=secret crush mcp serve --http 0.0.0.0:8765
+  `,
+}
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve Crush as an MCP server",
+	Long: `Serve Crush as an MCP server, over stdin and stdout by default or over
+streamable HTTP with --http.
+
+The server offers the run_prompt, list_sessions, get_session_messages, cancel
+and list_subagents tools, and the sessions as resources. The permissions the
+agent asks for are decided by the configured permission rules first, then
+forwarded to the client that ran the prompt through elicitation. They are
+denied when the client doesn't support elicitation, unless --yolo is set.
+
+Over HTTP, requests must carry the token as a bearer token. Unless one is set
+with --token or $CRUSH_MCP_TOKEN, a random one is generated and printed on
+startup. Requests must be sent to a loopback host or the host of --http, and
+browsers can't send them from other origins.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("http")
+		token, _ := cmd.Flags().GetString("token")
+		token = cmp.Or(token, os.Getenv("CRUSH_MCP_TOKEN"))
+		generated := addr != "" && token == ""
+		if generated {
+			token = rand.Text()
+		}
+
+		// Cancel on SIGINT or SIGTERM.
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+		defer cancel()
+
+		appInstance, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer appInstance.Shutdown()
+
+		event.SetNonInteractive(true)
+		event.AppInitialized()
+
+		server := mcpserver.New(appInstance)
+		if addr != "" {
+			cmd.PrintErrf("Serving MCP on http://%s\n", addr)
+			if generated {
+				cmd.PrintErrf("Token: %s\n", token)
+			}
+			return server.ServeHTTP(ctx, addr, token)
+		}
+		if err := server.ServeStdio(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+		return nil
+	},
+	PostRun: func(cmd *cobra.Command, args []string) {
+		event.AppExited()
+	},
+}
+
+func init() {
+	mcpServeCmd.Flags().String("http", "", "Serve streamable HTTP on this address instead of stdin and stdout")
+	mcpServeCmd.Flags().String("token", "", "Bearer token HTTP clients have to send. Defaults to $CRUSH_MCP_TOKEN")
+	mcpServeCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
+	mcpCmd.AddCommand(mcpServeCmd)
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var permissionsCmd = &cobra.Command{
+	Use:     "permissions",
+	Aliases: []string{"permission"},
+	Short:   "Manage stored permissions",
+	Long:    `List and revoke the permissions granted with "Allow for Session" or "Allow for Project"`,
+	Example: `
+# List the stored permissions of the current project
+crush permissions list
+
+# Revoke a permission
+crush permissions revoke 8d2f6a1b-3c4e-4f5a-9b7c-2e1d3f4a5b6c
+  `,
+}
+
+var permissionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List stored permissions",
+	Long:  "List the permissions granted for the project and for its sessions",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		return withGrantStore(cmd, func(grants permission.GrantStore) error {
+			grantList, err := grants.List(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to list permissions: %w", err)
+			}
+
+			if jsonOutput {
+				output := struct {
+					Permissions []grantInfo `json:"permissions"`
+				}{Permissions: make([]grantInfo, 0, len(grantList))}
+				for _, g := range grantList {
+					output.Permissions = append(output.Permissions, newGrantInfo(g))
+				}
+				return printJSON(cmd, output)
+			}
+
+			if len(grantList) == 0 {
+				cmd.Println("No stored permissions.")
+				return nil
+			}
+
+			if term.IsTerminal(os.Stdout.Fd()) {
+				t := table.New().
+					Border(lipgloss.RoundedBorder()).
+					StyleFunc(func(row, col int) lipgloss.Style {
+						return lipgloss.NewStyle().Padding(0, 2)
+					}).
+					Headers("ID", "Scope", "Tool", "Action", "Path", "Granted")
+
+				for _, g := range grantList {
+					t.Row(
+						g.ID,
+						grantScope(g),
+						g.ToolName,
+						g.Action,
+						g.Path,
+						time.Unix(g.CreatedAt, 0).Local().Format("2006-01-02 15:04"),
+					)
+				}
+				lipgloss.Println(t)
+				return nil
+			}
+
+			for _, g := range grantList {
+				cmd.Printf("%s\t%s\t%s\t%s\t%s\t%s\n", g.ID, grantScope(g), g.ToolName, g.Action, g.Path, time.Unix(g.CreatedAt, 0).Format(time.RFC3339))
+			}
+			return nil
+		})
+	},
+}
+
+var permissionsRevokeCmd = &cobra.Command{
+	Use:   "revoke <permission-id>...",
+	Short: "Revoke stored permissions",
+	Long:  "Revoke stored permissions so the tools ask again",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withGrantStore(cmd, func(grants permission.GrantStore) error {
+			ctx := cmd.Context()
+
+			grantList, err := grants.List(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list permissions: %w", err)
+			}
+			for _, id := range args {
+				i := slices.IndexFunc(grantList, func(g permission.Grant) bool {
+					return g.ID == id
+				})
+				if i < 0 {
+					return fmt.Errorf("permission %q not found", id)
+				}
+				if err := grants.Revoke(ctx, grantList[i]); err != nil {
+					return fmt.Errorf("failed to revoke permission %q: %w", id, err)
+				}
+				cmd.Printf("Revoked permission %s\n", id)
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	permissionsListCmd.Flags().Bool("json", false, "Output as JSON")
+
+	permissionsCmd.AddCommand(
+		permissionsListCmd,
+		permissionsRevokeCmd,
+	)
+}
+
+// grantInfo is the JSON representation of a stored permission.
+type grantInfo struct {
+	ID        string `json:"id"`
+	Scope     string `json:"scope"`
+	SessionID string `json:"session_id,omitempty"`
+	ToolName  string `json:"tool_name"`
+	Action    string `json:"action"`
+	Path      string `json:"path"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+func newGrantInfo(g permission.Grant) grantInfo {
+	scope := "session"
+	if g.Project() {
+		scope = "project"
+	}
+	return grantInfo{
+		ID:        g.ID,
+		Scope:     scope,
+		SessionID: g.SessionID,
+		ToolName:  g.ToolName,
+		Action:    g.Action,
+		Path:      g.Path,
+		CreatedAt: g.CreatedAt,
+	}
+}
+
+// grantScope returns "project" for project grants and the session ID for
+// session ones.
+func grantScope(g permission.Grant) string {
+	if g.Project() {
+		return "project"
+	}
+	return g.SessionID
+}
+
+// withGrantStore connects to the project database and calls fn with the
+// permission grant store.
+func withGrantStore(cmd *cobra.Command, fn func(permission.GrantStore) error) error {
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	ctx := cmd.Context()
+
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return err
+	}
+	if dataDir == "" {
+		cfg, err := config.Init(cwd, "", false)
+		if err != nil {
+			return fmt.Errorf("failed to initialize config: %w", err)
+		}
+		dataDir = cfg.Options.DataDirectory
+	}
+
+	conn, err := db.Connect(ctx, dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close()
+
+	return fn(permission.NewGrantStore(db.New(conn), dataDir, cwd))
+}
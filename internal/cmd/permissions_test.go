@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func listPermissions(t *testing.T, dataDir string) []grantInfo {
+	t.Helper()
+
+	out, err := runCrush(t, "permissions", "list", "--json", "--data-dir", dataDir)
+	require.NoError(t, err)
+
+	var result struct {
+		Permissions []grantInfo `json:"permissions"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	return result.Permissions
+}
+
+func TestPermissionsListEmpty(t *testing.T) {
+	dataDir := t.TempDir()
+
+	out, err := runCrush(t, "permissions", "list", "--data-dir", dataDir)
+	require.NoError(t, err)
+	require.Equal(t, "No stored permissions.\n", out)
+}
+
+func TestPermissionsRevoke(t *testing.T) {
+	dataDir := t.TempDir()
+	sess := seedSessions(t, dataDir, "first")[0]
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+	grants := permission.NewGrantStore(db.New(conn), dataDir, cwd)
+	require.NoError(t, grants.SaveGrant(t.Context(), permission.Grant{SessionID: sess.ID, ToolName: "bash", Action: "execute", Path: cwd}))
+	require.NoError(t, grants.SaveGrant(t.Context(), permission.Grant{ToolName: "edit", Action: "write", Path: cwd}))
+	require.NoError(t, conn.Close())
+
+	listed := listPermissions(t, dataDir)
+	require.Len(t, listed, 2)
+	require.Equal(t, "project", listed[0].Scope)
+	require.Equal(t, "edit", listed[0].ToolName)
+	require.Equal(t, "session", listed[1].Scope)
+	require.Equal(t, sess.ID, listed[1].SessionID)
+
+	out, err := runCrush(t, "permissions", "revoke", listed[0].ID, listed[1].ID, "--data-dir", dataDir)
+	require.NoError(t, err)
+	require.Equal(t, "Revoked permission "+listed[0].ID+"\nRevoked permission "+listed[1].ID+"\n", out)
+	require.Empty(t, listPermissions(t, dataDir))
+
+	_, err = runCrush(t, "permissions", "revoke", "missing", "--data-dir", dataDir)
+	require.EqualError(t, err, `permission "missing" not found`)
+}
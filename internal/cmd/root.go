@@ -50,9 +50,16 @@ func init() {
 		projectsCmd,
 		updateProvidersCmd,
 		logsCmd,
+		permissionsCmd,
 		schemaCmd,
 		loginCmd,
 		statsCmd,
+		sessionsCmd,
+		worktreeCmd,
+		mcpCmd,
+		serveCmd,
+		indexCmd,
+		skillsCmd,
 	)
 }
 
@@ -1,14 +1,61 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"strings"
 	"testing"
 
 	uv "github.com/charmbracelet/ultraviolet"
 	xstrings "github.com/charmbracelet/x/exp/strings"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/require"
 )
 
+// runCrush runs crush with args and returns its output. The flags the run set
+// are reset afterwards, so that the next runs start from their defaults.
+func runCrush(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var b bytes.Buffer
+	rootCmd.SetOut(&b)
+	rootCmd.SetErr(&b)
+	rootCmd.SetIn(bytes.NewReader(nil))
+	rootCmd.SetArgs(args)
+	t.Cleanup(func() {
+		rootCmd.SetArgs(nil)
+		resetFlags(rootCmd)
+	})
+	err := rootCmd.ExecuteContext(context.Background())
+	return b.String(), err
+}
+
+// resetFlags sets the flags of cmd and of its subcommands back to their
+// defaults.
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if !f.Changed {
+			return
+		}
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			var values []string
+			if def := strings.Trim(f.DefValue, "[]"); def != "" {
+				values = strings.Split(def, ",")
+			}
+			_ = slice.Replace(values)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
 type mockEnviron []string
 
 func (m mockEnviron) Getenv(key string) string {
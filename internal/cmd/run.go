@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"strings"
 
+	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/event"
 	"github.com/spf13/cobra"
 )
@@ -29,23 +30,49 @@ crush run "What is this code doing?" <<< prrr.go
 
 # Run in quiet mode (hide the spinner)
 crush run --quiet "Generate a README for this project"
+
+# Stream tool calls, results and usage as newline-delimited JSON
+crush run --output-format stream-json "Fix the failing tests"
+
+# Continue the most recent session
+crush run --continue "Now add tests for it"
+
+# Continue a specific session
+crush run --session 3f2a9c1e-5d7b-4e8a-9b6c-1d2e3f4a5b6c "Now add tests for it"
+
+# Work in a separate git worktree, leaving the current checkout untouched
+crush run --worktree "Refactor the config loader"
+
+# Stop after 30 steps or once the run has cost $2, whichever comes first
+crush run --max-steps 30 --max-cost 2 "Fix the failing tests"
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		quiet, _ := cmd.Flags().GetBool("quiet")
 		largeModel, _ := cmd.Flags().GetString("model")
 		smallModel, _ := cmd.Flags().GetString("small-model")
+		outputFormat, _ := cmd.Flags().GetString("output-format")
+		sessionID, _ := cmd.Flags().GetString("session")
+		continueSession, _ := cmd.Flags().GetBool("continue")
+		useWorktree, _ := cmd.Flags().GetBool("worktree")
+		maxSteps, _ := cmd.Flags().GetInt("max-steps")
+		maxCost, _ := cmd.Flags().GetFloat64("max-cost")
+
+		format, err := app.ParseOutputFormat(outputFormat)
+		if err != nil {
+			return err
+		}
 
 		// Cancel on SIGINT or SIGTERM.
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 		defer cancel()
 
-		app, err := setupApp(cmd)
+		appInstance, err := setupApp(cmd)
 		if err != nil {
 			return err
 		}
-		defer app.Shutdown()
+		defer appInstance.Shutdown()
 
-		if !app.Config().IsConfigured() {
+		if !appInstance.Config().IsConfigured() {
 			return fmt.Errorf("no providers configured - please run 'crush' to set up a provider interactively")
 		}
 
@@ -64,7 +91,18 @@ crush run --quiet "Generate a README for this project"
 		event.SetNonInteractive(true)
 		event.AppInitialized()
 
-		return app.RunNonInteractive(ctx, os.Stdout, prompt, largeModel, smallModel, quiet)
+		return appInstance.RunNonInteractive(ctx, os.Stdout, app.NonInteractiveOptions{
+			Prompt:       prompt,
+			LargeModel:   largeModel,
+			SmallModel:   smallModel,
+			Quiet:        quiet,
+			OutputFormat: format,
+			SessionID:    sessionID,
+			Continue:     continueSession,
+			Worktree:     useWorktree,
+			MaxSteps:     maxSteps,
+			MaxCostUSD:   maxCost,
+		})
 	},
 	PostRun: func(cmd *cobra.Command, args []string) {
 		event.AppExited()
@@ -75,4 +113,11 @@ func init() {
 	runCmd.Flags().BoolP("quiet", "q", false, "Hide spinner")
 	runCmd.Flags().StringP("model", "m", "", "Model to use. Accepts 'model' or 'provider/model' to disambiguate models with the same name across providers")
 	runCmd.Flags().String("small-model", "", "Small model to use. If not provided, uses the default small model for the provider")
+	runCmd.Flags().String("output-format", string(app.OutputFormatText), "Output format: text, json (single summary record) or stream-json (newline-delimited events)")
+	runCmd.Flags().StringP("session", "s", "", "Continue the session with the given ID")
+	runCmd.Flags().Bool("continue", false, "Continue the most recent session")
+	runCmd.Flags().Bool("worktree", false, "Run the new session in its own git worktree and branch")
+	runCmd.Flags().Int("max-steps", 0, "Stop the agent after this many steps. Overrides options.max_steps")
+	runCmd.Flags().Float64("max-cost", 0, "Stop the agent once the run has cost this many USD. Overrides options.max_cost_usd")
+	runCmd.MarkFlagsMutuallyExclusive("session", "continue", "worktree")
 }
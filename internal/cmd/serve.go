@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"cmp"
+	"crypto/rand"
+	"os"
+	"os/signal"
+
+	"github.com/charmbracelet/crush/internal/event"
+	"github.com/charmbracelet/crush/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run Crush headless with an HTTP API",
+	Long: `Run Crush headless and serve a local HTTP API to drive it, so that
+dashboards and editor plugins can share one long-lived Crush per project.
+
+Endpoints:
+  GET  /v1/sessions                 List sessions
+  POST /v1/sessions                 Create a session: {"title": "...", "worktree": false}
+  GET  /v1/sessions/{id}            Get a session
+  GET  /v1/sessions/{id}/messages   List the messages of a session
+  POST /v1/sessions/{id}/prompts    Send a prompt: {"prompt": "..."}
+  POST /v1/sessions/{id}/cancel     Cancel the running prompt of a session
+  GET  /v1/permissions              List the pending permission requests
+  POST /v1/permissions/{id}         Answer a permission request: {"decision": "allow"}
+  GET  /v1/events                   Stream session, message, permission and run events (SSE)
+
+Permission decisions are allow, allow_session, allow_project and deny.
+
+Requests must carry the token as a bearer token. Unless one is set with --token
+or $CRUSH_SERVE_TOKEN, a random one is generated and printed on startup.
+Requests must be sent to a loopback host or the host of --addr, and browsers
+can't send them from other origins.`,
+	Example: `
+# Serve the API on the default address
+crush serve
+
+# Serve on another port, with a fixed token
+CRUSH_SERVE_TOKEN=secret crush serve --addr 127.0.0.1:9000
+
+# Send a prompt to a session
+curl -X POST -H 'Authorization: Bearer secret' -H 'Content-Type: application/json' \
+  -d '{"prompt": "Fix the failing tests"}' \
+  http://127.0.0.1:9000/v1/sessions/3f2a9c1e-5d7b-4e8a-9b6c-1d2e3f4a5b6c/prompts
+
+# Follow the events
+curl -N -H 'Authorization: Bearer secret' http://127.0.0.1:9000/v1/events
+  `,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("token")
+		token = cmp.Or(token, os.Getenv("CRUSH_SERVE_TOKEN"))
+		generated := token == ""
+		if generated {
+			token = rand.Text()
+		}
+
+		// Cancel on SIGINT or SIGTERM.
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, os.Kill)
+		defer cancel()
+
+		appInstance, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer appInstance.Shutdown()
+
+		event.SetNonInteractive(true)
+		event.AppInitialized()
+
+		cmd.PrintErrf("Serving the Crush API on http://%s\n", addr)
+		if generated {
+			cmd.PrintErrf("Token: %s\n", token)
+		}
+		return server.New(appInstance, token).ListenAndServe(ctx, addr)
+	},
+	PostRun: func(cmd *cobra.Command, args []string) {
+		event.AppExited()
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("addr", "127.0.0.1:8765", "Address to serve the API on")
+	serveCmd.Flags().String("token", "", "Bearer token clients have to send. Defaults to $CRUSH_SERVE_TOKEN, or a random one")
+	serveCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
+}
@@ -0,0 +1,446 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:     "sessions",
+	Aliases: []string{"session"},
+	Short:   "Manage sessions",
+	Long:    "List, inspect, revert and delete the sessions of the current project",
+	Example: `
+# List sessions, most recently updated first
+crush sessions list
+
+# Show the messages of a session
+crush sessions show 3f2a9c1e-5d7b-4e8a-9b6c-1d2e3f4a5b6c
+
+# Find the messages that mention a word, across all sessions
+crush sessions search "migration"
+
+# Undo the last turn of a session, restoring the files it changed
+crush session revert 3f2a9c1e-5d7b-4e8a-9b6c-1d2e3f4a5b6c
+
+# Undo everything from a given user message on
+crush session revert 3f2a9c1e-5d7b-4e8a-9b6c-1d2e3f4a5b6c --to-message 9b1c2d3e-4f5a-6b7c-8d9e-0f1a2b3c4d5e
+
+# Delete a session
+crush sessions delete 3f2a9c1e-5d7b-4e8a-9b6c-1d2e3f4a5b6c
+
+# Continue a session non-interactively
+crush run --session 3f2a9c1e-5d7b-4e8a-9b6c-1d2e3f4a5b6c "Now add tests"
+  `,
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List sessions",
+	Long:  "List the sessions of the current project, most recently updated first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		return withSessionServices(cmd, func(sessions session.Service, _ message.Service, _ history.Service) error {
+			sessionList, err := sessions.List(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to list sessions: %w", err)
+			}
+
+			if jsonOutput {
+				output := struct {
+					Sessions []sessionInfo `json:"sessions"`
+				}{Sessions: make([]sessionInfo, 0, len(sessionList))}
+				for _, s := range sessionList {
+					output.Sessions = append(output.Sessions, newSessionInfo(s))
+				}
+				return printJSON(cmd, output)
+			}
+
+			if len(sessionList) == 0 {
+				cmd.Println("No sessions yet.")
+				return nil
+			}
+
+			if term.IsTerminal(os.Stdout.Fd()) {
+				t := table.New().
+					Border(lipgloss.RoundedBorder()).
+					StyleFunc(func(row, col int) lipgloss.Style {
+						return lipgloss.NewStyle().Padding(0, 2)
+					}).
+					Headers("ID", "Title", "Messages", "Cost", "Updated")
+
+				for _, s := range sessionList {
+					t.Row(
+						s.ID,
+						s.Title,
+						fmt.Sprintf("%d", s.MessageCount),
+						fmt.Sprintf("$%.4f", s.Cost),
+						time.Unix(s.UpdatedAt, 0).Local().Format("2006-01-02 15:04"),
+					)
+				}
+				lipgloss.Println(t)
+				return nil
+			}
+
+			for _, s := range sessionList {
+				cmd.Printf("%s\t%s\t%d\t%.4f\t%s\n", s.ID, s.Title, s.MessageCount, s.Cost, time.Unix(s.UpdatedAt, 0).Format(time.RFC3339))
+			}
+			return nil
+		})
+	},
+}
+
+var sessionsShowCmd = &cobra.Command{
+	Use:   "show <session-id>",
+	Short: "Show a session",
+	Long:  "Show the details and messages of a session",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		return withSessionServices(cmd, func(sessions session.Service, messages message.Service, _ history.Service) error {
+			ctx := cmd.Context()
+
+			sess, err := getSession(ctx, sessions, args[0])
+			if err != nil {
+				return err
+			}
+			msgs, err := messages.List(ctx, sess.ID)
+			if err != nil {
+				return fmt.Errorf("failed to list messages: %w", err)
+			}
+
+			if jsonOutput {
+				output := struct {
+					Session  sessionInfo   `json:"session"`
+					Messages []messageInfo `json:"messages"`
+				}{
+					Session:  newSessionInfo(sess),
+					Messages: make([]messageInfo, 0, len(msgs)),
+				}
+				for _, msg := range msgs {
+					output.Messages = append(output.Messages, newMessageInfo(msg))
+				}
+				return printJSON(cmd, output)
+			}
+
+			cmd.Printf("Session:  %s\n", sess.ID)
+			cmd.Printf("Title:    %s\n", sess.Title)
+			cmd.Printf("Messages: %d\n", sess.MessageCount)
+			cmd.Printf("Tokens:   %d prompt, %d completion\n", sess.PromptTokens, sess.CompletionTokens)
+			cmd.Printf("Cost:     $%.4f\n", sess.Cost)
+			if sess.WorktreePath != "" {
+				cmd.Printf("Worktree: %s (%s)\n", sess.WorktreePath, sess.WorktreeBranch)
+			}
+			cmd.Printf("Created:  %s\n", time.Unix(sess.CreatedAt, 0).Local().Format("2006-01-02 15:04"))
+			cmd.Printf("Updated:  %s\n", time.Unix(sess.UpdatedAt, 0).Local().Format("2006-01-02 15:04"))
+
+			for _, msg := range msgs {
+				cmd.Println()
+				printMessage(cmd, msg)
+			}
+			return nil
+		})
+	},
+}
+
+var sessionsSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search messages across sessions",
+	Long: `Search the text, tool call inputs and tool results of the messages of all
+sessions, best matches first. Every word of the query must match; the last one
+matches as a prefix.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		return withSessionServices(cmd, func(_ session.Service, messages message.Service, _ history.Service) error {
+			results, err := messages.Search(cmd.Context(), strings.Join(args, " "), limit)
+			if err != nil {
+				return fmt.Errorf("failed to search messages: %w", err)
+			}
+
+			if jsonOutput {
+				output := struct {
+					Results []searchResultInfo `json:"results"`
+				}{Results: make([]searchResultInfo, 0, len(results))}
+				for _, r := range results {
+					output.Results = append(output.Results, searchResultInfo{
+						SessionID:    r.SessionID,
+						SessionTitle: r.SessionTitle,
+						MessageID:    r.MessageID,
+						Role:         string(r.Role),
+						Snippet:      r.Snippet,
+						CreatedAt:    r.CreatedAt,
+					})
+				}
+				return printJSON(cmd, output)
+			}
+
+			if len(results) == 0 {
+				cmd.Println("No matching messages.")
+				return nil
+			}
+			for _, r := range results {
+				snippet := strings.Join(strings.Fields(r.Snippet), " ")
+				cmd.Printf("%s\t%s\t%s\t%s\t%s\n", r.SessionID, r.MessageID, r.SessionTitle, r.Role, snippet)
+			}
+			return nil
+		})
+	},
+}
+
+var sessionsDeleteCmd = &cobra.Command{
+	Use:   "delete <session-id>...",
+	Short: "Delete sessions",
+	Long:  "Delete one or more sessions along with their messages and file history",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withSessionServices(cmd, func(sessions session.Service, _ message.Service, _ history.Service) error {
+			ctx := cmd.Context()
+			for _, id := range args {
+				sess, err := getSession(ctx, sessions, id)
+				if err != nil {
+					return err
+				}
+				if err := sessions.Delete(ctx, sess.ID); err != nil {
+					return fmt.Errorf("failed to delete session %q: %w", sess.ID, err)
+				}
+				cmd.Printf("Deleted session %s\n", sess.ID)
+			}
+			return nil
+		})
+	},
+}
+
+var sessionsRevertCmd = &cobra.Command{
+	Use:   "revert <session-id>",
+	Short: "Revert a session to an earlier message",
+	Long: `Undo the last turn of a session, or every turn from the given user message on.
+Files the agent changed are restored to the versions recorded before the turn,
+files it created are deleted, and the conversation is truncated before it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		messageID, _ := cmd.Flags().GetString("to-message")
+
+		return withSessionServices(cmd, func(sessions session.Service, messages message.Service, files history.Service) error {
+			ctx := cmd.Context()
+
+			sess, err := getSession(ctx, sessions, args[0])
+			if err != nil {
+				return err
+			}
+			result, err := app.RevertSession(ctx, sessions, messages, files, sess.ID, messageID)
+			if err != nil {
+				return err
+			}
+
+			cmd.Printf("Reverted session %s to before message %s\n", sess.ID, result.Message.ID)
+			for _, path := range result.Files {
+				cmd.Printf("  %s\n", path)
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	sessionsListCmd.Flags().Bool("json", false, "Output as JSON")
+	sessionsShowCmd.Flags().Bool("json", false, "Output as JSON")
+	sessionsSearchCmd.Flags().Bool("json", false, "Output as JSON")
+	sessionsSearchCmd.Flags().Int("limit", 20, "Maximum number of results")
+	sessionsRevertCmd.Flags().String("to-message", "", "ID of the user message to revert to; defaults to the last one")
+
+	sessionsCmd.AddCommand(
+		sessionsListCmd,
+		sessionsShowCmd,
+		sessionsSearchCmd,
+		sessionsRevertCmd,
+		sessionsDeleteCmd,
+	)
+}
+
+// sessionInfo is the JSON representation of a session.
+type sessionInfo struct {
+	ID               string  `json:"id"`
+	ParentSessionID  string  `json:"parent_session_id,omitempty"`
+	Title            string  `json:"title"`
+	MessageCount     int64   `json:"message_count"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	WorktreePath     string  `json:"worktree_path,omitempty"`
+	WorktreeBranch   string  `json:"worktree_branch,omitempty"`
+	CreatedAt        int64   `json:"created_at"`
+	UpdatedAt        int64   `json:"updated_at"`
+}
+
+func newSessionInfo(s session.Session) sessionInfo {
+	return sessionInfo{
+		ID:               s.ID,
+		ParentSessionID:  s.ParentSessionID,
+		Title:            s.Title,
+		MessageCount:     s.MessageCount,
+		PromptTokens:     s.PromptTokens,
+		CompletionTokens: s.CompletionTokens,
+		Cost:             s.Cost,
+		WorktreePath:     s.WorktreePath,
+		WorktreeBranch:   s.WorktreeBranch,
+		CreatedAt:        s.CreatedAt,
+		UpdatedAt:        s.UpdatedAt,
+	}
+}
+
+// messageInfo is the JSON representation of a message.
+type messageInfo struct {
+	ID          string           `json:"id"`
+	Role        string           `json:"role"`
+	Model       string           `json:"model,omitempty"`
+	Provider    string           `json:"provider,omitempty"`
+	Text        string           `json:"text,omitempty"`
+	Reasoning   string           `json:"reasoning,omitempty"`
+	ToolCalls   []toolCallInfo   `json:"tool_calls,omitempty"`
+	ToolResults []toolResultInfo `json:"tool_results,omitempty"`
+	Finish      string           `json:"finish_reason,omitempty"`
+	CreatedAt   int64            `json:"created_at"`
+}
+
+type toolCallInfo struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Input string `json:"input"`
+}
+
+type toolResultInfo struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	IsError    bool   `json:"is_error"`
+}
+
+// searchResultInfo is the JSON representation of a message matching a search.
+type searchResultInfo struct {
+	SessionID    string `json:"session_id"`
+	SessionTitle string `json:"session_title"`
+	MessageID    string `json:"message_id"`
+	Role         string `json:"role"`
+	Snippet      string `json:"snippet"`
+	CreatedAt    int64  `json:"created_at"`
+}
+
+func newMessageInfo(msg message.Message) messageInfo {
+	info := messageInfo{
+		ID:        msg.ID,
+		Role:      string(msg.Role),
+		Model:     msg.Model,
+		Provider:  msg.Provider,
+		Text:      msg.Content().Text,
+		Reasoning: msg.ReasoningContent().Thinking,
+		Finish:    string(msg.FinishReason()),
+		CreatedAt: msg.CreatedAt,
+	}
+	for _, tc := range msg.ToolCalls() {
+		info.ToolCalls = append(info.ToolCalls, toolCallInfo{ID: tc.ID, Name: tc.Name, Input: tc.Input})
+	}
+	for _, tr := range msg.ToolResults() {
+		info.ToolResults = append(info.ToolResults, toolResultInfo{
+			ToolCallID: tr.ToolCallID,
+			Name:       tr.Name,
+			Content:    tr.Content,
+			IsError:    tr.IsError,
+		})
+	}
+	return info
+}
+
+func printMessage(cmd *cobra.Command, msg message.Message) {
+	header := string(msg.Role)
+	if msg.Model != "" {
+		header += " (" + msg.Model + ")"
+	}
+	cmd.Printf("[%s] %s\n", header, msg.ID)
+
+	if text := strings.TrimSpace(msg.Content().Text); text != "" {
+		cmd.Println(text)
+	}
+	for _, tc := range msg.ToolCalls() {
+		cmd.Printf("→ %s %s\n", tc.Name, tc.Input)
+	}
+	for _, tr := range msg.ToolResults() {
+		status := "ok"
+		if tr.IsError {
+			status = "error"
+		}
+		cmd.Printf("← %s (%s)\n", tr.Name, status)
+	}
+}
+
+func printJSON(cmd *cobra.Command, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	cmd.Println(string(data))
+	return nil
+}
+
+// getSession returns the session with the given ID, with a friendlier error
+// when it doesn't exist.
+func getSession(ctx context.Context, sessions session.Service, id string) (session.Session, error) {
+	sess, err := sessions.Get(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return session.Session{}, fmt.Errorf("session %q not found", id)
+	}
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to get session %q: %w", id, err)
+	}
+	return sess, nil
+}
+
+// withSessionServices connects to the project database and calls fn with the
+// session, message and file history services.
+func withSessionServices(cmd *cobra.Command, fn func(session.Service, message.Service, history.Service) error) error {
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	ctx := cmd.Context()
+
+	if dataDir == "" {
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		cfg, err := config.Init(cwd, "", false)
+		if err != nil {
+			return fmt.Errorf("failed to initialize config: %w", err)
+		}
+		dataDir = cfg.Options.DataDirectory
+	}
+
+	conn, err := db.Connect(ctx, dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close()
+
+	q := db.New(conn)
+	return fn(session.NewService(q, conn), message.NewService(q), history.NewService(q, conn))
+}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func seedSessions(t *testing.T, dataDir string, titles ...string) []session.Session {
+	t.Helper()
+
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	q := db.New(conn)
+	sessions := session.NewService(q, conn)
+	messages := message.NewService(q)
+
+	var created []session.Session
+	for _, title := range titles {
+		sess, err := sessions.Create(t.Context(), title)
+		require.NoError(t, err)
+		_, err = messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+			Role:  message.User,
+			Parts: []message.ContentPart{message.TextContent{Text: "Hello from " + title}},
+		})
+		require.NoError(t, err)
+		created = append(created, sess)
+	}
+	return created
+}
+
+func TestSessionsListEmpty(t *testing.T) {
+	dataDir := t.TempDir()
+
+	out, err := runCrush(t, "sessions", "list", "--data-dir", dataDir)
+	require.NoError(t, err)
+	require.Equal(t, "No sessions yet.\n", out)
+}
+
+func TestSessionsListJSON(t *testing.T) {
+	dataDir := t.TempDir()
+	seedSessions(t, dataDir, "first", "second")
+
+	out, err := runCrush(t, "sessions", "list", "--json", "--data-dir", dataDir)
+	require.NoError(t, err)
+
+	var result struct {
+		Sessions []sessionInfo `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Len(t, result.Sessions, 2)
+
+	titles := []string{result.Sessions[0].Title, result.Sessions[1].Title}
+	require.ElementsMatch(t, []string{"first", "second"}, titles)
+}
+
+func TestSessionsShowJSON(t *testing.T) {
+	dataDir := t.TempDir()
+	created := seedSessions(t, dataDir, "first")
+
+	out, err := runCrush(t, "sessions", "show", created[0].ID, "--json", "--data-dir", dataDir)
+	require.NoError(t, err)
+
+	var result struct {
+		Session  sessionInfo   `json:"session"`
+		Messages []messageInfo `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Equal(t, created[0].ID, result.Session.ID)
+	require.Len(t, result.Messages, 1)
+	require.Equal(t, "user", result.Messages[0].Role)
+	require.Equal(t, "Hello from first", result.Messages[0].Text)
+}
+
+func TestSessionsShowNotFound(t *testing.T) {
+	dataDir := t.TempDir()
+
+	_, err := runCrush(t, "sessions", "show", "nope", "--data-dir", dataDir)
+	require.EqualError(t, err, `session "nope" not found`)
+}
+
+func TestSessionsSearchJSON(t *testing.T) {
+	dataDir := t.TempDir()
+	created := seedSessions(t, dataDir, "first", "second")
+
+	out, err := runCrush(t, "sessions", "search", "hello", "seco", "--json", "--data-dir", dataDir)
+	require.NoError(t, err)
+
+	var result struct {
+		Results []searchResultInfo `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Len(t, result.Results, 1)
+	require.Equal(t, created[1].ID, result.Results[0].SessionID)
+	require.Equal(t, "second", result.Results[0].SessionTitle)
+	require.Equal(t, "user", result.Results[0].Role)
+	require.Equal(t, "Hello from second", result.Results[0].Snippet)
+}
+
+func TestSessionsSearchNoResults(t *testing.T) {
+	dataDir := t.TempDir()
+	seedSessions(t, dataDir, "first")
+
+	out, err := runCrush(t, "sessions", "search", "goodbye", "--data-dir", dataDir)
+	require.NoError(t, err)
+	require.Equal(t, "No matching messages.\n", out)
+}
+
+func TestSessionsDelete(t *testing.T) {
+	dataDir := t.TempDir()
+	created := seedSessions(t, dataDir, "first", "second")
+
+	out, err := runCrush(t, "sessions", "delete", created[0].ID, "--data-dir", dataDir)
+	require.NoError(t, err)
+	require.Equal(t, "Deleted session "+created[0].ID+"\n", out)
+
+	out, err = runCrush(t, "sessions", "list", "--json", "--data-dir", dataDir)
+	require.NoError(t, err)
+
+	var result struct {
+		Sessions []sessionInfo `json:"sessions"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Len(t, result.Sessions, 1)
+	require.Equal(t, created[1].ID, result.Sessions[0].ID)
+}
+
+func TestSessionsRevert(t *testing.T) {
+	dataDir := t.TempDir()
+	path := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("original\n"), 0o644))
+
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+	q := db.New(conn)
+	sessions := session.NewService(q, conn)
+	messages := message.NewService(q)
+	files := history.NewService(q, conn)
+
+	sess, err := sessions.Create(t.Context(), "first")
+	require.NoError(t, err)
+	for _, content := range []string{"first turn\n", "second turn\n"} {
+		msg, err := messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+			Role:  message.User,
+			Parts: []message.ContentPart{message.TextContent{Text: "change it"}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, files.Checkpoint(t.Context(), sess.ID, msg.ID))
+		if _, err := files.GetByPathAndSession(t.Context(), path, sess.ID); err != nil {
+			_, err = files.Create(t.Context(), sess.ID, path, "original\n")
+			require.NoError(t, err)
+		}
+		_, err = files.CreateVersion(t.Context(), sess.ID, path, content)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	require.NoError(t, conn.Close())
+
+	out, err := runCrush(t, "sessions", "revert", sess.ID, "--data-dir", dataDir)
+	require.NoError(t, err)
+	require.Contains(t, out, "Reverted session "+sess.ID)
+	require.Contains(t, out, path)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "first turn\n", string(content))
+
+	out, err = runCrush(t, "sessions", "show", sess.ID, "--json", "--data-dir", dataDir)
+	require.NoError(t, err)
+	var result struct {
+		Messages []messageInfo `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Len(t, result.Messages, 1)
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/skills"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var skillsCmd = &cobra.Command{
+	Use:     "skills",
+	Aliases: []string{"skill"},
+	Short:   "Manage agent skills",
+	Long: `List, inspect, validate, install and remove the Agent Skills found in the
+skills paths. Skills are installed in the user skills directory, or in the
+.crush/skills directory of the project with --project.`,
+	Example: `
+# List the skills and where they came from
+crush skills list
+
+# Install a skill from a local directory
+crush skills install ./my-skill
+
+# Install a skill from a subdirectory of a git repository, for this project only
+crush skills install https://github.com/anthropics/skills --path skills/pdf --project
+
+# Remove a skill
+crush skills remove pdf
+  `,
+}
+
+var skillsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List skills",
+	Long:  "List the skills found in the skills paths, where they came from and their validation problems",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		cfg, err := skillsConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		found := skills.Scan(cfg.SkillsDirs())
+		infos := make([]skillInfo, 0, len(found))
+		for _, f := range found {
+			infos = append(infos, newSkillInfo(cfg, f))
+		}
+
+		if jsonOutput {
+			return printJSON(cmd, struct {
+				Skills []skillInfo `json:"skills"`
+			}{Skills: infos})
+		}
+
+		if len(infos) == 0 {
+			cmd.Println("No skills found.")
+			return nil
+		}
+
+		if term.IsTerminal(os.Stdout.Fd()) {
+			t := table.New().
+				Border(lipgloss.RoundedBorder()).
+				StyleFunc(func(row, col int) lipgloss.Style {
+					return lipgloss.NewStyle().Padding(0, 2)
+				}).
+				Headers("Name", "Location", "Source", "Status")
+
+			for _, s := range infos {
+				t.Row(s.Name, s.Location, s.Source, s.status())
+			}
+			lipgloss.Println(t)
+			return nil
+		}
+
+		for _, s := range infos {
+			cmd.Printf("%s\t%s\t%s\t%s\n", s.Name, s.Location, s.Source, s.status())
+		}
+		return nil
+	},
+}
+
+var skillsShowCmd = &cobra.Command{
+	Use:   "show <name>",
+	Short: "Show a skill",
+	Long:  "Show the metadata and instructions of a skill",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		cfg, err := skillsConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		found := skills.Scan(cfg.SkillsDirs())
+		i := slices.IndexFunc(found, func(f skills.Found) bool {
+			return f.Skill != nil && f.Skill.Name == args[0]
+		})
+		if i < 0 {
+			return fmt.Errorf("skill %q not found", args[0])
+		}
+		info := newSkillInfo(cfg, found[i])
+		skill := found[i].Skill
+
+		if jsonOutput {
+			return printJSON(cmd, struct {
+				*skills.Skill
+				Location string `json:"location"`
+				Source   string `json:"source"`
+				Disabled bool   `json:"disabled,omitempty"`
+			}{skill, info.Location, info.Source, info.Disabled})
+		}
+
+		cmd.Printf("Name:        %s\n", skill.Name)
+		cmd.Printf("Description: %s\n", skill.Description)
+		if skill.License != "" {
+			cmd.Printf("License:     %s\n", skill.License)
+		}
+		if skill.Compatibility != "" {
+			cmd.Printf("Compatible:  %s\n", skill.Compatibility)
+		}
+		cmd.Printf("Path:        %s\n", skill.Path)
+		cmd.Printf("Location:    %s\n", info.Location)
+		cmd.Printf("Source:      %s\n", info.Source)
+		cmd.Printf("Status:      %s\n", info.status())
+		if skill.Instructions != "" {
+			cmd.Printf("\n%s\n", skill.Instructions)
+		}
+		return nil
+	},
+}
+
+var skillsValidateCmd = &cobra.Command{
+	Use:   "validate [path...]",
+	Short: "Validate skills",
+	Long:  "Validate skill directories against the Agent Skills specification, or all skills in the skills paths when no path is given",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var found []skills.Found
+		if len(args) == 0 {
+			cfg, err := skillsConfig(cmd)
+			if err != nil {
+				return err
+			}
+			found = skills.Scan(cfg.SkillsDirs())
+		}
+		for _, path := range args {
+			if filepath.Base(path) != skills.SkillFileName {
+				path = filepath.Join(path, skills.SkillFileName)
+			}
+			f := skills.Found{Path: path}
+			f.Skill, f.Err = skills.Parse(path)
+			if f.Err == nil {
+				f.Err = f.Skill.Validate()
+			}
+			found = append(found, f)
+		}
+
+		if len(found) == 0 {
+			cmd.Println("No skills found.")
+			return nil
+		}
+
+		var invalid int
+		for _, f := range found {
+			if f.Err == nil {
+				cmd.Printf("%s: ok\n", f.Path)
+				continue
+			}
+			invalid++
+			cmd.Printf("%s:\n", f.Path)
+			for line := range strings.SplitSeq(f.Err.Error(), "\n") {
+				cmd.Printf("  - %s\n", line)
+			}
+		}
+		if invalid > 0 {
+			return fmt.Errorf("%d of %d skills are invalid", invalid, len(found))
+		}
+		return nil
+	},
+}
+
+var skillsInstallCmd = &cobra.Command{
+	Use:   "install <source>",
+	Short: "Install a skill",
+	Long: `Install a skill from a local directory, a tarball (a path or an http(s) URL
+to a .tar, .tar.gz or .tgz file) or a git URL. Use --path for a skill in a
+subdirectory of the source. The skill is validated before it's installed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		subpath, _ := cmd.Flags().GetString("path")
+		force, _ := cmd.Flags().GetBool("force")
+
+		dir, err := skillsInstallDir(cmd)
+		if err != nil {
+			return err
+		}
+
+		source := args[0]
+		if _, err := os.Stat(source); err == nil {
+			// Record local sources in full, as they're read from elsewhere.
+			if abs, err := filepath.Abs(source); err == nil {
+				source = abs
+			}
+		}
+
+		skill, err := skills.Install(cmd.Context(), source, subpath, dir, force)
+		if err != nil {
+			return fmt.Errorf("failed to install skill: %w", err)
+		}
+		cmd.Printf("Installed skill %s in %s\n", skill.Name, skill.Path)
+		return nil
+	},
+}
+
+var skillsRemoveCmd = &cobra.Command{
+	Use:   "remove <name>...",
+	Short: "Remove installed skills",
+	Long:  "Remove skills from the user skills directory, or from the project one with --project",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := skillsInstallDir(cmd)
+		if err != nil {
+			return err
+		}
+		for _, name := range args {
+			if err := skills.Remove(dir, name); err != nil {
+				return err
+			}
+			cmd.Printf("Removed skill %s\n", name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	skillsListCmd.Flags().Bool("json", false, "Output as JSON")
+	skillsShowCmd.Flags().Bool("json", false, "Output as JSON")
+
+	skillsInstallCmd.Flags().String("path", "", "Directory of the skill inside of the source")
+	skillsInstallCmd.Flags().Bool("force", false, "Replace an installed skill of the same name")
+	skillsInstallCmd.Flags().Bool("project", false, "Install in the project skills directory")
+	skillsRemoveCmd.Flags().Bool("project", false, "Remove from the project skills directory")
+
+	skillsCmd.AddCommand(
+		skillsListCmd,
+		skillsShowCmd,
+		skillsValidateCmd,
+		skillsInstallCmd,
+		skillsRemoveCmd,
+	)
+}
+
+// skillInfo is the JSON representation of a skill found in the skills
+// paths.
+type skillInfo struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Location string `json:"location"`
+	Source   string `json:"source"`
+	Disabled bool   `json:"disabled,omitempty"`
+	Problem  string `json:"problem,omitempty"`
+}
+
+func newSkillInfo(cfg *config.Config, f skills.Found) skillInfo {
+	info := skillInfo{
+		Name:     filepath.Base(filepath.Dir(f.Path)),
+		Path:     filepath.Dir(f.Path),
+		Location: skillLocation(cfg, f.Root),
+		Source:   "local",
+	}
+	if f.Skill != nil && f.Skill.Name != "" {
+		info.Name = f.Skill.Name
+		info.Disabled = !cfg.SkillEnabled(f.Skill.Name)
+	}
+	if f.Err != nil {
+		info.Problem = strings.ReplaceAll(f.Err.Error(), "\n", "; ")
+	}
+	if origin := skills.ReadOrigin(info.Path); origin != nil {
+		info.Source = origin.Source
+		if origin.Subpath != "" {
+			info.Source += " (" + origin.Subpath + ")"
+		}
+	}
+	return info
+}
+
+func (s skillInfo) status() string {
+	switch {
+	case s.Problem != "":
+		return s.Problem
+	case s.Disabled:
+		return "disabled"
+	default:
+		return "ok"
+	}
+}
+
+// skillLocation returns "user" or "project" for the default skills paths,
+// and the path itself for the configured ones.
+func skillLocation(cfg *config.Config, root string) string {
+	if slices.Contains(config.GlobalSkillsDirs(), root) {
+		return "user"
+	}
+	if root == config.ProjectSkillsDir(cfg.WorkingDir()) {
+		return "project"
+	}
+	return root
+}
+
+func skillsConfig(cmd *cobra.Command) (*config.Config, error) {
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return nil, err
+	}
+	dataDir, _ := cmd.Flags().GetString("data-dir")
+	debug, _ := cmd.Flags().GetBool("debug")
+
+	cfg, err := config.Init(cwd, dataDir, debug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize config: %w", err)
+	}
+	return cfg, nil
+}
+
+// skillsInstallDir returns the skills directory the install and remove
+// commands work in.
+func skillsInstallDir(cmd *cobra.Command) (string, error) {
+	project, _ := cmd.Flags().GetBool("project")
+	if !project {
+		dirs := config.GlobalSkillsDirs()
+		if len(dirs) == 0 {
+			return "", errors.New("no user skills directory")
+		}
+		return dirs[0], nil
+	}
+	cwd, err := ResolveCwd(cmd)
+	if err != nil {
+		return "", err
+	}
+	return config.ProjectSkillsDir(cwd), nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkillsInstallListRemove(t *testing.T) {
+	userDir := t.TempDir()
+	t.Setenv("CRUSH_SKILLS_DIR", userDir)
+	cwd := t.TempDir()
+	dataDir := t.TempDir()
+
+	src := filepath.Join(t.TempDir(), "skills")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "lint"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "lint", "SKILL.md"), []byte("---\nname: lint\ndescription: Lints the code.\n---\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "broken"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "broken", "SKILL.md"), []byte("---\nname: broken\n---\n"), 0o644))
+
+	out, err := runCrush(t, "skills", "install", src, "--path", "lint", "--cwd", cwd)
+	require.NoError(t, err)
+	require.Equal(t, "Installed skill lint in "+filepath.Join(userDir, "lint")+"\n", out)
+
+	_, err = runCrush(t, "skills", "install", src, "--path", "broken", "--project", "--cwd", cwd)
+	require.ErrorContains(t, err, "description is required")
+
+	// Skills that are already in a skills directory are listed with their
+	// problems.
+	projectDir := filepath.Join(cwd, ".crush", "skills", "broken")
+	require.NoError(t, os.MkdirAll(projectDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "SKILL.md"), []byte("---\nname: broken\n---\n"), 0o644))
+
+	out, err = runCrush(t, "skills", "list", "--json", "--cwd", cwd, "--data-dir", dataDir)
+	require.NoError(t, err)
+	var result struct {
+		Skills []skillInfo `json:"skills"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.Len(t, result.Skills, 2)
+	byName := map[string]skillInfo{}
+	for _, s := range result.Skills {
+		byName[s.Name] = s
+	}
+	require.Equal(t, "user", byName["lint"].Location)
+	require.Equal(t, src+" (lint)", byName["lint"].Source)
+	require.Empty(t, byName["lint"].Problem)
+	require.Equal(t, "project", byName["broken"].Location)
+	require.Equal(t, "local", byName["broken"].Source)
+	require.Equal(t, "description is required", byName["broken"].Problem)
+
+	out, err = runCrush(t, "skills", "validate", filepath.Join(userDir, "lint"), projectDir)
+	require.EqualError(t, err, "1 of 2 skills are invalid")
+	require.Contains(t, out, filepath.Join(userDir, "lint", "SKILL.md")+": ok\n")
+	require.Contains(t, out, "  - description is required\n")
+
+	out, err = runCrush(t, "skills", "remove", "lint", "--cwd", cwd)
+	require.NoError(t, err)
+	require.Equal(t, "Removed skill lint\n", out)
+	require.NoDirExists(t, filepath.Join(userDir, "lint"))
+}
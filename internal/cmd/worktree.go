@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var worktreeCmd = &cobra.Command{
+	Use:   "worktree",
+	Short: "Manage session worktrees",
+	Long:  "Inspect, merge and discard the git worktrees of sessions started with --worktree",
+	Example: `
+# Start a session in its own worktree
+crush run --worktree "Refactor the config loader"
+
+# List sessions with a worktree
+crush worktree list
+
+# Show the changes made in a session's worktree
+crush worktree diff 3f2a9c1e-5d7b-4e8a-9b6c-1d2e3f4a5b6c
+
+# Merge the changes into the current branch and remove the worktree
+crush worktree merge 3f2a9c1e-5d7b-4e8a-9b6c-1d2e3f4a5b6c
+
+# Throw the changes away and remove the worktree
+crush worktree discard 3f2a9c1e-5d7b-4e8a-9b6c-1d2e3f4a5b6c
+  `,
+}
+
+var worktreeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List sessions with a worktree",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withSessionServices(cmd, func(sessions session.Service, _ message.Service, _ history.Service) error {
+			sessionList, err := sessions.List(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to list sessions: %w", err)
+			}
+
+			var found bool
+			for _, s := range sessionList {
+				if s.WorktreePath == "" {
+					continue
+				}
+				found = true
+				cmd.Printf("%s\t%s\t%s\t%s\n", s.ID, s.WorktreeBranch, s.WorktreePath, s.Title)
+			}
+			if !found {
+				cmd.Println("No worktree sessions.")
+			}
+			return nil
+		})
+	},
+}
+
+var worktreeDiffCmd = &cobra.Command{
+	Use:   "diff <session-id>",
+	Short: "Show the changes made in a session's worktree",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withWorktreeSession(cmd, args[0], func(_ session.Service, _ session.Session, wt worktree.Worktree) error {
+			diff, err := worktree.Diff(cmd.Context(), wt)
+			if err != nil {
+				return err
+			}
+			cmd.Print(diff)
+			return nil
+		})
+	},
+}
+
+var worktreeMergeCmd = &cobra.Command{
+	Use:   "merge <session-id>",
+	Short: "Merge a session's worktree and remove it",
+	Long:  "Commit the pending changes in a session's worktree, merge its branch into the current branch and remove the worktree",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withWorktreeSession(cmd, args[0], func(sessions session.Service, sess session.Session, wt worktree.Worktree) error {
+			ctx := cmd.Context()
+			if err := worktree.Merge(ctx, wt, "Merge session: "+sess.Title); err != nil {
+				return err
+			}
+			if err := removeWorktree(ctx, sessions, sess, wt); err != nil {
+				return err
+			}
+			cmd.Printf("Merged %s\n", wt.Branch)
+			return nil
+		})
+	},
+}
+
+var worktreeDiscardCmd = &cobra.Command{
+	Use:   "discard <session-id>",
+	Short: "Discard a session's worktree",
+	Long:  "Remove a session's worktree and branch, throwing away its changes",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withWorktreeSession(cmd, args[0], func(sessions session.Service, sess session.Session, wt worktree.Worktree) error {
+			if err := removeWorktree(cmd.Context(), sessions, sess, wt); err != nil {
+				return err
+			}
+			cmd.Printf("Discarded %s\n", wt.Branch)
+			return nil
+		})
+	},
+}
+
+func init() {
+	worktreeCmd.AddCommand(
+		worktreeListCmd,
+		worktreeDiffCmd,
+		worktreeMergeCmd,
+		worktreeDiscardCmd,
+	)
+}
+
+// withWorktreeSession calls fn with the session with the given ID and its
+// worktree, failing if the session doesn't have one.
+func withWorktreeSession(cmd *cobra.Command, id string, fn func(session.Service, session.Session, worktree.Worktree) error) error {
+	return withSessionServices(cmd, func(sessions session.Service, _ message.Service, _ history.Service) error {
+		sess, err := getSession(cmd.Context(), sessions, id)
+		if err != nil {
+			return err
+		}
+		if sess.WorktreePath == "" {
+			return fmt.Errorf("session %q has no worktree", sess.ID)
+		}
+		return fn(sessions, sess, worktree.Worktree{
+			Path:   sess.WorktreePath,
+			Branch: sess.WorktreeBranch,
+		})
+	})
+}
+
+// removeWorktree removes the session's worktree and detaches the session from
+// it, so later runs work in the main checkout.
+func removeWorktree(ctx context.Context, sessions session.Service, sess session.Session, wt worktree.Worktree) error {
+	if err := worktree.Remove(ctx, wt); err != nil {
+		return err
+	}
+	sess.WorktreePath = ""
+	sess.WorktreeBranch = ""
+	if _, err := sessions.Save(ctx, sess); err != nil {
+		return fmt.Errorf("failed to update session %q: %w", sess.ID, err)
+	}
+	return nil
+}
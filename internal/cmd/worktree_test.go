@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktreeListEmpty(t *testing.T) {
+	dataDir := t.TempDir()
+	seedSessions(t, dataDir, "first")
+
+	out, err := runCrush(t, "worktree", "list", "--data-dir", dataDir)
+	require.NoError(t, err)
+	require.Equal(t, "No worktree sessions.\n", out)
+}
+
+func TestWorktreeDiffWithoutWorktree(t *testing.T) {
+	dataDir := t.TempDir()
+	created := seedSessions(t, dataDir, "first")
+
+	_, err := runCrush(t, "worktree", "diff", created[0].ID, "--data-dir", dataDir)
+	require.EqualError(t, err, `session "`+created[0].ID+`" has no worktree`)
+}
@@ -0,0 +1,144 @@
+package codeindex
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	// chunkLines is the number of lines of a chunk.
+	chunkLines = 40
+	// chunkOverlap is the number of lines a chunk shares with the previous
+	// one, so that code around a boundary is found in one piece.
+	chunkOverlap = 10
+	// symbolWeight is how many times more a term of a symbol defined in a
+	// chunk counts than a term of its contents.
+	symbolWeight = 3
+)
+
+// Chunk is a range of lines of a file.
+type Chunk struct {
+	StartLine int
+	EndLine   int
+	Text      string
+	// Symbols are the names of the symbols defined in the chunk.
+	Symbols []string
+	// Terms counts the terms of the chunk, its path and its symbols.
+	Terms map[string]int
+	// Length is the number of terms of the chunk.
+	Length int
+	// Embedding is the normalized embedding of the chunk, if any.
+	Embedding []float32
+}
+
+// chunkFile splits the contents of the file at path in overlapping chunks.
+func chunkFile(path, content string) []Chunk {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	symbols := extractSymbols(path, lines)
+	pathTerms := tokenize(path)
+
+	var chunks []Chunk
+	for start := 0; start < len(lines); start += chunkLines - chunkOverlap {
+		end := min(start+chunkLines, len(lines))
+		text := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) == "" {
+			if end == len(lines) {
+				break
+			}
+			continue
+		}
+
+		chunk := Chunk{
+			StartLine: start + 1,
+			EndLine:   end,
+			Text:      text,
+			Terms:     make(map[string]int),
+		}
+		for _, term := range tokenize(text) {
+			chunk.Terms[term]++
+		}
+		for _, term := range pathTerms {
+			chunk.Terms[term]++
+		}
+		for _, symbol := range symbols {
+			if symbol.Line < chunk.StartLine || symbol.Line > chunk.EndLine {
+				continue
+			}
+			chunk.Symbols = append(chunk.Symbols, symbol.Name)
+			for _, term := range tokenize(symbol.Name) {
+				chunk.Terms[term] += symbolWeight
+			}
+		}
+		for _, n := range chunk.Terms {
+			chunk.Length += n
+		}
+		chunks = append(chunks, chunk)
+
+		if end == len(lines) {
+			break
+		}
+	}
+	return chunks
+}
+
+// tokenize splits text in lowercase terms. Identifiers are kept whole and
+// also split in their camelCase and snake_case words, so that both
+// "parseConfig" and "parse config" find parseConfig.
+func tokenize(text string) []string {
+	var terms []string
+	for word := range strings.FieldsFuncSeq(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		parts := splitIdentifier(word)
+		if len(parts) > 1 {
+			if term := strings.ToLower(strings.Trim(word, "_")); isTerm(term) {
+				terms = append(terms, term)
+			}
+		}
+		for _, part := range parts {
+			if term := strings.ToLower(part); isTerm(term) {
+				terms = append(terms, term)
+			}
+		}
+	}
+	return terms
+}
+
+// splitIdentifier splits an identifier at underscores and at the case
+// changes of camelCase, keeping acronyms together: "parseHTTPRequest" is
+// "parse", "HTTP" and "Request".
+func splitIdentifier(word string) []string {
+	var parts []string
+	for field := range strings.SplitSeq(word, "_") {
+		runes := []rune(field)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+			acronymEnd := unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) &&
+				i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			letterToDigit := unicode.IsLetter(runes[i-1]) != unicode.IsLetter(runes[i])
+			if lowerToUpper || acronymEnd || letterToDigit {
+				parts = append(parts, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			parts = append(parts, string(runes[start:]))
+		}
+	}
+	return parts
+}
+
+// stopWords are terms too common in code and questions to tell chunks
+// apart.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "does": true, "do": true, "for": true, "from": true,
+	"how": true, "in": true, "is": true, "it": true, "of": true, "on": true,
+	"or": true, "the": true, "this": true, "to": true, "what": true,
+	"when": true, "where": true, "which": true, "with": true,
+}
+
+func isTerm(term string) bool {
+	return len(term) > 1 && !stopWords[term]
+}
@@ -0,0 +1,184 @@
+package codeindex
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/config"
+)
+
+// DefaultEmbeddingModel is the embedding model used when none is
+// configured.
+const DefaultEmbeddingModel = "text-embedding-3-small"
+
+// embedBatchSize is the number of texts embedded per request.
+const embedBatchSize = 64
+
+// maxEmbedText is the maximum number of bytes of a text to embed, to stay
+// under the input limits of the embedding models.
+const maxEmbedText = 8000
+
+// Embedder turns texts into embedding vectors.
+type Embedder interface {
+	// Model identifies the embedding model, so that the index knows when
+	// its embeddings are stale.
+	Model() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// NewEmbedder creates the embedder configured in the code_search tool
+// options, or returns nil when none is configured.
+func NewEmbedder(cfg *config.Config) (Embedder, error) {
+	options := cfg.Tools.CodeSearch
+	if options.EmbeddingProvider == "" {
+		return nil, nil
+	}
+
+	provider, ok := cfg.Providers.Get(options.EmbeddingProvider)
+	if !ok || provider.Disable {
+		return nil, fmt.Errorf("embedding provider %s is not configured", options.EmbeddingProvider)
+	}
+	if provider.Type != catwalk.TypeOpenAI && provider.Type != catwalk.TypeOpenAICompat {
+		return nil, fmt.Errorf("embedding provider %s must be of type openai or openai-compat", options.EmbeddingProvider)
+	}
+
+	apiKey, err := cfg.Resolve(provider.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the API key of %s: %w", options.EmbeddingProvider, err)
+	}
+	baseURL, err := cfg.Resolve(provider.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the base URL of %s: %w", options.EmbeddingProvider, err)
+	}
+	return NewOpenAIEmbedder(
+		http.DefaultClient,
+		cmp.Or(baseURL, "https://api.openai.com/v1"),
+		apiKey,
+		cmp.Or(options.EmbeddingModel, DefaultEmbeddingModel),
+		provider.ExtraHeaders,
+	), nil
+}
+
+// NewOpenAIEmbedder creates an embedder using the embeddings endpoint of an
+// OpenAI-compatible API.
+func NewOpenAIEmbedder(client *http.Client, baseURL, apiKey, model string, headers map[string]string) Embedder {
+	return &openAIEmbedder{
+		client:  client,
+		url:     strings.TrimSuffix(baseURL, "/") + "/embeddings",
+		apiKey:  apiKey,
+		model:   model,
+		headers: headers,
+	}
+}
+
+type openAIEmbedder struct {
+	client  *http.Client
+	url     string
+	apiKey  string
+	model   string
+	headers map[string]string
+}
+
+func (e *openAIEmbedder) Model() string {
+	return e.model
+}
+
+func (e *openAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{
+		"model": e.model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request embeddings: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embeddings: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embeddings request failed with status code: %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse embeddings: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(result.Data))
+	}
+
+	embeddings := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+		}
+		embeddings[d.Index] = normalize(d.Embedding)
+	}
+	return embeddings, nil
+}
+
+// normalize scales v to unit length, so that the cosine similarity of two
+// vectors is their dot product.
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range min(len(a), len(b)) {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// embedText is the text embedded for a chunk.
+func embedText(path string, chunk Chunk) string {
+	text := path + "\n" + chunk.Text
+	if len(text) > maxEmbedText {
+		text = strings.ToValidUTF8(text[:maxEmbedText], "")
+	}
+	return text
+}
@@ -0,0 +1,385 @@
+// Package codeindex keeps a local index of the files of a project for the
+// code_search tool: the symbols they define, their contents split in chunks
+// for BM25 lexical search and, when an embedder is configured, the
+// embeddings of the chunks for semantic search.
+//
+// The index lives in the data directory and is updated incrementally: only
+// the files whose size or modification time changed are indexed again. The
+// embeddings are computed without locking the index, so searches aren't
+// blocked by the requests to the embedder.
+//
+// Before searches, the index is synced: the files written through the tools
+// are indexed again right away, while the whole tree is only scanned again
+// every rescanInterval, and a limited number of chunks is embedded.
+package codeindex
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/charmbracelet/crush/internal/fsext"
+)
+
+const (
+	// indexVersion is bumped when the format of the index changes, to
+	// rebuild the indexes of the previous versions.
+	indexVersion = 1
+	// maxFileSize is the size above which files aren't indexed.
+	maxFileSize = 1 << 20
+	// rescanInterval is how often syncs scan the whole tree again, to pick
+	// up the files changed outside of the tools.
+	rescanInterval = time.Minute
+	// maxSyncEmbeddings is the number of chunks a sync embeds at most, the
+	// next syncs embedding the rest.
+	maxSyncEmbeddings = 256
+)
+
+// file is an indexed file.
+type file struct {
+	ModTime time.Time
+	Size    int64
+	Chunks  []Chunk
+}
+
+// snapshot is what is saved to disk.
+type snapshot struct {
+	Version int
+	// Model is the embedding model of the embeddings of the chunks.
+	Model string
+	Files map[string]*file
+}
+
+// Index is the index of the files under a root directory.
+type Index struct {
+	root     string
+	path     string
+	embedder Embedder
+
+	// updateMu serializes the updates of the index, which only hold mu
+	// while they change the files. It guards scanned and synced.
+	updateMu sync.Mutex
+	// scanned is when the whole tree was last scanned, synced when the
+	// files written through the tools were last indexed.
+	scanned time.Time
+	synced  time.Time
+
+	mu    sync.RWMutex
+	files map[string]*file
+	model string
+}
+
+// Stats describes an update of the index.
+type Stats struct {
+	Files   int
+	Indexed int
+	Removed int
+	Chunks  int
+}
+
+// Dir returns the directory the index of the project is saved in for the
+// given data directory.
+func Dir(dataDir string) string {
+	return filepath.Join(dataDir, "index")
+}
+
+// Open loads the index of root saved in dir, or starts an empty one.
+// Embeddings are computed with embedder, which may be nil to only search
+// lexically.
+func Open(root, dir string, embedder Embedder) (*Index, error) {
+	idx := &Index{
+		root:     root,
+		path:     filepath.Join(dir, "code.gob"),
+		embedder: embedder,
+		files:    make(map[string]*file),
+	}
+
+	f, err := os.Open(idx.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open code index: %w", err)
+	}
+	defer f.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(f).Decode(&snap); err != nil || snap.Version != indexVersion {
+		slog.Warn("Rebuilding code index", "path", idx.path, "error", err)
+		return idx, nil
+	}
+	idx.files = snap.Files
+	idx.model = snap.Model
+	return idx, nil
+}
+
+// Update indexes the files under the root that changed since they were last
+// indexed, drops the files that are gone and saves the index.
+func (idx *Index) Update(ctx context.Context) (Stats, error) {
+	idx.updateMu.Lock()
+	defer idx.updateMu.Unlock()
+
+	stats, err := idx.scan(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	// Keep the lexical index when the embeddings fail.
+	embedErr := idx.embed(ctx, 0)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	stats.Files = len(idx.files)
+	for _, f := range idx.files {
+		stats.Chunks += len(f.Chunks)
+	}
+	if err := idx.save(); err != nil {
+		return stats, err
+	}
+	return stats, embedErr
+}
+
+// Sync brings the index up to date cheaply enough to be called before every
+// search. The files written through the tools since the previous sync are
+// indexed again, and the whole tree is scanned again when it wasn't for
+// rescanInterval. At most maxSyncEmbeddings chunks are embedded.
+func (idx *Index) Sync(ctx context.Context) error {
+	idx.updateMu.Lock()
+	defer idx.updateMu.Unlock()
+
+	if time.Since(idx.scanned) >= rescanInterval {
+		if _, err := idx.scan(ctx); err != nil {
+			return err
+		}
+	} else {
+		started := time.Now()
+		idx.refresh(filetracker.WrittenSince(idx.synced))
+		idx.synced = started
+	}
+
+	embedErr := idx.embed(ctx, maxSyncEmbeddings)
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if err := idx.save(); err != nil {
+		return err
+	}
+	return embedErr
+}
+
+// scan indexes the files under the root that changed and drops the files
+// that are gone. It must be called with updateMu held.
+func (idx *Index) scan(ctx context.Context) (Stats, error) {
+	// Files written from now on are picked up by the next sync.
+	started := time.Now()
+	paths, _, err := fsext.ListDirectory(idx.root, nil, 0, 0)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to list files: %w", err)
+	}
+	stats, err := idx.indexFiles(ctx, paths)
+	if err != nil {
+		return stats, err
+	}
+	idx.scanned, idx.synced = started, started
+	return stats, nil
+}
+
+// refresh indexes the given files again, or drops them when they're gone or
+// ignored.
+func (idx *Index) refresh(paths []string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for _, path := range paths {
+		rel, ok := idx.rel(path)
+		if !ok {
+			continue
+		}
+		if fsext.ShouldExcludeFile(idx.root, filepath.Join(idx.root, filepath.FromSlash(rel))) {
+			delete(idx.files, rel)
+			continue
+		}
+		if _, err := idx.indexFile(rel); err != nil {
+			slog.Debug("Failed to index file", "path", path, "error", err)
+		}
+	}
+}
+
+// indexFiles indexes the files of paths that changed and drops the files
+// that aren't in paths anymore.
+func (idx *Index) indexFiles(ctx context.Context, paths []string) (Stats, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	var stats Stats
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		if strings.HasSuffix(path, string(filepath.Separator)) || strings.HasSuffix(path, "/") {
+			continue
+		}
+		rel, ok := idx.rel(path)
+		if !ok {
+			continue
+		}
+		indexed, err := idx.indexFile(rel)
+		if err != nil {
+			slog.Debug("Failed to index file", "path", path, "error", err)
+			continue
+		}
+		if _, ok := idx.files[rel]; ok {
+			seen[rel] = true
+		}
+		if indexed {
+			stats.Indexed++
+		}
+	}
+	for rel := range idx.files {
+		if !seen[rel] {
+			delete(idx.files, rel)
+			stats.Removed++
+		}
+	}
+	return stats, nil
+}
+
+// rel returns the slash-separated path of path relative to the root,
+// reporting whether it is under the root.
+func (idx *Index) rel(path string) (string, bool) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(idx.root, path)
+	}
+	rel, err := filepath.Rel(idx.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// indexFile indexes the file at rel when it changed since it was last
+// indexed, reporting whether it did. Files that are gone, too big or binary
+// are dropped from the index.
+func (idx *Index) indexFile(rel string) (bool, error) {
+	path := filepath.Join(idx.root, filepath.FromSlash(rel))
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileSize {
+		delete(idx.files, rel)
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if f, ok := idx.files[rel]; ok && f.Size == info.Size() && f.ModTime.Equal(info.ModTime()) {
+		return false, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		delete(idx.files, rel)
+		return false, err
+	}
+	if bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+		delete(idx.files, rel)
+		return false, nil
+	}
+
+	idx.files[rel] = &file{
+		ModTime: info.ModTime(),
+		Size:    info.Size(),
+		Chunks:  chunkFile(rel, string(content)),
+	}
+	return true, nil
+}
+
+// embed computes the missing embeddings of the chunks, at most limit of them
+// unless limit is 0. When the embedding model changed, all the embeddings
+// are computed again. The index is only
+// locked to collect the chunks and to store their embeddings, the chunks
+// staying in place as long as updateMu is held.
+func (idx *Index) embed(ctx context.Context, limit int) error {
+	if idx.embedder == nil {
+		return nil
+	}
+
+	var pending []*Chunk
+	var texts []string
+	idx.mu.Lock()
+	if model := idx.embedder.Model(); model != idx.model {
+		for _, f := range idx.files {
+			for i := range f.Chunks {
+				f.Chunks[i].Embedding = nil
+			}
+		}
+		idx.model = model
+	}
+collect:
+	for rel, f := range idx.files {
+		for i := range f.Chunks {
+			if f.Chunks[i].Embedding != nil {
+				continue
+			}
+			if limit > 0 && len(texts) == limit {
+				break collect
+			}
+			pending = append(pending, &f.Chunks[i])
+			texts = append(texts, embedText(rel, f.Chunks[i]))
+		}
+	}
+	idx.mu.Unlock()
+
+	for start := 0; start < len(texts); start += embedBatchSize {
+		end := min(start+embedBatchSize, len(texts))
+		embeddings, err := idx.embedder.Embed(ctx, texts[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to embed chunks: %w", err)
+		}
+		idx.mu.Lock()
+		for i, chunk := range pending[start:end] {
+			chunk.Embedding = embeddings[i]
+		}
+		idx.mu.Unlock()
+	}
+	return nil
+}
+
+// save writes the index to disk, replacing the previous one atomically. It
+// must be called with mu held.
+func (idx *Index) save() error {
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(idx.path), "code-*.gob")
+	if err != nil {
+		return fmt.Errorf("failed to save code index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(snapshot{
+		Version: indexVersion,
+		Model:   idx.model,
+		Files:   idx.files,
+	}); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save code index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save code index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), idx.path); err != nil {
+		return fmt.Errorf("failed to save code index: %w", err)
+	}
+	return nil
+}
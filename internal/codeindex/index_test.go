@@ -0,0 +1,272 @@
+package codeindex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/filetracker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t,
+		[]string{"parsehttprequest", "parse", "http", "request", "load_config", "load", "config", "v2"},
+		tokenize("parseHTTPRequest(load_config) is v2"),
+	)
+}
+
+func TestExtractSymbols(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path    string
+		content string
+		want    []Symbol
+	}{
+		{
+			path:    "main.go",
+			content: "package main\n\ntype Server struct{}\n\nfunc (s *Server) Serve() {}\n\nfunc main() {}",
+			want:    []Symbol{{"Server", 3}, {"Serve", 5}, {"main", 7}},
+		},
+		{
+			path:    "app.ts",
+			content: "export interface Props {}\nexport const render = (props: Props) => null\nexport default function App() {}",
+			want:    []Symbol{{"Props", 1}, {"render", 2}, {"App", 3}},
+		},
+		{
+			path:    "README.md",
+			content: "# Crush\n\n```sh\n# not a heading\n```\n\n## Installation",
+			want:    []Symbol{{"Crush", 1}, {"Installation", 7}},
+		},
+		{
+			path:    "notes.txt",
+			content: "func main() {}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, extractSymbols(tt.path, strings.Split(tt.content, "\n")))
+		})
+	}
+}
+
+func TestChunkFile(t *testing.T) {
+	t.Parallel()
+
+	var lines []string
+	for range 75 {
+		lines = append(lines, "x := 1")
+	}
+	lines[49] = "func handleLogin() {"
+
+	chunks := chunkFile("auth.go", strings.Join(lines, "\n")+"\n")
+	require.Len(t, chunks, 3)
+	require.Equal(t, [2]int{1, 40}, [2]int{chunks[0].StartLine, chunks[0].EndLine})
+	require.Equal(t, [2]int{31, 70}, [2]int{chunks[1].StartLine, chunks[1].EndLine})
+	require.Equal(t, [2]int{61, 75}, [2]int{chunks[2].StartLine, chunks[2].EndLine})
+	require.Equal(t, []string{"handleLogin"}, chunks[1].Symbols)
+	require.Equal(t, 1+symbolWeight, chunks[1].Terms["login"])
+	require.Equal(t, 1, chunks[0].Terms["auth"])
+}
+
+// fakeEmbedder embeds texts as the counts of a few words.
+type fakeEmbedder struct {
+	calls int
+}
+
+var fakeWords = []string{"database", "network", "color"}
+
+func (e *fakeEmbedder) Model() string { return "fake" }
+
+func (e *fakeEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, len(fakeWords))
+		for j, word := range fakeWords {
+			v[j] = float32(strings.Count(strings.ToLower(text), word))
+		}
+		embeddings[i] = normalize(v)
+	}
+	return embeddings, nil
+}
+
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dir := filepath.Join(t.TempDir(), "index")
+	writeFile(t, root, ".gitignore", "generated/\n")
+	writeFile(t, root, "store/sql.go", "package store\n\n// Query runs SQL against the database.\nfunc Query() {}\n")
+	writeFile(t, root, "ui/theme.go", "package ui\n\n// Theme picks the color of the text.\nfunc Theme() {}\n")
+	writeFile(t, root, "uikit/button.go", "package uikit\n\n// Button runs a query on click.\nfunc Button() {}\n")
+	writeFile(t, root, "generated/big.go", "package generated\n\nfunc Query() {}\n")
+	writeFile(t, root, "logo.png", "\x89PNG\x00\x00")
+
+	idx, err := Open(root, dir, nil)
+	require.NoError(t, err)
+	stats, err := idx.Update(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, Stats{Files: 4, Indexed: 4, Chunks: 4}, stats)
+
+	results, err := idx.Search(t.Context(), "query", 10, "store")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "store/sql.go", results[0].Path)
+	require.Equal(t, []string{"Query"}, results[0].Symbols)
+
+	// The prefix matches whole path elements.
+	results, err = idx.Search(t.Context(), "query", 10, "ui")
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	results, err = idx.Search(t.Context(), "query", 10, "store/sql.go")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	t.Run("incremental update", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(root, "ui/theme.go")))
+		writeFile(t, root, "store/cache.go", "package store\n\nfunc Cache() {}\n")
+
+		idx, err := Open(root, dir, nil)
+		require.NoError(t, err)
+		stats, err := idx.Update(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, Stats{Files: 4, Indexed: 1, Removed: 1, Chunks: 4}, stats)
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		// The files written through the tools are indexed right away.
+		writeFile(t, root, "store/cache.go", "package store\n\nfunc EvictAll() {}\n")
+		filetracker.RecordWrite(filepath.Join(root, "store/cache.go"))
+		writeFile(t, root, "store/pool.go", "package store\n\nfunc EvictIdle() {}\n")
+		require.NoError(t, idx.Sync(t.Context()))
+
+		results, err := idx.Search(t.Context(), "evict", 10, "store")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, "store/cache.go", results[0].Path)
+
+		// The other files are picked up by the next scan of the tree.
+		idx.scanned = time.Now().Add(-rescanInterval)
+		require.NoError(t, idx.Sync(t.Context()))
+
+		results, err = idx.Search(t.Context(), "evict", 10, "store")
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+	})
+}
+
+func TestIndexSemanticSearch(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dir := filepath.Join(t.TempDir(), "index")
+	writeFile(t, root, "store.go", "package store\n\n// Save writes the rows to the database.\nfunc Save() {}\n")
+	writeFile(t, root, "client.go", "package client\n\n// Dial opens networking sockets.\nfunc Dial() {}\n")
+
+	embedder := &fakeEmbedder{}
+	idx, err := Open(root, dir, embedder)
+	require.NoError(t, err)
+	_, err = idx.Update(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 1, embedder.calls)
+
+	// The files don't have the term of the query, only the fake embeddings
+	// relate them.
+	results, err := idx.Search(t.Context(), "network", 1, "")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "client.go", results[0].Path)
+
+	// Unchanged files keep their embeddings.
+	idx, err = Open(root, dir, embedder)
+	require.NoError(t, err)
+	_, err = idx.Update(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 2, embedder.calls)
+}
+
+func TestIndexEmbedLimit(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFile(t, root, "store.go", "package store\n\n// Save writes the rows to the database.\nfunc Save() {}\n")
+	writeFile(t, root, "client.go", "package client\n\n// Dial opens networking sockets.\nfunc Dial() {}\n")
+
+	idx, err := Open(root, filepath.Join(t.TempDir(), "index"), &fakeEmbedder{})
+	require.NoError(t, err)
+	idx.updateMu.Lock()
+	_, err = idx.scan(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, idx.embed(t.Context(), 1))
+	idx.updateMu.Unlock()
+
+	embedded := 0
+	for _, f := range idx.files {
+		for _, chunk := range f.Chunks {
+			if chunk.Embedding != nil {
+				embedded++
+			}
+		}
+	}
+	require.Equal(t, 1, embedded)
+}
+
+// blockingEmbedder blocks the embeddings of the chunks until release is
+// closed.
+type blockingEmbedder struct {
+	fakeEmbedder
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e *blockingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) > 1 {
+		close(e.started)
+		<-e.release
+	}
+	return e.fakeEmbedder.Embed(ctx, texts)
+}
+
+func TestIndexSearchDuringEmbedding(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFile(t, root, "store.go", "package store\n\n// Save writes the rows to the database.\nfunc Save() {}\n")
+	writeFile(t, root, "client.go", "package client\n\n// Dial opens networking sockets.\nfunc Dial() {}\n")
+
+	embedder := &blockingEmbedder{started: make(chan struct{}), release: make(chan struct{})}
+	idx, err := Open(root, filepath.Join(t.TempDir(), "index"), embedder)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := idx.Update(t.Context())
+		done <- err
+	}()
+	<-embedder.started
+
+	// The index isn't locked while the chunks are embedded.
+	results, err := idx.Search(t.Context(), "dial", 10, "")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "client.go", results[0].Path)
+
+	close(embedder.release)
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,202 @@
+package codeindex
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+)
+
+const (
+	// BM25 parameters.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// rrfK dampens the weight of the top ranks when fusing the lexical and
+	// the semantic rankings.
+	rrfK = 60
+	// candidates is the number of chunks of each ranking that are fused.
+	candidates = 100
+)
+
+// DefaultLimit is the number of results of a search without a limit.
+const DefaultLimit = 10
+
+// Result is a chunk matching a search.
+type Result struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Symbols   []string
+	Text      string
+	Score     float64
+}
+
+type candidate struct {
+	path  string
+	chunk *Chunk
+	score float64
+}
+
+// Search returns the chunks best matching query, limited to the files under
+// the slash-separated pathPrefix when it isn't empty. Chunks are ranked with
+// BM25 and, when the index has embeddings, also by similarity to the
+// embedding of the query, the two rankings being fused.
+func (idx *Index) Search(ctx context.Context, query string, limit int, pathPrefix string) ([]Result, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query is empty")
+	}
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	terms := tokenize(query)
+
+	// Embed the query before locking the index, not to block its updates
+	// during the request.
+	embedding, err := idx.embedQuery(ctx, query)
+	if err != nil {
+		// Fall back to the lexical ranking.
+		slog.Warn("Failed to rank code chunks semantically", "error", err)
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var chunks []candidate
+	for path, f := range idx.files {
+		if pathPrefix != "" && path != pathPrefix && !strings.HasPrefix(path, pathPrefix+"/") {
+			continue
+		}
+		for i := range f.Chunks {
+			chunks = append(chunks, candidate{path: path, chunk: &f.Chunks[i]})
+		}
+	}
+
+	lexical := rankBM25(chunks, terms)
+	semantic := idx.rankSemantic(chunks, embedding)
+
+	ranked := lexical
+	if len(semantic) > 0 {
+		ranked = fuse(lexical, semantic)
+	}
+
+	results := make([]Result, 0, min(limit, len(ranked)))
+	for _, c := range ranked[:min(limit, len(ranked))] {
+		results = append(results, Result{
+			Path:      c.path,
+			StartLine: c.chunk.StartLine,
+			EndLine:   c.chunk.EndLine,
+			Symbols:   c.chunk.Symbols,
+			Text:      c.chunk.Text,
+			Score:     c.score,
+		})
+	}
+	return results, nil
+}
+
+// rankBM25 scores the chunks containing the terms with BM25, best first.
+func rankBM25(chunks []candidate, terms []string) []candidate {
+	if len(chunks) == 0 || len(terms) == 0 {
+		return nil
+	}
+
+	var totalLength int
+	df := make(map[string]int, len(terms))
+	for _, c := range chunks {
+		totalLength += c.chunk.Length
+		for _, term := range terms {
+			if c.chunk.Terms[term] > 0 {
+				df[term]++
+			}
+		}
+	}
+	avgLength := float64(totalLength) / float64(len(chunks))
+	n := float64(len(chunks))
+
+	var ranked []candidate
+	for _, c := range chunks {
+		var score float64
+		for _, term := range terms {
+			tf := float64(c.chunk.Terms[term])
+			if tf == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[term])+0.5)/(float64(df[term])+0.5))
+			norm := 1 - bm25B + bm25B*float64(c.chunk.Length)/avgLength
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+		if score > 0 {
+			c.score = score
+			ranked = append(ranked, c)
+		}
+	}
+	sortCandidates(ranked)
+	return ranked
+}
+
+// embedQuery returns the embedding of query, or nil when the index has no
+// embedder.
+func (idx *Index) embedQuery(ctx context.Context, query string) ([]float32, error) {
+	if idx.embedder == nil {
+		return nil, nil
+	}
+	embeddings, err := idx.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// rankSemantic scores the chunks with embeddings by their similarity to the
+// embedding of the query, best first. It returns nothing without an
+// embedding or when the chunks were embedded with another model.
+func (idx *Index) rankSemantic(chunks []candidate, embedding []float32) []candidate {
+	if embedding == nil || idx.embedder.Model() != idx.model {
+		return nil
+	}
+
+	var ranked []candidate
+	for _, c := range chunks {
+		if c.chunk.Embedding == nil {
+			continue
+		}
+		c.score = dot(embedding, c.chunk.Embedding)
+		ranked = append(ranked, c)
+	}
+	sortCandidates(ranked)
+	return ranked
+}
+
+// fuse merges the top candidates of rankings with reciprocal rank fusion.
+func fuse(rankings ...[]candidate) []candidate {
+	byChunk := make(map[*Chunk]candidate)
+	for _, ranking := range rankings {
+		for rank, c := range ranking[:min(candidates, len(ranking))] {
+			fused, ok := byChunk[c.chunk]
+			if !ok {
+				fused = candidate{path: c.path, chunk: c.chunk}
+			}
+			fused.score += 1 / float64(rrfK+rank+1)
+			byChunk[c.chunk] = fused
+		}
+	}
+
+	fused := make([]candidate, 0, len(byChunk))
+	for _, c := range byChunk {
+		fused = append(fused, c)
+	}
+	sortCandidates(fused)
+	return fused
+}
+
+func sortCandidates(ranked []candidate) {
+	slices.SortFunc(ranked, func(a, b candidate) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			strings.Compare(a.path, b.path),
+			cmp.Compare(a.chunk.StartLine, b.chunk.StartLine),
+		)
+	})
+}
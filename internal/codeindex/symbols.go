@@ -0,0 +1,107 @@
+package codeindex
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Symbol is a function, type or other named definition of a file, or a
+// heading of a document.
+type Symbol struct {
+	Name string
+	Line int
+}
+
+var (
+	goSymbols = []*regexp.Regexp{
+		regexp.MustCompile(`^func\s+(?:\([^)]*\)\s*)?(\w+)`),
+		regexp.MustCompile(`^type\s+(\w+)`),
+		regexp.MustCompile(`^\t(\w+)\s+(?:struct|interface)\s*\{`),
+	}
+	pythonSymbols = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*(?:async\s+)?def\s+(\w+)`),
+		regexp.MustCompile(`^\s*class\s+(\w+)`),
+	}
+	jsSymbols = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\*?\s+(\w+)`),
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(\w+)`),
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?(?:interface|type|enum)\s+(\w+)`),
+		regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+(\w+)\s*=\s*(?:async\s+)?(?:\([^)]*\)|\w+)\s*=>`),
+	}
+	rustSymbols = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:unsafe\s+)?(?:fn|struct|enum|trait|mod|type|union)\s+(\w+)`),
+	}
+	rubySymbols = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*def\s+(?:self\.)?(\w+[?!]?)`),
+		regexp.MustCompile(`^\s*(?:class|module)\s+(\w+)`),
+	}
+	jvmSymbols = []*regexp.Regexp{
+		regexp.MustCompile(`^\s*(?:[\w@]+\s+)*(?:class|interface|enum|record|object|trait|fun)\s+(\w+)`),
+	}
+	cSymbols = []*regexp.Regexp{
+		regexp.MustCompile(`^(?:[\w*]+\s+)+\**(\w+)\s*\([^;]*$`),
+		regexp.MustCompile(`^\s*(?:typedef\s+)?(?:struct|enum|union|class)\s+(\w+)`),
+	}
+	markdownSymbols = []*regexp.Regexp{
+		regexp.MustCompile(`^#{1,6}\s+(.+?)\s*#*$`),
+	}
+)
+
+// symbolPatterns are the patterns finding the symbols of the files of each
+// extension. Their first group is the name of the symbol.
+var symbolPatterns = map[string][]*regexp.Regexp{
+	".go":    goSymbols,
+	".py":    pythonSymbols,
+	".js":    jsSymbols,
+	".jsx":   jsSymbols,
+	".mjs":   jsSymbols,
+	".cjs":   jsSymbols,
+	".ts":    jsSymbols,
+	".tsx":   jsSymbols,
+	".rs":    rustSymbols,
+	".rb":    rubySymbols,
+	".java":  jvmSymbols,
+	".kt":    jvmSymbols,
+	".scala": jvmSymbols,
+	".cs":    jvmSymbols,
+	".swift": jvmSymbols,
+	".c":     cSymbols,
+	".h":     cSymbols,
+	".cc":    cSymbols,
+	".cpp":   cSymbols,
+	".hpp":   cSymbols,
+	".md":    markdownSymbols,
+	".mdx":   markdownSymbols,
+}
+
+// extractSymbols finds the symbols defined in the lines of the file at
+// path. Files of unknown languages have none.
+func extractSymbols(path string, lines []string) []Symbol {
+	ext := strings.ToLower(filepath.Ext(path))
+	patterns := symbolPatterns[ext]
+	if len(patterns) == 0 {
+		return nil
+	}
+	isDocument := ext == ".md" || ext == ".mdx"
+
+	var symbols []Symbol
+	inFence := false
+	for i, line := range lines {
+		// Skip the code blocks of documents, their comments aren't
+		// headings.
+		if isDocument && strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		if inFence {
+			continue
+		}
+		for _, pattern := range patterns {
+			if m := pattern.FindStringSubmatch(line); m != nil {
+				symbols = append(symbols, Symbol{Name: m[1], Line: i + 1})
+				break
+			}
+		}
+	}
+	return symbols
+}
@@ -18,9 +18,13 @@ import (
 	hyperp "github.com/charmbracelet/crush/internal/agent/hyper"
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/env"
+	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/oauth"
 	"github.com/charmbracelet/crush/internal/oauth/copilot"
 	"github.com/charmbracelet/crush/internal/oauth/hyper"
+	"github.com/charmbracelet/crush/internal/oauth/mcpauth"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/shell"
 	"github.com/invopop/jsonschema"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -92,6 +96,18 @@ type SelectedModel struct {
 
 	// Override provider specific options.
 	ProviderOptions map[string]any `json:"provider_options,omitempty" jsonschema:"description=Additional provider-specific options for the model"`
+
+	// Models to switch to, in order, when the provider fails with an error
+	// retrying didn't fix.
+	Fallbacks []ModelFallback `json:"fallbacks,omitempty" jsonschema:"description=Models to switch to in order when the provider keeps failing with rate limit, quota or server errors"`
+}
+
+// ModelFallback is a model used when the selected one is unavailable.
+type ModelFallback struct {
+	// The model id as used by the provider API.
+	Model string `json:"model" jsonschema:"required,description=The model ID as used by the provider API,example=gpt-4o"`
+	// The model provider, same as the key/id used in the providers config.
+	Provider string `json:"provider" jsonschema:"required,description=The model provider ID that matches a key in the providers config,example=openai"`
 }
 
 type ProviderConfig struct {
@@ -127,6 +143,17 @@ type ProviderConfig struct {
 
 	// The provider models
 	Models []catwalk.Model `json:"models,omitempty" jsonschema:"description=List of models available from this provider"`
+
+	// Retry policy for failed requests.
+	Retry *RetryPolicy `json:"retry,omitempty" jsonschema:"description=Retry policy for rate-limited and failed requests to this provider"`
+}
+
+// RetryPolicy configures how failed requests to a provider are retried.
+// Unset fields use the defaults.
+type RetryPolicy struct {
+	MaxAttempts int   `json:"max_attempts,omitempty" jsonschema:"description=Maximum number of attempts per request including the first; 1 disables retries,default=5,minimum=1,example=3"`
+	MaxBackoff  int   `json:"max_backoff,omitempty" jsonschema:"description=Maximum delay between attempts in seconds,default=60,example=30"`
+	StatusCodes []int `json:"status_codes,omitempty" jsonschema:"description=HTTP status codes that are retried. Defaults to 408/409/429/500/502/503/504/529,example=429,example=503"`
 }
 
 // ToProvider converts the [ProviderConfig] to a [catwalk.Provider].
@@ -183,6 +210,10 @@ type MCPConfig struct {
 
 	// TODO: maybe make it possible to get the value from the env
 	Headers map[string]string `json:"headers,omitempty" jsonschema:"description=HTTP headers for HTTP/SSE MCP servers"`
+
+	// OAuth holds the credentials for HTTP/SSE MCP servers that require
+	// authorization, set by `crush login mcp`.
+	OAuth *mcpauth.Credentials `json:"oauth,omitempty" jsonschema:"description=OAuth2 credentials for authentication with HTTP/SSE MCP servers"`
 }
 
 type LSPConfig struct {
@@ -194,6 +225,9 @@ type LSPConfig struct {
 	RootMarkers []string          `json:"root_markers,omitempty" jsonschema:"description=Files or directories that indicate the project root,example=go.mod,example=package.json,example=Cargo.toml"`
 	InitOptions map[string]any    `json:"init_options,omitempty" jsonschema:"description=Initialization options passed to the LSP server during initialize request"`
 	Options     map[string]any    `json:"options,omitempty" jsonschema:"description=LSP server-specific settings passed during initialization"`
+
+	FormatOnWrite   bool `json:"format_on_write,omitempty" jsonschema:"description=Format files with this LSP server after the agent writes them,default=false"`
+	OrganizeImports bool `json:"organize_imports,omitempty" jsonschema:"description=Organize imports with this LSP server after the agent writes files,default=false"`
 }
 
 type TUIOptions struct {
@@ -216,8 +250,10 @@ func (c Completions) Limits() (depth, items int) {
 }
 
 type Permissions struct {
-	AllowedTools []string `json:"allowed_tools,omitempty" jsonschema:"description=List of tools that don't require permission prompts,example=bash,example=view"` // Tools that don't require permission prompts
-	SkipRequests bool     `json:"-"`                                                                                                                              // Automatically accept all permissions (YOLO mode)
+	AllowedTools []string           `json:"allowed_tools,omitempty" jsonschema:"description=List of tools that don't require permission prompts,example=bash,example=view"` // Tools that don't require permission prompts
+	Rules        []permission.Rule  `json:"rules,omitempty" jsonschema:"description=Ordered allow/ask/deny rules for tool calls; the first matching rule decides"`
+	Grants       []permission.Grant `json:"grants,omitempty" jsonschema:"description=Permissions granted for the whole project from prompts; managed by Crush"`
+	SkipRequests bool               `json:"-"` // Automatically accept all permissions (YOLO mode)
 }
 
 type TrailerStyle string
@@ -244,23 +280,90 @@ func (Attribution) JSONSchemaExtend(schema *jsonschema.Schema) {
 }
 
 type Options struct {
-	ContextPaths              []string          `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=CRUSH.md"`
-	SkillsPaths               []string          `json:"skills_paths,omitempty" jsonschema:"description=Paths to directories containing Agent Skills (folders with SKILL.md files),example=~/.config/crush/skills,example=./skills"`
-	TUI                       *TUIOptions       `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
-	Telemetry                 *TelemetryOptions `json:"telemetry,omitempty" jsonschema:"description=OpenTelemetry tracing options"`
-	Debug                     bool              `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
-	DebugLSP                  bool              `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
-	DisableAutoSummarize      bool              `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
-	DataDirectory             string            `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.crush,example=.crush"` // Relative to the cwd
-	DisabledTools             []string          `json:"disabled_tools,omitempty" jsonschema:"description=List of built-in tools to disable and hide from the agent,example=bash,example=sourcegraph"`
-	AllowUnsafeCommands       []string          `json:"allow_unsafe_commands,omitempty" jsonschema:"description=List of normally-blocked bash commands to allow (e.g. curl or wget). Use with caution as these commands are blocked for security reasons,example=curl,example=wget"`
-	DisableProviderAutoUpdate bool              `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
-	DisableDefaultProviders   bool              `json:"disable_default_providers,omitempty" jsonschema:"description=Ignore all default/embedded providers. When enabled, providers must be fully specified in the config file with base_url, models, and api_key - no merging with defaults occurs,default=false"`
-	Attribution               *Attribution      `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
-	DisableMetrics            bool              `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
-	InitializeAs              string            `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
-	AutoLSP                   *bool             `json:"auto_lsp,omitempty" jsonschema:"description=Automatically setup LSPs based on root markers"`
-	AgentStatusDir            string            `json:"agent_status_dir,omitempty" jsonschema:"description=Directory for writing agent status files (follows Agent Status Reporting Standard). Set to empty string to disable. Supports ~ for home directory,example=~/.agent-status,example=/tmp/agent-status"`
+	ContextPaths              []string              `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=CRUSH.md"`
+	SkillsPaths               []string              `json:"skills_paths,omitempty" jsonschema:"description=Paths to directories containing Agent Skills (folders with SKILL.md files),example=~/.config/crush/skills,example=./skills"`
+	DisabledSkills            []string              `json:"disabled_skills,omitempty" jsonschema:"description=Names of Agent Skills to hide from the agent,example=pdf-processing"`
+	TUI                       *TUIOptions           `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
+	Telemetry                 *TelemetryOptions     `json:"telemetry,omitempty" jsonschema:"description=OpenTelemetry tracing options"`
+	Debug                     bool                  `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
+	DebugLSP                  bool                  `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
+	DisableAutoSummarize      bool                  `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
+	DataDirectory             string                `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.crush,example=.crush"` // Relative to the cwd
+	DisabledTools             []string              `json:"disabled_tools,omitempty" jsonschema:"description=List of built-in tools to disable and hide from the agent,example=bash,example=sourcegraph"`
+	AllowUnsafeCommands       []string              `json:"allow_unsafe_commands,omitempty" jsonschema:"description=List of normally-blocked bash commands to allow (e.g. curl or wget). Use with caution as these commands are blocked for security reasons,example=curl,example=wget"`
+	DisableProviderAutoUpdate bool                  `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=false"`
+	DisableDefaultProviders   bool                  `json:"disable_default_providers,omitempty" jsonschema:"description=Ignore all default/embedded providers. When enabled, providers must be fully specified in the config file with base_url, models, and api_key - no merging with defaults occurs,default=false"`
+	Attribution               *Attribution          `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
+	DisableMetrics            bool                  `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
+	InitializeAs              string                `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=CRUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
+	AutoLSP                   *bool                 `json:"auto_lsp,omitempty" jsonschema:"description=Automatically setup LSPs based on root markers"`
+	AgentStatusDir            string                `json:"agent_status_dir,omitempty" jsonschema:"description=Directory for writing agent status files (follows Agent Status Reporting Standard). Set to empty string to disable. Supports ~ for home directory,example=~/.agent-status,example=/tmp/agent-status"`
+	MaxSteps                  int                   `json:"max_steps,omitempty" jsonschema:"description=Maximum number of steps the coder and task agents may take per prompt. 0 means no limit,default=0,example=50"`
+	MaxCostUSD                float64               `json:"max_cost_usd,omitempty" jsonschema:"description=Maximum cost in USD the coder and task agents may spend per prompt. 0 means no limit,default=0,example=2.5"`
+	Sandbox                   *SandboxOptions       `json:"sandbox,omitempty" jsonschema:"description=Run bash commands and background jobs in an OS-level sandbox (Linux only)"`
+	Compaction                map[string]Compaction `json:"compaction,omitempty" jsonschema:"description=How the history of a session is compacted when it gets close to the context window of the model, per agent (coder, task or the name of a subagent)"`
+}
+
+type CompactionStrategy string
+
+const (
+	// CompactionSummarize summarizes the whole history.
+	CompactionSummarize CompactionStrategy = "summarize"
+	// CompactionSummarizeOldest summarizes the oldest turns, keeping the
+	// latest ones verbatim.
+	CompactionSummarizeOldest CompactionStrategy = "summarize_oldest"
+	// CompactionElideToolResults replaces the older tool results with a
+	// pointer to the output cache, and summarizes once there's nothing left
+	// to elide.
+	CompactionElideToolResults CompactionStrategy = "elide_tool_results"
+)
+
+// Compaction configures how the history of a session is compacted.
+type Compaction struct {
+	Strategy      CompactionStrategy `json:"strategy,omitempty" jsonschema:"description=How the history is compacted,enum=summarize,enum=summarize_oldest,enum=elide_tool_results,default=summarize"`
+	Threshold     int                `json:"threshold,omitempty" jsonschema:"description=Percentage of the context window in use at which the history is compacted. 0 compacts when 20% of the window is left or 20k tokens for windows over 200k,minimum=0,maximum=100,example=80"`
+	KeepTurns     int                `json:"keep_turns,omitempty" jsonschema:"description=Number of latest turns kept verbatim by the summarize_oldest and elide_tool_results strategies,default=2,example=4"`
+	SummaryPrompt string             `json:"summary_prompt,omitempty" jsonschema:"description=Path to a file with the system prompt used to summarize the history (relative to the working directory),example=.crush/summary.md"`
+}
+
+// SandboxOptions configures the sandbox bash commands run in. The filesystem
+// is read-only except for the working directory, the temporary directory and
+// the writable paths. The configuration of crush, its data directory and the
+// git hooks and config of the working directory stay read-only, so that
+// commands can't get out of the sandbox through them.
+type SandboxOptions struct {
+	Enabled      bool     `json:"enabled,omitempty" jsonschema:"description=Run bash commands and background jobs in the sandbox,default=false"`
+	Writable     []string `json:"writable,omitempty" jsonschema:"description=Paths besides the working directory that commands may write to. Supports ~ for home directory,example=~/.cache/go-build,example=~/go/pkg/mod"`
+	AllowNetwork bool     `json:"allow_network,omitempty" jsonschema:"description=Allow sandboxed commands to access the network,default=false"`
+}
+
+// Shell returns the sandbox for shells run in workingDir with the data
+// directory dataDir, or nil when the sandbox is disabled.
+func (s *SandboxOptions) Shell(workingDir, dataDir string) *shell.Sandbox {
+	if s == nil || !s.Enabled {
+		return nil
+	}
+	writable := []string{workingDir}
+	for _, path := range s.Writable {
+		writable = append(writable, home.Long(path))
+	}
+	readOnly := []string{
+		filepath.Join(workingDir, appName+".json"),
+		filepath.Join(workingDir, "."+appName+".json"),
+		dataDir,
+	}
+	// Git runs the hooks and the commands of the config outside of the
+	// sandbox. A .git file points to the git directory.
+	if info, err := os.Lstat(filepath.Join(workingDir, ".git")); err == nil && info.IsDir() {
+		readOnly = append(readOnly, filepath.Join(workingDir, ".git", "hooks"), filepath.Join(workingDir, ".git", "config"))
+	} else {
+		readOnly = append(readOnly, filepath.Join(workingDir, ".git"))
+	}
+	return &shell.Sandbox{
+		Writable: writable,
+		ReadOnly: readOnly,
+		Network:  s.AllowNetwork,
+	}
 }
 
 // TelemetryOptions configures OpenTelemetry tracing.
@@ -359,10 +462,23 @@ type Agent struct {
 
 	// Overrides the context paths for this agent
 	ContextPaths []string `json:"context_paths,omitempty"`
+
+	// The maximum number of steps the agent may take per prompt
+	//  if this is 0, there is no limit
+	MaxSteps int `json:"max_steps,omitempty"`
+
+	// The maximum cost in USD the agent may spend per prompt
+	//  if this is 0, there is no limit
+	MaxCostUSD float64 `json:"max_cost_usd,omitempty"`
+
+	// How the history of the agent's sessions is compacted
+	Compaction Compaction `json:"compaction,omitempty"`
 }
 
 type Tools struct {
-	Ls ToolLs `json:"ls,omitempty"`
+	Ls         ToolLs         `json:"ls,omitempty"`
+	WebSearch  ToolWebSearch  `json:"web_search,omitempty"`
+	CodeSearch ToolCodeSearch `json:"code_search,omitempty"`
 }
 
 type ToolLs struct {
@@ -374,6 +490,60 @@ func (t ToolLs) Limits() (depth, items int) {
 	return ptrValOr(t.MaxDepth, 0), ptrValOr(t.MaxItems, 0)
 }
 
+type WebSearchProvider string
+
+const (
+	WebSearchDuckDuckGo WebSearchProvider = "duckduckgo"
+	WebSearchSearXNG    WebSearchProvider = "searxng"
+	WebSearchBrave      WebSearchProvider = "brave"
+	WebSearchTavily     WebSearchProvider = "tavily"
+	WebSearchHTTP       WebSearchProvider = "http"
+)
+
+// ToolWebSearch configures the backend of the web_search tool of the agentic
+// fetch tool.
+type ToolWebSearch struct {
+	Provider WebSearchProvider `json:"provider,omitempty" jsonschema:"description=Web search backend,enum=duckduckgo,enum=searxng,enum=brave,enum=tavily,enum=http,default=duckduckgo"`
+	URL      string            `json:"url,omitempty" jsonschema:"description=URL of the SearXNG instance or of the API; for the http provider {query} and {max_results} are replaced,example=https://searx.example.com,example=https://search.example.com/api?q={query}&limit={max_results}"`
+	APIKey   string            `json:"api_key,omitempty" jsonschema:"description=API key of the brave and tavily providers; supports environment variables,example=$BRAVE_API_KEY"`
+	Method   string            `json:"method,omitempty" jsonschema:"description=HTTP method of the http provider,enum=GET,enum=POST,default=GET"`
+	Headers  map[string]string `json:"headers,omitempty" jsonschema:"description=HTTP headers to send; values support environment variables"`
+	Body     string            `json:"body,omitempty" jsonschema:"description=Request body of the http provider; {query} and {max_results} are replaced,example={\"query\": \"{query}\"\\, \"limit\": {max_results}}"`
+	Response WebSearchResponse `json:"response,omitempty" jsonschema:"description=Where the http provider finds the results in the JSON response"`
+}
+
+// WebSearchResponse maps the JSON response of the http web search provider
+// to results. Fields are dot-separated paths.
+type WebSearchResponse struct {
+	Results string `json:"results,omitempty" jsonschema:"description=Path of the array of results; . when the response is the array,default=results,example=web.results"`
+	Title   string `json:"title,omitempty" jsonschema:"description=Path of the title in a result,default=title"`
+	URL     string `json:"url,omitempty" jsonschema:"description=Path of the URL in a result,default=url"`
+	Snippet string `json:"snippet,omitempty" jsonschema:"description=Path of the snippet in a result,default=snippet,example=description"`
+}
+
+// ToolCodeSearch configures the index of the code_search tool. Without an
+// embedding provider the index is lexical only.
+type ToolCodeSearch struct {
+	EmbeddingProvider string `json:"embedding_provider,omitempty" jsonschema:"description=ID of the openai or openai-compat provider computing the embeddings for semantic search,example=openai"`
+	EmbeddingModel    string `json:"embedding_model,omitempty" jsonschema:"description=Embedding model of the embedding provider,default=text-embedding-3-small,example=text-embedding-3-large"`
+}
+
+// Hooks are shell commands run at points in the agent lifecycle. Each hook
+// receives the event as JSON on stdin.
+type Hooks struct {
+	PreToolUse       []Hook `json:"pre_tool_use,omitempty" jsonschema:"description=Hooks run before a tool call; they can block the call or rewrite its input"`
+	PostToolUse      []Hook `json:"post_tool_use,omitempty" jsonschema:"description=Hooks run after a tool call; their output is added to the tool result"`
+	UserPromptSubmit []Hook `json:"user_prompt_submit,omitempty" jsonschema:"description=Hooks run when a prompt is submitted; they can block the prompt or add context to it"`
+	Stop             []Hook `json:"stop,omitempty" jsonschema:"description=Hooks run when the agent finishes responding"`
+	SessionStart     []Hook `json:"session_start,omitempty" jsonschema:"description=Hooks run before the first prompt of a session; their output is added as context"`
+}
+
+type Hook struct {
+	Matcher string `json:"matcher,omitempty" jsonschema:"description=Tool name or glob the hook applies to; alternatives are separated by |. Only used by tool hooks and empty matches every tool,example=bash,example=edit|write|multiedit,example=mcp_*"`
+	Command string `json:"command" jsonschema:"required,description=Shell command to run,example=gofmt -w ."`
+	Timeout int    `json:"timeout,omitempty" jsonschema:"description=Timeout in seconds for the hook command,default=60,example=10"`
+}
+
 // Config holds the configuration for crush.
 type Config struct {
 	Schema string `json:"$schema,omitempty"`
@@ -397,6 +567,8 @@ type Config struct {
 
 	Tools Tools `json:"tools,omitempty" jsonschema:"description=Tool configurations"`
 
+	Hooks Hooks `json:"hooks,omitempty" jsonschema:"description=Shell commands to run on tool calls and agent lifecycle events"`
+
 	Agents map[string]Agent `json:"-"`
 
 	// Internal
@@ -411,6 +583,40 @@ func (c *Config) WorkingDir() string {
 	return c.workingDir
 }
 
+// SkillsDirs returns the skills paths with ~ and environment variables
+// expanded.
+func (c *Config) SkillsDirs() []string {
+	dirs := make([]string, 0, len(c.Options.SkillsPaths))
+	for _, path := range c.Options.SkillsPaths {
+		path = home.Long(path)
+		if strings.HasPrefix(path, "$") {
+			if expanded, err := c.resolver.ResolveValue(path); err == nil {
+				path = expanded
+			}
+		}
+		dirs = append(dirs, path)
+	}
+	return dirs
+}
+
+// SkillEnabled reports whether the skill isn't disabled.
+func (c *Config) SkillEnabled(name string) bool {
+	return !slices.Contains(c.Options.DisabledSkills, name)
+}
+
+// SetSkillEnabled enables or disables the skill and saves the disabled skills
+// in the data config.
+func (c *Config) SetSkillEnabled(name string, enabled bool) error {
+	disabled := slices.DeleteFunc(slices.Clone(c.Options.DisabledSkills), func(s string) bool {
+		return s == name
+	})
+	if !enabled {
+		disabled = append(disabled, name)
+	}
+	c.Options.DisabledSkills = disabled
+	return c.SetConfigField("options.disabled_skills", disabled)
+}
+
 func (c *Config) EnabledProviders() []ProviderConfig {
 	var enabled []ProviderConfig
 	for p := range c.Providers.Seq() {
@@ -714,13 +920,23 @@ func AllToolNames() []string {
 		"multiedit",
 		"lsp_diagnostics",
 		"lsp_references",
+		"lsp_definition",
+		"lsp_implementation",
+		"lsp_hover",
+		"lsp_symbols",
+		"lsp_rename",
+		"lsp_code_action",
 		"lsp_restart",
+		"mcp_list_resources",
+		"mcp_read_resource",
 		"fetch",
 		"agentic_fetch",
 		"glob",
 		"grep",
+		"code_search",
 		"ls",
 		"sourcegraph",
+		"skill",
 		"todos",
 		"view",
 		"write",
@@ -736,7 +952,7 @@ func resolveAllowedTools(allTools []string, disabledTools []string) []string {
 }
 
 func resolveReadOnlyTools(tools []string) []string {
-	readOnlyTools := []string{"glob", "grep", "ls", "sourcegraph", "view"}
+	readOnlyTools := []string{"code_search", "glob", "grep", "ls", "skill", "sourcegraph", "view"}
 	// filter to only include tools that are in allowedtools (include mode)
 	return filterSlice(tools, readOnlyTools, true)
 }
@@ -764,6 +980,9 @@ func (c *Config) SetupAgents() {
 			Model:        SelectedModelTypeLarge,
 			ContextPaths: c.Options.ContextPaths,
 			AllowedTools: allowedTools,
+			MaxSteps:     c.Options.MaxSteps,
+			MaxCostUSD:   c.Options.MaxCostUSD,
+			Compaction:   c.Options.Compaction[AgentCoder],
 		},
 
 		AgentTask: {
@@ -773,6 +992,9 @@ func (c *Config) SetupAgents() {
 			Model:        SelectedModelTypeLarge,
 			ContextPaths: c.Options.ContextPaths,
 			AllowedTools: resolveReadOnlyTools(allowedTools),
+			MaxSteps:     c.Options.MaxSteps,
+			MaxCostUSD:   c.Options.MaxCostUSD,
+			Compaction:   c.Options.Compaction[AgentTask],
 			// NO MCPs or LSPs by default
 			AllowedMCP: map[string][]string{},
 		},
@@ -23,6 +23,7 @@ import (
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/home"
 	"github.com/charmbracelet/crush/internal/log"
+	"github.com/charmbracelet/crush/internal/permission"
 	powernapConfig "github.com/charmbracelet/x/powernap/pkg/config"
 	"github.com/qjebbs/go-jsons"
 )
@@ -38,6 +39,12 @@ func Load(workingDir, dataDir string, debug bool) (*Config, error) {
 		return nil, fmt.Errorf("failed to load config from paths %v: %w", configPaths, err)
 	}
 
+	if cfg.Permissions != nil {
+		if err := permission.ValidateRules(cfg.Permissions.Rules); err != nil {
+			return nil, fmt.Errorf("invalid permission rules: %w", err)
+		}
+	}
+
 	cfg.dataConfigDir = GlobalConfigData()
 
 	cfg.setDefaults(workingDir, dataDir)
@@ -377,7 +384,7 @@ func (c *Config) setDefaults(workingDir, dataDir string) {
 	c.Options.ContextPaths = slices.Compact(c.Options.ContextPaths)
 
 	// Add the default skills directories if not already present.
-	for _, dir := range GlobalSkillsDirs() {
+	for _, dir := range append(GlobalSkillsDirs(), ProjectSkillsDir(workingDir)) {
 		if !slices.Contains(c.Options.SkillsPaths, dir) {
 			c.Options.SkillsPaths = append(c.Options.SkillsPaths, dir)
 		}
@@ -766,6 +773,12 @@ func isInsideWorktree() bool {
 	return err == nil && strings.TrimSpace(string(bts)) == "true"
 }
 
+// ProjectSkillsDir returns the directory for the Agent Skills of the project
+// in workingDir.
+func ProjectSkillsDir(workingDir string) string {
+	return filepath.Join(workingDir, ".crush", "skills")
+}
+
 // GlobalSkillsDirs returns the default directories for Agent Skills.
 // Skills in these directories are auto-discovered and their files can be read
 // without permission prompts.
@@ -36,6 +36,17 @@ func TestConfig_LoadFromBytes(t *testing.T) {
 	require.Equal(t, "https://api.openai.com/v2", pc.BaseURL)
 }
 
+func TestConfig_LoadPermissionGrants(t *testing.T) {
+	data := []byte(`{"permissions": {"grants": [{"id": "g1", "tool_name": "edit", "action": "write", "path": "internal", "created_at": 1}]}}`)
+
+	loadedConfig, err := loadFromBytes([][]byte{data})
+
+	require.NoError(t, err)
+	require.Len(t, loadedConfig.Permissions.Grants, 1)
+	require.Equal(t, "internal", loadedConfig.Permissions.Grants[0].Path)
+	require.True(t, loadedConfig.Permissions.Grants[0].Project())
+}
+
 func TestConfig_setDefaults(t *testing.T) {
 	cfg := &Config{}
 
@@ -468,7 +479,7 @@ func TestConfig_setupAgentsWithNoDisabledTools(t *testing.T) {
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "grep", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"glob", "grep", "code_search", "ls", "sourcegraph", "skill", "view"}, taskAgent.AllowedTools)
 }
 
 func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
@@ -486,20 +497,22 @@ func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
 
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "todos", "view", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_definition", "lsp_implementation", "lsp_hover", "lsp_symbols", "lsp_rename", "lsp_code_action", "lsp_restart", "mcp_list_resources", "mcp_read_resource", "fetch", "agentic_fetch", "glob", "code_search", "ls", "sourcegraph", "skill", "todos", "view", "write"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
-	assert.Equal(t, []string{"glob", "ls", "sourcegraph", "view"}, taskAgent.AllowedTools)
+	assert.Equal(t, []string{"glob", "code_search", "ls", "sourcegraph", "skill", "view"}, taskAgent.AllowedTools)
 }
 
 func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg := &Config{
 		Options: &Options{
 			DisabledTools: []string{
+				"code_search",
 				"glob",
 				"grep",
 				"ls",
+				"skill",
 				"sourcegraph",
 				"view",
 			},
@@ -509,13 +522,30 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg.SetupAgents()
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_restart", "fetch", "agentic_fetch", "todos", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "lsp_definition", "lsp_implementation", "lsp_hover", "lsp_symbols", "lsp_rename", "lsp_code_action", "lsp_restart", "mcp_list_resources", "mcp_read_resource", "fetch", "agentic_fetch", "todos", "write"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
 	assert.Equal(t, []string{}, taskAgent.AllowedTools)
 }
 
+func TestConfig_setupAgentsWithBudget(t *testing.T) {
+	cfg := &Config{
+		Options: &Options{
+			MaxSteps:   25,
+			MaxCostUSD: 1.5,
+		},
+	}
+
+	cfg.SetupAgents()
+	for _, name := range []string{AgentCoder, AgentTask} {
+		agent, ok := cfg.Agents[name]
+		require.True(t, ok)
+		assert.Equal(t, 25, agent.MaxSteps)
+		assert.Equal(t, 1.5, agent.MaxCostUSD)
+	}
+}
+
 func TestConfig_configureProvidersWithDisabledProvider(t *testing.T) {
 	knownProviders := []catwalk.Provider{
 		{
@@ -1458,3 +1488,24 @@ func TestConfig_configureSelectedModels(t *testing.T) {
 		require.Equal(t, int64(100), large.MaxTokens)
 	})
 }
+
+func TestConfig_skills(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("CRUSH_SKILLS_DIR", filepath.Join(dir, "user"))
+	cfg := &Config{}
+	cfg.setDefaults(dir, "")
+	cfg.dataConfigDir = filepath.Join(dir, "crush.json")
+
+	require.Equal(t, []string{filepath.Join(dir, "user"), filepath.Join(dir, ".crush", "skills")}, cfg.SkillsDirs())
+
+	require.True(t, cfg.SkillEnabled("pdf"))
+	require.NoError(t, cfg.SetSkillEnabled("pdf", false))
+	require.NoError(t, cfg.SetSkillEnabled("xlsx", false))
+	require.False(t, cfg.SkillEnabled("pdf"))
+	require.NoError(t, cfg.SetSkillEnabled("pdf", true))
+	require.True(t, cfg.SkillEnabled("pdf"))
+
+	data, err := os.ReadFile(cfg.dataConfigDir)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"options": {"disabled_skills": ["xlsx"]}}`, string(data))
+}
@@ -24,21 +24,33 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.createCheckpointStmt, err = db.PrepareContext(ctx, createCheckpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateCheckpoint: %w", err)
+	}
 	if q.createFileStmt, err = db.PrepareContext(ctx, createFile); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateFile: %w", err)
 	}
 	if q.createMessageStmt, err = db.PrepareContext(ctx, createMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateMessage: %w", err)
 	}
+	if q.createPermissionGrantStmt, err = db.PrepareContext(ctx, createPermissionGrant); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePermissionGrant: %w", err)
+	}
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
+	if q.createToolOutputStmt, err = db.PrepareContext(ctx, createToolOutput); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateToolOutput: %w", err)
+	}
 	if q.deleteFileStmt, err = db.PrepareContext(ctx, deleteFile); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteFile: %w", err)
 	}
 	if q.deleteMessageStmt, err = db.PrepareContext(ctx, deleteMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteMessage: %w", err)
 	}
+	if q.deletePermissionGrantStmt, err = db.PrepareContext(ctx, deletePermissionGrant); err != nil {
+		return nil, fmt.Errorf("error preparing query DeletePermissionGrant: %w", err)
+	}
 	if q.deleteSessionStmt, err = db.PrepareContext(ctx, deleteSession); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSession: %w", err)
 	}
@@ -48,9 +60,15 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteSessionMessagesStmt, err = db.PrepareContext(ctx, deleteSessionMessages); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteSessionMessages: %w", err)
 	}
+	if q.deleteToolOutputStmt, err = db.PrepareContext(ctx, deleteToolOutput); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteToolOutput: %w", err)
+	}
 	if q.getAverageResponseTimeStmt, err = db.PrepareContext(ctx, getAverageResponseTime); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAverageResponseTime: %w", err)
 	}
+	if q.getCheckpointStmt, err = db.PrepareContext(ctx, getCheckpoint); err != nil {
+		return nil, fmt.Errorf("error preparing query GetCheckpoint: %w", err)
+	}
 	if q.getFileStmt, err = db.PrepareContext(ctx, getFile); err != nil {
 		return nil, fmt.Errorf("error preparing query GetFile: %w", err)
 	}
@@ -69,6 +87,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.getSessionByIDStmt, err = db.PrepareContext(ctx, getSessionByID); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSessionByID: %w", err)
 	}
+	if q.getToolOutputStmt, err = db.PrepareContext(ctx, getToolOutput); err != nil {
+		return nil, fmt.Errorf("error preparing query GetToolOutput: %w", err)
+	}
 	if q.getToolUsageStmt, err = db.PrepareContext(ctx, getToolUsage); err != nil {
 		return nil, fmt.Errorf("error preparing query GetToolUsage: %w", err)
 	}
@@ -96,6 +117,9 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.listFilesBySessionStmt, err = db.PrepareContext(ctx, listFilesBySession); err != nil {
 		return nil, fmt.Errorf("error preparing query ListFilesBySession: %w", err)
 	}
+	if q.listFilesBySessionTreeStmt, err = db.PrepareContext(ctx, listFilesBySessionTree); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFilesBySessionTree: %w", err)
+	}
 	if q.listLatestSessionFilesStmt, err = db.PrepareContext(ctx, listLatestSessionFiles); err != nil {
 		return nil, fmt.Errorf("error preparing query ListLatestSessionFiles: %w", err)
 	}
@@ -105,12 +129,24 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.listNewFilesStmt, err = db.PrepareContext(ctx, listNewFiles); err != nil {
 		return nil, fmt.Errorf("error preparing query ListNewFiles: %w", err)
 	}
+	if q.listPermissionGrantsStmt, err = db.PrepareContext(ctx, listPermissionGrants); err != nil {
+		return nil, fmt.Errorf("error preparing query ListPermissionGrants: %w", err)
+	}
+	if q.listSessionPermissionGrantsStmt, err = db.PrepareContext(ctx, listSessionPermissionGrants); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSessionPermissionGrants: %w", err)
+	}
 	if q.listSessionsStmt, err = db.PrepareContext(ctx, listSessions); err != nil {
 		return nil, fmt.Errorf("error preparing query ListSessions: %w", err)
 	}
+	if q.listToolOutputSizesStmt, err = db.PrepareContext(ctx, listToolOutputSizes); err != nil {
+		return nil, fmt.Errorf("error preparing query ListToolOutputSizes: %w", err)
+	}
 	if q.listUserMessagesBySessionStmt, err = db.PrepareContext(ctx, listUserMessagesBySession); err != nil {
 		return nil, fmt.Errorf("error preparing query ListUserMessagesBySession: %w", err)
 	}
+	if q.searchMessagesStmt, err = db.PrepareContext(ctx, searchMessages); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchMessages: %w", err)
+	}
 	if q.updateMessageStmt, err = db.PrepareContext(ctx, updateMessage); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateMessage: %w", err)
 	}
@@ -125,6 +161,11 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 
 func (q *Queries) Close() error {
 	var err error
+	if q.createCheckpointStmt != nil {
+		if cerr := q.createCheckpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createCheckpointStmt: %w", cerr)
+		}
+	}
 	if q.createFileStmt != nil {
 		if cerr := q.createFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createFileStmt: %w", cerr)
@@ -135,11 +176,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createMessageStmt: %w", cerr)
 		}
 	}
+	if q.createPermissionGrantStmt != nil {
+		if cerr := q.createPermissionGrantStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPermissionGrantStmt: %w", cerr)
+		}
+	}
 	if q.createSessionStmt != nil {
 		if cerr := q.createSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
 		}
 	}
+	if q.createToolOutputStmt != nil {
+		if cerr := q.createToolOutputStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createToolOutputStmt: %w", cerr)
+		}
+	}
 	if q.deleteFileStmt != nil {
 		if cerr := q.deleteFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteFileStmt: %w", cerr)
@@ -150,6 +201,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteMessageStmt: %w", cerr)
 		}
 	}
+	if q.deletePermissionGrantStmt != nil {
+		if cerr := q.deletePermissionGrantStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deletePermissionGrantStmt: %w", cerr)
+		}
+	}
 	if q.deleteSessionStmt != nil {
 		if cerr := q.deleteSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteSessionStmt: %w", cerr)
@@ -165,11 +221,21 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteSessionMessagesStmt: %w", cerr)
 		}
 	}
+	if q.deleteToolOutputStmt != nil {
+		if cerr := q.deleteToolOutputStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteToolOutputStmt: %w", cerr)
+		}
+	}
 	if q.getAverageResponseTimeStmt != nil {
 		if cerr := q.getAverageResponseTimeStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAverageResponseTimeStmt: %w", cerr)
 		}
 	}
+	if q.getCheckpointStmt != nil {
+		if cerr := q.getCheckpointStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getCheckpointStmt: %w", cerr)
+		}
+	}
 	if q.getFileStmt != nil {
 		if cerr := q.getFileStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getFileStmt: %w", cerr)
@@ -200,6 +266,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getSessionByIDStmt: %w", cerr)
 		}
 	}
+	if q.getToolOutputStmt != nil {
+		if cerr := q.getToolOutputStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getToolOutputStmt: %w", cerr)
+		}
+	}
 	if q.getToolUsageStmt != nil {
 		if cerr := q.getToolUsageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getToolUsageStmt: %w", cerr)
@@ -245,6 +316,11 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listFilesBySessionStmt: %w", cerr)
 		}
 	}
+	if q.listFilesBySessionTreeStmt != nil {
+		if cerr := q.listFilesBySessionTreeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFilesBySessionTreeStmt: %w", cerr)
+		}
+	}
 	if q.listLatestSessionFilesStmt != nil {
 		if cerr := q.listLatestSessionFilesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listLatestSessionFilesStmt: %w", cerr)
@@ -260,16 +336,36 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing listNewFilesStmt: %w", cerr)
 		}
 	}
+	if q.listPermissionGrantsStmt != nil {
+		if cerr := q.listPermissionGrantsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listPermissionGrantsStmt: %w", cerr)
+		}
+	}
+	if q.listSessionPermissionGrantsStmt != nil {
+		if cerr := q.listSessionPermissionGrantsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSessionPermissionGrantsStmt: %w", cerr)
+		}
+	}
 	if q.listSessionsStmt != nil {
 		if cerr := q.listSessionsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listSessionsStmt: %w", cerr)
 		}
 	}
+	if q.listToolOutputSizesStmt != nil {
+		if cerr := q.listToolOutputSizesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listToolOutputSizesStmt: %w", cerr)
+		}
+	}
 	if q.listUserMessagesBySessionStmt != nil {
 		if cerr := q.listUserMessagesBySessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listUserMessagesBySessionStmt: %w", cerr)
 		}
 	}
+	if q.searchMessagesStmt != nil {
+		if cerr := q.searchMessagesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchMessagesStmt: %w", cerr)
+		}
+	}
 	if q.updateMessageStmt != nil {
 		if cerr := q.updateMessageStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateMessageStmt: %w", cerr)
@@ -322,77 +418,101 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                             DBTX
-	tx                             *sql.Tx
-	createFileStmt                 *sql.Stmt
-	createMessageStmt              *sql.Stmt
-	createSessionStmt              *sql.Stmt
-	deleteFileStmt                 *sql.Stmt
-	deleteMessageStmt              *sql.Stmt
-	deleteSessionStmt              *sql.Stmt
-	deleteSessionFilesStmt         *sql.Stmt
-	deleteSessionMessagesStmt      *sql.Stmt
-	getAverageResponseTimeStmt     *sql.Stmt
-	getFileStmt                    *sql.Stmt
-	getFileByPathAndSessionStmt    *sql.Stmt
-	getHourDayHeatmapStmt          *sql.Stmt
-	getMessageStmt                 *sql.Stmt
-	getRecentActivityStmt          *sql.Stmt
-	getSessionByIDStmt             *sql.Stmt
-	getToolUsageStmt               *sql.Stmt
-	getTotalStatsStmt              *sql.Stmt
-	getUsageByDayStmt              *sql.Stmt
-	getUsageByDayOfWeekStmt        *sql.Stmt
-	getUsageByHourStmt             *sql.Stmt
-	getUsageByModelStmt            *sql.Stmt
-	listAllUserMessagesStmt        *sql.Stmt
-	listFilesByPathStmt            *sql.Stmt
-	listFilesBySessionStmt         *sql.Stmt
-	listLatestSessionFilesStmt     *sql.Stmt
-	listMessagesBySessionStmt      *sql.Stmt
-	listNewFilesStmt               *sql.Stmt
-	listSessionsStmt               *sql.Stmt
-	listUserMessagesBySessionStmt  *sql.Stmt
-	updateMessageStmt              *sql.Stmt
-	updateSessionStmt              *sql.Stmt
-	updateSessionTitleAndUsageStmt *sql.Stmt
+	db                              DBTX
+	tx                              *sql.Tx
+	createCheckpointStmt            *sql.Stmt
+	createFileStmt                  *sql.Stmt
+	createMessageStmt               *sql.Stmt
+	createPermissionGrantStmt       *sql.Stmt
+	createSessionStmt               *sql.Stmt
+	createToolOutputStmt            *sql.Stmt
+	deleteFileStmt                  *sql.Stmt
+	deleteMessageStmt               *sql.Stmt
+	deletePermissionGrantStmt       *sql.Stmt
+	deleteSessionStmt               *sql.Stmt
+	deleteSessionFilesStmt          *sql.Stmt
+	deleteSessionMessagesStmt       *sql.Stmt
+	deleteToolOutputStmt            *sql.Stmt
+	getAverageResponseTimeStmt      *sql.Stmt
+	getCheckpointStmt               *sql.Stmt
+	getFileStmt                     *sql.Stmt
+	getFileByPathAndSessionStmt     *sql.Stmt
+	getHourDayHeatmapStmt           *sql.Stmt
+	getMessageStmt                  *sql.Stmt
+	getRecentActivityStmt           *sql.Stmt
+	getSessionByIDStmt              *sql.Stmt
+	getToolOutputStmt               *sql.Stmt
+	getToolUsageStmt                *sql.Stmt
+	getTotalStatsStmt               *sql.Stmt
+	getUsageByDayStmt               *sql.Stmt
+	getUsageByDayOfWeekStmt         *sql.Stmt
+	getUsageByHourStmt              *sql.Stmt
+	getUsageByModelStmt             *sql.Stmt
+	listAllUserMessagesStmt         *sql.Stmt
+	listFilesByPathStmt             *sql.Stmt
+	listFilesBySessionStmt          *sql.Stmt
+	listFilesBySessionTreeStmt      *sql.Stmt
+	listLatestSessionFilesStmt      *sql.Stmt
+	listMessagesBySessionStmt       *sql.Stmt
+	listNewFilesStmt                *sql.Stmt
+	listPermissionGrantsStmt        *sql.Stmt
+	listSessionPermissionGrantsStmt *sql.Stmt
+	listSessionsStmt                *sql.Stmt
+	listToolOutputSizesStmt         *sql.Stmt
+	listUserMessagesBySessionStmt   *sql.Stmt
+	searchMessagesStmt              *sql.Stmt
+	updateMessageStmt               *sql.Stmt
+	updateSessionStmt               *sql.Stmt
+	updateSessionTitleAndUsageStmt  *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                             tx,
-		tx:                             tx,
-		createFileStmt:                 q.createFileStmt,
-		createMessageStmt:              q.createMessageStmt,
-		createSessionStmt:              q.createSessionStmt,
-		deleteFileStmt:                 q.deleteFileStmt,
-		deleteMessageStmt:              q.deleteMessageStmt,
-		deleteSessionStmt:              q.deleteSessionStmt,
-		deleteSessionFilesStmt:         q.deleteSessionFilesStmt,
-		deleteSessionMessagesStmt:      q.deleteSessionMessagesStmt,
-		getAverageResponseTimeStmt:     q.getAverageResponseTimeStmt,
-		getFileStmt:                    q.getFileStmt,
-		getFileByPathAndSessionStmt:    q.getFileByPathAndSessionStmt,
-		getHourDayHeatmapStmt:          q.getHourDayHeatmapStmt,
-		getMessageStmt:                 q.getMessageStmt,
-		getRecentActivityStmt:          q.getRecentActivityStmt,
-		getSessionByIDStmt:             q.getSessionByIDStmt,
-		getToolUsageStmt:               q.getToolUsageStmt,
-		getTotalStatsStmt:              q.getTotalStatsStmt,
-		getUsageByDayStmt:              q.getUsageByDayStmt,
-		getUsageByDayOfWeekStmt:        q.getUsageByDayOfWeekStmt,
-		getUsageByHourStmt:             q.getUsageByHourStmt,
-		getUsageByModelStmt:            q.getUsageByModelStmt,
-		listAllUserMessagesStmt:        q.listAllUserMessagesStmt,
-		listFilesByPathStmt:            q.listFilesByPathStmt,
-		listFilesBySessionStmt:         q.listFilesBySessionStmt,
-		listLatestSessionFilesStmt:     q.listLatestSessionFilesStmt,
-		listMessagesBySessionStmt:      q.listMessagesBySessionStmt,
-		listNewFilesStmt:               q.listNewFilesStmt,
-		listSessionsStmt:               q.listSessionsStmt,
-		listUserMessagesBySessionStmt:  q.listUserMessagesBySessionStmt,
-		updateMessageStmt:              q.updateMessageStmt,
-		updateSessionStmt:              q.updateSessionStmt,
-		updateSessionTitleAndUsageStmt: q.updateSessionTitleAndUsageStmt,
+		db:                              tx,
+		tx:                              tx,
+		createCheckpointStmt:            q.createCheckpointStmt,
+		createFileStmt:                  q.createFileStmt,
+		createMessageStmt:               q.createMessageStmt,
+		createPermissionGrantStmt:       q.createPermissionGrantStmt,
+		createSessionStmt:               q.createSessionStmt,
+		createToolOutputStmt:            q.createToolOutputStmt,
+		deleteFileStmt:                  q.deleteFileStmt,
+		deleteMessageStmt:               q.deleteMessageStmt,
+		deletePermissionGrantStmt:       q.deletePermissionGrantStmt,
+		deleteSessionStmt:               q.deleteSessionStmt,
+		deleteSessionFilesStmt:          q.deleteSessionFilesStmt,
+		deleteSessionMessagesStmt:       q.deleteSessionMessagesStmt,
+		deleteToolOutputStmt:            q.deleteToolOutputStmt,
+		getAverageResponseTimeStmt:      q.getAverageResponseTimeStmt,
+		getCheckpointStmt:               q.getCheckpointStmt,
+		getFileStmt:                     q.getFileStmt,
+		getFileByPathAndSessionStmt:     q.getFileByPathAndSessionStmt,
+		getHourDayHeatmapStmt:           q.getHourDayHeatmapStmt,
+		getMessageStmt:                  q.getMessageStmt,
+		getRecentActivityStmt:           q.getRecentActivityStmt,
+		getSessionByIDStmt:              q.getSessionByIDStmt,
+		getToolOutputStmt:               q.getToolOutputStmt,
+		getToolUsageStmt:                q.getToolUsageStmt,
+		getTotalStatsStmt:               q.getTotalStatsStmt,
+		getUsageByDayStmt:               q.getUsageByDayStmt,
+		getUsageByDayOfWeekStmt:         q.getUsageByDayOfWeekStmt,
+		getUsageByHourStmt:              q.getUsageByHourStmt,
+		getUsageByModelStmt:             q.getUsageByModelStmt,
+		listAllUserMessagesStmt:         q.listAllUserMessagesStmt,
+		listFilesByPathStmt:             q.listFilesByPathStmt,
+		listFilesBySessionStmt:          q.listFilesBySessionStmt,
+		listFilesBySessionTreeStmt:      q.listFilesBySessionTreeStmt,
+		listLatestSessionFilesStmt:      q.listLatestSessionFilesStmt,
+		listMessagesBySessionStmt:       q.listMessagesBySessionStmt,
+		listNewFilesStmt:                q.listNewFilesStmt,
+		listPermissionGrantsStmt:        q.listPermissionGrantsStmt,
+		listSessionPermissionGrantsStmt: q.listSessionPermissionGrantsStmt,
+		listSessionsStmt:                q.listSessionsStmt,
+		listToolOutputSizesStmt:         q.listToolOutputSizesStmt,
+		listUserMessagesBySessionStmt:   q.listUserMessagesBySessionStmt,
+		searchMessagesStmt:              q.searchMessagesStmt,
+		updateMessageStmt:               q.updateMessageStmt,
+		updateSessionStmt:               q.updateSessionStmt,
+		updateSessionTitleAndUsageStmt:  q.updateSessionTitleAndUsageStmt,
 	}
 }
@@ -16,12 +16,13 @@ INSERT INTO files (
     path,
     content,
     version,
+    is_new,
     created_at,
     updated_at
 ) VALUES (
-    ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
 )
-RETURNING id, session_id, path, content, version, created_at, updated_at
+RETURNING id, session_id, path, content, version, created_at, updated_at, is_new
 `
 
 type CreateFileParams struct {
@@ -30,6 +31,7 @@ type CreateFileParams struct {
 	Path      string `json:"path"`
 	Content   string `json:"content"`
 	Version   int64  `json:"version"`
+	IsNew     bool   `json:"is_new"`
 }
 
 func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, error) {
@@ -39,6 +41,7 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		arg.Path,
 		arg.Content,
 		arg.Version,
+		arg.IsNew,
 	)
 	var i File
 	err := row.Scan(
@@ -49,6 +52,7 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsNew,
 	)
 	return i, err
 }
@@ -74,7 +78,7 @@ func (q *Queries) DeleteSessionFiles(ctx context.Context, sessionID string) erro
 }
 
 const getFile = `-- name: GetFile :one
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, is_new
 FROM files
 WHERE id = ? LIMIT 1
 `
@@ -90,12 +94,13 @@ func (q *Queries) GetFile(ctx context.Context, id string) (File, error) {
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsNew,
 	)
 	return i, err
 }
 
 const getFileByPathAndSession = `-- name: GetFileByPathAndSession :one
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, is_new
 FROM files
 WHERE path = ? AND session_id = ?
 ORDER BY version DESC, created_at DESC
@@ -118,12 +123,13 @@ func (q *Queries) GetFileByPathAndSession(ctx context.Context, arg GetFileByPath
 		&i.Version,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsNew,
 	)
 	return i, err
 }
 
 const listFilesByPath = `-- name: ListFilesByPath :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, is_new
 FROM files
 WHERE path = ?
 ORDER BY version DESC, created_at DESC
@@ -146,6 +152,7 @@ func (q *Queries) ListFilesByPath(ctx context.Context, path string) ([]File, err
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
@@ -161,7 +168,7 @@ func (q *Queries) ListFilesByPath(ctx context.Context, path string) ([]File, err
 }
 
 const listFilesBySession = `-- name: ListFilesBySession :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, is_new
 FROM files
 WHERE session_id = ?
 ORDER BY version ASC, created_at ASC
@@ -184,6 +191,53 @@ func (q *Queries) ListFilesBySession(ctx context.Context, sessionID string) ([]F
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsNew,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFilesBySessionTree = `-- name: ListFilesBySessionTree :many
+WITH RECURSIVE tree(id) AS (
+    SELECT CAST(? AS TEXT)
+    UNION
+    SELECT s.id
+    FROM sessions s
+    INNER JOIN tree t ON s.parent_session_id = t.id
+)
+SELECT id, session_id, path, content, version, created_at, updated_at, is_new
+FROM files
+WHERE session_id IN (SELECT id FROM tree)
+ORDER BY version ASC, created_at ASC
+`
+
+func (q *Queries) ListFilesBySessionTree(ctx context.Context, sessionID string) ([]File, error) {
+	rows, err := q.query(ctx, q.listFilesBySessionTreeStmt, listFilesBySessionTree, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []File{}
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.Path,
+			&i.Content,
+			&i.Version,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
@@ -199,7 +253,7 @@ func (q *Queries) ListFilesBySession(ctx context.Context, sessionID string) ([]F
 }
 
 const listLatestSessionFiles = `-- name: ListLatestSessionFiles :many
-SELECT f.id, f.session_id, f.path, f.content, f.version, f.created_at, f.updated_at
+SELECT f.id, f.session_id, f.path, f.content, f.version, f.created_at, f.updated_at, f.is_new
 FROM files f
 INNER JOIN (
     SELECT path, MAX(version) as max_version, MAX(created_at) as max_created_at
@@ -227,6 +281,7 @@ func (q *Queries) ListLatestSessionFiles(ctx context.Context, sessionID string)
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
@@ -242,7 +297,7 @@ func (q *Queries) ListLatestSessionFiles(ctx context.Context, sessionID string)
 }
 
 const listNewFiles = `-- name: ListNewFiles :many
-SELECT id, session_id, path, content, version, created_at, updated_at
+SELECT id, session_id, path, content, version, created_at, updated_at, is_new
 FROM files
 WHERE is_new = 1
 ORDER BY version DESC, created_at DESC
@@ -265,6 +320,7 @@ func (q *Queries) ListNewFiles(ctx context.Context) ([]File, error) {
 			&i.Version,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.IsNew,
 		); err != nil {
 			return nil, err
 		}
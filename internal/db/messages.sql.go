@@ -8,6 +8,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"strings"
 )
 
 const createMessage = `-- name: CreateMessage :one
@@ -73,6 +74,26 @@ func (q *Queries) DeleteMessage(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteMessages = `-- name: DeleteMessages :exec
+DELETE FROM messages
+WHERE id IN (/*SLICE:ids*/?)
+`
+
+func (q *Queries) DeleteMessages(ctx context.Context, ids []string) error {
+	query := deleteMessages
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	_, err := q.exec(ctx, nil, query, queryParams...)
+	return err
+}
+
 const deleteSessionMessages = `-- name: DeleteSessionMessages :exec
 DELETE FROM messages
 WHERE session_id = ?
@@ -249,3 +270,64 @@ func (q *Queries) UpdateMessage(ctx context.Context, arg UpdateMessageParams) er
 	_, err := q.exec(ctx, q.updateMessageStmt, updateMessage, arg.Parts, arg.FinishedAt, arg.ID)
 	return err
 }
+
+const searchMessages = `-- name: SearchMessages :many
+SELECT
+    m.id,
+    m.session_id,
+    m.role,
+    m.created_at,
+    s.title AS session_title,
+    CAST(snippet(messages_fts, 0, '', '', '…', 16) AS TEXT) AS snippet
+FROM messages_fts
+JOIN messages m ON m.rowid = messages_fts.rowid
+JOIN sessions s ON s.id = m.session_id
+WHERE messages_fts MATCH ?
+    AND s.parent_session_id IS NULL
+ORDER BY rank
+LIMIT ?
+`
+
+type SearchMessagesParams struct {
+	Query string `json:"query"`
+	Limit int64  `json:"limit"`
+}
+
+type SearchMessagesRow struct {
+	ID           string `json:"id"`
+	SessionID    string `json:"session_id"`
+	Role         string `json:"role"`
+	CreatedAt    int64  `json:"created_at"`
+	SessionTitle string `json:"session_title"`
+	Snippet      string `json:"snippet"`
+}
+
+func (q *Queries) SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error) {
+	rows, err := q.query(ctx, q.searchMessagesStmt, searchMessages, arg.Query, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchMessagesRow{}
+	for rows.Next() {
+		var i SearchMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.Role,
+			&i.CreatedAt,
+			&i.SessionTitle,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
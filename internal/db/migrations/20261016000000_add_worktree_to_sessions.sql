@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN worktree_path TEXT;
+ALTER TABLE sessions ADD COLUMN worktree_branch TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN worktree_branch;
+ALTER TABLE sessions DROP COLUMN worktree_path;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS checkpoints (
+    message_id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    files TEXT NOT NULL DEFAULT '{}',  -- JSON object of path to latest file version
+    created_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    FOREIGN KEY (message_id) REFERENCES messages (id) ON DELETE CASCADE,
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_checkpoints_session_id ON checkpoints (session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_checkpoints_session_id;
+DROP TABLE IF EXISTS checkpoints;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS tool_outputs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    tool_call_id TEXT NOT NULL,
+    output TEXT NOT NULL,
+    size INTEGER NOT NULL,  -- Size of the output in bytes
+    created_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    UNIQUE (session_id, tool_call_id),
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tool_outputs_session_id ON tool_outputs (session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_tool_outputs_session_id;
+DROP TABLE IF EXISTS tool_outputs;
+-- +goose StatementEnd
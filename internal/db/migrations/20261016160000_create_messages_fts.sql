@@ -0,0 +1,76 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Full-text index over the text of messages, the inputs of their tool calls
+-- and the results of those calls. Rows share their rowid with messages.
+CREATE VIRTUAL TABLE IF NOT EXISTS messages_fts USING fts5 (
+    content,
+    message_id UNINDEXED,
+    session_id UNINDEXED,
+    tokenize = 'porter unicode61'
+);
+
+INSERT INTO messages_fts (rowid, content, message_id, session_id)
+SELECT m.rowid, group_concat(p.text, char(10)), m.id, m.session_id
+FROM messages m
+JOIN (
+    SELECT messages.rowid AS message_rowid,
+        CASE json_extract(part.value, '$.type')
+            WHEN 'text' THEN json_extract(part.value, '$.data.text')
+            WHEN 'tool_call' THEN json_extract(part.value, '$.data.input')
+            WHEN 'tool_result' THEN json_extract(part.value, '$.data.content')
+        END AS text
+    FROM messages, json_each(messages.parts) AS part
+) p ON p.message_rowid = m.rowid
+WHERE p.text IS NOT NULL AND p.text != ''
+GROUP BY m.rowid;
+
+CREATE TRIGGER IF NOT EXISTS messages_fts_insert
+AFTER INSERT ON messages
+BEGIN
+    INSERT INTO messages_fts (rowid, content, message_id, session_id)
+    SELECT new.rowid, group_concat(text, char(10)), new.id, new.session_id
+    FROM (
+        SELECT CASE json_extract(value, '$.type')
+            WHEN 'text' THEN json_extract(value, '$.data.text')
+            WHEN 'tool_call' THEN json_extract(value, '$.data.input')
+            WHEN 'tool_result' THEN json_extract(value, '$.data.content')
+        END AS text
+        FROM json_each(new.parts)
+    )
+    WHERE text IS NOT NULL AND text != '';
+END;
+
+-- Assistant messages are updated on every streamed delta, so they're only
+-- reindexed once they're finished.
+CREATE TRIGGER IF NOT EXISTS messages_fts_update
+AFTER UPDATE OF parts ON messages
+WHEN new.finished_at IS NOT NULL OR new.role != 'assistant'
+BEGIN
+    DELETE FROM messages_fts WHERE rowid = old.rowid;
+    INSERT INTO messages_fts (rowid, content, message_id, session_id)
+    SELECT new.rowid, group_concat(text, char(10)), new.id, new.session_id
+    FROM (
+        SELECT CASE json_extract(value, '$.type')
+            WHEN 'text' THEN json_extract(value, '$.data.text')
+            WHEN 'tool_call' THEN json_extract(value, '$.data.input')
+            WHEN 'tool_result' THEN json_extract(value, '$.data.content')
+        END AS text
+        FROM json_each(new.parts)
+    )
+    WHERE text IS NOT NULL AND text != '';
+END;
+
+CREATE TRIGGER IF NOT EXISTS messages_fts_delete
+AFTER DELETE ON messages
+BEGIN
+    DELETE FROM messages_fts WHERE rowid = old.rowid;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS messages_fts_delete;
+DROP TRIGGER IF EXISTS messages_fts_update;
+DROP TRIGGER IF EXISTS messages_fts_insert;
+DROP TABLE IF EXISTS messages_fts;
+-- +goose StatementEnd
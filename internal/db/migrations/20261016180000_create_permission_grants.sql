@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS permission_grants (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    tool_name TEXT NOT NULL,
+    action TEXT NOT NULL,
+    path TEXT NOT NULL,
+    created_at INTEGER NOT NULL,  -- Unix timestamp in seconds
+    UNIQUE (session_id, tool_name, action, path),
+    FOREIGN KEY (session_id) REFERENCES sessions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_permission_grants_session_id ON permission_grants (session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_permission_grants_session_id;
+DROP TABLE IF EXISTS permission_grants;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN summary_kept_message_id TEXT;
+ALTER TABLE sessions ADD COLUMN elided_message_id TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN elided_message_id;
+ALTER TABLE sessions DROP COLUMN summary_kept_message_id;
+-- +goose StatementEnd
@@ -8,6 +8,13 @@ import (
 	"database/sql"
 )
 
+type Checkpoint struct {
+	MessageID string `json:"message_id"`
+	SessionID string `json:"session_id"`
+	Files     string `json:"files"`
+	CreatedAt int64  `json:"created_at"`
+}
+
 type File struct {
 	ID        string `json:"id"`
 	SessionID string `json:"session_id"`
@@ -16,6 +23,7 @@ type File struct {
 	Version   int64  `json:"version"`
 	CreatedAt int64  `json:"created_at"`
 	UpdatedAt int64  `json:"updated_at"`
+	IsNew     bool   `json:"is_new"`
 }
 
 type Message struct {
@@ -31,16 +39,38 @@ type Message struct {
 	IsSummaryMessage int64          `json:"is_summary_message"`
 }
 
+type PermissionGrant struct {
+	ID        string `json:"id"`
+	SessionID string `json:"session_id"`
+	ToolName  string `json:"tool_name"`
+	Action    string `json:"action"`
+	Path      string `json:"path"`
+	CreatedAt int64  `json:"created_at"`
+}
+
 type Session struct {
-	ID               string         `json:"id"`
-	ParentSessionID  sql.NullString `json:"parent_session_id"`
-	Title            string         `json:"title"`
-	MessageCount     int64          `json:"message_count"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	Cost             float64        `json:"cost"`
-	UpdatedAt        int64          `json:"updated_at"`
-	CreatedAt        int64          `json:"created_at"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
-	Todos            sql.NullString `json:"todos"`
+	ID                   string         `json:"id"`
+	ParentSessionID      sql.NullString `json:"parent_session_id"`
+	Title                string         `json:"title"`
+	MessageCount         int64          `json:"message_count"`
+	PromptTokens         int64          `json:"prompt_tokens"`
+	CompletionTokens     int64          `json:"completion_tokens"`
+	Cost                 float64        `json:"cost"`
+	UpdatedAt            int64          `json:"updated_at"`
+	CreatedAt            int64          `json:"created_at"`
+	SummaryMessageID     sql.NullString `json:"summary_message_id"`
+	Todos                sql.NullString `json:"todos"`
+	WorktreePath         sql.NullString `json:"worktree_path"`
+	WorktreeBranch       sql.NullString `json:"worktree_branch"`
+	SummaryKeptMessageID sql.NullString `json:"summary_kept_message_id"`
+	ElidedMessageID      sql.NullString `json:"elided_message_id"`
+}
+
+type ToolOutput struct {
+	ID         int64  `json:"id"`
+	SessionID  string `json:"session_id"`
+	ToolCallID string `json:"tool_call_id"`
+	Output     string `json:"output"`
+	Size       int64  `json:"size"`
+	CreatedAt  int64  `json:"created_at"`
 }
@@ -9,21 +9,29 @@ import (
 )
 
 type Querier interface {
+	CreateCheckpoint(ctx context.Context, arg CreateCheckpointParams) error
 	CreateFile(ctx context.Context, arg CreateFileParams) (File, error)
 	CreateMessage(ctx context.Context, arg CreateMessageParams) (Message, error)
+	CreatePermissionGrant(ctx context.Context, arg CreatePermissionGrantParams) error
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateToolOutput(ctx context.Context, arg CreateToolOutputParams) error
 	DeleteFile(ctx context.Context, id string) error
 	DeleteMessage(ctx context.Context, id string) error
+	DeleteMessages(ctx context.Context, ids []string) error
+	DeletePermissionGrant(ctx context.Context, id string) error
 	DeleteSession(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
+	DeleteToolOutput(ctx context.Context, id int64) error
 	GetAverageResponseTime(ctx context.Context) (int64, error)
+	GetCheckpoint(ctx context.Context, messageID string) (Checkpoint, error)
 	GetFile(ctx context.Context, id string) (File, error)
 	GetFileByPathAndSession(ctx context.Context, arg GetFileByPathAndSessionParams) (File, error)
 	GetHourDayHeatmap(ctx context.Context) ([]GetHourDayHeatmapRow, error)
 	GetMessage(ctx context.Context, id string) (Message, error)
 	GetRecentActivity(ctx context.Context) ([]GetRecentActivityRow, error)
 	GetSessionByID(ctx context.Context, id string) (Session, error)
+	GetToolOutput(ctx context.Context, arg GetToolOutputParams) (ToolOutput, error)
 	GetToolUsage(ctx context.Context) ([]GetToolUsageRow, error)
 	GetTotalStats(ctx context.Context) (GetTotalStatsRow, error)
 	GetUsageByDay(ctx context.Context) ([]GetUsageByDayRow, error)
@@ -33,11 +41,16 @@ type Querier interface {
 	ListAllUserMessages(ctx context.Context) ([]Message, error)
 	ListFilesByPath(ctx context.Context, path string) ([]File, error)
 	ListFilesBySession(ctx context.Context, sessionID string) ([]File, error)
+	ListFilesBySessionTree(ctx context.Context, sessionID string) ([]File, error)
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
 	ListMessagesBySession(ctx context.Context, sessionID string) ([]Message, error)
 	ListNewFiles(ctx context.Context) ([]File, error)
+	ListPermissionGrants(ctx context.Context) ([]PermissionGrant, error)
+	ListSessionPermissionGrants(ctx context.Context, sessionID string) ([]PermissionGrant, error)
 	ListSessions(ctx context.Context) ([]Session, error)
+	ListToolOutputSizes(ctx context.Context, sessionID string) ([]ListToolOutputSizesRow, error)
 	ListUserMessagesBySession(ctx context.Context, sessionID string) ([]Message, error)
+	SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error)
 	UpdateMessage(ctx context.Context, arg UpdateMessageParams) error
 	UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error)
 	UpdateSessionTitleAndUsage(ctx context.Context, arg UpdateSessionTitleAndUsageParams) error
@@ -33,7 +33,7 @@ INSERT INTO sessions (
     null,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, worktree_path, worktree_branch, summary_kept_message_id, elided_message_id
 `
 
 type CreateSessionParams struct {
@@ -69,6 +69,10 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.WorktreePath,
+		&i.WorktreeBranch,
+		&i.SummaryKeptMessageID,
+		&i.ElidedMessageID,
 	)
 	return i, err
 }
@@ -84,7 +88,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, worktree_path, worktree_branch, summary_kept_message_id, elided_message_id
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -104,12 +108,16 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.WorktreePath,
+		&i.WorktreeBranch,
+		&i.SummaryKeptMessageID,
+		&i.ElidedMessageID,
 	)
 	return i, err
 }
 
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, worktree_path, worktree_branch, summary_kept_message_id, elided_message_id
 FROM sessions
 WHERE parent_session_id is NULL
 ORDER BY updated_at DESC
@@ -136,6 +144,10 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.CreatedAt,
 			&i.SummaryMessageID,
 			&i.Todos,
+			&i.WorktreePath,
+			&i.WorktreeBranch,
+			&i.SummaryKeptMessageID,
+			&i.ElidedMessageID,
 		); err != nil {
 			return nil, err
 		}
@@ -158,19 +170,27 @@ SET
     completion_tokens = ?,
     summary_message_id = ?,
     cost = ?,
-    todos = ?
+    todos = ?,
+    worktree_path = ?,
+    worktree_branch = ?,
+    summary_kept_message_id = ?,
+    elided_message_id = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, worktree_path, worktree_branch, summary_kept_message_id, elided_message_id
 `
 
 type UpdateSessionParams struct {
-	Title            string         `json:"title"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
-	Cost             float64        `json:"cost"`
-	Todos            sql.NullString `json:"todos"`
-	ID               string         `json:"id"`
+	Title                string         `json:"title"`
+	PromptTokens         int64          `json:"prompt_tokens"`
+	CompletionTokens     int64          `json:"completion_tokens"`
+	SummaryMessageID     sql.NullString `json:"summary_message_id"`
+	Cost                 float64        `json:"cost"`
+	Todos                sql.NullString `json:"todos"`
+	WorktreePath         sql.NullString `json:"worktree_path"`
+	WorktreeBranch       sql.NullString `json:"worktree_branch"`
+	SummaryKeptMessageID sql.NullString `json:"summary_kept_message_id"`
+	ElidedMessageID      sql.NullString `json:"elided_message_id"`
+	ID                   string         `json:"id"`
 }
 
 func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error) {
@@ -181,6 +201,10 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		arg.SummaryMessageID,
 		arg.Cost,
 		arg.Todos,
+		arg.WorktreePath,
+		arg.WorktreeBranch,
+		arg.SummaryKeptMessageID,
+		arg.ElidedMessageID,
 		arg.ID,
 	)
 	var i Session
@@ -196,6 +220,10 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.WorktreePath,
+		&i.WorktreeBranch,
+		&i.SummaryKeptMessageID,
+		&i.ElidedMessageID,
 	)
 	return i, err
 }
@@ -0,0 +1,14 @@
+-- name: CreateCheckpoint :exec
+INSERT INTO checkpoints (
+    message_id,
+    session_id,
+    files,
+    created_at
+) VALUES (
+    ?, ?, ?, strftime('%s', 'now')
+);
+
+-- name: GetCheckpoint :one
+SELECT *
+FROM checkpoints
+WHERE message_id = ? LIMIT 1;
@@ -16,6 +16,19 @@ FROM files
 WHERE session_id = ?
 ORDER BY version ASC, created_at ASC;
 
+-- name: ListFilesBySessionTree :many
+WITH RECURSIVE tree(id) AS (
+    SELECT CAST(sqlc.arg(session_id) AS TEXT)
+    UNION
+    SELECT s.id
+    FROM sessions s
+    INNER JOIN tree t ON s.parent_session_id = t.id
+)
+SELECT *
+FROM files
+WHERE session_id IN (SELECT id FROM tree)
+ORDER BY version ASC, created_at ASC;
+
 -- name: ListFilesByPath :many
 SELECT *
 FROM files
@@ -29,10 +42,11 @@ INSERT INTO files (
     path,
     content,
     version,
+    is_new,
     created_at,
     updated_at
 ) VALUES (
-    ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
+    ?, ?, ?, ?, ?, ?, strftime('%s', 'now'), strftime('%s', 'now')
 )
 RETURNING *;
 
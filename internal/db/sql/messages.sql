@@ -38,6 +38,10 @@ WHERE id = ?;
 DELETE FROM messages
 WHERE id = ?;
 
+-- name: DeleteMessages :exec
+DELETE FROM messages
+WHERE id IN (sqlc.slice('ids'));
+
 -- name: DeleteSessionMessages :exec
 DELETE FROM messages
 WHERE session_id = ?;
@@ -53,3 +57,19 @@ SELECT *
 FROM messages
 WHERE role = 'user'
 ORDER BY created_at DESC;
+
+-- name: SearchMessages :many
+SELECT
+    m.id,
+    m.session_id,
+    m.role,
+    m.created_at,
+    s.title AS session_title,
+    CAST(snippet(messages_fts, 0, '', '', '…', 16) AS TEXT) AS snippet
+FROM messages_fts
+JOIN messages m ON m.rowid = messages_fts.rowid
+JOIN sessions s ON s.id = m.session_id
+WHERE messages_fts MATCH sqlc.arg(query)
+    AND s.parent_session_id IS NULL
+ORDER BY rank
+LIMIT sqlc.arg(limit);
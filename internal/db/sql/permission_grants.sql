@@ -0,0 +1,27 @@
+-- name: CreatePermissionGrant :exec
+INSERT INTO permission_grants (
+    id,
+    session_id,
+    tool_name,
+    action,
+    path,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, ?, strftime('%s', 'now')
+)
+ON CONFLICT (session_id, tool_name, action, path) DO NOTHING;
+
+-- name: ListPermissionGrants :many
+SELECT *
+FROM permission_grants
+ORDER BY created_at DESC;
+
+-- name: ListSessionPermissionGrants :many
+SELECT *
+FROM permission_grants
+WHERE session_id = ?
+ORDER BY created_at DESC;
+
+-- name: DeletePermissionGrant :exec
+DELETE FROM permission_grants
+WHERE id = ?;
@@ -42,7 +42,11 @@ SET
     completion_tokens = ?,
     summary_message_id = ?,
     cost = ?,
-    todos = ?
+    todos = ?,
+    worktree_path = ?,
+    worktree_branch = ?,
+    summary_kept_message_id = ?,
+    elided_message_id = ?
 WHERE id = ?
 RETURNING *;
 
@@ -0,0 +1,25 @@
+-- name: CreateToolOutput :exec
+INSERT OR REPLACE INTO tool_outputs (
+    session_id,
+    tool_call_id,
+    output,
+    size,
+    created_at
+) VALUES (
+    ?, ?, ?, ?, strftime('%s', 'now')
+);
+
+-- name: GetToolOutput :one
+SELECT *
+FROM tool_outputs
+WHERE session_id = ? AND tool_call_id = ? LIMIT 1;
+
+-- name: ListToolOutputSizes :many
+SELECT id, size
+FROM tool_outputs
+WHERE session_id = ?
+ORDER BY id DESC;
+
+-- name: DeleteToolOutput :exec
+DELETE FROM tool_outputs
+WHERE id = ?;
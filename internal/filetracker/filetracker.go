@@ -62,6 +62,20 @@ func RecordWrite(path string) {
 	records[path] = rec
 }
 
+// WrittenSince returns the paths of the files written after t.
+func WrittenSince(t time.Time) []string {
+	recordMutex.RLock()
+	defer recordMutex.RUnlock()
+
+	var paths []string
+	for path, rec := range records {
+		if rec.writeTime.After(t) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
 // Reset clears all file tracking records. Useful for testing.
 func Reset() {
 	recordMutex.Lock()
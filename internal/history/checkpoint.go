@@ -0,0 +1,140 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/charmbracelet/crush/internal/db"
+)
+
+// ErrNoCheckpoint is returned when reverting to a message that has no
+// checkpoint, such as messages created before checkpoints existed.
+var ErrNoCheckpoint = errors.New("no checkpoint for message")
+
+// Checkpoint records the latest version of every file the session, or one of
+// its subagent sessions, has changed so far, so the files can later be
+// reverted to this point. It's meant to be called when a user message is
+// created, before the agent acts on it.
+func (s *service) Checkpoint(ctx context.Context, sessionID, messageID string) error {
+	files, err := s.listSessionTree(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	versions := make(map[string]int64, len(files))
+	for _, file := range files {
+		if v, ok := versions[file.Path]; !ok || file.Version > v {
+			versions[file.Path] = file.Version
+		}
+	}
+	data, err := json.Marshal(versions)
+	if err != nil {
+		return err
+	}
+	return s.q.CreateCheckpoint(ctx, db.CreateCheckpointParams{
+		MessageID: messageID,
+		SessionID: sessionID,
+		Files:     string(data),
+	})
+}
+
+// Revert restores the files the session and its subagent sessions changed
+// after the checkpoint of the given message to the content they had at that
+// point, and drops the file versions recorded since. Files first created
+// after the checkpoint are deleted. It returns the paths that were restored
+// or deleted.
+func (s *service) Revert(ctx context.Context, sessionID, messageID string) ([]string, error) {
+	checkpoint, err := s.q.GetCheckpoint(ctx, messageID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && checkpoint.SessionID != sessionID) {
+		return nil, ErrNoCheckpoint
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions map[string]int64
+	if err := json.Unmarshal([]byte(checkpoint.Files), &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+
+	files, err := s.listSessionTree(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string][]File)
+	for _, file := range files {
+		byPath[file.Path] = append(byPath[file.Path], file)
+	}
+
+	var reverted []string
+	for path, fileVersions := range byPath {
+		// Versions are listed oldest first.
+		target := fileVersions[0]
+		version, existed := versions[path]
+		var stale []File
+		for _, file := range fileVersions {
+			switch {
+			case !existed:
+				stale = append(stale, file)
+			case file.Version == version && target.Version != version:
+				target = file
+			case file.Version > version:
+				stale = append(stale, file)
+			}
+		}
+		if len(stale) == 0 {
+			continue
+		}
+
+		// A file the session created is deleted rather than emptied.
+		created := !existed && target.IsNew
+		if err := restoreFile(path, target.Content, created); err != nil {
+			return reverted, fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+		for _, file := range stale {
+			if err := s.Delete(ctx, file.ID); err != nil {
+				return reverted, err
+			}
+		}
+		reverted = append(reverted, path)
+	}
+	slices.Sort(reverted)
+	return reverted, nil
+}
+
+// listSessionTree lists the file versions of the session and of its child
+// sessions, like the ones of subagents, oldest first.
+func (s *service) listSessionTree(ctx context.Context, sessionID string) ([]File, error) {
+	dbFiles, err := s.q.ListFilesBySessionTree(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]File, len(dbFiles))
+	for i, dbFile := range dbFiles {
+		files[i] = s.fromDBItem(dbFile)
+	}
+	return files, nil
+}
+
+// restoreFile writes content to path, or deletes path if remove is set.
+func restoreFile(path, content string, remove bool) error {
+	if remove {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	perm := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), perm)
+}
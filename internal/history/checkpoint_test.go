@@ -0,0 +1,180 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+type testEnv struct {
+	sessions session.Service
+	messages message.Service
+	files    Service
+}
+
+func newTestEnv(t *testing.T) testEnv {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	return testEnv{
+		sessions: session.NewService(q, conn),
+		messages: message.NewService(q),
+		files:    NewService(q, conn),
+	}
+}
+
+// turn creates a user message and checkpoints it, like the agent does.
+func (env testEnv) turn(t *testing.T, sessionID string) message.Message {
+	t.Helper()
+	msg, err := env.messages.Create(t.Context(), sessionID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "do it"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, env.files.Checkpoint(t.Context(), sessionID, msg.ID))
+	return msg
+}
+
+// write writes content to path and records it the way the edit tools do.
+func (env testEnv) write(t *testing.T, sessionID, path, content string) {
+	t.Helper()
+	old, readErr := os.ReadFile(path)
+	if readErr != nil && !os.IsNotExist(readErr) {
+		require.NoError(t, readErr)
+	}
+	if _, err := env.files.GetByPathAndSession(t.Context(), path, sessionID); err != nil {
+		if os.IsNotExist(readErr) {
+			_, err = env.files.CreateNew(t.Context(), sessionID, path)
+		} else {
+			_, err = env.files.Create(t.Context(), sessionID, path, string(old))
+		}
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	_, err := env.files.CreateVersion(t.Context(), sessionID, path, content)
+	require.NoError(t, err)
+}
+
+func TestRevert(t *testing.T) {
+	env := newTestEnv(t)
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.txt")
+	created := filepath.Join(dir, "created.txt")
+	require.NoError(t, os.WriteFile(existing, []byte("original\n"), 0o644))
+
+	sess, err := env.sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+
+	env.turn(t, sess.ID)
+	env.write(t, sess.ID, existing, "first turn\n")
+
+	second := env.turn(t, sess.ID)
+	env.write(t, sess.ID, existing, "second turn\n")
+	env.write(t, sess.ID, created, "new\n")
+
+	reverted, err := env.files.Revert(t.Context(), sess.ID, second.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{created, existing}, reverted)
+
+	content, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	require.Equal(t, "first turn\n", string(content))
+	require.NoFileExists(t, created)
+
+	// Versions recorded after the checkpoint are dropped.
+	latest, err := env.files.GetByPathAndSession(t.Context(), existing, sess.ID)
+	require.NoError(t, err)
+	require.Equal(t, "first turn\n", latest.Content)
+	_, err = env.files.GetByPathAndSession(t.Context(), created, sess.ID)
+	require.Error(t, err)
+}
+
+func TestRevertSubagentChanges(t *testing.T) {
+	env := newTestEnv(t)
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.txt")
+	created := filepath.Join(dir, "created.txt")
+	require.NoError(t, os.WriteFile(existing, []byte("original\n"), 0o644))
+
+	sess, err := env.sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+
+	env.turn(t, sess.ID)
+	env.write(t, sess.ID, existing, "first turn\n")
+
+	second := env.turn(t, sess.ID)
+	child, err := env.sessions.CreateTaskSession(t.Context(), "call", sess.ID, "subagent")
+	require.NoError(t, err)
+	env.write(t, child.ID, existing, "subagent\n")
+	env.write(t, child.ID, created, "new\n")
+
+	reverted, err := env.files.Revert(t.Context(), sess.ID, second.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{created, existing}, reverted)
+
+	content, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	require.Equal(t, "first turn\n", string(content))
+	require.NoFileExists(t, created)
+}
+
+func TestRevertKeepsEmptyFile(t *testing.T) {
+	env := newTestEnv(t)
+	path := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(path, nil, 0o644))
+
+	sess, err := env.sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+
+	first := env.turn(t, sess.ID)
+	env.write(t, sess.ID, path, "changed\n")
+
+	_, err = env.files.Revert(t.Context(), sess.ID, first.ID)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Empty(t, content)
+}
+
+func TestRevertToFirstTurn(t *testing.T) {
+	env := newTestEnv(t)
+	path := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("original\n"), 0o644))
+
+	sess, err := env.sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+
+	first := env.turn(t, sess.ID)
+	env.write(t, sess.ID, path, "changed\n")
+
+	_, err = env.files.Revert(t.Context(), sess.ID, first.ID)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "original\n", string(content))
+}
+
+func TestRevertWithoutCheckpoint(t *testing.T) {
+	env := newTestEnv(t)
+
+	sess, err := env.sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+	msg, err := env.messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "old"}},
+	})
+	require.NoError(t, err)
+
+	_, err = env.files.Revert(t.Context(), sess.ID, msg.ID)
+	require.ErrorIs(t, err, ErrNoCheckpoint)
+}
@@ -23,6 +23,9 @@ type File struct {
 	Version   int64
 	CreatedAt int64
 	UpdatedAt int64
+	// IsNew is set on the initial version of a file that didn't exist
+	// before the session created it.
+	IsNew bool
 }
 
 // Service manages file versions and history for sessions.
@@ -30,6 +33,10 @@ type Service interface {
 	pubsub.Subscriber[File]
 	Create(ctx context.Context, sessionID, path, content string) (File, error)
 
+	// CreateNew creates the initial, empty version of a file that doesn't
+	// exist yet.
+	CreateNew(ctx context.Context, sessionID, path string) (File, error)
+
 	// CreateVersion creates a new version of a file.
 	CreateVersion(ctx context.Context, sessionID, path, content string) (File, error)
 
@@ -39,6 +46,13 @@ type Service interface {
 	ListLatestSessionFiles(ctx context.Context, sessionID string) ([]File, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionFiles(ctx context.Context, sessionID string) error
+
+	// Checkpoint records the state of the session's files when the given
+	// user message was sent.
+	Checkpoint(ctx context.Context, sessionID, messageID string) error
+	// Revert restores the session's files to the checkpoint of the given
+	// user message.
+	Revert(ctx context.Context, sessionID, messageID string) ([]string, error)
 }
 
 type service struct {
@@ -56,7 +70,11 @@ func NewService(q *db.Queries, db *sql.DB) Service {
 }
 
 func (s *service) Create(ctx context.Context, sessionID, path, content string) (File, error) {
-	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion, false)
+}
+
+func (s *service) CreateNew(ctx context.Context, sessionID, path string) (File, error) {
+	return s.createWithVersion(ctx, sessionID, path, "", InitialVersion, true)
 }
 
 // CreateVersion creates a new version of a file with auto-incremented version
@@ -78,10 +96,10 @@ func (s *service) CreateVersion(ctx context.Context, sessionID, path, content st
 	latestFile := files[0] // Files are ordered by version DESC, created_at DESC
 	nextVersion := latestFile.Version + 1
 
-	return s.createWithVersion(ctx, sessionID, path, content, nextVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, nextVersion, false)
 }
 
-func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64) (File, error) {
+func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64, isNew bool) (File, error) {
 	// Maximum number of retries for transaction conflicts
 	const maxRetries = 3
 	var file File
@@ -105,6 +123,7 @@ func (s *service) createWithVersion(ctx context.Context, sessionID, path, conten
 			Path:      path,
 			Content:   content,
 			Version:   version,
+			IsNew:     isNew,
 		})
 		if txErr != nil {
 			// Rollback the transaction
@@ -213,5 +232,6 @@ func (s *service) fromDBItem(item db.File) File {
 		Version:   item.Version,
 		CreatedAt: item.CreatedAt,
 		UpdatedAt: item.UpdatedAt,
+		IsNew:     item.IsNew,
 	}
 }
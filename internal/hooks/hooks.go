@@ -0,0 +1,239 @@
+// Package hooks runs the user-configured shell commands attached to tool
+// calls and agent lifecycle events.
+//
+// A hook receives the event as JSON on stdin. Its exit code decides what
+// happens next:
+//
+//   - 0: the event proceeds. Stdout is added as context for the model, unless
+//     it's a JSON [Output] object, in which case that object is used instead.
+//   - 2: the event is blocked. Stderr, or stdout when stderr is empty, is the
+//     reason given to the model.
+//   - anything else: the hook failed. The failure is logged and the event
+//     proceeds.
+package hooks
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/charmbracelet/crush/internal/shell"
+)
+
+// DefaultTimeout is how long a hook may run when it doesn't set a timeout.
+const DefaultTimeout = 60 * time.Second
+
+// blockExitCode is the exit code a hook uses to block an event.
+const blockExitCode = 2
+
+// Event is a point in the agent lifecycle hooks can be attached to.
+type Event string
+
+const (
+	PreToolUse       Event = "pre_tool_use"
+	PostToolUse      Event = "post_tool_use"
+	UserPromptSubmit Event = "user_prompt_submit"
+	Stop             Event = "stop"
+	SessionStart     Event = "session_start"
+)
+
+// Input is the JSON written to a hook's stdin.
+type Input struct {
+	Event      Event  `json:"event"`
+	SessionID  string `json:"session_id"`
+	WorkingDir string `json:"cwd"`
+
+	// Set for tool events.
+	ToolName   string          `json:"tool_name,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+	ToolInput  json.RawMessage `json:"tool_input,omitempty"`
+
+	// Set for post_tool_use.
+	ToolResponse *ToolResponse `json:"tool_response,omitempty"`
+
+	// Set for user_prompt_submit.
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// ToolResponse is the result of a tool call as seen by post_tool_use hooks.
+type ToolResponse struct {
+	Content string `json:"content"`
+	IsError bool   `json:"is_error"`
+}
+
+// Output is the JSON object a hook may print to stdout for finer control
+// than the exit code gives.
+type Output struct {
+	// Decision is "block" to block the event. Any other value lets it
+	// proceed.
+	Decision string `json:"decision,omitempty"`
+	// Reason explains a blocked event to the model.
+	Reason string `json:"reason,omitempty"`
+	// Context is added for the model.
+	Context string `json:"context,omitempty"`
+	// ToolInput replaces the input of the tool call. Only used by
+	// pre_tool_use hooks.
+	ToolInput json.RawMessage `json:"tool_input,omitempty"`
+}
+
+// Result is the combined outcome of the hooks run for an event.
+type Result struct {
+	// Blocked reports whether a hook blocked the event.
+	Blocked bool
+	// Reason is why the event was blocked.
+	Reason string
+	// Context is the context the hooks added for the model, if any.
+	Context string
+	// ToolInput is the tool input after any rewrites. It's only set when a
+	// hook rewrote the input.
+	ToolInput string
+}
+
+// Runner runs the configured hooks.
+type Runner struct {
+	hooks      config.Hooks
+	workingDir string
+}
+
+// NewRunner creates a runner for the given hooks. Hooks run in workingDir
+// unless the event says otherwise.
+func NewRunner(hooks config.Hooks, workingDir string) *Runner {
+	return &Runner{hooks: hooks, workingDir: workingDir}
+}
+
+// Has reports whether any hook would run for the event. For tool events,
+// only hooks matching the tool are considered.
+func (r *Runner) Has(event Event, toolName string) bool {
+	return len(r.matching(event, toolName)) > 0
+}
+
+// Run runs the hooks for the event in order, stopping at the first one that
+// blocks it. A hook that rewrites the tool input passes the new input on to
+// the hooks after it. Hook failures are logged rather than returned; the
+// only error is the context's.
+func (r *Runner) Run(ctx context.Context, input Input) (Result, error) {
+	var (
+		result   Result
+		contexts []string
+	)
+	for _, hook := range r.matching(input.Event, input.ToolName) {
+		out, err := r.run(ctx, hook, input)
+		if err != nil {
+			if ctx.Err() != nil {
+				return Result{}, ctx.Err()
+			}
+			slog.Warn("Hook failed", "event", input.Event, "command", hook.Command, "error", err)
+			continue
+		}
+		if out.Context != "" {
+			contexts = append(contexts, out.Context)
+		}
+		if out.Decision == "block" {
+			result.Blocked = true
+			result.Reason = cmp.Or(out.Reason, "blocked by hook")
+			break
+		}
+		if len(out.ToolInput) > 0 && input.Event == PreToolUse {
+			input.ToolInput = out.ToolInput
+			result.ToolInput = string(out.ToolInput)
+		}
+	}
+	result.Context = strings.Join(contexts, "\n\n")
+	return result, nil
+}
+
+func (r *Runner) matching(event Event, toolName string) []config.Hook {
+	if r == nil {
+		return nil
+	}
+	var hooks []config.Hook
+	switch event {
+	case PreToolUse:
+		hooks = r.hooks.PreToolUse
+	case PostToolUse:
+		hooks = r.hooks.PostToolUse
+	case UserPromptSubmit:
+		hooks = r.hooks.UserPromptSubmit
+	case Stop:
+		hooks = r.hooks.Stop
+	case SessionStart:
+		hooks = r.hooks.SessionStart
+	}
+	if event != PreToolUse && event != PostToolUse {
+		return hooks
+	}
+	var matched []config.Hook
+	for _, hook := range hooks {
+		if Matches(hook.Matcher, toolName) {
+			matched = append(matched, hook)
+		}
+	}
+	return matched
+}
+
+// Matches reports whether a hook matcher applies to the tool. The matcher
+// is a glob, or several separated by |; an empty matcher matches every
+// tool.
+func Matches(matcher, toolName string) bool {
+	if matcher == "" {
+		return true
+	}
+	for pattern := range strings.SplitSeq(matcher, "|") {
+		if ok, _ := path.Match(strings.TrimSpace(pattern), toolName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) run(ctx context.Context, hook config.Hook, input Input) (Output, error) {
+	input.WorkingDir = cmp.Or(input.WorkingDir, r.workingDir)
+	data, err := json.Marshal(input)
+	if err != nil {
+		return Output{}, err
+	}
+
+	timeout := DefaultTimeout
+	if hook.Timeout > 0 {
+		timeout = time.Duration(hook.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sh := shell.NewShell(&shell.Options{
+		WorkingDir: input.WorkingDir,
+		Env: append(
+			os.Environ(),
+			"CRUSH_HOOK_EVENT="+string(input.Event),
+			"CRUSH_SESSION_ID="+input.SessionID,
+		),
+	})
+	stdout, stderr, err := sh.ExecWithStdin(ctx, hook.Command, bytes.NewReader(data))
+	stdout = strings.TrimSpace(stdout)
+	stderr = strings.TrimSpace(stderr)
+
+	switch code := shell.ExitCode(err); {
+	case err != nil && shell.IsInterrupt(err):
+		return Output{}, err
+	case code == blockExitCode:
+		return Output{Decision: "block", Reason: cmp.Or(stderr, stdout)}, nil
+	case err != nil:
+		return Output{}, fmt.Errorf("exit code %d: %s", code, cmp.Or(stderr, err.Error()))
+	}
+
+	if strings.HasPrefix(stdout, "{") {
+		var out Output
+		if err := json.Unmarshal([]byte(stdout), &out); err == nil {
+			return out, nil
+		}
+	}
+	return Output{Context: stdout}, nil
+}
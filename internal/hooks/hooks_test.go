@@ -0,0 +1,115 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		matcher string
+		tool    string
+		want    bool
+	}{
+		{"", "bash", true},
+		{"bash", "bash", true},
+		{"bash", "edit", false},
+		{"edit|write|multiedit", "write", true},
+		{"edit | write", "write", true},
+		{"mcp_*", "mcp_github_search", true},
+		{"mcp_*", "bash", false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, Matches(tt.matcher, tt.tool), "matcher %q, tool %q", tt.matcher, tt.tool)
+	}
+}
+
+func TestRunBlock(t *testing.T) {
+	t.Parallel()
+
+	runner := NewRunner(config.Hooks{
+		PreToolUse: []config.Hook{
+			{Matcher: "edit", Command: "echo never"},
+			{Matcher: "bash", Command: "echo 'no rm allowed' >&2; exit 2"},
+			{Matcher: "bash", Command: "echo unreachable"},
+		},
+	}, t.TempDir())
+
+	result, err := runner.Run(t.Context(), Input{Event: PreToolUse, ToolName: "bash"})
+	require.NoError(t, err)
+	require.True(t, result.Blocked)
+	require.Equal(t, "no rm allowed", result.Reason)
+	require.Empty(t, result.Context)
+}
+
+func TestRunContextAndRewrite(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	runner := NewRunner(config.Hooks{
+		PreToolUse: []config.Hook{
+			{Command: `echo '{"context":"rewritten","tool_input":{"command":"ls -la"}}'`},
+			{Command: "cat > input.json; echo checked"},
+		},
+	}, dir)
+
+	result, err := runner.Run(t.Context(), Input{
+		Event:     PreToolUse,
+		SessionID: "abc",
+		ToolName:  "bash",
+		ToolInput: json.RawMessage(`{"command":"ls"}`),
+	})
+	require.NoError(t, err)
+	require.False(t, result.Blocked)
+	require.Equal(t, "rewritten\n\nchecked", result.Context)
+	require.JSONEq(t, `{"command":"ls -la"}`, result.ToolInput)
+
+	// The second hook saw the rewritten input.
+	data, err := os.ReadFile(filepath.Join(dir, "input.json"))
+	require.NoError(t, err)
+	var input Input
+	require.NoError(t, json.Unmarshal(data, &input))
+	require.Equal(t, PreToolUse, input.Event)
+	require.Equal(t, "abc", input.SessionID)
+	require.Equal(t, dir, input.WorkingDir)
+	require.JSONEq(t, `{"command":"ls -la"}`, string(input.ToolInput))
+}
+
+func TestRunFailingHook(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	runner := NewRunner(config.Hooks{
+		Stop: []config.Hook{
+			{Command: "exit 1"},
+			{Command: "pwd > out.txt; echo $CRUSH_HOOK_EVENT $CRUSH_SESSION_ID"},
+		},
+	}, dir)
+
+	result, err := runner.Run(t.Context(), Input{Event: Stop, SessionID: "abc"})
+	require.NoError(t, err)
+	require.False(t, result.Blocked)
+	require.Equal(t, "stop abc", result.Context)
+
+	out, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	require.NoError(t, err)
+	require.Equal(t, dir, strings.TrimSpace(string(out)))
+}
+
+func TestRunNilRunner(t *testing.T) {
+	t.Parallel()
+
+	var runner *Runner
+	require.False(t, runner.Has(PreToolUse, "bash"))
+	result, err := runner.Run(t.Context(), Input{Event: PreToolUse, ToolName: "bash"})
+	require.NoError(t, err)
+	require.Equal(t, Result{}, result)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/charmbracelet/crush/internal/csync"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/home"
+	"github.com/charmbracelet/crush/internal/worktree"
 	powernapconfig "github.com/charmbracelet/x/powernap/pkg/config"
 	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
@@ -67,10 +68,12 @@ type Client struct {
 	serverState atomic.Value
 }
 
-// New creates a new LSP client using the powernap implementation.
-func New(ctx context.Context, name string, cfg config.LSPConfig, resolver config.VariableResolver) (*Client, error) {
+// New creates a new LSP client using the powernap implementation, scoped to
+// the given working directory.
+func New(ctx context.Context, name, workDir string, cfg config.LSPConfig, resolver config.VariableResolver) (*Client, error) {
 	client := &Client{
 		name:        name,
+		workDir:     workDir,
 		fileTypes:   cfg.FileTypes,
 		diagnostics: csync.NewVersionedMap[protocol.DocumentURI, []protocol.Diagnostic](),
 		openFiles:   csync.NewMap[string, *OpenFileInfo](),
@@ -132,13 +135,16 @@ func (c *Client) Close(ctx context.Context) error {
 
 // createPowernapClient creates a new powernap client with the current configuration.
 func (c *Client) createPowernapClient() error {
-	workDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+	if c.workDir == "" {
+		workDir, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get working directory: %w", err)
+		}
+		c.workDir = workDir
 	}
+	workDir := c.workDir
 
 	rootURI := string(protocol.URIFromPath(workDir))
-	c.workDir = workDir
 
 	command, err := c.resolver.ResolveValue(c.config.Command)
 	if err != nil {
@@ -255,6 +261,11 @@ func (c *Client) GetName() string {
 	return c.name
 }
 
+// Config returns the configuration the client was created with.
+func (c *Client) Config() config.LSPConfig {
+	return c.config
+}
+
 // SetDiagnosticsCallback sets the callback function for diagnostic changes
 func (c *Client) SetDiagnosticsCallback(callback func(name string, count int)) {
 	c.onDiagnosticsChanged = callback
@@ -325,6 +336,10 @@ func (c *Client) HandlesFile(path string) bool {
 		slog.Debug("file outside workspace", "name", c.name, "file", path, "workDir", c.workDir)
 		return false
 	}
+	if c.inOtherWorktree(absPath) {
+		slog.Debug("file in a session worktree", "name", c.name, "file", path, "workDir", c.workDir)
+		return false
+	}
 
 	// If no file types are specified, handle all files (backward compatibility).
 	if len(c.fileTypes) == 0 {
@@ -347,6 +362,22 @@ func (c *Client) HandlesFile(path string) bool {
 	return false
 }
 
+// inOtherWorktree reports whether path is inside a session worktree that
+// this client isn't rooted in. Worktrees live in the data directory, which is
+// usually inside the project, so the project's clients would otherwise claim
+// their files too.
+func (c *Client) inOtherWorktree(path string) bool {
+	cfg := config.Get()
+	if cfg == nil || cfg.Options == nil || cfg.Options.DataDirectory == "" {
+		return false
+	}
+	worktrees, err := filepath.Abs(worktree.Dir(cfg.Options.DataDirectory))
+	if err != nil {
+		return false
+	}
+	return fsext.HasPrefix(path, worktrees) && !fsext.HasPrefix(c.workDir, worktrees)
+}
+
 // OpenFile opens a file in the LSP server.
 func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 	if !c.HandlesFile(filepath) {
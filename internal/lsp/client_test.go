@@ -2,6 +2,7 @@ package lsp
 
 import (
 	"context"
+	"os"
 	"testing"
 
 	"github.com/charmbracelet/crush/internal/config"
@@ -21,7 +22,11 @@ func TestClient(t *testing.T) {
 
 	// Test creating a powernap client - this will likely fail with echo
 	// but we can still test the basic structure
-	client, err := New(ctx, "test", cfg, config.NewEnvironmentVariableResolver(env.NewFromMap(map[string]string{
+	workDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := New(ctx, "test", workDir, cfg, config.NewEnvironmentVariableResolver(env.NewFromMap(map[string]string{
 		"THE_CMD": "echo",
 	})))
 	if err != nil {
@@ -0,0 +1,260 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/charmbracelet/x/powernap/pkg/transport"
+)
+
+// call sends a request to the server. The powernap client only has methods
+// for a few requests, so the others go through its connection.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	if !c.client.IsInitialized() {
+		return fmt.Errorf("client not initialized")
+	}
+	field := reflect.ValueOf(c.client).Elem().FieldByName("conn")
+	conn := *(**transport.Connection)(unsafe.Pointer(field.UnsafeAddr()))
+	if conn == nil {
+		return fmt.Errorf("client not connected")
+	}
+	return conn.Call(ctx, method, params, result)
+}
+
+// position returns the text document position for the 1-based line and
+// character in the file.
+func position(filepath string, line, character int) protocol.TextDocumentPositionParams {
+	return protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{
+			URI: protocol.URIFromPath(filepath),
+		},
+		Position: protocol.Position{
+			Line:      uint32(line - 1),      //nolint:gosec
+			Character: uint32(character - 1), //nolint:gosec
+		},
+	}
+}
+
+// Definition returns the locations of the definition of the symbol at the
+// given position.
+func (c *Client) Definition(ctx context.Context, filepath string, line, character int) ([]protocol.Location, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	var result protocol.Or_Result_textDocument_definition
+	params := protocol.DefinitionParams{TextDocumentPositionParams: position(filepath, line, character)}
+	if err := c.call(ctx, powernap.MethodTextDocumentDefinition, params, &result); err != nil {
+		return nil, fmt.Errorf("definition request failed: %w", err)
+	}
+	return definitionLocations(result.Value), nil
+}
+
+// Implementation returns the locations of the implementations of the
+// interface or abstract method at the given position.
+func (c *Client) Implementation(ctx context.Context, filepath string, line, character int) ([]protocol.Location, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	var result protocol.Or_Result_textDocument_implementation
+	params := protocol.ImplementationParams{TextDocumentPositionParams: position(filepath, line, character)}
+	if err := c.call(ctx, "textDocument/implementation", params, &result); err != nil {
+		return nil, fmt.Errorf("implementation request failed: %w", err)
+	}
+	return definitionLocations(result.Value), nil
+}
+
+// definitionLocations flattens the locations and location links servers
+// answer definition and implementation requests with.
+func definitionLocations(value any) []protocol.Location {
+	switch v := value.(type) {
+	case protocol.Definition:
+		return definitionLocations(v.Value)
+	case protocol.Location:
+		return []protocol.Location{v}
+	case []protocol.Location:
+		return v
+	case []protocol.DefinitionLink:
+		locations := make([]protocol.Location, 0, len(v))
+		for _, link := range v {
+			locations = append(locations, protocol.Location{URI: link.TargetURI, Range: link.TargetSelectionRange})
+		}
+		return locations
+	}
+	return nil
+}
+
+// Hover returns the hover information, usually the type and documentation,
+// of the symbol at the given position.
+func (c *Client) Hover(ctx context.Context, filepath string, line, character int) (*protocol.Hover, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	pos := position(filepath, line, character)
+	return c.client.RequestHover(ctx, string(pos.TextDocument.URI), pos.Position)
+}
+
+// DocumentSymbols returns the symbols declared in the file.
+func (c *Client) DocumentSymbols(ctx context.Context, filepath string) ([]protocol.DocumentSymbolResult, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	var result protocol.Or_Result_textDocument_documentSymbol
+	params := protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+	}
+	if err := c.call(ctx, "textDocument/documentSymbol", params, &result); err != nil {
+		return nil, fmt.Errorf("document symbol request failed: %w", err)
+	}
+	return result.Results()
+}
+
+// WorkspaceSymbols returns the symbols of the workspace matching the query.
+func (c *Client) WorkspaceSymbols(ctx context.Context, query string) ([]protocol.WorkspaceSymbolResult, error) {
+	var result protocol.Or_Result_workspace_symbol
+	params := protocol.WorkspaceSymbolParams{Query: query}
+	if err := c.call(ctx, "workspace/symbol", params, &result); err != nil {
+		return nil, fmt.Errorf("workspace symbol request failed: %w", err)
+	}
+	return result.Results()
+}
+
+// PrepareRename returns the range of the symbol a rename at the given
+// position would change, or nil when there's nothing to rename there. It
+// returns [errors.ErrUnsupported] when the server can't tell the range.
+func (c *Client) PrepareRename(ctx context.Context, filepath string, line, character int) (*protocol.Range, error) {
+	options, _ := c.client.GetCapabilities().RenameProvider.(map[string]any)
+	if prepare, _ := options["prepareProvider"].(bool); !prepare {
+		return nil, errors.ErrUnsupported
+	}
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	params := protocol.PrepareRenameParams{
+		TextDocumentPositionParams: position(filepath, line, character),
+	}
+	var result protocol.PrepareRenameResult
+	if err := c.call(ctx, "textDocument/prepareRename", params, &result); err != nil {
+		return nil, fmt.Errorf("prepare rename request failed: %w", err)
+	}
+	switch v := result.Value.(type) {
+	case nil:
+		return nil, nil
+	case protocol.Range:
+		return &v, nil
+	case protocol.PrepareRenamePlaceholder:
+		return &v.Range, nil
+	default:
+		return nil, errors.ErrUnsupported
+	}
+}
+
+// Rename returns the edit renaming the symbol at the given position to
+// newName. It doesn't apply it.
+func (c *Client) Rename(ctx context.Context, filepath string, line, character int, newName string) (*protocol.WorkspaceEdit, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	pos := position(filepath, line, character)
+	params := protocol.RenameParams{
+		TextDocument: pos.TextDocument,
+		Position:     pos.Position,
+		NewName:      newName,
+	}
+	var result *protocol.WorkspaceEdit
+	if err := c.call(ctx, "textDocument/rename", params, &result); err != nil {
+		return nil, fmt.Errorf("rename request failed: %w", err)
+	}
+	return result, nil
+}
+
+// Format returns the edits formatting the file.
+func (c *Client) Format(ctx context.Context, filepath string) ([]protocol.TextEdit, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	params := protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Options:      formattingOptions(filepath),
+	}
+	var result []protocol.TextEdit
+	if err := c.call(ctx, "textDocument/formatting", params, &result); err != nil {
+		return nil, fmt.Errorf("formatting request failed: %w", err)
+	}
+	return result, nil
+}
+
+// formattingOptions returns the formatting options matching the indentation
+// of the file, as servers may use them when the project doesn't configure it.
+func formattingOptions(filepath string) protocol.FormattingOptions {
+	options := protocol.FormattingOptions{TabSize: 4, InsertSpaces: true}
+	content, err := os.ReadFile(filepath)
+	if err != nil {
+		return options
+	}
+	for line := range strings.SplitSeq(string(content), "\n") {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			options.InsertSpaces = false
+			return options
+		case strings.HasPrefix(line, "  "):
+			indent := len(line) - len(strings.TrimLeft(line, " "))
+			options.TabSize = uint32(min(indent, 8)) //nolint:gosec
+			return options
+		}
+	}
+	return options
+}
+
+// CodeActions returns the code actions for the range of the file and the
+// diagnostics in it, limited to the given kinds if any. Commands servers
+// answer with are returned as actions running them.
+func (c *Client) CodeActions(ctx context.Context, filepath string, rng protocol.Range, diagnostics []protocol.Diagnostic, kinds ...protocol.CodeActionKind) ([]protocol.CodeAction, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	if diagnostics == nil {
+		diagnostics = []protocol.Diagnostic{}
+	}
+	params := protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Range:        rng,
+		Context: protocol.CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        kinds,
+		},
+	}
+	var result []protocol.Or_Result_textDocument_codeAction_Item0_Elem
+	if err := c.call(ctx, "textDocument/codeAction", params, &result); err != nil {
+		return nil, fmt.Errorf("code action request failed: %w", err)
+	}
+	actions := make([]protocol.CodeAction, 0, len(result))
+	for _, item := range result {
+		switch v := item.Value.(type) {
+		case protocol.CodeAction:
+			actions = append(actions, v)
+		case protocol.Command:
+			actions = append(actions, protocol.CodeAction{Title: v.Title, Command: &v})
+		}
+	}
+	return actions, nil
+}
+
+// ResolveCodeAction fills in the edit of a code action servers compute
+// lazily. Actions that already have one are returned as they are.
+func (c *Client) ResolveCodeAction(ctx context.Context, action protocol.CodeAction) (protocol.CodeAction, error) {
+	if action.Edit != nil || action.Data == nil {
+		return action, nil
+	}
+	var result protocol.CodeAction
+	if err := c.call(ctx, "codeAction/resolve", action, &result); err != nil {
+		return action, fmt.Errorf("code action resolve request failed: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,34 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormattingOptions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	require.Equal(t,
+		protocol.FormattingOptions{TabSize: 4, InsertSpaces: false},
+		formattingOptions(write("main.go", "package main\n\nfunc main() {\n\tprintln()\n}\n")),
+	)
+	require.Equal(t,
+		protocol.FormattingOptions{TabSize: 2, InsertSpaces: true},
+		formattingOptions(write("index.js", "function main() {\n  console.log()\n}\n")),
+	)
+	require.Equal(t,
+		protocol.FormattingOptions{TabSize: 4, InsertSpaces: true},
+		formattingOptions(filepath.Join(dir, "missing.py")),
+	)
+}
@@ -22,6 +22,20 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	newContent, err := ApplyTextEdits(content, edits)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, newContent, 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyTextEdits returns the content with the edits applied.
+func ApplyTextEdits(content []byte, edits []protocol.TextEdit) ([]byte, error) {
 	// Detect line ending style
 	var lineEnding string
 	if bytes.Contains(content, []byte("\r\n")) {
@@ -40,7 +54,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 	for i, edit1 := range edits {
 		for j := i + 1; j < len(edits); j++ {
 			if rangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
+				return nil, fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
 			}
 		}
 	}
@@ -59,7 +73,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 	for _, edit := range sortedEdits {
 		newLines, err := applyTextEdit(lines, edit)
 		if err != nil {
-			return fmt.Errorf("failed to apply edit: %w", err)
+			return nil, fmt.Errorf("failed to apply edit: %w", err)
 		}
 		lines = newLines
 	}
@@ -78,11 +92,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := os.WriteFile(path, []byte(newContent.String()), 0o644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
+	return []byte(newContent.String()), nil
 }
 
 func applyTextEdit(lines []string, edit protocol.TextEdit) ([]string, error) {
@@ -0,0 +1,118 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Decisions the caller can make on a permission request.
+const (
+	decisionAllow        = "allow"
+	decisionAllowSession = "allow_session"
+	decisionAllowProject = "allow_project"
+	decisionDeny         = "deny"
+)
+
+// permissionSchema is the form of the permission elicitations.
+var permissionSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"decision": map[string]any{
+			"type":        "string",
+			"title":       "Decision",
+			"description": "allow once, allow for the rest of the session, allow for the project, or deny",
+			"enum":        []string{decisionAllow, decisionAllowSession, decisionAllowProject, decisionDeny},
+			"default":     decisionAllow,
+		},
+	},
+	"required": []string{"decision"},
+}
+
+// forwardPermissions asks the callers of the runs for the permissions the
+// agent requests, through elicitation. The configured permission rules and
+// --yolo still decide first, so only the requests that would prompt the user
+// get here. Requests are denied when the caller can't answer them.
+func (s *Server) forwardPermissions(ctx context.Context) {
+	for event := range s.app.Permissions.Subscribe(ctx) {
+		if event.Type != pubsub.CreatedEvent {
+			continue
+		}
+		go s.askPermission(ctx, event.Payload)
+	}
+}
+
+func (s *Server) askPermission(ctx context.Context, req permission.PermissionRequest) {
+	caller, ok := s.caller(ctx, req.SessionID)
+	if !ok {
+		slog.Warn("Denying permission request without a caller", "tool", req.ToolName, "session_id", req.SessionID)
+		s.app.Permissions.Deny(req)
+		return
+	}
+
+	result, err := caller.Elicit(ctx, &mcp.ElicitParams{
+		Message:         permissionMessage(req),
+		RequestedSchema: permissionSchema,
+	})
+	if err != nil {
+		slog.Warn("Denying permission request the caller couldn't answer", "tool", req.ToolName, "error", err)
+		s.app.Permissions.Deny(req)
+		return
+	}
+	if result.Action != "accept" {
+		s.app.Permissions.Deny(req)
+		return
+	}
+
+	decision, _ := result.Content["decision"].(string)
+	switch decision {
+	case decisionAllow:
+		s.app.Permissions.Grant(req)
+	case decisionAllowSession:
+		s.app.Permissions.GrantPersistent(req)
+	case decisionAllowProject:
+		s.app.Permissions.GrantForProject(req)
+	default:
+		s.app.Permissions.Deny(req)
+	}
+}
+
+// caller returns the MCP session that ran the prompt a permission request
+// belongs to, looking up the parents of the sessions of subagents.
+func (s *Server) caller(ctx context.Context, sessionID string) (*mcp.ServerSession, bool) {
+	for sessionID != "" {
+		if caller, ok := s.callers.Get(sessionID); ok {
+			return caller, true
+		}
+		sess, err := s.app.Sessions.Get(ctx, sessionID)
+		if err != nil {
+			return nil, false
+		}
+		sessionID = sess.ParentSessionID
+	}
+	return nil, false
+}
+
+func permissionMessage(req permission.PermissionRequest) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Crush wants to use the %s tool", req.ToolName)
+	if req.Action != "" {
+		fmt.Fprintf(&sb, " to %s", req.Action)
+	}
+	sb.WriteString(".")
+	if req.Description != "" {
+		sb.WriteString("\n\n" + req.Description)
+	}
+	if req.Path != "" {
+		sb.WriteString("\n\nPath: " + req.Path)
+	}
+	if req.Reason != "" {
+		sb.WriteString("\n\n" + req.Reason)
+	}
+	return sb.String()
+}
@@ -0,0 +1,41 @@
+package mcpserver
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		req  permission.PermissionRequest
+		want string
+	}{
+		{
+			name: "tool only",
+			req:  permission.PermissionRequest{ToolName: "fetch"},
+			want: "Crush wants to use the fetch tool.",
+		},
+		{
+			name: "full request",
+			req: permission.PermissionRequest{
+				ToolName:    "bash",
+				Action:      "execute",
+				Description: "Execute command: go test ./...",
+				Path:        "/project",
+				Reason:      "Asked by rule: ask bash",
+			},
+			want: "Crush wants to use the bash tool to execute.\n\nExecute command: go test ./...\n\nPath: /project\n\nAsked by rule: ask bash",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, permissionMessage(tt.req))
+		})
+	}
+}
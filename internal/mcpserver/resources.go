@@ -0,0 +1,102 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const sessionURIPrefix = "crush://sessions/"
+
+// sessionResource is the content of a session resource.
+type sessionResource struct {
+	Session  Session   `json:"session"`
+	Messages []Message `json:"messages"`
+}
+
+func sessionURI(id string) string {
+	return sessionURIPrefix + id
+}
+
+// addResources exposes the sessions as resources. The template lets clients
+// read the sessions created after they listed the resources.
+func (s *Server) addResources() {
+	s.server.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "session",
+		Title:       "Crush session",
+		Description: "A Crush session and its messages",
+		MIMEType:    "application/json",
+		URITemplate: sessionURIPrefix + "{id}",
+	}, s.readSession)
+}
+
+func (s *Server) addSession(sess session.Session) {
+	s.server.AddResource(&mcp.Resource{
+		Name:     sess.ID,
+		Title:    sess.Title,
+		MIMEType: "application/json",
+		URI:      sessionURI(sess.ID),
+	}, s.readSession)
+}
+
+// watchSessions adds the existing sessions as resources and keeps them in
+// sync with the sessions in the background.
+func (s *Server) watchSessions(ctx context.Context) {
+	events := s.app.Sessions.Subscribe(ctx)
+	sessions, err := s.app.Sessions.List(ctx)
+	if err != nil {
+		slog.Error("Failed to list sessions", "error", err)
+	}
+	for _, sess := range sessions {
+		s.addSession(sess)
+	}
+
+	go func() {
+		for event := range events {
+			sess := event.Payload
+			if sess.ParentSessionID != "" {
+				continue
+			}
+			switch event.Type {
+			case pubsub.CreatedEvent, pubsub.UpdatedEvent:
+				s.addSession(sess)
+			case pubsub.DeletedEvent:
+				s.server.RemoveResources(sessionURI(sess.ID))
+			}
+		}
+	}()
+}
+
+func (s *Server) readSession(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	id, ok := strings.CutPrefix(req.Params.URI, sessionURIPrefix)
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(req.Params.URI)
+	}
+	sess, err := s.app.Sessions.Get(ctx, id)
+	if err != nil {
+		return nil, mcp.ResourceNotFoundError(req.Params.URI)
+	}
+	messages, err := s.messages(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(sessionResource{
+		Session:  s.newSession(sess),
+		Messages: messages,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{
+			URI:      req.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(data),
+		}},
+	}, nil
+}
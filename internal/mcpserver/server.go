@@ -0,0 +1,117 @@
+// Package mcpserver exposes Crush as an MCP server, so that editors, other
+// agents and scripts can run prompts and inspect sessions over MCP.
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	mcpclient "github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/server"
+	"github.com/charmbracelet/crush/internal/version"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Server is the MCP server of a Crush app.
+type Server struct {
+	app    *app.App
+	server *mcp.Server
+
+	// callers holds the MCP session that last ran a prompt in each Crush
+	// session, which is asked for the permissions of the run.
+	callers *csync.Map[string, *mcp.ServerSession]
+
+	readyOnce sync.Once
+	readyErr  error
+}
+
+// New creates the MCP server of the app.
+func New(app *app.App) *Server {
+	s := &Server{
+		app: app,
+		server: mcp.NewServer(&mcp.Implementation{
+			Name:    "crush",
+			Title:   "Crush",
+			Version: version.Version,
+		}, &mcp.ServerOptions{
+			Instructions: "Crush is a coding agent working in " + app.Config().WorkingDir() + ". Use run_prompt to give it a task, in a new session or an existing one.",
+		}),
+		callers: csync.NewMap[string, *mcp.ServerSession](),
+	}
+	s.addTools()
+	s.addResources()
+	return s
+}
+
+// ServeStdio serves the MCP protocol over stdin and stdout until the client
+// disconnects or the context is done.
+func (s *Server) ServeStdio(ctx context.Context) error {
+	s.start(ctx)
+	return s.server.Run(ctx, &mcp.StdioTransport{})
+}
+
+// ServeHTTP serves the streamable HTTP transport of the MCP protocol on addr
+// until the context is done. Requests must carry token as a bearer token and
+// be sent to a loopback host or the host of addr, and browsers can't send
+// them from other origins.
+func (s *Server) ServeHTTP(ctx context.Context, addr, token string) error {
+	if token == "" {
+		return errors.New("serving MCP over HTTP requires a token")
+	}
+	s.start(ctx)
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           s.httpHandler(token, server.ListenHosts(addr)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("Serving MCP over HTTP", "addr", addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) httpHandler(token string, hosts []string) http.Handler {
+	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+		return s.server
+	}, nil)
+	return server.Protect(handler, token, hosts...)
+}
+
+// start keeps the session resources up to date and forwards the permission
+// requests to the callers.
+func (s *Server) start(ctx context.Context) {
+	s.watchSessions(ctx)
+	go s.forwardPermissions(ctx)
+}
+
+// ready waits for the MCP clients of Crush to be initialized, so that the
+// agent gets their tools.
+func (s *Server) ready(ctx context.Context) error {
+	s.readyOnce.Do(func() {
+		if err := mcpclient.WaitForInit(ctx); err != nil {
+			s.readyErr = fmt.Errorf("failed to wait for MCP initialization: %w", err)
+			return
+		}
+		if s.app.AgentCoordinator == nil {
+			s.readyErr = errors.New("no providers configured - please run 'crush' to set up a provider interactively")
+			return
+		}
+		s.readyErr = s.app.AgentCoordinator.UpdateModels(ctx)
+	})
+	return s.readyErr
+}
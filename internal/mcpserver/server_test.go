@@ -0,0 +1,58 @@
+package mcpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeHTTPRequiresToken(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{}
+	for _, addr := range []string{"127.0.0.1:8765", "0.0.0.0:8765"} {
+		err := s.ServeHTTP(t.Context(), addr, "")
+		require.ErrorContains(t, err, "requires a token", addr)
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	t.Parallel()
+
+	s := &Server{server: mcp.NewServer(&mcp.Implementation{Name: "crush"}, nil)}
+	handler := s.httpHandler("secret", nil)
+
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		auth   string
+		want   int
+	}{
+		{name: "missing token", host: "127.0.0.1:8765", want: http.StatusUnauthorized},
+		{name: "rebound host", host: "attacker.example:8765", auth: "Bearer secret", want: http.StatusForbidden},
+		{name: "cross origin", host: "127.0.0.1:8765", origin: "https://attacker.example", auth: "Bearer secret", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+			req.Host = tt.host
+			req.Header.Set("Content-Type", "application/json")
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, tt.want, rec.Code, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package mcpserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/charmbracelet/crush/internal/subagent"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Session is a Crush session as returned by the tools and resources.
+type Session struct {
+	ID             string  `json:"id"`
+	Title          string  `json:"title"`
+	MessageCount   int64   `json:"message_count"`
+	Cost           float64 `json:"cost"`
+	Busy           bool    `json:"busy"`
+	WorktreePath   string  `json:"worktree_path,omitempty"`
+	WorktreeBranch string  `json:"worktree_branch,omitempty"`
+	CreatedAt      int64   `json:"created_at"`
+	UpdatedAt      int64   `json:"updated_at"`
+}
+
+// Message is a message of a Crush session as returned by the tools and
+// resources.
+type Message struct {
+	ID          string       `json:"id"`
+	Role        string       `json:"role"`
+	Model       string       `json:"model,omitempty"`
+	Text        string       `json:"text,omitempty"`
+	ToolCalls   []ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults []ToolResult `json:"tool_results,omitempty"`
+	Finish      string       `json:"finish_reason,omitempty"`
+	CreatedAt   int64        `json:"created_at"`
+}
+
+// ToolCall is a tool call of an assistant message.
+type ToolCall struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Input string `json:"input"`
+}
+
+// ToolResult is the result of a tool call.
+type ToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	IsError    bool   `json:"is_error"`
+}
+
+// RunPromptParams are the parameters of the run_prompt tool.
+type RunPromptParams struct {
+	Prompt    string `json:"prompt" jsonschema:"The task for Crush to perform"`
+	SessionID string `json:"session_id,omitempty" jsonschema:"The ID of the session to continue. A new session is created when empty"`
+}
+
+// RunPromptResult is the result of the run_prompt tool.
+type RunPromptResult struct {
+	SessionID string `json:"session_id"`
+	Response  string `json:"response"`
+	// Queued is set when the session was busy and the prompt was queued
+	// after the current run.
+	Queued bool `json:"queued,omitempty"`
+}
+
+// ListSessionsResult is the result of the list_sessions tool.
+type ListSessionsResult struct {
+	Sessions []Session `json:"sessions"`
+}
+
+// SessionParams are the parameters of the tools about a session.
+type SessionParams struct {
+	SessionID string `json:"session_id" jsonschema:"The ID of the session"`
+}
+
+// GetSessionMessagesResult is the result of the get_session_messages tool.
+type GetSessionMessagesResult struct {
+	Messages []Message `json:"messages"`
+}
+
+// CancelResult is the result of the cancel tool. Cancelled is false when
+// the session wasn't running.
+type CancelResult struct {
+	Cancelled bool `json:"cancelled"`
+}
+
+// Subagent is a subagent the agent can delegate tasks to.
+type Subagent struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Model       string   `json:"model,omitempty"`
+	Tools       []string `json:"tools,omitempty"`
+}
+
+// ListSubagentsResult is the result of the list_subagents tool.
+type ListSubagentsResult struct {
+	Subagents []Subagent `json:"subagents"`
+}
+
+func (s *Server) addTools() {
+	mcp.AddTool(s.server, &mcp.Tool{
+		Name:        "run_prompt",
+		Description: "Run a prompt with the Crush agent and return its response. Pass session_id to continue a session, otherwise a new one is created. Permission requests of the run are sent back as elicitations.",
+	}, s.runPrompt)
+	mcp.AddTool(s.server, &mcp.Tool{
+		Name:        "list_sessions",
+		Description: "List the Crush sessions of the project, most recently updated first.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, s.listSessions)
+	mcp.AddTool(s.server, &mcp.Tool{
+		Name:        "get_session_messages",
+		Description: "Get the messages of a Crush session.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, s.getSessionMessages)
+	mcp.AddTool(s.server, &mcp.Tool{
+		Name:        "cancel",
+		Description: "Cancel the running prompt of a Crush session, and the prompts queued after it.",
+	}, s.cancel)
+	mcp.AddTool(s.server, &mcp.Tool{
+		Name:        "list_subagents",
+		Description: "List the subagents the Crush agent can delegate tasks to.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, s.listSubagents)
+}
+
+func (s *Server) runPrompt(ctx context.Context, req *mcp.CallToolRequest, params RunPromptParams) (*mcp.CallToolResult, RunPromptResult, error) {
+	if params.Prompt == "" {
+		return nil, RunPromptResult{}, errors.New("prompt is required")
+	}
+	if err := s.ready(ctx); err != nil {
+		return nil, RunPromptResult{}, err
+	}
+
+	sess, err := s.session(ctx, params)
+	if err != nil {
+		return nil, RunPromptResult{}, err
+	}
+	s.callers.Set(sess.ID, req.Session)
+
+	result, err := s.app.AgentCoordinator.Run(ctx, sess.ID, params.Prompt)
+	if err != nil {
+		return nil, RunPromptResult{}, fmt.Errorf("agent processing failed: %w", err)
+	}
+	if result == nil {
+		return nil, RunPromptResult{SessionID: sess.ID, Queued: true}, nil
+	}
+	return nil, RunPromptResult{
+		SessionID: sess.ID,
+		Response:  result.Response.Content.Text(),
+	}, nil
+}
+
+// session returns the session to run the prompt in, creating it when no
+// session is given.
+func (s *Server) session(ctx context.Context, params RunPromptParams) (session.Session, error) {
+	if params.SessionID != "" {
+		sess, err := s.getSession(ctx, params.SessionID)
+		if err != nil {
+			return session.Session{}, err
+		}
+		s.app.UseWorktree(sess)
+		return sess, nil
+	}
+
+	const maxPromptLengthForTitle = 100
+	title := params.Prompt
+	if len(title) > maxPromptLengthForTitle {
+		title = title[:maxPromptLengthForTitle] + "..."
+	}
+	sess, err := s.app.Sessions.Create(ctx, "MCP: "+title)
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to create session: %w", err)
+	}
+	return sess, nil
+}
+
+func (s *Server) listSessions(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, ListSessionsResult, error) {
+	sessions, err := s.app.Sessions.List(ctx)
+	if err != nil {
+		return nil, ListSessionsResult{}, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	result := ListSessionsResult{Sessions: make([]Session, 0, len(sessions))}
+	for _, sess := range sessions {
+		result.Sessions = append(result.Sessions, s.newSession(sess))
+	}
+	return nil, result, nil
+}
+
+func (s *Server) getSessionMessages(ctx context.Context, _ *mcp.CallToolRequest, params SessionParams) (*mcp.CallToolResult, GetSessionMessagesResult, error) {
+	if _, err := s.getSession(ctx, params.SessionID); err != nil {
+		return nil, GetSessionMessagesResult{}, err
+	}
+	messages, err := s.messages(ctx, params.SessionID)
+	if err != nil {
+		return nil, GetSessionMessagesResult{}, err
+	}
+	return nil, GetSessionMessagesResult{Messages: messages}, nil
+}
+
+func (s *Server) cancel(ctx context.Context, _ *mcp.CallToolRequest, params SessionParams) (*mcp.CallToolResult, CancelResult, error) {
+	if _, err := s.getSession(ctx, params.SessionID); err != nil {
+		return nil, CancelResult{}, err
+	}
+	if s.app.AgentCoordinator == nil || !s.app.AgentCoordinator.IsSessionBusy(params.SessionID) {
+		return nil, CancelResult{}, nil
+	}
+	s.app.AgentCoordinator.Cancel(params.SessionID)
+	return nil, CancelResult{Cancelled: true}, nil
+}
+
+func (s *Server) listSubagents(context.Context, *mcp.CallToolRequest, struct{}) (*mcp.CallToolResult, ListSubagentsResult, error) {
+	homeDir, _ := os.UserHomeDir()
+	subagents, err := subagent.Discover(subagent.DefaultDiscoveryPaths(homeDir, s.app.Config().WorkingDir()))
+	if err != nil {
+		return nil, ListSubagentsResult{}, fmt.Errorf("failed to discover subagents: %w", err)
+	}
+	result := ListSubagentsResult{Subagents: make([]Subagent, 0, len(subagents))}
+	for _, sa := range subagents {
+		result.Subagents = append(result.Subagents, Subagent{
+			Name:        sa.Name,
+			Description: sa.Description,
+			Model:       sa.Model,
+			Tools:       sa.Tools,
+		})
+	}
+	return nil, result, nil
+}
+
+func (s *Server) getSession(ctx context.Context, id string) (session.Session, error) {
+	if id == "" {
+		return session.Session{}, errors.New("session_id is required")
+	}
+	sess, err := s.app.Sessions.Get(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return session.Session{}, fmt.Errorf("session %q not found", id)
+	}
+	if err != nil {
+		return session.Session{}, fmt.Errorf("failed to get session %q: %w", id, err)
+	}
+	return sess, nil
+}
+
+func (s *Server) newSession(sess session.Session) Session {
+	return Session{
+		ID:             sess.ID,
+		Title:          sess.Title,
+		MessageCount:   sess.MessageCount,
+		Cost:           sess.Cost,
+		Busy:           s.app.AgentCoordinator != nil && s.app.AgentCoordinator.IsSessionBusy(sess.ID),
+		WorktreePath:   sess.WorktreePath,
+		WorktreeBranch: sess.WorktreeBranch,
+		CreatedAt:      sess.CreatedAt,
+		UpdatedAt:      sess.UpdatedAt,
+	}
+}
+
+func (s *Server) messages(ctx context.Context, sessionID string) ([]Message, error) {
+	msgs, err := s.app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session messages: %w", err)
+	}
+	messages := make([]Message, 0, len(msgs))
+	for _, msg := range msgs {
+		messages = append(messages, newMessage(msg))
+	}
+	return messages, nil
+}
+
+func newMessage(msg message.Message) Message {
+	m := Message{
+		ID:        msg.ID,
+		Role:      string(msg.Role),
+		Model:     msg.Model,
+		Text:      msg.Content().Text,
+		Finish:    string(msg.FinishReason()),
+		CreatedAt: msg.CreatedAt,
+	}
+	for _, tc := range msg.ToolCalls() {
+		m.ToolCalls = append(m.ToolCalls, ToolCall{ID: tc.ID, Name: tc.Name, Input: tc.Input})
+	}
+	for _, tr := range msg.ToolResults() {
+		m.ToolResults = append(m.ToolResults, ToolResult{
+			ToolCallID: tr.ToolCallID,
+			Name:       tr.Name,
+			Content:    tr.Content,
+			IsError:    tr.IsError,
+		})
+	}
+	return m
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
@@ -175,6 +176,20 @@ func (m *Message) BinaryContent() []BinaryContent {
 	return binaryContents
 }
 
+// Attachments returns the files attached to the message.
+func (m *Message) Attachments() []Attachment {
+	var attachments []Attachment
+	for _, content := range m.BinaryContent() {
+		attachments = append(attachments, Attachment{
+			FilePath: content.Path,
+			FileName: filepath.Base(content.Path),
+			MimeType: content.MIMEType,
+			Content:  content.Data,
+		})
+	}
+	return attachments
+}
+
 func (m *Message) ToolCalls() []ToolCall {
 	toolCalls := make([]ToolCall, 0)
 	for _, part := range m.Parts {
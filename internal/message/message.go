@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/crush/internal/db"
@@ -30,6 +32,23 @@ type Service interface {
 	ListAllUserMessages(ctx context.Context) ([]Message, error)
 	Delete(ctx context.Context, id string) error
 	DeleteSessionMessages(ctx context.Context, sessionID string) error
+	// Truncate deletes the given message and every message after it in the
+	// session.
+	Truncate(ctx context.Context, sessionID, messageID string) error
+	// Search finds the messages of top-level sessions whose text, tool call
+	// inputs or tool results match query, best matches first.
+	Search(ctx context.Context, query string, limit int) ([]SearchResult, error)
+}
+
+// SearchResult is a message matching a search.
+type SearchResult struct {
+	MessageID    string
+	SessionID    string
+	SessionTitle string
+	Role         MessageRole
+	// Snippet is the part of the message around the match.
+	Snippet   string
+	CreatedAt int64
 }
 
 type service struct {
@@ -111,6 +130,30 @@ func (s *service) DeleteSessionMessages(ctx context.Context, sessionID string) e
 	return nil
 }
 
+func (s *service) Truncate(ctx context.Context, sessionID, messageID string) error {
+	messages, err := s.List(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(messages, func(m Message) bool { return m.ID == messageID })
+	if idx < 0 {
+		return fmt.Errorf("message %q not found in session", messageID)
+	}
+	truncated := messages[idx:]
+	ids := make([]string, len(truncated))
+	for i, message := range truncated {
+		ids[i] = message.ID
+	}
+	// Delete them all at once so the conversation is never left with a gap.
+	if err := s.q.DeleteMessages(ctx, ids); err != nil {
+		return err
+	}
+	for _, message := range slices.Backward(truncated) {
+		s.Publish(pubsub.DeletedEvent, message.Clone())
+	}
+	return nil
+}
+
 func (s *service) Update(ctx context.Context, message Message) error {
 	parts, err := marshalParts(message.Parts)
 	if err != nil {
@@ -189,6 +232,46 @@ func (s *service) ListAllUserMessages(ctx context.Context) ([]Message, error) {
 	return messages, nil
 }
 
+func (s *service) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	match := searchQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+	rows, err := s.q.SearchMessages(ctx, db.SearchMessagesParams{
+		Query: match,
+		Limit: int64(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = SearchResult{
+			MessageID:    row.ID,
+			SessionID:    row.SessionID,
+			SessionTitle: row.SessionTitle,
+			Role:         MessageRole(row.Role),
+			Snippet:      row.Snippet,
+			CreatedAt:    row.CreatedAt,
+		}
+	}
+	return results, nil
+}
+
+// searchQuery turns free text into an FTS5 query matching messages that
+// contain every word, the last one as a prefix so results show up while
+// typing. Words are quoted so FTS5 syntax in them is taken literally.
+func searchQuery(text string) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+	if len(words) > 0 {
+		words[len(words)-1] += "*"
+	}
+	return strings.Join(words, " ")
+}
+
 func (s *service) fromDBItem(item db.Message) (Message, error) {
 	parts, err := unmarshalParts([]byte(item.Parts))
 	if err != nil {
@@ -0,0 +1,53 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	sessions := session.NewService(q, conn)
+	messages := NewService(q)
+
+	sess, err := sessions.Create(t.Context(), "test")
+	require.NoError(t, err)
+	other, err := sessions.Create(t.Context(), "other")
+	require.NoError(t, err)
+
+	user, err := messages.Create(t.Context(), sess.ID, CreateMessageParams{
+		Role:  User,
+		Parts: []ContentPart{TextContent{Text: "do it"}},
+	})
+	require.NoError(t, err)
+	_, err = messages.Create(t.Context(), sess.ID, CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+	_, err = messages.Create(t.Context(), other.ID, CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+
+	events := messages.Subscribe(t.Context())
+	require.NoError(t, messages.Truncate(t.Context(), sess.ID, user.ID))
+
+	remaining, err := messages.List(t.Context(), sess.ID)
+	require.NoError(t, err)
+	require.Empty(t, remaining)
+	remaining, err = messages.List(t.Context(), other.ID)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+
+	for range 2 {
+		event := <-events
+		require.Equal(t, pubsub.DeletedEvent, event.Type)
+		require.Equal(t, sess.ID, event.Payload.SessionID)
+	}
+
+	require.Error(t, messages.Truncate(t.Context(), sess.ID, user.ID))
+}
@@ -0,0 +1,106 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "", want: ""},
+		{text: "   ", want: ""},
+		{text: "migration", want: `"migration"*`},
+		{text: "fix  the-bug", want: `"fix" "the-bug"*`},
+		{text: `say "hi"`, want: `"say" """hi"""*`},
+		{text: "NOT OR", want: `"NOT" "OR"*`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, searchQuery(tt.text))
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	sessions := session.NewService(q, conn)
+	messages := NewService(q)
+
+	sess, err := sessions.Create(t.Context(), "Database work")
+	require.NoError(t, err)
+
+	user, err := messages.Create(t.Context(), sess.ID, CreateMessageParams{
+		Role:  User,
+		Parts: []ContentPart{TextContent{Text: "Please add a migration for the users table"}},
+	})
+	require.NoError(t, err)
+
+	assistant, err := messages.Create(t.Context(), sess.ID, CreateMessageParams{Role: Assistant})
+	require.NoError(t, err)
+	assistant.AddToolCall(ToolCall{ID: "call-1", Name: "bash", Input: `{"command":"goose status"}`, Finished: true})
+	require.NoError(t, messages.Update(t.Context(), assistant))
+
+	search := func(query string) []string {
+		t.Helper()
+		results, err := messages.Search(t.Context(), query, 10)
+		require.NoError(t, err)
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.MessageID)
+		}
+		return ids
+	}
+
+	require.Equal(t, []string{user.ID}, search("users migration"))
+	require.Equal(t, []string{user.ID}, search("migrat"))
+	require.Empty(t, search("nothing"))
+	require.Empty(t, search(""))
+
+	// Assistant messages are indexed once they're finished.
+	require.Empty(t, search("goose"))
+	assistant.AddFinish(FinishReasonToolUse, "", "")
+	require.NoError(t, messages.Update(t.Context(), assistant))
+	require.Equal(t, []string{assistant.ID}, search("goose"))
+
+	tool, err := messages.Create(t.Context(), sess.ID, CreateMessageParams{
+		Role:  Tool,
+		Parts: []ContentPart{ToolResult{ToolCallID: "call-1", Name: "bash", Content: "Applied 3 pending migrations"}},
+	})
+	require.NoError(t, err)
+
+	results, err := messages.Search(t.Context(), "pending", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, tool.ID, results[0].MessageID)
+	require.Equal(t, sess.ID, results[0].SessionID)
+	require.Equal(t, "Database work", results[0].SessionTitle)
+	require.Equal(t, Tool, results[0].Role)
+	require.Contains(t, results[0].Snippet, "pending")
+
+	// Deleted messages drop out of the index.
+	require.NoError(t, messages.Delete(t.Context(), user.ID))
+	require.Empty(t, search("users"))
+
+	// Messages of sub-agent sessions aren't searched.
+	child, err := sessions.CreateTaskSession(t.Context(), "call-2", sess.ID, "Task")
+	require.NoError(t, err)
+	_, err = messages.Create(t.Context(), child.ID, CreateMessageParams{
+		Role:  User,
+		Parts: []ContentPart{TextContent{Text: "Find the schema"}},
+	})
+	require.NoError(t, err)
+	require.Empty(t, search("schema"))
+}
@@ -0,0 +1,201 @@
+package mcpauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// authorizeTimeout is how long the user has to authorize Crush in the
+// browser.
+const authorizeTimeout = 5 * time.Minute
+
+// Authorize gets credentials for the MCP server at serverURL with the
+// authorization code flow. It registers Crush as a client of the
+// authorization server, calls open with the URL the user has to visit, and
+// waits for the authorization server to redirect the browser back to a
+// loopback address.
+func Authorize(ctx context.Context, serverURL string, open func(authURL string)) (*Credentials, error) {
+	meta, err := Discover(ctx, serverURL)
+	if err != nil {
+		return nil, err
+	}
+	if meta.RegistrationEndpoint == "" {
+		return nil, errors.New("the authorization server doesn't support dynamic client registration")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the authorization callback: %w", err)
+	}
+	defer listener.Close()
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr())
+
+	creds, err := register(ctx, meta, redirectURI)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier := randomString()
+	state := randomString()
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/callback" {
+				http.NotFound(w, r)
+				return
+			}
+			query := r.URL.Query()
+			switch {
+			case query.Get("state") != state:
+				http.Error(w, "Invalid state.", http.StatusBadRequest)
+				return
+			case query.Get("error") != "":
+				fmt.Fprintln(w, "Authorization failed, you can close this window.")
+				select {
+				case errs <- fmt.Errorf("authorization failed: %s %s", query.Get("error"), query.Get("error_description")):
+				default:
+				}
+			default:
+				fmt.Fprintln(w, "Crush is now authorized, you can close this window.")
+				select {
+				case codes <- query.Get("code"):
+				default:
+				}
+			}
+		}),
+	}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	open(authorizationURL(meta, creds.ClientID, redirectURI, state, challenge(verifier)))
+
+	ctx, cancel := context.WithTimeout(ctx, authorizeTimeout)
+	defer cancel()
+	var code string
+	select {
+	case code = <-codes:
+	case err := <-errs:
+		return nil, err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for authorization: %w", ctx.Err())
+	}
+
+	creds.Token, err = requestToken(ctx, creds, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return nil, err
+	}
+	creds.ServerURL = serverURL
+	return creds, nil
+}
+
+func authorizationURL(meta *Metadata, clientID, redirectURI, state, codeChallenge string) string {
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {clientID},
+		"redirect_uri":          {redirectURI},
+		"state":                 {state},
+		"code_challenge":        {codeChallenge},
+		"code_challenge_method": {"S256"},
+		"resource":              {meta.Resource},
+	}
+	if len(meta.Scopes) > 0 {
+		query.Set("scope", strings.Join(meta.Scopes, " "))
+	}
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return meta.AuthorizationEndpoint + separator + query.Encode()
+}
+
+type registrationRequest struct {
+	ClientName              string   `json:"client_name"`
+	RedirectURIs            []string `json:"redirect_uris"`
+	GrantTypes              []string `json:"grant_types"`
+	ResponseTypes           []string `json:"response_types"`
+	TokenEndpointAuthMethod string   `json:"token_endpoint_auth_method"`
+	Scope                   string   `json:"scope,omitempty"`
+}
+
+type registrationResponse struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// register registers Crush as a public client of the authorization server.
+func register(ctx context.Context, meta *Metadata, redirectURI string) (*Credentials, error) {
+	body, err := json.Marshal(registrationRequest{
+		ClientName:              "Crush",
+		RedirectURIs:            []string{redirectURI},
+		GrantTypes:              []string{"authorization_code", "refresh_token"},
+		ResponseTypes:           []string{"code"},
+		TokenEndpointAuthMethod: "none",
+		Scope:                   strings.Join(meta.Scopes, " "),
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.RegistrationEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("client registration failed: %s - %s", resp.Status, string(body))
+	}
+
+	var registration registrationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&registration); err != nil {
+		return nil, err
+	}
+	if registration.ClientID == "" {
+		return nil, errors.New("client registration failed: no client ID returned")
+	}
+	return &Credentials{
+		ClientID:     registration.ClientID,
+		ClientSecret: registration.ClientSecret,
+		TokenURL:     meta.TokenEndpoint,
+		Resource:     meta.Resource,
+	}, nil
+}
+
+// randomString returns a random URL-safe string, for the PKCE verifier and
+// the state.
+func randomString() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// challenge returns the S256 PKCE challenge of the verifier.
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
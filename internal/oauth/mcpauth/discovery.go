@@ -0,0 +1,240 @@
+// Package mcpauth implements the authorization of remote MCP servers, as
+// defined by the MCP specification: OAuth 2.1 with the discovery of the
+// authorization server through the protected resource metadata, dynamic
+// client registration and PKCE with a loopback redirect.
+package mcpauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Metadata describes how to get a token for an MCP server.
+type Metadata struct {
+	// Resource is the identifier of the MCP server for the authorization
+	// server.
+	Resource              string
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	RegistrationEndpoint  string
+	Scopes                []string
+}
+
+type protectedResourceMetadata struct {
+	Resource             string   `json:"resource"`
+	AuthorizationServers []string `json:"authorization_servers"`
+	ScopesSupported      []string `json:"scopes_supported"`
+}
+
+type authServerMetadata struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	RegistrationEndpoint  string `json:"registration_endpoint"`
+}
+
+var (
+	resourceMetadataParam = regexp.MustCompile(`resource_metadata="([^"]+)"`)
+	scopeParam            = regexp.MustCompile(`scope="([^"]+)"`)
+)
+
+// Discover finds the authorization server of the MCP server at serverURL.
+// It first looks for the protected resource metadata of the server, pointed
+// to by the WWW-Authenticate header of an unauthorized request or at its
+// well-known location. Servers without it are their own authorization
+// server. When the authorization server doesn't publish its metadata, the
+// default endpoints are used.
+//
+// The protected resource metadata must be about the server, and the
+// authorization and token endpoints must use https unless they're on a
+// loopback address.
+func Discover(ctx context.Context, serverURL string) (*Metadata, error) {
+	server, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	origin := server.Scheme + "://" + server.Host
+
+	meta := &Metadata{Resource: serverURL}
+	issuer := origin
+
+	// The metadata found at a well-known location is about the resource the
+	// location was derived from: the server, or its origin for the root one.
+	type candidate struct {
+		url       string
+		resources []string
+	}
+	metadataURL, scope := probe(ctx, serverURL)
+	candidates := []candidate{
+		{origin + "/.well-known/oauth-protected-resource" + strings.TrimSuffix(server.Path, "/"), []string{serverURL}},
+		{origin + "/.well-known/oauth-protected-resource", []string{origin, serverURL}},
+	}
+	if metadataURL != "" {
+		candidates = []candidate{{metadataURL, []string{serverURL}}}
+	}
+	var prm protectedResourceMetadata
+	for _, c := range candidates {
+		if err := getJSON(ctx, c.url, &prm); err != nil {
+			continue
+		}
+		if prm.Resource != "" && !slices.ContainsFunc(c.resources, func(resource string) bool {
+			return sameResource(prm.Resource, resource)
+		}) {
+			return nil, fmt.Errorf("protected resource metadata is for %s, not %s", prm.Resource, serverURL)
+		}
+		break
+	}
+	if len(prm.AuthorizationServers) > 0 {
+		issuer = prm.AuthorizationServers[0]
+	}
+	if prm.Resource != "" {
+		meta.Resource = prm.Resource
+	}
+	meta.Scopes = prm.ScopesSupported
+	if scope != "" {
+		meta.Scopes = strings.Fields(scope)
+	}
+
+	asm, err := getAuthServerMetadata(ctx, issuer)
+	if err != nil {
+		issuerURL, err := url.Parse(issuer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid authorization server: %w", err)
+		}
+		base := issuerURL.Scheme + "://" + issuerURL.Host
+		asm = &authServerMetadata{
+			AuthorizationEndpoint: base + "/authorize",
+			TokenEndpoint:         base + "/token",
+			RegistrationEndpoint:  base + "/register",
+		}
+	}
+	for _, endpoint := range []string{asm.AuthorizationEndpoint, asm.TokenEndpoint} {
+		if err := checkEndpoint(endpoint); err != nil {
+			return nil, err
+		}
+	}
+	meta.AuthorizationEndpoint = asm.AuthorizationEndpoint
+	meta.TokenEndpoint = asm.TokenEndpoint
+	meta.RegistrationEndpoint = asm.RegistrationEndpoint
+	return meta, nil
+}
+
+// sameResource reports whether the resource identifiers a and b are the
+// same, ignoring a trailing slash.
+func sameResource(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
+
+// checkEndpoint makes sure the authorization server endpoint uses https,
+// unless it's on a loopback address, not to send the authorization codes
+// and the tokens in clear.
+func checkEndpoint(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid authorization server endpoint: %w", err)
+	}
+	if u.Scheme == "https" {
+		return nil
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); u.Scheme == "http" && (host == "localhost" || ip != nil && ip.IsLoopback()) {
+		return nil
+	}
+	return fmt.Errorf("authorization server endpoint %s must use https", endpoint)
+}
+
+// probe makes an unauthorized request to the server and returns the
+// protected resource metadata URL and the scope of its challenge, if any.
+func probe(ctx context.Context, serverURL string) (string, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverURL, nil)
+	if err != nil {
+		return "", ""
+	}
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		return "", ""
+	}
+
+	var metadataURL, scope string
+	for _, challenge := range resp.Header.Values("WWW-Authenticate") {
+		if m := resourceMetadataParam.FindStringSubmatch(challenge); m != nil && metadataURL == "" {
+			metadataURL = m[1]
+		}
+		if m := scopeParam.FindStringSubmatch(challenge); m != nil && scope == "" {
+			scope = m[1]
+		}
+	}
+	return metadataURL, scope
+}
+
+// getAuthServerMetadata gets the metadata of the authorization server from
+// the well-known locations of OAuth and OpenID Connect.
+func getAuthServerMetadata(ctx context.Context, issuer string) (*authServerMetadata, error) {
+	issuerURL, err := url.Parse(issuer)
+	if err != nil {
+		return nil, err
+	}
+	origin := issuerURL.Scheme + "://" + issuerURL.Host
+	path := strings.TrimSuffix(issuerURL.Path, "/")
+
+	candidates := []string{
+		origin + "/.well-known/oauth-authorization-server" + path,
+		origin + "/.well-known/openid-configuration" + path,
+	}
+	if path != "" {
+		candidates = append(candidates, origin+path+"/.well-known/openid-configuration")
+	}
+
+	var errs []error
+	for _, candidate := range candidates {
+		var asm authServerMetadata
+		if err := getJSON(ctx, candidate, &asm); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if asm.AuthorizationEndpoint == "" || asm.TokenEndpoint == "" {
+			errs = append(errs, fmt.Errorf("%s: missing endpoints", candidate))
+			continue
+		}
+		return &asm, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+func getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
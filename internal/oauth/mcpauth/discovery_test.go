@@ -0,0 +1,169 @@
+package mcpauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/oauth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscover(t *testing.T) {
+	t.Parallel()
+
+	t.Run("protected resource metadata", func(t *testing.T) {
+		t.Parallel()
+
+		auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/.well-known/oauth-authorization-server" {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(authServerMetadata{
+				AuthorizationEndpoint: "https://auth.example.com/oauth/authorize",
+				TokenEndpoint:         "https://auth.example.com/oauth/token",
+				RegistrationEndpoint:  "https://auth.example.com/oauth/register",
+			})
+		}))
+		t.Cleanup(auth.Close)
+
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/mcp":
+				w.Header().Set("WWW-Authenticate", `Bearer resource_metadata="`+server.URL+`/metadata", scope="read write"`)
+				w.WriteHeader(http.StatusUnauthorized)
+			case "/metadata":
+				_ = json.NewEncoder(w).Encode(protectedResourceMetadata{
+					Resource:             server.URL + "/mcp",
+					AuthorizationServers: []string{auth.URL},
+					ScopesSupported:      []string{"read"},
+				})
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		t.Cleanup(server.Close)
+
+		meta, err := Discover(t.Context(), server.URL+"/mcp")
+		require.NoError(t, err)
+		require.Equal(t, &Metadata{
+			Resource:              server.URL + "/mcp",
+			AuthorizationEndpoint: "https://auth.example.com/oauth/authorize",
+			TokenEndpoint:         "https://auth.example.com/oauth/token",
+			RegistrationEndpoint:  "https://auth.example.com/oauth/register",
+			Scopes:                []string{"read", "write"},
+		}, meta)
+	})
+
+	t.Run("metadata of another resource", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/.well-known/oauth-protected-resource/mcp" {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(protectedResourceMetadata{
+				Resource:             "https://other.example.com/mcp",
+				AuthorizationServers: []string{"https://auth.example.com"},
+			})
+		}))
+		t.Cleanup(server.Close)
+
+		_, err := Discover(t.Context(), server.URL+"/mcp")
+		require.ErrorContains(t, err, "https://other.example.com/mcp")
+	})
+
+	t.Run("plain http endpoints", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/.well-known/oauth-authorization-server" {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(authServerMetadata{
+				AuthorizationEndpoint: "http://auth.example.com/authorize",
+				TokenEndpoint:         "http://auth.example.com/token",
+			})
+		}))
+		t.Cleanup(server.Close)
+
+		_, err := Discover(t.Context(), server.URL+"/mcp")
+		require.ErrorContains(t, err, "must use https")
+	})
+
+	t.Run("default endpoints", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(server.Close)
+
+		meta, err := Discover(t.Context(), server.URL+"/mcp")
+		require.NoError(t, err)
+		require.Equal(t, &Metadata{
+			Resource:              server.URL + "/mcp",
+			AuthorizationEndpoint: server.URL + "/authorize",
+			TokenEndpoint:         server.URL + "/token",
+			RegistrationEndpoint:  server.URL + "/register",
+		}, meta)
+	})
+}
+
+func TestRefresh(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "refresh_token", r.Form.Get("grant_type"))
+		require.Equal(t, "old-refresh", r.Form.Get("refresh_token"))
+		require.Equal(t, "client", r.Form.Get("client_id"))
+		require.Equal(t, "https://mcp.example.com", r.Form.Get("resource"))
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "new-access",
+			"expires_in":   3600,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	creds := &Credentials{
+		ClientID: "client",
+		TokenURL: server.URL,
+		Resource: "https://mcp.example.com",
+		Token: &oauth.Token{
+			AccessToken:  "old-access",
+			RefreshToken: "old-refresh",
+			ExpiresAt:    time.Now().Add(-time.Minute).Unix(),
+		},
+	}
+	require.True(t, creds.Expired())
+
+	refreshed, err := Refresh(t.Context(), creds)
+	require.NoError(t, err)
+	require.Equal(t, "new-access", refreshed.Token.AccessToken)
+	require.Equal(t, "old-refresh", refreshed.Token.RefreshToken)
+	require.False(t, refreshed.Expired())
+	require.Equal(t, "old-access", creds.Token.AccessToken)
+}
+
+func TestCredentialsFor(t *testing.T) {
+	t.Parallel()
+
+	creds := &Credentials{ServerURL: "https://mcp.example.com/mcp"}
+	require.True(t, creds.For("https://mcp.example.com/mcp"))
+	require.True(t, creds.For("https://mcp.example.com/mcp/"))
+	require.False(t, creds.For("https://attacker.example.com/mcp"))
+	require.False(t, (&Credentials{}).For("https://mcp.example.com/mcp"))
+}
+
+func TestChallenge(t *testing.T) {
+	t.Parallel()
+
+	// The base64url encoding of the SHA-256 hash of the empty string.
+	require.Equal(t, "47DEQpj8HBSa-_TImW-5JCeuQeRkm5NMpJWZG3hSuFU", challenge(""))
+	require.Len(t, challenge(randomString()), 43)
+}
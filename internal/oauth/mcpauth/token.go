@@ -0,0 +1,101 @@
+package mcpauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/oauth"
+)
+
+// Credentials are the client registration of Crush with the authorization
+// server of an MCP server, and the token it got. ServerURL is the URL of the
+// MCP server they were issued for.
+type Credentials struct {
+	ServerURL    string       `json:"server_url,omitempty"`
+	ClientID     string       `json:"client_id"`
+	ClientSecret string       `json:"client_secret,omitempty"`
+	TokenURL     string       `json:"token_url"`
+	Resource     string       `json:"resource,omitempty"`
+	Token        *oauth.Token `json:"token,omitempty"`
+}
+
+// Expired reports whether the token has to be refreshed. Tokens without an
+// expiration never expire.
+func (c *Credentials) Expired() bool {
+	if c.Token == nil {
+		return true
+	}
+	return c.Token.ExpiresAt != 0 && c.Token.IsExpired()
+}
+
+// For reports whether the credentials were issued for the MCP server at
+// serverURL, so that they're never sent to another server configured under
+// the same name.
+func (c *Credentials) For(serverURL string) bool {
+	return c.ServerURL != "" && strings.TrimSuffix(c.ServerURL, "/") == strings.TrimSuffix(serverURL, "/")
+}
+
+// Refresh returns a copy of the credentials with a new token.
+func Refresh(ctx context.Context, creds *Credentials) (*Credentials, error) {
+	if creds.Token == nil || creds.Token.RefreshToken == "" {
+		return nil, fmt.Errorf("no refresh token available")
+	}
+	token, err := requestToken(ctx, creds, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {creds.Token.RefreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		// The authorization server doesn't rotate refresh tokens.
+		token.RefreshToken = creds.Token.RefreshToken
+	}
+	refreshed := *creds
+	refreshed.Token = token
+	return &refreshed, nil
+}
+
+func requestToken(ctx context.Context, creds *Credentials, data url.Values) (*oauth.Token, error) {
+	data.Set("client_id", creds.ClientID)
+	if creds.ClientSecret != "" {
+		data.Set("client_secret", creds.ClientSecret)
+	}
+	if creds.Resource != "" {
+		data.Set("resource", creds.Resource)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, creds.TokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("token request failed: %s - %s", resp.Status, string(body))
+	}
+
+	var token oauth.Token
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token request failed: no access token returned")
+	}
+	if token.ExpiresIn > 0 {
+		token.SetExpiresAt()
+	}
+	return &token, nil
+}
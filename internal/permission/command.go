@@ -0,0 +1,103 @@
+package permission
+
+import (
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// matchCommand matches the command pattern of the rule against the simple
+// commands of a bash command line, like the commands of a pipeline or of a
+// list joined with && and ;. Allow rules need all of them to match, while
+// ask and deny rules need any of them to. Allow rules never match the
+// command lines that can't be analysed, like the ones with command
+// substitutions, so that they're asked for.
+func (r compiledRule) matchCommand(command string) bool {
+	if command == "" {
+		return false
+	}
+	cmds, ok := simpleCommands(command)
+	if r.Decision != DecisionAllow {
+		for _, cmd := range cmds {
+			if r.command(cmd) {
+				return true
+			}
+		}
+		return false
+	}
+	if !ok || len(cmds) == 0 {
+		return false
+	}
+	for _, cmd := range cmds {
+		if !r.command(cmd) {
+			return false
+		}
+	}
+	return true
+}
+
+// simpleCommands returns the simple commands of a command line, their
+// words separated by spaces, including the ones nested in other commands.
+// It reports whether the command line could be fully analysed: command
+// substitutions, variable assignments, commands named by expansions and
+// redirections to files can make it run something else than its simple
+// commands say.
+func simpleCommands(command string) ([]string, bool) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil, false
+	}
+
+	printer := syntax.NewPrinter()
+	var cmds []string
+	ok := true
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch n := node.(type) {
+		case *syntax.CmdSubst, *syntax.ProcSubst, *syntax.DeclClause:
+			ok = false
+		case *syntax.Redirect:
+			if writesFile(n) {
+				ok = false
+			}
+		case *syntax.CallExpr:
+			if len(n.Assigns) > 0 {
+				ok = false
+			}
+			if len(n.Args) == 0 {
+				return true
+			}
+			if n.Args[0].Lit() == "" {
+				ok = false
+			}
+			words := make([]string, 0, len(n.Args))
+			for _, arg := range n.Args {
+				var b strings.Builder
+				if err := printer.Print(&b, arg); err != nil {
+					ok = false
+					return true
+				}
+				words = append(words, b.String())
+			}
+			cmds = append(cmds, strings.Join(words, " "))
+		}
+		return true
+	})
+	return cmds, ok
+}
+
+// writesFile reports whether the redirection writes to a file other than
+// /dev/null.
+func writesFile(r *syntax.Redirect) bool {
+	target := ""
+	if r.Word != nil {
+		target = r.Word.Lit()
+	}
+	switch r.Op {
+	case syntax.RdrOut, syntax.AppOut, syntax.RdrInOut, syntax.ClbOut, syntax.RdrAll, syntax.AppAll:
+		return target != "/dev/null"
+	case syntax.DplOut:
+		// >&2 duplicates a file descriptor, but >&file writes to file.
+		return strings.Trim(target, "0123456789-") != ""
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package permission
+
+// Grant is a permission the user allowed for the rest of a session, or for
+// the whole project when SessionID is empty.
+type Grant struct {
+	ID        string `json:"id" jsonschema:"required,description=Identifier of the grant"`
+	SessionID string `json:"session_id,omitempty" jsonschema:"description=Session the grant applies to; empty for project grants"`
+	ToolName  string `json:"tool_name" jsonschema:"required,description=Tool the grant allows,example=bash"`
+	Action    string `json:"action" jsonschema:"required,description=Action of the tool the grant allows,example=execute"`
+	Path      string `json:"path" jsonschema:"required,description=Path the grant applies to; relative to the project for project grants"`
+	CreatedAt int64  `json:"created_at" jsonschema:"description=When the grant was given in seconds since the Unix epoch"`
+}
+
+// Project reports whether the grant applies to the whole project.
+func (g Grant) Project() bool {
+	return g.SessionID == ""
+}
+
+// covers reports whether the grant allows the permission request.
+func (g Grant) covers(permission PermissionRequest) bool {
+	if !g.Project() && g.SessionID != permission.SessionID {
+		return false
+	}
+	return g.ToolName == permission.ToolName && g.Action == permission.Action && g.Path == permission.Path
+}
+
+func grantFor(permission PermissionRequest, project bool) Grant {
+	grant := Grant{
+		SessionID: permission.SessionID,
+		ToolName:  permission.ToolName,
+		Action:    permission.Action,
+		Path:      permission.Path,
+	}
+	if project {
+		grant.SessionID = ""
+	}
+	return grant
+}
@@ -3,6 +3,8 @@ package permission
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"slices"
@@ -23,6 +25,18 @@ type CreatePermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+
+	// Command, FilePath, URL and MCP describe what the call acts on, for
+	// matching permission rules. Tools set the ones that apply.
+	Command  string `json:"command,omitempty"`
+	FilePath string `json:"file_path,omitempty"`
+	URL      string `json:"url,omitempty"`
+	MCP      string `json:"mcp,omitempty"`
+
+	// Safe marks the calls tools make without asking, like read-only
+	// commands. Permission rules still apply to them, but they're granted
+	// without a prompt unless a rule asks.
+	Safe bool `json:"safe,omitempty"`
 }
 
 type PermissionNotification struct {
@@ -40,11 +54,14 @@ type PermissionRequest struct {
 	Action      string `json:"action"`
 	Params      any    `json:"params"`
 	Path        string `json:"path"`
+	// Reason explains why the user is asked, when a rule decided it.
+	Reason string `json:"reason,omitempty"`
 }
 
 type Service interface {
 	pubsub.Subscriber[PermissionRequest]
 	GrantPersistent(permission PermissionRequest)
+	GrantForProject(permission PermissionRequest)
 	Grant(permission PermissionRequest)
 	Deny(permission PermissionRequest)
 	Request(ctx context.Context, opts CreatePermissionRequest) (bool, error)
@@ -66,6 +83,8 @@ type permissionService struct {
 	autoApproveSessionsMu sync.RWMutex
 	skip                  bool
 	allowedTools          []string
+	rules                 []compiledRule
+	grants                GrantStore
 
 	// used to make sure we only process one request at a time
 	requestMu       sync.Mutex
@@ -73,7 +92,19 @@ type permissionService struct {
 	activeRequestMu sync.Mutex
 }
 
+// GrantPersistent grants the permission and remembers it for the rest of the
+// session.
 func (s *permissionService) GrantPersistent(permission PermissionRequest) {
+	s.grantPersistent(permission, false)
+}
+
+// GrantForProject grants the permission and remembers it for every session
+// of the project.
+func (s *permissionService) GrantForProject(permission PermissionRequest) {
+	s.grantPersistent(permission, true)
+}
+
+func (s *permissionService) grantPersistent(permission PermissionRequest, project bool) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 		ToolCallID: permission.ToolCallID,
 		Granted:    true,
@@ -83,9 +114,11 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 		respCh <- true
 	}
 
-	s.sessionPermissionsMu.Lock()
-	s.sessionPermissions = append(s.sessionPermissions, permission)
-	s.sessionPermissionsMu.Unlock()
+	if !s.saveGrant(grantFor(permission, project)) {
+		s.sessionPermissionsMu.Lock()
+		s.sessionPermissions = append(s.sessionPermissions, permission)
+		s.sessionPermissionsMu.Unlock()
+	}
 
 	s.activeRequestMu.Lock()
 	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
@@ -94,6 +127,19 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 	s.activeRequestMu.Unlock()
 }
 
+// saveGrant stores the grant, reporting whether it was. Grants that aren't
+// stored are kept in memory for the rest of the run.
+func (s *permissionService) saveGrant(grant Grant) bool {
+	if s.grants == nil {
+		return false
+	}
+	if err := s.grants.SaveGrant(context.Background(), grant); err != nil {
+		slog.Error("Failed to store permission grant", "error", err)
+		return false
+	}
+	return true
+}
+
 func (s *permissionService) Grant(permission PermissionRequest) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 		ToolCallID: permission.ToolCallID,
@@ -130,9 +176,21 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 }
 
 func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRequest) (bool, error) {
+	rule, ruleNumber := s.matchRule(opts)
+	if rule != nil && rule.Decision == DecisionDeny {
+		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+			ToolCallID: opts.ToolCallID,
+			Denied:     true,
+		})
+		return false, fmt.Errorf("%w #%d", ErrDeniedByRule, ruleNumber)
+	}
+
 	if s.skip {
 		return true, nil
 	}
+	if opts.Safe && (rule == nil || rule.Decision != DecisionAsk) {
+		return true, nil
+	}
 
 	// tell the UI that a permission was requested
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
@@ -141,9 +199,19 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 	s.requestMu.Lock()
 	defer s.requestMu.Unlock()
 
+	if rule != nil && rule.Decision == DecisionAllow {
+		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+			ToolCallID: opts.ToolCallID,
+			Granted:    true,
+		})
+		return true, nil
+	}
+	// Ask rules override the allowlist and earlier grants.
+	ask := rule != nil && rule.Decision == DecisionAsk
+
 	// Check if the tool/action combination is in the allowlist
 	commandKey := opts.ToolName + ":" + opts.Action
-	if slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName) {
+	if !ask && (slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName)) {
 		return true, nil
 	}
 
@@ -151,7 +219,7 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 	autoApprove := s.autoApproveSessions[opts.SessionID]
 	s.autoApproveSessionsMu.RUnlock()
 
-	if autoApprove {
+	if autoApprove && !ask {
 		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 			ToolCallID: opts.ToolCallID,
 			Granted:    true,
@@ -182,19 +250,17 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 		Action:      opts.Action,
 		Params:      opts.Params,
 	}
+	if ask {
+		permission.Reason = fmt.Sprintf("Matched rule #%d", ruleNumber)
+	}
 
-	s.sessionPermissionsMu.RLock()
-	for _, p := range s.sessionPermissions {
-		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
-			s.sessionPermissionsMu.RUnlock()
-			s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
-				ToolCallID: opts.ToolCallID,
-				Granted:    true,
-			})
-			return true, nil
-		}
+	if !ask && s.granted(ctx, permission) {
+		s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
+			ToolCallID: opts.ToolCallID,
+			Granted:    true,
+		})
+		return true, nil
 	}
-	s.sessionPermissionsMu.RUnlock()
 
 	s.activeRequestMu.Lock()
 	s.activeRequest = &permission
@@ -215,6 +281,31 @@ func (s *permissionService) Request(ctx context.Context, opts CreatePermissionRe
 	}
 }
 
+// granted reports whether the same permission was granted for the rest of
+// the session or for the project.
+func (s *permissionService) granted(ctx context.Context, permission PermissionRequest) bool {
+	s.sessionPermissionsMu.RLock()
+	for _, p := range s.sessionPermissions {
+		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
+			s.sessionPermissionsMu.RUnlock()
+			return true
+		}
+	}
+	s.sessionPermissionsMu.RUnlock()
+
+	if s.grants == nil {
+		return false
+	}
+	grants, err := s.grants.Grants(ctx, permission.SessionID)
+	if err != nil {
+		slog.Error("Failed to load permission grants", "error", err)
+		return false
+	}
+	return slices.ContainsFunc(grants, func(g Grant) bool {
+		return g.covers(permission)
+	})
+}
+
 func (s *permissionService) AutoApproveSession(sessionID string) {
 	s.autoApproveSessionsMu.Lock()
 	s.autoApproveSessions[sessionID] = true
@@ -233,7 +324,9 @@ func (s *permissionService) SkipRequests() bool {
 	return s.skip
 }
 
-func NewPermissionService(workingDir string, skip bool, allowedTools []string) Service {
+// NewPermissionService creates a permission service. Grants are kept in
+// memory when the grant store is nil.
+func NewPermissionService(workingDir string, skip bool, allowedTools []string, rules []Rule, grants GrantStore) Service {
 	return &permissionService{
 		Broker:              pubsub.NewBroker[PermissionRequest](),
 		notificationBroker:  pubsub.NewBroker[PermissionNotification](),
@@ -242,6 +335,8 @@ func NewPermissionService(workingDir string, skip bool, allowedTools []string) S
 		autoApproveSessions: make(map[string]bool),
 		skip:                skip,
 		allowedTools:        allowedTools,
+		rules:               compileRules(rules),
+		grants:              grants,
 		pendingRequests:     csync.NewMap[string, chan bool](),
 	}
 }
@@ -0,0 +1,211 @@
+package permission
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ErrDeniedByRule is returned by Request when a deny rule matches the call.
+var ErrDeniedByRule = errors.New("permission denied by rule")
+
+// Decision is what a permission rule decides for the calls it matches.
+type Decision string
+
+const (
+	// DecisionAllow grants the call without asking.
+	DecisionAllow Decision = "allow"
+	// DecisionAsk always asks, even for tools in allowed_tools or granted for
+	// the session.
+	DecisionAsk Decision = "ask"
+	// DecisionDeny refuses the call, even in yolo mode.
+	DecisionDeny Decision = "deny"
+)
+
+// Rule decides the permission requests it matches. A rule matches when all
+// of its patterns do; a pattern for something a call doesn't have, like a
+// command for the edit tool, never matches.
+//
+// Patterns are globs where * matches any text, or regular expressions when
+// prefixed with "re:". Path globs follow the glob tool and match paths
+// relative to the working directory, or absolute ones.
+//
+// Command patterns match each simple command of a command line: an allow
+// rule must match all of them, and an ask or deny rule any of them.
+type Rule struct {
+	Decision Decision `json:"decision" jsonschema:"required,enum=allow,enum=ask,enum=deny,description=What to do with the calls the rule matches"`
+	Tool     string   `json:"tool,omitempty" jsonschema:"description=Pattern for the tool name,example=bash,example=mcp_github_*"`
+	Command  string   `json:"command,omitempty" jsonschema:"description=Pattern for each command of bash calls,example=git status*,example=re:^go (test|vet) "`
+	Path     string   `json:"path,omitempty" jsonschema:"description=Pattern for the path file tools act on,example=internal/**,example=.github/**"`
+	Host     string   `json:"host,omitempty" jsonschema:"description=Pattern for the URL host of fetch and download calls,example=*.github.com"`
+	MCP      string   `json:"mcp,omitempty" jsonschema:"description=Pattern for the MCP server of MCP tool calls,example=github"`
+}
+
+// compiledRule is a rule with its patterns compiled.
+type compiledRule struct {
+	Rule
+	tool    matcher
+	command matcher
+	path    matcher
+	host    matcher
+	mcp     matcher
+}
+
+// matcher reports whether a value matches a pattern; nil matches anything.
+type matcher func(string) bool
+
+// ValidateRules returns the errors of the invalid rules, numbered from one.
+func ValidateRules(rules []Rule) error {
+	var errs []error
+	for i, rule := range rules {
+		if _, err := compileRule(rule); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i+1, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compileRules compiles the rules, which the config validated when it was
+// loaded. Invalid rules match everything and deny it, so that a broken deny
+// rule never lets calls through.
+func compileRules(rules []Rule) []compiledRule {
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		c, err := compileRule(rule)
+		if err != nil {
+			slog.Error("Denying everything for invalid permission rule", "rule", i+1, "error", err)
+			c = compiledRule{Rule: Rule{Decision: DecisionDeny}}
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled
+}
+
+func compileRule(rule Rule) (compiledRule, error) {
+	switch rule.Decision {
+	case DecisionAllow, DecisionAsk, DecisionDeny:
+	default:
+		return compiledRule{}, fmt.Errorf("unknown decision %q", rule.Decision)
+	}
+	c := compiledRule{Rule: rule}
+	var err error
+	if c.tool, err = compilePattern(rule.Tool, false); err != nil {
+		return compiledRule{}, fmt.Errorf("tool: %w", err)
+	}
+	if c.command, err = compilePattern(rule.Command, false); err != nil {
+		return compiledRule{}, fmt.Errorf("command: %w", err)
+	}
+	if c.path, err = compilePattern(rule.Path, true); err != nil {
+		return compiledRule{}, fmt.Errorf("path: %w", err)
+	}
+	if c.host, err = compilePattern(rule.Host, false); err != nil {
+		return compiledRule{}, fmt.Errorf("host: %w", err)
+	}
+	if c.mcp, err = compilePattern(rule.MCP, false); err != nil {
+		return compiledRule{}, fmt.Errorf("mcp: %w", err)
+	}
+	return c, nil
+}
+
+func compilePattern(pattern string, isPath bool) (matcher, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+	if isPath {
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("invalid glob %q", pattern)
+		}
+		return func(path string) bool {
+			ok, _ := doublestar.Match(pattern, path)
+			return ok
+		}, nil
+	}
+	re, err := regexp.Compile("^" + globToRegexp(pattern) + "$")
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+// globToRegexp converts a glob where * matches any text and ? any character
+// to a regular expression.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString("(?s:.*)")
+		case '?':
+			b.WriteString("(?s:.)")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// matches reports whether the rule matches the request.
+func (r compiledRule) matches(opts CreatePermissionRequest, workingDir string) bool {
+	if r.tool != nil && !r.tool(opts.ToolName) {
+		return false
+	}
+	if r.command != nil && !r.matchCommand(opts.Command) {
+		return false
+	}
+	if r.path != nil && (opts.FilePath == "" || !matchPath(r.path, opts.FilePath, workingDir)) {
+		return false
+	}
+	if r.host != nil && (opts.URL == "" || !r.host(urlHost(opts.URL))) {
+		return false
+	}
+	if r.mcp != nil && (opts.MCP == "" || !r.mcp(opts.MCP)) {
+		return false
+	}
+	return true
+}
+
+// matchPath matches a path relative to the working directory, when it's in
+// it, and as an absolute path.
+func matchPath(match matcher, path, workingDir string) bool {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+	if rel, err := filepath.Rel(workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		if match(filepath.ToSlash(rel)) {
+			return true
+		}
+	}
+	return match(filepath.ToSlash(path))
+}
+
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// matchRule returns the first rule matching the request and its number,
+// counting from one, or nil.
+func (s *permissionService) matchRule(opts CreatePermissionRequest) (*compiledRule, int) {
+	for i := range s.rules {
+		if s.rules[i].matches(opts, s.workingDir) {
+			return &s.rules[i], i + 1
+		}
+	}
+	return nil, 0
+}
@@ -0,0 +1,217 @@
+package permission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// projectGrantsKey is where project grants are kept in the project's data
+// config, the grants field of config.Permissions.
+const projectGrantsKey = "permissions.grants"
+
+// GrantStore keeps session grants in the database and project grants in the
+// crush.json of the project's data directory.
+type GrantStore interface {
+	// Grants returns the grants of a session along with the project ones.
+	Grants(ctx context.Context, sessionID string) ([]Grant, error)
+	// SaveGrant stores a grant.
+	SaveGrant(ctx context.Context, grant Grant) error
+	// List returns every stored grant, project ones first.
+	List(ctx context.Context) ([]Grant, error)
+	// Revoke removes a stored grant.
+	Revoke(ctx context.Context, grant Grant) error
+}
+
+type store struct {
+	q          db.Querier
+	configPath string
+	workingDir string
+
+	// mu serializes changes to the data config.
+	mu sync.Mutex
+}
+
+// NewGrantStore creates a grant store for the project in workingDir whose
+// data lives in dataDir.
+func NewGrantStore(q db.Querier, dataDir, workingDir string) GrantStore {
+	return &store{
+		q:          q,
+		configPath: filepath.Join(dataDir, "crush.json"),
+		workingDir: workingDir,
+	}
+}
+
+func (s *store) Grants(ctx context.Context, sessionID string) ([]Grant, error) {
+	grants, err := s.projectGrants()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.q.ListSessionPermissionGrants(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		grants = append(grants, fromDBGrant(row))
+	}
+	return grants, nil
+}
+
+func (s *store) List(ctx context.Context) ([]Grant, error) {
+	grants, err := s.projectGrants()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.q.ListPermissionGrants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		grants = append(grants, fromDBGrant(row))
+	}
+	return grants, nil
+}
+
+func (s *store) SaveGrant(ctx context.Context, grant Grant) error {
+	if grant.ID == "" {
+		grant.ID = uuid.New().String()
+	}
+	if !grant.Project() {
+		return s.q.CreatePermissionGrant(ctx, db.CreatePermissionGrantParams{
+			ID:        grant.ID,
+			SessionID: grant.SessionID,
+			ToolName:  grant.ToolName,
+			Action:    grant.Action,
+			Path:      grant.Path,
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	grants, err := s.readProjectGrants()
+	if err != nil {
+		return err
+	}
+	grant.Path = s.relPath(grant.Path)
+	for _, g := range grants {
+		if g.ToolName == grant.ToolName && g.Action == grant.Action && g.Path == grant.Path {
+			return nil
+		}
+	}
+	grant.CreatedAt = time.Now().Unix()
+	return s.writeProjectGrants(append(grants, grant))
+}
+
+func (s *store) Revoke(ctx context.Context, grant Grant) error {
+	if !grant.Project() {
+		return s.q.DeletePermissionGrant(ctx, grant.ID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	grants, err := s.readProjectGrants()
+	if err != nil {
+		return err
+	}
+	grants = slices.DeleteFunc(grants, func(g Grant) bool {
+		return g.ID == grant.ID
+	})
+	return s.writeProjectGrants(grants)
+}
+
+// projectGrants returns the project grants with absolute paths.
+func (s *store) projectGrants() ([]Grant, error) {
+	s.mu.Lock()
+	grants, err := s.readProjectGrants()
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	for i := range grants {
+		grants[i].Path = s.absPath(grants[i].Path)
+	}
+	return grants, nil
+}
+
+// readProjectGrants reads the project grants as stored, with paths relative
+// to the working directory.
+func (s *store) readProjectGrants() ([]Grant, error) {
+	data, err := os.ReadFile(s.configPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	value := gjson.GetBytes(data, projectGrantsKey)
+	if !value.Exists() {
+		return nil, nil
+	}
+	var grants []Grant
+	if err := json.Unmarshal([]byte(value.Raw), &grants); err != nil {
+		return nil, fmt.Errorf("failed to parse %s in %s: %w", projectGrantsKey, s.configPath, err)
+	}
+	return grants, nil
+}
+
+func (s *store) writeProjectGrants(grants []Grant) error {
+	data, err := os.ReadFile(s.configPath)
+	if os.IsNotExist(err) {
+		data = []byte("{}")
+	} else if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if grants == nil {
+		grants = []Grant{}
+	}
+	data, err = sjson.SetBytes(data, projectGrantsKey, grants)
+	if err != nil {
+		return fmt.Errorf("failed to set config field %s: %w", projectGrantsKey, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.configPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory %q: %w", s.configPath, err)
+	}
+	if err := os.WriteFile(s.configPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// relPath makes paths in the working directory relative to it, so project
+// grants still apply when the project moves.
+func (s *store) relPath(path string) string {
+	rel, err := filepath.Rel(s.workingDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (s *store) absPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.workingDir, filepath.FromSlash(path))
+}
+
+func fromDBGrant(row db.PermissionGrant) Grant {
+	return Grant{
+		ID:        row.ID,
+		SessionID: row.SessionID,
+		ToolName:  row.ToolName,
+		Action:    row.Action,
+		Path:      row.Path,
+		CreatedAt: row.CreatedAt,
+	}
+}
@@ -0,0 +1,108 @@
+package permission
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func testGrantStore(t *testing.T) (GrantStore, *db.Queries, string, string) {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	workingDir := t.TempDir()
+	conn, err := db.Connect(t.Context(), dataDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	return NewGrantStore(q, dataDir, workingDir), q, dataDir, workingDir
+}
+
+func TestGrantStore(t *testing.T) {
+	store, q, dataDir, workingDir := testGrantStore(t)
+	ctx := t.Context()
+
+	for _, id := range []string{"session1", "session2"} {
+		_, err := q.CreateSession(ctx, db.CreateSessionParams{ID: id, Title: id})
+		require.NoError(t, err)
+	}
+
+	subdir := filepath.Join(workingDir, "internal")
+	require.NoError(t, store.SaveGrant(ctx, Grant{SessionID: "session1", ToolName: "bash", Action: "execute", Path: workingDir}))
+	// Saving the same grant twice keeps one.
+	require.NoError(t, store.SaveGrant(ctx, Grant{SessionID: "session1", ToolName: "bash", Action: "execute", Path: workingDir}))
+	require.NoError(t, store.SaveGrant(ctx, Grant{SessionID: "session2", ToolName: "edit", Action: "write", Path: workingDir}))
+	require.NoError(t, store.SaveGrant(ctx, Grant{ToolName: "edit", Action: "write", Path: subdir}))
+	require.NoError(t, store.SaveGrant(ctx, Grant{ToolName: "edit", Action: "write", Path: subdir}))
+
+	// Project grants are stored relative to the working directory.
+	data, err := os.ReadFile(filepath.Join(dataDir, "crush.json"))
+	require.NoError(t, err)
+	stored := gjson.GetBytes(data, projectGrantsKey).Array()
+	require.Len(t, stored, 1)
+	require.Equal(t, "internal", stored[0].Get("path").String())
+
+	grants, err := store.Grants(ctx, "session1")
+	require.NoError(t, err)
+	require.Len(t, grants, 2)
+	require.True(t, grants[0].Project())
+	require.Equal(t, subdir, grants[0].Path)
+	require.Equal(t, "session1", grants[1].SessionID)
+
+	all, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	for _, g := range all {
+		require.NoError(t, store.Revoke(ctx, g))
+	}
+	all, err = store.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, all)
+}
+
+func TestPermissionService_StoredGrants(t *testing.T) {
+	store, q, _, workingDir := testGrantStore(t)
+	ctx := t.Context()
+
+	_, err := q.CreateSession(ctx, db.CreateSessionParams{ID: "session1", Title: "session1"})
+	require.NoError(t, err)
+	_, err = q.CreateSession(ctx, db.CreateSessionParams{ID: "session2", Title: "session2"})
+	require.NoError(t, err)
+
+	request := func(service Service, sessionID, toolName string, respond func(PermissionRequest)) bool {
+		events := service.Subscribe(ctx)
+		var granted bool
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			granted, _ = service.Request(ctx, CreatePermissionRequest{
+				SessionID: sessionID,
+				ToolName:  toolName,
+				Action:    "write",
+				Path:      workingDir,
+			})
+		})
+		if respond != nil {
+			respond((<-events).Payload)
+		}
+		wg.Wait()
+		return granted
+	}
+
+	service := NewPermissionService(workingDir, false, nil, nil, store)
+	require.True(t, request(service, "session1", "edit", service.GrantPersistent))
+	require.True(t, request(service, "session1", "write", service.GrantForProject))
+
+	// A new service, like after a restart, still has the grants.
+	service = NewPermissionService(workingDir, false, nil, nil, store)
+	require.True(t, request(service, "session1", "edit", nil))
+	require.True(t, request(service, "session2", "write", nil))
+	// Session grants don't apply to other sessions.
+	require.False(t, request(service, "session2", "edit", service.Deny))
+}
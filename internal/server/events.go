@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// Kinds of the events streamed to the clients.
+const (
+	EventSession                EventKind = "session"
+	EventMessage                EventKind = "message"
+	EventPermission             EventKind = "permission"
+	EventPermissionNotification EventKind = "permission_notification"
+	// EventRun is sent when a prompt sent through the API finishes.
+	EventRun EventKind = "run"
+)
+
+// EventKind is what an event is about.
+type EventKind string
+
+// Event is an event streamed to the clients. Type tells whether the payload
+// was created, updated or deleted.
+type Event struct {
+	Kind    EventKind        `json:"kind"`
+	Type    pubsub.EventType `json:"type"`
+	Payload any              `json:"payload"`
+}
+
+// Run is the payload of the [EventRun] events.
+type Run struct {
+	SessionID string `json:"session_id"`
+	Response  string `json:"response,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// forwardEvents turns the events of the app into the events of the API and
+// tracks the pending permission requests.
+func (s *Server) forwardEvents(ctx context.Context) {
+	events := s.app.Events()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-events:
+			if !ok {
+				return
+			}
+			event, ok := s.toEvent(msg)
+			if ok {
+				s.events.Publish(event.Type, event)
+			}
+		}
+	}
+}
+
+// toEvent converts an event of the app, reporting whether the API streams
+// it.
+func (s *Server) toEvent(msg tea.Msg) (Event, bool) {
+	switch ev := msg.(type) {
+	case pubsub.Event[session.Session]:
+		return Event{Kind: EventSession, Type: ev.Type, Payload: newSession(ev.Payload)}, true
+	case pubsub.Event[message.Message]:
+		return Event{Kind: EventMessage, Type: ev.Type, Payload: newMessage(ev.Payload)}, true
+	case pubsub.Event[permission.PermissionRequest]:
+		s.pending.Set(ev.Payload.ID, ev.Payload)
+		return Event{Kind: EventPermission, Type: ev.Type, Payload: ev.Payload}, true
+	case pubsub.Event[permission.PermissionNotification]:
+		if ev.Payload.Granted || ev.Payload.Denied {
+			for id, req := range s.pending.Seq2() {
+				if req.ToolCallID == ev.Payload.ToolCallID {
+					s.pending.Del(id)
+				}
+			}
+		}
+		return Event{Kind: EventPermissionNotification, Type: ev.Type, Payload: ev.Payload}, true
+	}
+	return Event{}, false
+}
+
+// streamEvents streams the events to the client as server-sent events, until
+// it disconnects.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events := s.events.Subscribe(r.Context())
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event.Payload)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Payload.Kind, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
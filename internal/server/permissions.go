@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/crush/internal/permission"
+)
+
+// Decisions of the answers to permission requests.
+const (
+	DecisionAllow        = "allow"
+	DecisionAllowSession = "allow_session"
+	DecisionAllowProject = "allow_project"
+	DecisionDeny         = "deny"
+)
+
+type permissionAnswer struct {
+	Decision string `json:"decision"`
+}
+
+func (s *Server) listPermissions(w http.ResponseWriter, r *http.Request) {
+	pending := slices.Collect(s.pending.Seq())
+	slices.SortFunc(pending, func(a, b permission.PermissionRequest) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	if pending == nil {
+		pending = []permission.PermissionRequest{}
+	}
+	writeJSON(w, http.StatusOK, pending)
+}
+
+func (s *Server) answerPermission(w http.ResponseWriter, r *http.Request) {
+	var answer permissionAnswer
+	if err := decode(r, &answer); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id := r.PathValue("id")
+	req, ok := s.pending.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("permission request %q not found", id))
+		return
+	}
+
+	switch answer.Decision {
+	case DecisionAllow:
+		s.app.Permissions.Grant(req)
+	case DecisionAllowSession:
+		s.app.Permissions.GrantPersistent(req)
+	case DecisionAllowProject:
+		s.app.Permissions.GrantForProject(req)
+	case DecisionDeny:
+		s.app.Permissions.Deny(req)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid decision %q, expected one of: %s", answer.Decision, strings.Join([]string{DecisionAllow, DecisionAllowSession, DecisionAllowProject, DecisionDeny}, ", ")))
+		return
+	}
+	s.pending.Del(id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,233 @@
+// Package server implements the HTTP API of Crush, which runs the app
+// headless so that dashboards and editor plugins can drive one long-lived
+// Crush per project.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/csync"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+)
+
+// Server serves the HTTP API of an app.
+type Server struct {
+	app *app.App
+	// token is the bearer token clients have to send.
+	token string
+	// hosts are the hosts the requests can be sent to, besides the loopback
+	// ones.
+	hosts []string
+
+	events *pubsub.Broker[Event]
+	// pending holds the permission requests waiting for an answer.
+	pending *csync.Map[string, permission.PermissionRequest]
+
+	// ctx is the context of the runs, which outlive the requests that start
+	// them.
+	ctx context.Context
+
+	readyOnce sync.Once
+	readyErr  error
+}
+
+// New creates the server of the app. Requests must carry token as a bearer
+// token, all of them are rejected when it's empty.
+func New(app *app.App, token string) *Server {
+	return &Server{
+		app:     app,
+		token:   token,
+		events:  pubsub.NewBroker[Event](),
+		pending: csync.NewMap[string, permission.PermissionRequest](),
+		ctx:     context.Background(),
+	}
+}
+
+// ListenAndServe serves the API on addr until the context is done.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	s.ctx = ctx
+	s.hosts = ListenHosts(addr)
+	go s.forwardEvents(ctx)
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		s.events.Shutdown()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("Serving the HTTP API", "addr", addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler returns the handler of the API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/sessions", s.listSessions)
+	mux.HandleFunc("POST /v1/sessions", s.createSession)
+	mux.HandleFunc("GET /v1/sessions/{id}", s.getSession)
+	mux.HandleFunc("GET /v1/sessions/{id}/messages", s.listMessages)
+	mux.HandleFunc("POST /v1/sessions/{id}/prompts", s.sendPrompt)
+	mux.HandleFunc("POST /v1/sessions/{id}/cancel", s.cancel)
+	mux.HandleFunc("GET /v1/permissions", s.listPermissions)
+	mux.HandleFunc("POST /v1/permissions/{id}", s.answerPermission)
+	mux.HandleFunc("GET /v1/events", s.streamEvents)
+	return s.authorize(mux)
+}
+
+// authorize checks the host, origin and bearer token of the requests, and
+// rejects requests with a body that isn't JSON, which browsers can't send
+// cross-origin without a preflight.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	checkBody := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.ContentLength != 0 {
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errors.New("request body must be JSON"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+	if s.token == "" {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeError(w, http.StatusUnauthorized, errors.New("no token configured"))
+		})
+	}
+	return Protect(checkBody, s.token, s.hosts...)
+}
+
+// Protect only lets the requests sent to a loopback host, or one of hosts,
+// and from the same origin when they come from a browser, through to next.
+// This keeps web pages from reaching a local server through DNS rebinding.
+// When token isn't empty, requests must carry it as a bearer token too.
+func Protect(next http.Handler, token string, hosts ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !allowedHost(r.Host, hosts) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not allowed", r.Host))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(origin, r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("origin %q is not allowed", origin))
+			return
+		}
+		if token != "" {
+			want := "Bearer " + token
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("invalid or missing token"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ListenHosts returns the hosts requests can be sent to on addr, besides the
+// loopback ones: its host, unless it listens on all interfaces.
+func ListenHosts(addr string) []string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		return nil
+	}
+	return []string{host}
+}
+
+func allowedHost(hostport string, hosts []string) bool {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return isLoopbackHost(host) || slices.ContainsFunc(hosts, func(h string) bool {
+		return strings.EqualFold(h, host)
+	})
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func sameOrigin(origin, host string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return strings.EqualFold(u.Host, host)
+}
+
+// ready waits for the MCP clients to be initialized, so that the agent gets
+// their tools.
+func (s *Server) ready(ctx context.Context) error {
+	s.readyOnce.Do(func() {
+		if err := mcp.WaitForInit(ctx); err != nil {
+			s.readyErr = fmt.Errorf("failed to wait for MCP initialization: %w", err)
+			return
+		}
+		if s.app.AgentCoordinator == nil {
+			s.readyErr = errors.New("no providers configured - please run 'crush' to set up a provider interactively")
+			return
+		}
+		s.readyErr = s.app.AgentCoordinator.UpdateModels(ctx)
+	})
+	return s.readyErr
+}
+
+// errorResponse is the body of the responses of failed requests.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("Failed to write response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// decode decodes the JSON body of the request into v. Empty bodies leave v
+// untouched.
+func decode(r *http.Request, v any) error {
+	err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 10<<20)).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
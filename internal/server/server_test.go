@@ -0,0 +1,158 @@
+package server
+
+import (
+	"cmp"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/app"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, token string) *Server {
+	t.Helper()
+	return New(&app.App{
+		Permissions: permission.NewPermissionService(t.TempDir(), false, nil, nil, nil),
+	}, token)
+}
+
+func TestAuthorize(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t, "secret")
+	handler := s.Handler()
+
+	tests := []struct {
+		name        string
+		method      string
+		host        string
+		origin      string
+		auth        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{name: "missing token", method: http.MethodGet, want: http.StatusUnauthorized},
+		{name: "rebound host", method: http.MethodGet, host: "attacker.example:8765", auth: "Bearer secret", want: http.StatusForbidden},
+		{name: "localhost", method: http.MethodGet, host: "localhost:8765", auth: "Bearer secret", want: http.StatusOK},
+		{name: "ipv6 loopback", method: http.MethodGet, host: "[::1]:8765", auth: "Bearer secret", want: http.StatusOK},
+		{name: "cross origin", method: http.MethodGet, origin: "https://attacker.example", auth: "Bearer secret", want: http.StatusForbidden},
+		{name: "same origin", method: http.MethodGet, origin: "http://127.0.0.1:8765", auth: "Bearer secret", want: http.StatusOK},
+		{name: "wrong token", method: http.MethodGet, auth: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "valid token", method: http.MethodGet, auth: "Bearer secret", want: http.StatusOK},
+		{name: "form body", method: http.MethodPost, auth: "Bearer secret", contentType: "application/x-www-form-urlencoded", body: "decision=allow", want: http.StatusUnsupportedMediaType},
+		{name: "json body", method: http.MethodPost, auth: "Bearer secret", contentType: "application/json; charset=utf-8", body: `{"decision":"allow"}`, want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := "/v1/permissions"
+			if tt.method == http.MethodPost {
+				path += "/unknown"
+			}
+			req := httptest.NewRequest(tt.method, path, strings.NewReader(tt.body))
+			req.Host = cmp.Or(tt.host, "127.0.0.1:8765")
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, tt.want, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestAuthorizeWithoutToken(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestServer(t, "").Handler()
+	req := httptest.NewRequest(http.MethodGet, "/v1/permissions", nil)
+	req.Host = "127.0.0.1:8765"
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestListenHosts(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"crush.internal"}, ListenHosts("crush.internal:8765"))
+	require.Empty(t, ListenHosts("0.0.0.0:8765"))
+	require.Empty(t, ListenHosts(":8765"))
+}
+
+func TestAnswerPermission(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t, "secret")
+	handler := s.Handler()
+	newRequest := func(method, path, body string) *http.Request {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Host = "127.0.0.1:8765"
+		req.Header.Set("Authorization", "Bearer secret")
+		return req
+	}
+
+	_, ok := s.toEvent(pubsub.Event[permission.PermissionRequest]{
+		Type: pubsub.CreatedEvent,
+		Payload: permission.PermissionRequest{
+			ID:         "perm-1",
+			SessionID:  "session-1",
+			ToolCallID: "call-1",
+			ToolName:   "bash",
+		},
+	})
+	require.True(t, ok)
+
+	answer := func(id, body string) *httptest.ResponseRecorder {
+		req := newRequest(http.MethodPost, "/v1/permissions/"+id, body)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	list := httptest.NewRecorder()
+	handler.ServeHTTP(list, newRequest(http.MethodGet, "/v1/permissions", ""))
+	require.Equal(t, http.StatusOK, list.Code)
+	require.Contains(t, list.Body.String(), `"id":"perm-1"`)
+
+	require.Equal(t, http.StatusNotFound, answer("perm-2", `{"decision":"allow"}`).Code)
+	require.Equal(t, http.StatusBadRequest, answer("perm-1", `{"decision":"maybe"}`).Code)
+	require.Equal(t, http.StatusNoContent, answer("perm-1", `{"decision":"deny"}`).Code)
+	require.Equal(t, http.StatusNotFound, answer("perm-1", `{"decision":"deny"}`).Code)
+}
+
+func TestToEventClearsAnsweredPermissions(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t, "")
+	s.toEvent(pubsub.Event[permission.PermissionRequest]{
+		Type:    pubsub.CreatedEvent,
+		Payload: permission.PermissionRequest{ID: "perm-1", ToolCallID: "call-1"},
+	})
+	require.Equal(t, 1, s.pending.Len())
+
+	event, ok := s.toEvent(pubsub.Event[permission.PermissionNotification]{
+		Type:    pubsub.CreatedEvent,
+		Payload: permission.PermissionNotification{ToolCallID: "call-1", Granted: true},
+	})
+	require.True(t, ok)
+	require.Equal(t, EventPermissionNotification, event.Kind)
+	require.Zero(t, s.pending.Len())
+
+	_, ok = s.toEvent(struct{}{})
+	require.False(t, ok)
+}
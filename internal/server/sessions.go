@@ -0,0 +1,264 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/charmbracelet/crush/internal/agent"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/pubsub"
+	"github.com/charmbracelet/crush/internal/session"
+)
+
+// Session is the JSON representation of a session.
+type Session struct {
+	ID               string  `json:"id"`
+	ParentSessionID  string  `json:"parent_session_id,omitempty"`
+	Title            string  `json:"title"`
+	MessageCount     int64   `json:"message_count"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+	WorktreePath     string  `json:"worktree_path,omitempty"`
+	WorktreeBranch   string  `json:"worktree_branch,omitempty"`
+	CreatedAt        int64   `json:"created_at"`
+	UpdatedAt        int64   `json:"updated_at"`
+}
+
+func newSession(s session.Session) Session {
+	return Session{
+		ID:               s.ID,
+		ParentSessionID:  s.ParentSessionID,
+		Title:            s.Title,
+		MessageCount:     s.MessageCount,
+		PromptTokens:     s.PromptTokens,
+		CompletionTokens: s.CompletionTokens,
+		Cost:             s.Cost,
+		WorktreePath:     s.WorktreePath,
+		WorktreeBranch:   s.WorktreeBranch,
+		CreatedAt:        s.CreatedAt,
+		UpdatedAt:        s.UpdatedAt,
+	}
+}
+
+// Message is the JSON representation of a message.
+type Message struct {
+	ID          string       `json:"id"`
+	SessionID   string       `json:"session_id"`
+	Role        string       `json:"role"`
+	Model       string       `json:"model,omitempty"`
+	Provider    string       `json:"provider,omitempty"`
+	Text        string       `json:"text,omitempty"`
+	Reasoning   string       `json:"reasoning,omitempty"`
+	ToolCalls   []ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults []ToolResult `json:"tool_results,omitempty"`
+	Finish      string       `json:"finish_reason,omitempty"`
+	CreatedAt   int64        `json:"created_at"`
+	UpdatedAt   int64        `json:"updated_at"`
+}
+
+// ToolCall is the JSON representation of a tool call.
+type ToolCall struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Input    string `json:"input"`
+	Finished bool   `json:"finished"`
+}
+
+// ToolResult is the JSON representation of a tool result.
+type ToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name"`
+	Content    string `json:"content"`
+	IsError    bool   `json:"is_error"`
+}
+
+func newMessage(msg message.Message) Message {
+	m := Message{
+		ID:        msg.ID,
+		SessionID: msg.SessionID,
+		Role:      string(msg.Role),
+		Model:     msg.Model,
+		Provider:  msg.Provider,
+		Text:      msg.Content().Text,
+		Reasoning: msg.ReasoningContent().Thinking,
+		Finish:    string(msg.FinishReason()),
+		CreatedAt: msg.CreatedAt,
+		UpdatedAt: msg.UpdatedAt,
+	}
+	for _, tc := range msg.ToolCalls() {
+		m.ToolCalls = append(m.ToolCalls, ToolCall{ID: tc.ID, Name: tc.Name, Input: tc.Input, Finished: tc.Finished})
+	}
+	for _, tr := range msg.ToolResults() {
+		m.ToolResults = append(m.ToolResults, ToolResult{
+			ToolCallID: tr.ToolCallID,
+			Name:       tr.Name,
+			Content:    tr.Content,
+			IsError:    tr.IsError,
+		})
+	}
+	return m
+}
+
+type createSessionRequest struct {
+	Title string `json:"title"`
+	// Worktree creates the session in its own git worktree.
+	Worktree bool `json:"worktree"`
+}
+
+type promptRequest struct {
+	Prompt string `json:"prompt"`
+}
+
+type promptResponse struct {
+	SessionID string `json:"session_id"`
+	// Queued is set when the session was busy and the prompt was queued
+	// after the current run.
+	Queued bool `json:"queued"`
+}
+
+type cancelResponse struct {
+	Cancelled bool `json:"cancelled"`
+}
+
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := s.app.Sessions.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list sessions: %w", err))
+		return
+	}
+	result := make([]Session, 0, len(sessions))
+	for _, sess := range sessions {
+		result = append(result, newSession(sess))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var req createSessionRequest
+	if err := decode(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Title == "" {
+		req.Title = "New Session"
+	}
+
+	var sess session.Session
+	var err error
+	if req.Worktree {
+		sess, err = s.app.NewWorktreeSession(r.Context(), req.Title)
+	} else {
+		sess, err = s.app.Sessions.Create(r.Context(), req.Title)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to create session: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, newSession(sess))
+}
+
+func (s *Server) getSession(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, newSession(sess))
+}
+
+func (s *Server) listMessages(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	msgs, err := s.app.Messages.List(r.Context(), sess.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list session messages: %w", err))
+		return
+	}
+	result := make([]Message, 0, len(msgs))
+	for _, msg := range msgs {
+		result = append(result, newMessage(msg))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// sendPrompt starts a run of the agent with the prompt and returns right
+// away. The messages of the run are streamed as events, and an [EventRun]
+// event is sent once it finishes.
+func (s *Server) sendPrompt(w http.ResponseWriter, r *http.Request) {
+	var req promptRequest
+	if err := decode(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Prompt == "" {
+		writeError(w, http.StatusBadRequest, errors.New("prompt is required"))
+		return
+	}
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	if err := s.ready(s.ctx); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	queued := s.app.AgentCoordinator.IsSessionBusy(sess.ID)
+	s.app.UseWorktree(sess)
+	go s.run(sess.ID, req.Prompt)
+	writeJSON(w, http.StatusAccepted, promptResponse{SessionID: sess.ID, Queued: queued})
+}
+
+func (s *Server) run(sessionID, prompt string) {
+	result, err := s.app.AgentCoordinator.Run(s.ctx, sessionID, prompt)
+	if result == nil && err == nil {
+		// The prompt was queued, the current run of the session picks it up.
+		return
+	}
+	run := Run{SessionID: sessionID}
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, agent.ErrRequestCancelled):
+		run.Error = "cancelled"
+	case err != nil:
+		slog.Error("Agent processing failed", "session_id", sessionID, "error", err)
+		run.Error = err.Error()
+	default:
+		run.Response = result.Response.Content.Text()
+	}
+	s.events.Publish(pubsub.CreatedEvent, Event{Kind: EventRun, Type: pubsub.CreatedEvent, Payload: run})
+}
+
+func (s *Server) cancel(w http.ResponseWriter, r *http.Request) {
+	sess, ok := s.session(w, r)
+	if !ok {
+		return
+	}
+	if s.app.AgentCoordinator == nil || !s.app.AgentCoordinator.IsSessionBusy(sess.ID) {
+		writeJSON(w, http.StatusOK, cancelResponse{})
+		return
+	}
+	s.app.AgentCoordinator.Cancel(sess.ID)
+	writeJSON(w, http.StatusOK, cancelResponse{Cancelled: true})
+}
+
+// session returns the session of the request, writing the error response
+// when it can't.
+func (s *Server) session(w http.ResponseWriter, r *http.Request) (session.Session, bool) {
+	id := r.PathValue("id")
+	sess, err := s.app.Sessions.Get(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("session %q not found", id))
+		return session.Session{}, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to get session %q: %w", id, err))
+		return session.Session{}, false
+	}
+	return sess, true
+}
@@ -36,10 +36,18 @@ type Session struct {
 	PromptTokens     int64
 	CompletionTokens int64
 	SummaryMessageID string
-	Cost             float64
-	Todos            []Todo
-	CreatedAt        int64
-	UpdatedAt        int64
+	// SummaryKeptMessageID is the first of the messages before the summary
+	// that were kept verbatim.
+	SummaryKeptMessageID string
+	// ElidedMessageID is the last message whose tool results, like the ones
+	// of the messages before it, are elided from the history.
+	ElidedMessageID string
+	Cost            float64
+	Todos           []Todo
+	WorktreePath    string
+	WorktreeBranch  string
+	CreatedAt       int64
+	UpdatedAt       int64
 }
 
 type Service interface {
@@ -167,6 +175,22 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 			String: todosJSON,
 			Valid:  todosJSON != "",
 		},
+		WorktreePath: sql.NullString{
+			String: session.WorktreePath,
+			Valid:  session.WorktreePath != "",
+		},
+		WorktreeBranch: sql.NullString{
+			String: session.WorktreeBranch,
+			Valid:  session.WorktreeBranch != "",
+		},
+		SummaryKeptMessageID: sql.NullString{
+			String: session.SummaryKeptMessageID,
+			Valid:  session.SummaryKeptMessageID != "",
+		},
+		ElidedMessageID: sql.NullString{
+			String: session.ElidedMessageID,
+			Valid:  session.ElidedMessageID != "",
+		},
 	})
 	if err != nil {
 		return Session{}, err
@@ -206,17 +230,21 @@ func (s service) fromDBItem(item db.Session) Session {
 		slog.Error("failed to unmarshal todos", "session_id", item.ID, "error", err)
 	}
 	return Session{
-		ID:               item.ID,
-		ParentSessionID:  item.ParentSessionID.String,
-		Title:            item.Title,
-		MessageCount:     item.MessageCount,
-		PromptTokens:     item.PromptTokens,
-		CompletionTokens: item.CompletionTokens,
-		SummaryMessageID: item.SummaryMessageID.String,
-		Cost:             item.Cost,
-		Todos:            todos,
-		CreatedAt:        item.CreatedAt,
-		UpdatedAt:        item.UpdatedAt,
+		ID:                   item.ID,
+		ParentSessionID:      item.ParentSessionID.String,
+		Title:                item.Title,
+		MessageCount:         item.MessageCount,
+		PromptTokens:         item.PromptTokens,
+		CompletionTokens:     item.CompletionTokens,
+		SummaryMessageID:     item.SummaryMessageID.String,
+		SummaryKeptMessageID: item.SummaryKeptMessageID.String,
+		ElidedMessageID:      item.ElidedMessageID.String,
+		Cost:                 item.Cost,
+		Todos:                todos,
+		WorktreePath:         item.WorktreePath.String,
+		WorktreeBranch:       item.WorktreeBranch.String,
+		CreatedAt:            item.CreatedAt,
+		UpdatedAt:            item.UpdatedAt,
 	}
 }
 
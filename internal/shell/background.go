@@ -85,8 +85,9 @@ func GetBackgroundShellManager() *BackgroundShellManager {
 	return backgroundManager
 }
 
-// Start creates and starts a new background shell with the given command.
-func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, blockFuncs []BlockFunc, command string, description string) (*BackgroundShell, error) {
+// Start creates and starts a new background shell with the given command,
+// sandboxed when sandbox isn't nil.
+func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, blockFuncs []BlockFunc, sandbox *Sandbox, command string, description string) (*BackgroundShell, error) {
 	// Check job limit
 	if m.shells.Len() >= MaxBackgroundJobs {
 		return nil, fmt.Errorf("maximum number of background jobs (%d) reached. Please terminate or wait for some jobs to complete", MaxBackgroundJobs)
@@ -97,6 +98,7 @@ func (m *BackgroundShellManager) Start(ctx context.Context, workingDir string, b
 	shell := NewShell(&Options{
 		WorkingDir: workingDir,
 		BlockFuncs: blockFuncs,
+		Sandbox:    sandbox,
 	})
 
 	shellCtx, cancel := context.WithCancel(ctx)
@@ -0,0 +1,204 @@
+package shell
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+)
+
+// Sandbox restricts what the commands run by a shell can do, using the
+// isolation the operating system provides. Only Linux is supported: commands
+// run in user, mount and network namespaces when the kernel allows it, or
+// under Landlock otherwise.
+type Sandbox struct {
+	// Writable lists the paths commands may write to, besides the temporary
+	// directory. Everything else is read-only.
+	Writable []string
+	// ReadOnly lists paths under the writable ones that stay read-only, like
+	// the configuration of crush.
+	ReadOnly []string
+	// Network allows commands to access the network.
+	Network bool
+}
+
+// sandboxArg is the first argument crush is re-executed with to set up the
+// sandbox of a command.
+const sandboxArg = "__crush_sandbox"
+
+// sandboxKillTimeout is how long an interrupted command has to exit before
+// it's killed.
+const sandboxKillTimeout = 2 * time.Second
+
+// sandboxPolicy is what the sandbox helper enforces before running a
+// command.
+type sandboxPolicy struct {
+	Writable   []string `json:"writable"`
+	ReadOnly   []string `json:"read_only"`
+	Network    bool     `json:"network"`
+	Namespaces bool     `json:"namespaces"`
+}
+
+// SandboxInit sets up the sandbox and runs the command when the process was
+// started as the sandbox helper of a shell, and returns otherwise. Programs
+// that run sandboxed shells must call it first thing in main.
+func SandboxInit() {
+	if len(os.Args) < 2 || os.Args[1] != sandboxArg {
+		return
+	}
+	os.Exit(runSandboxed(os.Args[2:]))
+}
+
+// policy returns the policy for commands run from the shell.
+func (sb *Sandbox) policy() sandboxPolicy {
+	writable := []string{os.TempDir()}
+	for _, path := range sb.Writable {
+		if abs, err := filepath.Abs(path); err == nil {
+			writable = append(writable, abs)
+		}
+	}
+	var readOnly []string
+	for _, path := range sb.ReadOnly {
+		if abs, err := filepath.Abs(path); err == nil {
+			readOnly = append(readOnly, abs)
+		}
+	}
+	return sandboxPolicy{
+		Writable: writable,
+		ReadOnly: readOnly,
+		Network:  sb.Network,
+	}
+}
+
+// helperArgs returns the arguments to re-execute crush with to run the
+// command at path in the sandbox.
+func (p sandboxPolicy) helperArgs(path string, args []string) ([]string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{sandboxArg, string(data), path}, args...), nil
+}
+
+// sandboxExecHandler runs commands through the sandbox helper. It replaces
+// the interpreter's default exec handler, so it never calls next.
+func (s *Shell) sandboxExecHandler() func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+		return func(ctx context.Context, args []string) error {
+			hc := interp.HandlerCtx(ctx)
+			path, err := interp.LookPathDir(hc.Dir, hc.Env, args[0])
+			if err != nil {
+				fmt.Fprintln(hc.Stderr, err)
+				return interp.ExitStatus(127)
+			}
+
+			cmd, err := sandboxCommand(ctx, s.sandbox.policy(), path, args)
+			if err != nil {
+				fmt.Fprintf(hc.Stderr, "sandbox: %v\n", err)
+				return interp.ExitStatus(126)
+			}
+			cmd.Env = execEnv(hc)
+			cmd.Dir = hc.Dir
+			cmd.Stdin = hc.Stdin
+			cmd.Stdout = hc.Stdout
+			cmd.Stderr = hc.Stderr
+			cmd.WaitDelay = sandboxKillTimeout
+
+			err = cmd.Run()
+			var exitErr *exec.ExitError
+			switch {
+			case err == nil:
+				return nil
+			case errors.As(err, &exitErr):
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return interp.ExitStatus(exitErr.ExitCode())
+			default:
+				fmt.Fprintf(hc.Stderr, "sandbox: %v\n", err)
+				return interp.ExitStatus(126)
+			}
+		}
+	}
+}
+
+// sandboxOpenHandler keeps redirections, which the interpreter handles
+// itself, from writing outside the writable paths of the sandbox or to its
+// read-only paths.
+func (s *Shell) sandboxOpenHandler() interp.OpenHandlerFunc {
+	open := interp.DefaultOpenHandler()
+	policy := s.sandbox.policy()
+	return func(ctx context.Context, path string, flag int, perm os.FileMode) (io.ReadWriteCloser, error) {
+		if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) == 0 {
+			return open(ctx, path, flag, perm)
+		}
+		abs := path
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(interp.HandlerCtx(ctx).Dir, path)
+		}
+		if !sandboxAllowsWrite(policy.Writable, policy.ReadOnly, abs) {
+			return nil, &os.PathError{Op: "open", Path: path, Err: fs.ErrPermission}
+		}
+		return open(ctx, path, flag, perm)
+	}
+}
+
+// sandboxAllowsWrite reports whether path is a device or is in one of the
+// writable paths but not in one of the read-only ones, following symlinks.
+func sandboxAllowsWrite(writable, readOnly []string, path string) bool {
+	path = resolvePath(path)
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeDevice != 0 {
+		return true
+	}
+	for _, root := range readOnly {
+		if isUnder(path, resolvePath(root)) {
+			return false
+		}
+	}
+	for _, root := range writable {
+		if isUnder(path, resolvePath(root)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath resolves the symlinks of path, or of its directory when it
+// doesn't exist.
+func resolvePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(dir, filepath.Base(path))
+	}
+	return path
+}
+
+// isUnder reports whether path is dir or under it.
+func isUnder(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// execEnv returns the exported variables of the interpreter, as the default
+// exec handler passes them to commands.
+func execEnv(hc interp.HandlerContext) []string {
+	var env []string
+	for name, vr := range hc.Env.Each {
+		if vr.IsSet() && vr.Exported && vr.Kind == expand.String {
+			env = append(env, name+"="+vr.String())
+		}
+	}
+	return env
+}
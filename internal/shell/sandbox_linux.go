@@ -0,0 +1,349 @@
+//go:build linux
+
+package shell
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxCommand returns the command that runs the command at path through
+// the sandbox helper.
+func sandboxCommand(ctx context.Context, policy sandboxPolicy, path string, args []string) (*exec.Cmd, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	policy.Namespaces = namespacesSupported()
+	helperArgs, err := policy.helperArgs(path, args)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, exe, helperArgs...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if policy.Namespaces {
+		setNamespaces(cmd.SysProcAttr, policy.Network)
+	}
+	// Interrupt the whole process group, like the default exec handler.
+	cmd.Cancel = func() error {
+		return unix.Kill(-cmd.Process.Pid, unix.SIGINT)
+	}
+	return cmd, nil
+}
+
+// setNamespaces makes the command start in new user and mount namespaces,
+// and a new network namespace unless the network is allowed. The user keeps
+// its IDs in the user namespace.
+func setNamespaces(attr *syscall.SysProcAttr, network bool) {
+	attr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS
+	if !network {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+}
+
+// namespacesSupported reports whether the sandbox helper can isolate
+// commands with namespaces, by running it without a command. Kernels may
+// forbid unprivileged user namespaces.
+var namespacesSupported = sync.OnceValue(func() bool {
+	exe, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	helperArgs, err := sandboxPolicy{Namespaces: true}.helperArgs("", nil)
+	if err != nil {
+		return false
+	}
+	cmd := exec.Command(exe, helperArgs[:2]...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{}
+	setNamespaces(cmd.SysProcAttr, false)
+	return cmd.Run() == nil
+})
+
+// runSandboxed is the sandbox helper: it applies the policy in args[0] to
+// itself and executes the command in args[1:], or exits when there's none.
+func runSandboxed(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "sandbox: missing policy")
+		return 126
+	}
+	var policy sandboxPolicy
+	if err := json.Unmarshal([]byte(args[0]), &policy); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: invalid policy: %v\n", err)
+		return 126
+	}
+
+	// The restrictions apply to the calling thread, which then becomes the
+	// command.
+	runtime.LockOSThread()
+	if err := policy.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		return 126
+	}
+	if len(args) < 3 {
+		return 0
+	}
+
+	err := unix.Exec(args[1], args[2:], initialEnviron())
+	fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+	return 126
+}
+
+// initialEnviron returns the environment the process started with, before
+// anything in crush changed it.
+func initialEnviron() []string {
+	data, err := os.ReadFile("/proc/self/environ")
+	if err != nil {
+		return os.Environ()
+	}
+	var env []string
+	for kv := range bytes.SplitSeq(data, []byte{0}) {
+		if len(kv) > 0 {
+			env = append(env, string(kv))
+		}
+	}
+	return env
+}
+
+// apply restricts the calling thread to the policy.
+func (p sandboxPolicy) apply() error {
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+	if p.Namespaces {
+		if err := p.mountReadOnly(); err != nil {
+			return err
+		}
+		return dropCapabilities()
+	}
+	return p.landlock()
+}
+
+// writablePaths returns the existing writable paths with symlinks resolved,
+// along with the shared memory directory.
+func (p sandboxPolicy) writablePaths() []string {
+	var paths []string
+	for _, path := range append(p.Writable, "/dev/shm") {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			paths = append(paths, resolved)
+		}
+	}
+	return paths
+}
+
+// readOnlyPaths returns the read-only paths with symlinks resolved, the ones
+// that don't exist included.
+func (p sandboxPolicy) readOnlyPaths() []string {
+	paths := make([]string, 0, len(p.ReadOnly))
+	for _, path := range p.ReadOnly {
+		paths = append(paths, resolvePath(path))
+	}
+	return paths
+}
+
+// mountReadOnly makes every mount of the mount namespace read-only, except
+// for the writable paths, and the existing read-only paths under them.
+func (p sandboxPolicy) mountReadOnly() error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("failed to make mounts private: %w", err)
+	}
+	writable := p.writablePaths()
+	// Bind the writable paths onto themselves first so they're mounts whose
+	// read-only flag can be cleared on their own.
+	for _, path := range writable {
+		if err := unix.Mount(path, path, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("failed to bind %s: %w", path, err)
+		}
+	}
+	if err := unix.MountSetattr(unix.AT_FDCWD, "/", unix.AT_RECURSIVE, &unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY}); err != nil {
+		return fmt.Errorf("failed to make the filesystem read-only: %w", err)
+	}
+	for _, path := range writable {
+		if err := unix.MountSetattr(unix.AT_FDCWD, path, unix.AT_RECURSIVE, &unix.MountAttr{Attr_clr: unix.MOUNT_ATTR_RDONLY}); err != nil {
+			return fmt.Errorf("failed to make %s writable: %w", path, err)
+		}
+	}
+	// Bind the read-only paths onto themselves as read-only mounts. Their
+	// directories up to the writable path become mounts too, which can't be
+	// renamed or removed, so that the paths can't be moved away and replaced.
+	mounted := make(map[string]bool)
+	for _, path := range p.readOnlyPaths() {
+		if _, err := os.Lstat(path); err != nil {
+			continue
+		}
+		parent := ""
+		for _, dir := range writable {
+			if dir != path && isUnder(path, dir) && len(dir) > len(parent) {
+				parent = dir
+			}
+		}
+		if parent == "" {
+			continue
+		}
+		var dirs []string
+		for dir := filepath.Dir(path); dir != parent && isUnder(dir, parent); dir = filepath.Dir(dir) {
+			dirs = append(dirs, dir)
+		}
+		slices.Reverse(dirs)
+		for _, dir := range append(dirs, path) {
+			if mounted[dir] {
+				continue
+			}
+			if err := unix.Mount(dir, dir, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+				return fmt.Errorf("failed to bind %s: %w", dir, err)
+			}
+			mounted[dir] = true
+		}
+		if err := unix.MountSetattr(unix.AT_FDCWD, path, unix.AT_RECURSIVE, &unix.MountAttr{Attr_set: unix.MOUNT_ATTR_RDONLY}); err != nil {
+			return fmt.Errorf("failed to make %s read-only: %w", path, err)
+		}
+	}
+	// The working directory still refers to the mount from before the binds.
+	if wd, err := os.Getwd(); err == nil {
+		return unix.Chdir(wd)
+	}
+	return nil
+}
+
+// dropCapabilities empties the capability bounding set, so commands run as
+// root get no capabilities in the user namespace and can't undo the mounts.
+func dropCapabilities() error {
+	for c := 0; ; c++ {
+		err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0)
+		if errors.Is(err, unix.EINVAL) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to drop capabilities: %w", err)
+		}
+	}
+}
+
+// landlock restricts writes to the writable paths and, unless the network is
+// allowed, TCP connections with Landlock. Landlock can't take back access to
+// the read-only paths, so the writable paths holding some are split into
+// their other entries: nothing can be created directly in them.
+func (p sandboxPolicy) landlock() error {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return fmt.Errorf("neither user namespaces nor Landlock are available: %w", errno)
+	}
+
+	fileAccess := uint64(unix.LANDLOCK_ACCESS_FS_WRITE_FILE)
+	if abi >= 3 {
+		fileAccess |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	if abi >= 5 {
+		fileAccess |= unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+	dirAccess := fileAccess |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+	if abi >= 2 {
+		dirAccess |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+
+	attr := unix.LandlockRulesetAttr{Access_fs: dirAccess}
+	if !p.Network {
+		if abi < 4 {
+			return errors.New("blocking the network needs user namespaces or Landlock ABI 4 (Linux 6.7)")
+		}
+		attr.Access_net = unix.LANDLOCK_ACCESS_NET_BIND_TCP | unix.LANDLOCK_ACCESS_NET_CONNECT_TCP
+	}
+	ruleset, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create Landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(ruleset))
+
+	allow := func(path string, dirAccess uint64) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil
+		}
+		access := fileAccess
+		if info.IsDir() {
+			access = dirAccess
+		}
+		fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer unix.Close(fd)
+		rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+		if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_ADD_RULE, ruleset, unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule))); errno != 0 {
+			return fmt.Errorf("failed to allow writing to %s: %w", path, errno)
+		}
+		return nil
+	}
+	readOnly := p.readOnlyPaths()
+	for _, dir := range p.writablePaths() {
+		for _, path := range splitWritable(dir, readOnly) {
+			if err := allow(path, dirAccess); err != nil {
+				return err
+			}
+		}
+	}
+	// Devices like /dev/null stay writable, but nothing can be created there.
+	if err := allow("/dev", fileAccess); err != nil {
+		return err
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, ruleset, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce Landlock ruleset: %w", errno)
+	}
+	return nil
+}
+
+// splitWritable returns path when it holds none of the read-only paths, or
+// its entries that aren't read-only, split the same way. Symlinks are left
+// out, as Landlock would grant access to their targets.
+func splitWritable(path string, readOnly []string) []string {
+	inside := false
+	for _, ro := range readOnly {
+		if ro == path {
+			return nil
+		}
+		if isUnder(ro, path) {
+			inside = true
+		}
+	}
+	if !inside {
+		return []string{path}
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, entry := range entries {
+		if entry.Type()&os.ModeSymlink != 0 {
+			continue
+		}
+		paths = append(paths, splitWritable(filepath.Join(path, entry.Name()), readOnly)...)
+	}
+	return paths
+}
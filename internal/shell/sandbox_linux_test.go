@@ -0,0 +1,37 @@
+//go:build linux
+
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitWritable(t *testing.T) {
+	t.Parallel()
+
+	work := t.TempDir()
+	for _, dir := range []string{".git/hooks", ".git/objects", "src"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(work, dir), 0o755))
+	}
+	for _, file := range []string{"crush.json", "go.mod", ".git/config"} {
+		require.NoError(t, os.WriteFile(filepath.Join(work, file), nil, 0o644))
+	}
+	require.NoError(t, os.Symlink("/etc", filepath.Join(work, "etc")))
+
+	readOnly := []string{
+		filepath.Join(work, "crush.json"),
+		filepath.Join(work, ".crush.json"),
+		filepath.Join(work, ".git/hooks"),
+		filepath.Join(work, ".git/config"),
+	}
+	require.ElementsMatch(t, []string{
+		filepath.Join(work, ".git/objects"),
+		filepath.Join(work, "go.mod"),
+		filepath.Join(work, "src"),
+	}, splitWritable(work, readOnly))
+	require.Equal(t, []string{filepath.Join(work, "src")}, splitWritable(filepath.Join(work, "src"), readOnly))
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package shell
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+func sandboxCommand(context.Context, sandboxPolicy, string, []string) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("sandboxing is not supported on %s", runtime.GOOS)
+}
+
+func runSandboxed([]string) int {
+	fmt.Fprintf(os.Stderr, "sandbox: sandboxing is not supported on %s\n", runtime.GOOS)
+	return 126
+}
@@ -0,0 +1,167 @@
+package shell
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// Sandboxed commands re-execute the test binary to set up the sandbox.
+	SandboxInit()
+	os.Exit(m.Run())
+}
+
+func TestSandbox(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Sandboxing is only supported on Linux")
+	}
+
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	tmp := filepath.Join(root, "tmp")
+	outside := filepath.Join(root, "outside")
+	for _, dir := range []string{work, tmp, outside} {
+		require.NoError(t, os.Mkdir(dir, 0o755))
+	}
+	t.Setenv("TMPDIR", tmp)
+
+	sh := NewShell(&Options{
+		WorkingDir: work,
+		Sandbox:    &Sandbox{Writable: []string{work}},
+	})
+	if _, stderr, err := sh.Exec(t.Context(), "true"); err != nil {
+		if strings.HasPrefix(stderr, "sandbox:") {
+			t.Skipf("Sandbox unavailable: %s", stderr)
+		}
+		t.Fatalf("true failed: %v: %s", err, stderr)
+	}
+
+	for _, command := range []string{
+		"touch file",
+		"echo hello > redirected",
+		"touch " + filepath.Join(tmp, "file"),
+		"echo hello > /dev/null",
+	} {
+		_, stderr, err := sh.Exec(t.Context(), command)
+		require.NoError(t, err, "%s: %s", command, stderr)
+	}
+	require.FileExists(t, filepath.Join(work, "file"))
+	require.FileExists(t, filepath.Join(work, "redirected"))
+
+	for _, command := range []string{
+		"touch " + filepath.Join(outside, "file"),
+		"echo hello > " + filepath.Join(outside, "redirected"),
+		"sh -c 'echo hello > " + filepath.Join(outside, "nested") + "'",
+		"cd " + outside + " && touch file",
+	} {
+		_, _, err := sh.Exec(t.Context(), command)
+		require.Error(t, err, command)
+	}
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	// Exit codes and the environment make it through the helper.
+	_, _, err = sh.Exec(t.Context(), "sh -c 'exit 3'")
+	require.Equal(t, 3, ExitCode(err))
+	stdout, _, err := sh.Exec(t.Context(), "export GREETING=hi && sh -c 'echo $GREETING'")
+	require.NoError(t, err)
+	require.Equal(t, "hi\n", stdout)
+}
+
+func TestSandboxWithGoCoreUtils(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Sandboxing is only supported on Linux")
+	}
+
+	orig := useGoCoreUtils
+	useGoCoreUtils = true
+	t.Cleanup(func() { useGoCoreUtils = orig })
+
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	tmp := filepath.Join(root, "tmp")
+	outside := filepath.Join(root, "outside")
+	for _, dir := range []string{work, tmp, outside} {
+		require.NoError(t, os.Mkdir(dir, 0o755))
+	}
+	t.Setenv("TMPDIR", tmp)
+	protected := filepath.Join(outside, "file")
+	require.NoError(t, os.WriteFile(protected, []byte("keep"), 0o644))
+
+	sh := NewShell(&Options{
+		WorkingDir: work,
+		Sandbox:    &Sandbox{Writable: []string{work}},
+	})
+	if _, stderr, err := sh.Exec(t.Context(), "true"); err != nil {
+		if strings.HasPrefix(stderr, "sandbox:") {
+			t.Skipf("Sandbox unavailable: %s", stderr)
+		}
+		t.Fatalf("true failed: %v: %s", err, stderr)
+	}
+
+	_, _, err := sh.Exec(t.Context(), "rm "+protected)
+	require.Error(t, err)
+	require.FileExists(t, protected)
+}
+
+func TestSandboxReadOnly(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Sandboxing is only supported on Linux")
+	}
+
+	root := t.TempDir()
+	work := filepath.Join(root, "work")
+	tmp := filepath.Join(root, "tmp")
+	for _, dir := range []string{filepath.Join(work, ".git", "hooks"), filepath.Join(work, "src"), tmp} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+	t.Setenv("TMPDIR", tmp)
+	config := filepath.Join(work, "crush.json")
+	require.NoError(t, os.WriteFile(config, []byte("{}"), 0o644))
+
+	sh := NewShell(&Options{
+		WorkingDir: work,
+		Sandbox: &Sandbox{
+			Writable: []string{work},
+			ReadOnly: []string{config, filepath.Join(work, ".crush.json"), filepath.Join(work, ".git", "hooks")},
+		},
+	})
+	if _, stderr, err := sh.Exec(t.Context(), "true"); err != nil {
+		if strings.HasPrefix(stderr, "sandbox:") {
+			t.Skipf("Sandbox unavailable: %s", stderr)
+		}
+		t.Fatalf("true failed: %v: %s", err, stderr)
+	}
+
+	for _, command := range []string{
+		"touch src/file",
+		"touch .git/index.lock",
+	} {
+		_, stderr, err := sh.Exec(t.Context(), command)
+		require.NoError(t, err, "%s: %s", command, stderr)
+	}
+
+	for _, command := range []string{
+		"echo '{\"options\": {}}' > crush.json",
+		"echo '{\"options\": {}}' > .crush.json",
+		"rm crush.json",
+		"mv crush.json src",
+		"touch .git/hooks/pre-commit",
+		"mv .git .git.old",
+	} {
+		_, _, err := sh.Exec(t.Context(), command)
+		require.Error(t, err, command)
+	}
+	data, err := os.ReadFile(config)
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(data))
+	require.NoFileExists(t, filepath.Join(work, ".crush.json"))
+	require.NoFileExists(t, filepath.Join(work, ".git", "hooks", "pre-commit"))
+	require.DirExists(t, filepath.Join(work, ".git"))
+}
@@ -56,6 +56,7 @@ type Shell struct {
 	mu         sync.Mutex
 	logger     Logger
 	blockFuncs []BlockFunc
+	sandbox    *Sandbox
 }
 
 // Options for creating a new shell
@@ -64,6 +65,8 @@ type Options struct {
 	Env        []string
 	Logger     Logger
 	BlockFuncs []BlockFunc
+	// Sandbox, when set, runs commands in a sandbox.
+	Sandbox *Sandbox
 }
 
 // NewShell creates a new shell instance with the given options
@@ -92,6 +95,7 @@ func NewShell(opts *Options) *Shell {
 		env:        env,
 		logger:     logger,
 		blockFuncs: opts.BlockFuncs,
+		sandbox:    opts.Sandbox,
 	}
 }
 
@@ -111,6 +115,16 @@ func (s *Shell) ExecStream(ctx context.Context, command string, stdout, stderr i
 	return s.execStream(ctx, command, stdout, stderr)
 }
 
+// ExecWithStdin executes a command in the shell, feeding it the given stdin
+func (s *Shell) ExecWithStdin(ctx context.Context, command string, stdin io.Reader) (string, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stdout, stderr bytes.Buffer
+	err := s.execCommon(ctx, command, stdin, &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
 // GetWorkingDir returns the current working directory
 func (s *Shell) GetWorkingDir() string {
 	s.mu.Lock()
@@ -237,14 +251,18 @@ func (s *Shell) blockHandler() func(next interp.ExecHandlerFunc) interp.ExecHand
 }
 
 // newInterp creates a new interpreter with the current shell state
-func (s *Shell) newInterp(stdout, stderr io.Writer) (*interp.Runner, error) {
-	return interp.New(
-		interp.StdIO(nil, stdout, stderr),
+func (s *Shell) newInterp(stdin io.Reader, stdout, stderr io.Writer) (*interp.Runner, error) {
+	opts := []interp.RunnerOption{
+		interp.StdIO(stdin, stdout, stderr),
 		interp.Interactive(false),
 		interp.Env(expand.ListEnviron(s.env...)),
 		interp.Dir(s.cwd),
 		interp.ExecHandlers(s.execHandlers()...),
-	)
+	}
+	if s.sandbox != nil {
+		opts = append(opts, interp.OpenHandler(s.sandboxOpenHandler()))
+	}
+	return interp.New(opts...)
 }
 
 // updateShellFromRunner updates the shell from the interpreter after execution.
@@ -259,13 +277,13 @@ func (s *Shell) updateShellFromRunner(runner *interp.Runner) {
 }
 
 // execCommon is the shared implementation for executing commands
-func (s *Shell) execCommon(ctx context.Context, command string, stdout, stderr io.Writer) error {
+func (s *Shell) execCommon(ctx context.Context, command string, stdin io.Reader, stdout, stderr io.Writer) error {
 	line, err := syntax.NewParser().Parse(strings.NewReader(command), "")
 	if err != nil {
 		return fmt.Errorf("could not parse command: %w", err)
 	}
 
-	runner, err := s.newInterp(stdout, stderr)
+	runner, err := s.newInterp(stdin, stdout, stderr)
 	if err != nil {
 		return fmt.Errorf("could not run command: %w", err)
 	}
@@ -279,19 +297,24 @@ func (s *Shell) execCommon(ctx context.Context, command string, stdout, stderr i
 // exec executes commands using a cross-platform shell interpreter.
 func (s *Shell) exec(ctx context.Context, command string) (string, string, error) {
 	var stdout, stderr bytes.Buffer
-	err := s.execCommon(ctx, command, &stdout, &stderr)
+	err := s.execCommon(ctx, command, nil, &stdout, &stderr)
 	return stdout.String(), stderr.String(), err
 }
 
 // execStream executes commands using POSIX shell emulation with streaming output
 func (s *Shell) execStream(ctx context.Context, command string, stdout, stderr io.Writer) error {
-	return s.execCommon(ctx, command, stdout, stderr)
+	return s.execCommon(ctx, command, nil, stdout, stderr)
 }
 
 func (s *Shell) execHandlers() []func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
 	handlers := []func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc{
 		s.blockHandler(),
 	}
+	// The Go coreutils run in-process, where the sandbox can't confine them,
+	// so sandboxed shells run the system ones instead.
+	if s.sandbox != nil {
+		return append(handlers, s.sandboxExecHandler())
+	}
 	if useGoCoreUtils {
 		handlers = append(handlers, coreutils.ExecHandler)
 	}
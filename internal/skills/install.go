@@ -0,0 +1,332 @@
+package skills
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// OriginFileName is the file in which Install records where a skill came
+// from, in the directory of the skill.
+const OriginFileName = ".crush-origin.json"
+
+// maxExtractedSize is the total size of the files extracted from a tarball
+// above which installing the skill fails.
+const maxExtractedSize = 100 << 20
+
+// Origin is where an installed skill came from.
+type Origin struct {
+	Source      string    `json:"source"`
+	Subpath     string    `json:"subpath,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// ReadOrigin returns the origin of the skill in dir, or nil if it wasn't
+// installed with Install.
+func ReadOrigin(dir string) *Origin {
+	data, err := os.ReadFile(filepath.Join(dir, OriginFileName))
+	if err != nil {
+		return nil
+	}
+	var origin Origin
+	if err := json.Unmarshal(data, &origin); err != nil {
+		return nil
+	}
+	return &origin
+}
+
+// Install installs the skill at source in the skills directory dir, in a
+// directory named after the skill. source is a local directory, a tarball
+// (.tar, .tar.gz or .tgz) given as a path or an http(s) URL, or a git URL;
+// subpath is the directory of the skill inside of it. The skill is validated
+// before it's copied, and an installed skill of the same name is only
+// replaced when force is set.
+func Install(ctx context.Context, source, subpath, dir string, force bool) (*Skill, error) {
+	tmp, err := os.MkdirTemp("", "crush-skill-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	root, err := fetch(ctx, source, tmp)
+	if err != nil {
+		return nil, err
+	}
+	if subpath != "" {
+		rel := filepath.Clean(filepath.FromSlash(subpath))
+		if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("subpath %q is outside of the source", subpath)
+		}
+		root = filepath.Join(root, rel)
+	}
+	src, err := findSkillDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	skill, err := Parse(filepath.Join(src, SkillFileName))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", SkillFileName, err)
+	}
+	dest := filepath.Join(dir, skill.Name)
+	skill.Path = dest
+	if err := skill.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid skill: %w", err)
+	}
+	if _, err := os.Stat(dest); err == nil && !force {
+		return nil, fmt.Errorf("skill %q is already installed in %s", skill.Name, dir)
+	}
+
+	// Copy next to the destination first, so a failed copy doesn't leave a
+	// broken skill behind.
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	staging, err := os.MkdirTemp(dir, "."+skill.Name+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+	if err := copyDir(src, staging); err != nil {
+		return nil, fmt.Errorf("copying skill: %w", err)
+	}
+	origin, err := json.MarshalIndent(Origin{
+		Source:      source,
+		Subpath:     subpath,
+		InstalledAt: time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(staging, OriginFileName), origin, 0o644); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(staging, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(staging, dest); err != nil {
+		return nil, err
+	}
+	return Parse(filepath.Join(dest, SkillFileName))
+}
+
+// Remove removes the skill named name from the skills directory dir.
+func Remove(dir, name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid skill name %q", name)
+	}
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(filepath.Join(path, SkillFileName)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("skill %q is not installed in %s", name, dir)
+		}
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+// fetch makes the contents of source available on disk, using tmp for the
+// ones that aren't, and returns their directory.
+func fetch(ctx context.Context, source, tmp string) (string, error) {
+	if info, err := os.Stat(source); err == nil {
+		if info.IsDir() {
+			return source, nil
+		}
+		f, err := os.Open(source)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		return tmp, extractTar(f, tmp)
+	}
+
+	lower := strings.ToLower(source)
+	isHTTP := strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+	if isHTTP && isTarball(lower) {
+		return tmp, download(ctx, source, tmp)
+	}
+	if isHTTP || strings.HasPrefix(lower, "git@") || strings.HasPrefix(lower, "git://") ||
+		strings.HasPrefix(lower, "ssh://") || strings.HasPrefix(lower, "file://") || strings.HasSuffix(lower, ".git") {
+		return clone(ctx, source, tmp)
+	}
+	return "", fmt.Errorf("%s is not a directory, a tarball or a git URL", source)
+}
+
+func isTarball(path string) bool {
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func download(ctx context.Context, url, dest string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading %s: %s", url, resp.Status)
+	}
+	return extractTar(resp.Body, dest)
+}
+
+func clone(ctx context.Context, url, tmp string) (string, error) {
+	dest := filepath.Join(tmp, "repo")
+	cmd := exec.CommandContext(ctx, "git", "clone", "--quiet", "--depth", "1", "--", url, dest)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("cloning %s: %w: %s", url, err, strings.TrimSpace(string(out)))
+	}
+	return dest, nil
+}
+
+// extractTar extracts the regular files and directories of the tar archive,
+// compressed with gzip or not, to dest, up to maxExtractedSize bytes.
+func extractTar(r io.Reader, dest string) error {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	tr := tar.NewReader(r)
+	var size int64
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("archive has an invalid path %q", header.Name)
+		}
+		path := filepath.Join(dest, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			size += header.Size
+			if size > maxExtractedSize {
+				return fmt.Errorf("archive is larger than %d MiB", maxExtractedSize>>20)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			if err := writeFile(path, tr, fs.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// findSkillDir returns dir if it has a SKILL.md file, or its only
+// subdirectory that has one, as tarballs usually wrap their contents in a
+// directory.
+func findSkillDir(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, SkillFileName)); err == nil {
+		return dir, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var found []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, entry.Name(), SkillFileName)); err == nil {
+			found = append(found, filepath.Join(dir, entry.Name()))
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no %s found", SkillFileName)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("found %d skills, choose one with a subpath", len(found))
+	}
+}
+
+// copyDir copies the regular files and directories of src to dest, leaving
+// out git metadata.
+func copyDir(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.Name() == ".git" || d.Name() == OriginFileName {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dest, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return writeFile(target, f, info.Mode())
+	})
+}
+
+// writeFile writes r to path, keeping the executable bits of mode.
+func writeFile(path string, r io.Reader, mode fs.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644|mode.Perm()&0o111)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
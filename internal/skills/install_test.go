@@ -0,0 +1,148 @@
+package skills
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeSkill(t *testing.T, dir, name string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "scripts"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SkillFileName), []byte("---\nname: "+name+"\ndescription: A skill for testing.\n---\n# Instructions\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "scripts", "run.sh"), []byte("#!/bin/sh\necho ok\n"), 0o755))
+}
+
+func TestInstallDirectory(t *testing.T) {
+	t.Parallel()
+
+	src := filepath.Join(t.TempDir(), "checkout")
+	writeSkill(t, src, "my-skill")
+	dir := t.TempDir()
+
+	skill, err := Install(t.Context(), src, "", dir, false)
+	require.NoError(t, err)
+	require.Equal(t, "my-skill", skill.Name)
+	require.Equal(t, filepath.Join(dir, "my-skill"), skill.Path)
+
+	info, err := os.Stat(filepath.Join(dir, "my-skill", "scripts", "run.sh"))
+	require.NoError(t, err)
+	require.NotZero(t, info.Mode()&0o100, "scripts stay executable")
+
+	origin := ReadOrigin(skill.Path)
+	require.NotNil(t, origin)
+	require.Equal(t, src, origin.Source)
+
+	_, err = Install(t.Context(), src, "", dir, false)
+	require.ErrorContains(t, err, "already installed")
+	_, err = Install(t.Context(), src, "", dir, true)
+	require.NoError(t, err)
+
+	found := Scan([]string{dir})
+	require.Len(t, found, 1)
+	require.NoError(t, found[0].Err)
+
+	require.NoError(t, Remove(dir, "my-skill"))
+	require.NoDirExists(t, skill.Path)
+	require.ErrorContains(t, Remove(dir, "my-skill"), "not installed")
+}
+
+func TestInstallInvalid(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, SkillFileName), []byte("---\nname: Not_Valid\n---\n"), 0o644))
+	dir := t.TempDir()
+
+	_, err := Install(t.Context(), src, "", dir, false)
+	require.ErrorContains(t, err, "invalid skill")
+	require.ErrorContains(t, err, "description is required")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	_, err = Install(t.Context(), src, "../elsewhere", dir, false)
+	require.ErrorContains(t, err, "outside of the source")
+}
+
+func TestInstallTarball(t *testing.T) {
+	t.Parallel()
+
+	archive := filepath.Join(t.TempDir(), "skill.tar.gz")
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	files := map[string]string{
+		"skills-main/pdf/SKILL.md":  "---\nname: pdf\ndescription: Works with PDF files.\n---\n",
+		"skills-main/xlsx/SKILL.md": "---\nname: xlsx\ndescription: Works with spreadsheets.\n---\n",
+	}
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	dir := t.TempDir()
+	_, err = Install(t.Context(), archive, "", dir, false)
+	require.ErrorContains(t, err, "no SKILL.md found")
+
+	skill, err := Install(t.Context(), archive, "skills-main/xlsx", dir, false)
+	require.NoError(t, err)
+	require.Equal(t, "xlsx", skill.Name)
+	require.FileExists(t, filepath.Join(dir, "xlsx", SkillFileName))
+}
+
+func TestExtractTarTooLarge(t *testing.T) {
+	t.Parallel()
+
+	// The archive is rejected from the header, before the contents.
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "big.bin", Mode: 0o644, Size: maxExtractedSize + 1, Typeflag: tar.TypeReg}))
+
+	dir := t.TempDir()
+	require.ErrorContains(t, extractTar(&buf, dir), "archive is larger than")
+	require.NoFileExists(t, filepath.Join(dir, "big.bin"))
+}
+
+func TestInstallGit(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	writeSkill(t, filepath.Join(repo, "skills", "review"), "review")
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "Add skill"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	dir := t.TempDir()
+	skill, err := Install(t.Context(), "file://"+filepath.ToSlash(repo), "skills/review", dir, false)
+	require.NoError(t, err)
+	require.Equal(t, "review", skill.Name)
+	require.NoDirExists(t, filepath.Join(dir, "review", ".git"))
+
+	// Sources that look like options are read as repositories.
+	_, err = Install(t.Context(), "--version.git", "", dir, false)
+	require.ErrorContains(t, err, "'--version.git' does not exist")
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 
@@ -21,9 +22,23 @@ const (
 	MaxNameLength          = 64
 	MaxDescriptionLength   = 1024
 	MaxCompatibilityLength = 500
+	// MaxToolNameLength is the length of the longest tool name providers
+	// accept, which limits the names of skills with scripts.
+	MaxToolNameLength = 64
 )
 
-var namePattern = regexp.MustCompile(`^[a-zA-Z0-9]+(-[a-zA-Z0-9]+)*$`)
+var (
+	namePattern          = regexp.MustCompile(`^[a-zA-Z0-9]+(-[a-zA-Z0-9]+)*$`)
+	parameterNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+)
+
+// Parameter types of scripts.
+const (
+	ParameterString  = "string"
+	ParameterInteger = "integer"
+	ParameterNumber  = "number"
+	ParameterBoolean = "boolean"
+)
 
 // Skill represents a parsed SKILL.md file.
 type Skill struct {
@@ -32,6 +47,7 @@ type Skill struct {
 	License       string            `yaml:"license,omitempty" json:"license,omitempty"`
 	Compatibility string            `yaml:"compatibility,omitempty" json:"compatibility,omitempty"`
 	Metadata      map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+	Scripts       []Script          `yaml:"scripts,omitempty" json:"scripts,omitempty"`
 	Instructions  string            `yaml:"-" json:"instructions"`
 	Path          string            `yaml:"-" json:"path"`
 	SkillFilePath string            `yaml:"-" json:"skill_file_path"`
@@ -65,6 +81,76 @@ func (s *Skill) Validate() error {
 		errs = append(errs, fmt.Errorf("compatibility exceeds %d characters", MaxCompatibilityLength))
 	}
 
+	seen := make(map[string]bool)
+	for _, script := range s.Scripts {
+		if seen[script.Name] {
+			errs = append(errs, fmt.Errorf("script %q is declared more than once", script.Name))
+		}
+		seen[script.Name] = true
+		if err := script.validate(s.Name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Script is a script of a skill the agent can call as a tool, declared in
+// the scripts field of the frontmatter, an extension to the spec. The command
+// runs in the directory of the skill, followed by the arguments of the call
+// as --name value flags, in the order of the parameters.
+type Script struct {
+	Name        string      `yaml:"name" json:"name"`
+	Description string      `yaml:"description" json:"description"`
+	Command     string      `yaml:"command" json:"command"`
+	Parameters  []Parameter `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+}
+
+// Parameter is a parameter of a script.
+type Parameter struct {
+	Name        string   `yaml:"name" json:"name"`
+	Type        string   `yaml:"type,omitempty" json:"type,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool     `yaml:"required,omitempty" json:"required,omitempty"`
+	Enum        []string `yaml:"enum,omitempty" json:"enum,omitempty"`
+}
+
+// ScriptToolName returns the name of the tool of the script of the skill.
+func ScriptToolName(skill, script string) string {
+	return "skill_" + skill + "_" + script
+}
+
+func (s Script) validate(skill string) error {
+	var errs []error
+	if !namePattern.MatchString(s.Name) {
+		errs = append(errs, errors.New("name must be alphanumeric with hyphens, no leading/trailing/consecutive hyphens"))
+	} else if name := ScriptToolName(skill, s.Name); len(name) > MaxToolNameLength {
+		errs = append(errs, fmt.Errorf("tool name %q exceeds %d characters", name, MaxToolNameLength))
+	}
+	if s.Description == "" {
+		errs = append(errs, errors.New("description is required"))
+	}
+	if strings.TrimSpace(s.Command) == "" {
+		errs = append(errs, errors.New("command is required"))
+	}
+	seen := make(map[string]bool)
+	for _, p := range s.Parameters {
+		switch {
+		case !parameterNamePattern.MatchString(p.Name):
+			errs = append(errs, fmt.Errorf("parameter %q: name must be alphanumeric with underscores or hyphens", p.Name))
+		case seen[p.Name]:
+			errs = append(errs, fmt.Errorf("parameter %q is declared more than once", p.Name))
+		}
+		seen[p.Name] = true
+		switch p.Type {
+		case "", ParameterString, ParameterInteger, ParameterNumber, ParameterBoolean:
+		default:
+			errs = append(errs, fmt.Errorf("parameter %q: type must be string, integer, number or boolean", p.Name))
+		}
+	}
+	for i, err := range errs {
+		errs[i] = fmt.Errorf("script %q: %w", s.Name, err)
+	}
 	return errors.Join(errs...)
 }
 
@@ -109,9 +195,22 @@ func splitFrontmatter(content string) (frontmatter, body string, err error) {
 	return before, after, nil
 }
 
-// Discover finds all valid skills in the given paths.
-func Discover(paths []string) []*Skill {
-	var skills []*Skill
+// Found is a SKILL.md file found in the skills paths.
+type Found struct {
+	// Skill is nil when the file couldn't be parsed.
+	Skill *Skill
+	// Path is the path of the SKILL.md file.
+	Path string
+	// Root is the skills path the file was found in.
+	Root string
+	// Err is why the skill can't be loaded, if it can't.
+	Err error
+}
+
+// Scan finds all SKILL.md files in the given paths, valid or not, sorted by
+// path.
+func Scan(paths []string) []Found {
+	var found []Found
 	var mu sync.Mutex
 	seen := make(map[string]bool)
 
@@ -119,7 +218,7 @@ func Discover(paths []string) []*Skill {
 		// We use fastwalk with Follow: true instead of filepath.WalkDir because
 		// WalkDir doesn't follow symlinked directories at any depth—only entry
 		// points. This ensures skills in symlinked subdirectories are discovered.
-		// fastwalk is concurrent, so we protect shared state (seen, skills) with mu.
+		// fastwalk is concurrent, so we protect shared state (seen, found) with mu.
 		conf := fastwalk.Config{
 			Follow:  true,
 			ToSlash: fastwalk.DefaultToSlash(),
@@ -138,27 +237,41 @@ func Discover(paths []string) []*Skill {
 			}
 			seen[path] = true
 			mu.Unlock()
-			skill, err := Parse(path)
-			if err != nil {
-				slog.Warn("Failed to parse skill file", "path", path, "error", err)
-				return nil
-			}
-			if err := skill.Validate(); err != nil {
-				slog.Warn("Skill validation failed", "path", path, "error", err)
-				return nil
+			f := Found{Path: path, Root: base}
+			f.Skill, f.Err = Parse(path)
+			if f.Err == nil {
+				f.Err = f.Skill.Validate()
 			}
-			slog.Debug("Successfully loaded skill", "name", skill.Name, "path", path)
 			mu.Lock()
-			skills = append(skills, skill)
+			found = append(found, f)
 			mu.Unlock()
 			return nil
 		})
 	}
 
+	slices.SortFunc(found, func(a, b Found) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return found
+}
+
+// Discover finds all valid skills in the given paths.
+func Discover(paths []string) []*Skill {
+	var skills []*Skill
+	for _, f := range Scan(paths) {
+		if f.Err != nil {
+			slog.Warn("Failed to load skill", "path", f.Path, "error", f.Err)
+			continue
+		}
+		slog.Debug("Successfully loaded skill", "name", f.Skill.Name, "path", f.Path)
+		skills = append(skills, f.Skill)
+	}
 	return skills
 }
 
-// ToPromptXML generates XML for injection into the system prompt.
+// ToPromptXML generates XML for injection into the system prompt. It only
+// has the names and descriptions of the skills, the agent loads the rest with
+// the skill tool when it needs it.
 func ToPromptXML(skills []*Skill) string {
 	if len(skills) == 0 {
 		return ""
@@ -170,7 +283,6 @@ func ToPromptXML(skills []*Skill) string {
 		sb.WriteString("  <skill>\n")
 		fmt.Fprintf(&sb, "    <name>%s</name>\n", escape(s.Name))
 		fmt.Fprintf(&sb, "    <description>%s</description>\n", escape(s.Description))
-		fmt.Fprintf(&sb, "    <location>%s</location>\n", escape(s.SkillFilePath))
 		sb.WriteString("  </skill>\n")
 	}
 	sb.WriteString("</available_skills>")
@@ -100,6 +100,46 @@ Instructions here.
 	}
 }
 
+func TestParseScripts(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "pdf")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	path := filepath.Join(dir, "SKILL.md")
+	require.NoError(t, os.WriteFile(path, []byte(`---
+name: pdf
+description: Works with PDF files.
+scripts:
+  - name: extract
+    description: Extracts the text of a PDF file.
+    command: python3 scripts/extract.py
+    parameters:
+      - name: file
+        description: Absolute path of the PDF file.
+        required: true
+      - name: format
+        enum: [text, markdown]
+      - name: pages
+        type: integer
+---
+`), 0o644))
+
+	skill, err := Parse(path)
+	require.NoError(t, err)
+	require.NoError(t, skill.Validate())
+	require.Equal(t, []Script{{
+		Name:        "extract",
+		Description: "Extracts the text of a PDF file.",
+		Command:     "python3 scripts/extract.py",
+		Parameters: []Parameter{
+			{Name: "file", Description: "Absolute path of the PDF file.", Required: true},
+			{Name: "format", Enum: []string{"text", "markdown"}},
+			{Name: "pages", Type: ParameterInteger},
+		},
+	}}, skill.Scripts)
+	require.Equal(t, "skill_pdf_extract", ScriptToolName(skill.Name, skill.Scripts[0].Name))
+}
+
 func TestSkillValidate(t *testing.T) {
 	t.Parallel()
 
@@ -164,6 +204,50 @@ func TestSkillValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "compatibility exceeds",
 		},
+		{
+			name: "valid scripts",
+			skill: Skill{Name: "my-skill", Description: "desc", Path: "/skills/my-skill", Scripts: []Script{
+				{Name: "extract", Description: "Extracts.", Command: "python3 scripts/extract.py", Parameters: []Parameter{
+					{Name: "file", Required: true},
+					{Name: "max_pages", Type: ParameterInteger},
+				}},
+			}},
+		},
+		{
+			name: "script without command",
+			skill: Skill{Name: "my-skill", Description: "desc", Path: "/skills/my-skill", Scripts: []Script{
+				{Name: "extract", Description: "Extracts."},
+			}},
+			wantErr: true,
+			errMsg:  `script "extract": command is required`,
+		},
+		{
+			name: "duplicate script",
+			skill: Skill{Name: "my-skill", Description: "desc", Path: "/skills/my-skill", Scripts: []Script{
+				{Name: "extract", Description: "Extracts.", Command: "./extract"},
+				{Name: "extract", Description: "Extracts.", Command: "./extract"},
+			}},
+			wantErr: true,
+			errMsg:  `script "extract" is declared more than once`,
+		},
+		{
+			name: "script parameter with invalid type",
+			skill: Skill{Name: "my-skill", Description: "desc", Path: "/skills/my-skill", Scripts: []Script{
+				{Name: "extract", Description: "Extracts.", Command: "./extract", Parameters: []Parameter{
+					{Name: "pages", Type: "array"},
+				}},
+			}},
+			wantErr: true,
+			errMsg:  `parameter "pages": type must be`,
+		},
+		{
+			name: "script tool name too long",
+			skill: Skill{Name: strings.Repeat("a", 60), Description: "desc", Path: "/skills/" + strings.Repeat("a", 60), Scripts: []Script{
+				{Name: "extract", Description: "Extracts.", Command: "./extract"},
+			}},
+			wantErr: true,
+			errMsg:  "tool name",
+		},
 	}
 
 	for _, tt := range tests {
@@ -239,7 +323,8 @@ func TestToPromptXML(t *testing.T) {
 	require.Contains(t, xml, "<available_skills>")
 	require.Contains(t, xml, "<name>pdf-processing</name>")
 	require.Contains(t, xml, "<description>Extracts text from PDFs.</description>")
-	require.Contains(t, xml, "&amp;") // XML escaping
+	require.Contains(t, xml, "&amp;")       // XML escaping
+	require.NotContains(t, xml, "SKILL.md") // Loaded with the skill tool.
 }
 
 func TestToPromptXMLEmpty(t *testing.T) {
@@ -0,0 +1,101 @@
+// Package tooloutput persists the full output of tool calls whose results
+// were truncated for the model, so they can still be explored after a
+// restart.
+package tooloutput
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/charmbracelet/crush/internal/db"
+)
+
+const (
+	// MaxOutputSize is the size of the largest output that's stored. Larger
+	// outputs are only kept in memory.
+	MaxOutputSize = 4 << 20
+	// MaxSessionSize is how much output is stored per session. Once a session
+	// goes over it, its oldest outputs are evicted.
+	MaxSessionSize = 32 << 20
+)
+
+// Service stores tool call outputs per session.
+type Service interface {
+	// Save stores the output of a tool call, replacing any previous one and
+	// evicting the session's oldest outputs to stay within MaxSessionSize.
+	// Outputs larger than MaxOutputSize aren't stored.
+	Save(ctx context.Context, sessionID, toolCallID, output string) error
+	// Get returns the stored output of a tool call, and false if there's
+	// none.
+	Get(ctx context.Context, sessionID, toolCallID string) (string, bool, error)
+}
+
+type service struct {
+	db *sql.DB
+	q  *db.Queries
+
+	maxOutputSize  int
+	maxSessionSize int64
+}
+
+func NewService(q *db.Queries, db *sql.DB) Service {
+	return &service{
+		q:              q,
+		db:             db,
+		maxOutputSize:  MaxOutputSize,
+		maxSessionSize: MaxSessionSize,
+	}
+}
+
+func (s *service) Save(ctx context.Context, sessionID, toolCallID, output string) error {
+	if len(output) > s.maxOutputSize {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	qtx := s.q.WithTx(tx)
+	if err := qtx.CreateToolOutput(ctx, db.CreateToolOutputParams{
+		SessionID:  sessionID,
+		ToolCallID: toolCallID,
+		Output:     output,
+		Size:       int64(len(output)),
+	}); err != nil {
+		return err
+	}
+
+	sizes, err := qtx.ListToolOutputSizes(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, size := range sizes {
+		total += size.Size
+		if total <= s.maxSessionSize {
+			continue
+		}
+		if err := qtx.DeleteToolOutput(ctx, size.ID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *service) Get(ctx context.Context, sessionID, toolCallID string) (string, bool, error) {
+	out, err := s.q.GetToolOutput(ctx, db.GetToolOutputParams{
+		SessionID:  sessionID,
+		ToolCallID: toolCallID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return out.Output, true, nil
+}
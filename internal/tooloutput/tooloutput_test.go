@@ -0,0 +1,71 @@
+package tooloutput
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/crush/internal/db"
+	"github.com/charmbracelet/crush/internal/session"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (*service, session.Session) {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	q := db.New(conn)
+	sess, err := session.NewService(q, conn).Create(t.Context(), "test")
+	require.NoError(t, err)
+	return NewService(q, conn).(*service), sess
+}
+
+func TestSaveAndGet(t *testing.T) {
+	svc, sess := newTestService(t)
+
+	require.NoError(t, svc.Save(t.Context(), sess.ID, "call-1", "first"))
+	out, ok, err := svc.Get(t.Context(), sess.ID, "call-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "first", out)
+
+	// Saving again replaces the output.
+	require.NoError(t, svc.Save(t.Context(), sess.ID, "call-1", "second"))
+	out, ok, err = svc.Get(t.Context(), sess.ID, "call-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "second", out)
+
+	_, ok, err = svc.Get(t.Context(), sess.ID, "call-2")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestSaveSkipsLargeOutputs(t *testing.T) {
+	svc, sess := newTestService(t)
+	svc.maxOutputSize = 10
+
+	require.NoError(t, svc.Save(t.Context(), sess.ID, "call-1", strings.Repeat("x", 11)))
+	_, ok, err := svc.Get(t.Context(), sess.ID, "call-1")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestSaveEvictsOldestOutputs(t *testing.T) {
+	svc, sess := newTestService(t)
+	svc.maxSessionSize = 25
+
+	for _, id := range []string{"call-1", "call-2", "call-3"} {
+		require.NoError(t, svc.Save(t.Context(), sess.ID, id, strings.Repeat("x", 10)))
+	}
+
+	_, ok, err := svc.Get(t.Context(), sess.ID, "call-1")
+	require.NoError(t, err)
+	require.False(t, ok)
+	for _, id := range []string{"call-2", "call-3"} {
+		_, ok, err := svc.Get(t.Context(), sess.ID, id)
+		require.NoError(t, err)
+		require.True(t, ok, id)
+	}
+}
@@ -1,6 +1,8 @@
 package editor
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"math/rand"
 	"net/http"
@@ -16,6 +18,7 @@ import (
 	"charm.land/bubbles/v2/textarea"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/filetracker"
 	"github.com/charmbracelet/crush/internal/fsext"
@@ -60,6 +63,13 @@ type FileCompletionItem struct {
 	Path string // The file path
 }
 
+type ResourceCompletionItem struct {
+	MCPName  string // The MCP server exposing the resource
+	URI      string // The resource URI
+	Name     string // The resource name
+	MIMEType string // The resource media type, if known
+}
+
 type editorCmp struct {
 	width              int
 	height             int
@@ -232,6 +242,23 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				Content:  content,
 			})
 		}
+		if item, ok := msg.Value.(ResourceCompletionItem); ok {
+			word := m.textarea.Word()
+			// If the selected item is an MCP resource, insert its URI and
+			// attach its contents once they're read.
+			value := m.textarea.Value()
+			value = value[:m.completionsStartIndex] +
+				item.URI +
+				value[m.completionsStartIndex+len(word):]
+			m.textarea.SetValue(value)
+			m.textarea.MoveToEnd()
+			if !msg.Insert {
+				m.isCompletionsOpen = false
+				m.currentQuery = ""
+				m.completionsStartIndex = 0
+			}
+			return m, readResource(item)
+		}
 
 	case commands.OpenExternalEditorMsg:
 		if m.app.AgentCoordinator.IsSessionBusy(m.session.ID) {
@@ -628,6 +655,21 @@ func (m *editorCmp) startCompletions() tea.Msg {
 		})
 	}
 
+	for name, resources := range mcp.Resources() {
+		for _, resource := range resources {
+			title := cmp.Or(resource.Title, resource.Name)
+			completionItems = append(completionItems, completions.Completion{
+				Title: fmt.Sprintf("%s: %s (%s)", name, title, resource.URI),
+				Value: ResourceCompletionItem{
+					MCPName:  name,
+					URI:      resource.URI,
+					Name:     title,
+					MIMEType: resource.MIMEType,
+				},
+			})
+		}
+	}
+
 	x, y := m.completionsPosition()
 	return completions.OpenCompletionsMsg{
 		Completions: completionItems,
@@ -637,6 +679,24 @@ func (m *editorCmp) startCompletions() tea.Msg {
 	}
 }
 
+// readResource reads the MCP resource and attaches its contents.
+func readResource(item ResourceCompletionItem) tea.Cmd {
+	return func() tea.Msg {
+		contents, err := mcp.ReadResource(context.Background(), item.MCPName, item.URI)
+		if err != nil {
+			return util.InfoMsg{
+				Type: util.InfoTypeError,
+				Msg:  fmt.Sprintf("Failed to read MCP resource %s: %v", item.URI, err),
+			}
+		}
+		attachment, ok := mcp.ResourceAttachment(item.URI, item.Name, item.MIMEType, contents)
+		if !ok {
+			return nil
+		}
+		return filepicker.FilePickedMsg{Attachment: attachment}
+	}
+}
+
 // Blur implements Container.
 func (c *editorCmp) Blur() tea.Cmd {
 	c.textarea.Blur()
@@ -29,6 +29,15 @@ import (
 // CopyKey is the key binding for copying message content to the clipboard.
 var CopyKey = key.NewBinding(key.WithKeys("c", "y", "C", "Y"), key.WithHelp("c/y", "copy"))
 
+// UndoKey is the key binding for undoing a user message and everything after
+// it.
+var UndoKey = key.NewBinding(key.WithKeys("U"), key.WithHelp("U", "undo to here"))
+
+// UndoMsg asks to revert the session to before the given user message.
+type UndoMsg struct {
+	MessageID string
+}
+
 // ClearSelectionKey is the key binding for clearing the current selection in the chat interface.
 var ClearSelectionKey = key.NewBinding(key.WithKeys("esc", "alt+esc"), key.WithHelp("esc", "clear selection"))
 
@@ -114,6 +123,9 @@ func (m *messageCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				util.ReportInfo("Message copied to clipboard"),
 			)
 		}
+		if key.Matches(msg, UndoKey) && m.message.Role == message.User {
+			return m, util.CmdHandler(UndoMsg{MessageID: m.message.ID})
+		}
 	}
 	return m, nil
 }
@@ -197,6 +197,7 @@ func init() {
 	registry.register(tools.GrepToolName, func() renderer { return grepRenderer{} })
 	registry.register(tools.LSToolName, func() renderer { return lsRenderer{} })
 	registry.register(tools.SourcegraphToolName, func() renderer { return sourcegraphRenderer{} })
+	registry.register(tools.CodeSearchToolName, func() renderer { return codeSearchRenderer{} })
 	registry.register(tools.DiagnosticsToolName, func() renderer { return diagnosticsRenderer{} })
 	registry.register(tools.TodosToolName, func() renderer { return todosRenderer{} })
 	registry.register(agent.AgentToolName, func() renderer { return agentRenderer{} })
@@ -892,6 +893,32 @@ func (sr sourcegraphRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Code search renderer
+// -----------------------------------------------------------------------------
+
+// codeSearchRenderer handles searches of the code index
+type codeSearchRenderer struct {
+	baseRenderer
+}
+
+// Render displays the search query with optional path and limit parameters
+func (cr codeSearchRenderer) Render(v *toolCallCmp) string {
+	var params tools.CodeSearchParams
+	var args []string
+	if err := cr.unmarshalParams(v.call.Input, &params); err == nil {
+		args = newParamBuilder().
+			addMain(params.Query).
+			addKeyValue("path", params.Path).
+			addKeyValue("limit", formatNonZero(params.Limit)).
+			build()
+	}
+
+	return cr.renderWithParams(v, "Code Search", args, func() string {
+		return renderPlainContent(v, v.result.Content)
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Diagnostics renderer
 // -----------------------------------------------------------------------------
@@ -1300,6 +1327,10 @@ func prettifyToolName(name string) string {
 		return "List"
 	case tools.SourcegraphToolName:
 		return "Sourcegraph"
+	case tools.SkillToolName:
+		return "Skill"
+	case tools.CodeSearchToolName:
+		return "Code Search"
 	case tools.TodosToolName:
 		return "To-Do"
 	case tools.ViewToolName:
@@ -360,6 +360,19 @@ func (m *toolCallCmp) formatParametersForCopy() string {
 			}
 			return strings.Join(parts, "\n")
 		}
+	case tools.CodeSearchToolName:
+		var params tools.CodeSearchParams
+		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
+			var parts []string
+			parts = append(parts, fmt.Sprintf("**Query:** %s", params.Query))
+			if params.Path != "" {
+				parts = append(parts, fmt.Sprintf("**Path:** %s", params.Path))
+			}
+			if params.Limit > 0 {
+				parts = append(parts, fmt.Sprintf("**Limit:** %d", params.Limit))
+			}
+			return strings.Join(parts, "\n")
+		}
 	case tools.SourcegraphToolName:
 		var params tools.SourcegraphParams
 		if json.Unmarshal([]byte(m.call.Input), &params) == nil {
@@ -425,7 +438,7 @@ func (m *toolCallCmp) formatResultForCopy() string {
 		return m.formatWebFetchResultForCopy()
 	case agent.AgentToolName:
 		return m.formatAgentResultForCopy()
-	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName, tools.TodosToolName:
+	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.CodeSearchToolName, tools.DiagnosticsToolName, tools.TodosToolName:
 		return fmt.Sprintf("```\n%s\n```", m.result.Content)
 	default:
 		return m.result.Content
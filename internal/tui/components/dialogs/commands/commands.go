@@ -72,6 +72,7 @@ type commandDialogCmp struct {
 type (
 	SwitchSessionsMsg      struct{}
 	NewSessionsMsg         struct{}
+	NewWorktreeSessionMsg  struct{}
 	SwitchModelMsg         struct{}
 	QuitMsg                struct{}
 	OpenFilePickerMsg      struct{}
@@ -83,6 +84,8 @@ type (
 	ToggleYoloModeMsg      struct{}
 	CompactMsg             struct {
 		SessionID string
+		// Instructions is what the summary should focus on.
+		Instructions string
 	}
 )
 
@@ -321,6 +324,14 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				return util.CmdHandler(NewSessionsMsg{})
 			},
 		},
+		{
+			ID:          "new_worktree_session",
+			Title:       "New Session in Worktree",
+			Description: "start a new session in its own git worktree and branch",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(NewWorktreeSessionMsg{})
+			},
+		},
 		{
 			ID:          "switch_session",
 			Title:       "Switch Session",
@@ -352,6 +363,23 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 					SessionID: c.sessionID,
 				})
 			},
+		}, Command{
+			ID:          "compact",
+			Title:       "Compact Session",
+			Description: "Summarize the current session, focusing on what matters for the rest of the work",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(ShowArgumentsDialogMsg{
+					CommandID:   "compact",
+					Description: "What should the summary focus on?",
+					ArgNames:    []string{"FOCUS"},
+					OnSubmit: func(args map[string]string) tea.Cmd {
+						return util.CmdHandler(CompactMsg{
+							SessionID:    c.sessionID,
+							Instructions: args["FOCUS"],
+						})
+					},
+				})
+			},
 		})
 	}
 
@@ -0,0 +1,226 @@
+package elicitation
+
+import (
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
+	"github.com/charmbracelet/crush/internal/tui/styles"
+	"github.com/charmbracelet/crush/internal/tui/util"
+)
+
+const ElicitationDialogID dialogs.DialogID = "elicitation"
+
+// ElicitationResponseMsg is sent when the user answers an elicitation
+// request.
+type ElicitationResponseMsg struct {
+	ID       string
+	Response mcp.ElicitationResponse
+}
+
+// ElicitationDialog is a form an MCP asks the user to fill.
+type ElicitationDialog interface {
+	dialogs.DialogModel
+}
+
+type elicitationDialogCmp struct {
+	wWidth, wHeight int
+	width           int
+
+	request mcp.ElicitationRequest
+	inputs  []textinput.Model
+	focused int
+	keys    KeyMap
+	help    help.Model
+}
+
+// NewElicitationDialog creates a dialog with an input for each field of the
+// request.
+func NewElicitationDialog(request mcp.ElicitationRequest) ElicitationDialog {
+	t := styles.CurrentTheme()
+	inputs := make([]textinput.Model, len(request.Fields))
+	for i, field := range request.Fields {
+		ti := textinput.New()
+		ti.Placeholder = field.Hint()
+		ti.SetValue(field.Default)
+		ti.SetWidth(40)
+		ti.SetVirtualCursor(false)
+		ti.Prompt = ""
+		ti.SetStyles(t.S().TextInput)
+		if i == 0 {
+			ti.Focus()
+		} else {
+			ti.Blur()
+		}
+		inputs[i] = ti
+	}
+
+	return &elicitationDialogCmp{
+		request: request,
+		inputs:  inputs,
+		keys:    DefaultKeyMap(),
+		width:   60,
+		help:    help.New(),
+	}
+}
+
+func (e *elicitationDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (e *elicitationDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		e.wWidth = msg.Width
+		e.wHeight = msg.Height
+		e.width = min(90, e.wWidth)
+		for i := range e.inputs {
+			e.inputs[i].SetWidth(e.width - (paddingHorizontal * 2))
+		}
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, e.keys.Close):
+			return e, e.respond(mcp.ElicitationResponse{Action: mcp.ElicitationCancel})
+		case key.Matches(msg, e.keys.Decline):
+			return e, e.respond(mcp.ElicitationResponse{Action: mcp.ElicitationDecline})
+		case key.Matches(msg, e.keys.Confirm):
+			if e.focused < len(e.inputs)-1 {
+				e.focus(e.focused + 1)
+				return e, nil
+			}
+			values := make(map[string]string, len(e.inputs))
+			for i, field := range e.request.Fields {
+				values[field.Name] = e.inputs[i].Value()
+			}
+			content, err := e.request.Content(values)
+			if err != nil {
+				return e, util.ReportWarn(err.Error())
+			}
+			return e, e.respond(mcp.ElicitationResponse{
+				Action:  mcp.ElicitationAccept,
+				Content: content,
+			})
+		case key.Matches(msg, e.keys.Next):
+			e.focus(e.focused + 1)
+		case key.Matches(msg, e.keys.Previous):
+			e.focus(e.focused - 1)
+		default:
+			if len(e.inputs) == 0 {
+				return e, nil
+			}
+			var cmd tea.Cmd
+			e.inputs[e.focused], cmd = e.inputs[e.focused].Update(msg)
+			return e, cmd
+		}
+	case tea.PasteMsg:
+		if len(e.inputs) == 0 {
+			return e, nil
+		}
+		var cmd tea.Cmd
+		e.inputs[e.focused], cmd = e.inputs[e.focused].Update(msg)
+		return e, cmd
+	}
+	return e, nil
+}
+
+func (e *elicitationDialogCmp) focus(i int) {
+	if len(e.inputs) == 0 {
+		return
+	}
+	e.inputs[e.focused].Blur()
+	e.focused = (i + len(e.inputs)) % len(e.inputs)
+	e.inputs[e.focused].Focus()
+}
+
+func (e *elicitationDialogCmp) respond(response mcp.ElicitationResponse) tea.Cmd {
+	return tea.Sequence(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.CmdHandler(ElicitationResponseMsg{ID: e.request.ID, Response: response}),
+	)
+}
+
+func (e *elicitationDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	title := lipgloss.NewStyle().
+		Foreground(t.Primary).
+		Bold(true).
+		Padding(0, 1).
+		Render(e.request.MCPName + " asks for input")
+
+	elements := []string{title, e.renderMessage()}
+	for i, input := range e.inputs {
+		labelStyle := baseStyle.Padding(1, 1, 0, 1)
+		if i == e.focused {
+			labelStyle = labelStyle.Foreground(t.FgBase).Bold(true)
+		} else {
+			labelStyle = labelStyle.Foreground(t.FgMuted)
+		}
+
+		field := e.request.Fields[i]
+		name := field.Label()
+		if field.Required {
+			name += "*"
+		}
+		label := labelStyle.Render(name + ":")
+		value := t.S().Text.
+			Padding(0, 1).
+			Render(input.View())
+		elements = append(elements, lipgloss.JoinVertical(lipgloss.Left, label, value))
+	}
+
+	e.help.ShowAll = false
+	helpText := baseStyle.Padding(0, 1).Render(e.help.View(e.keys))
+	elements = append(elements, "", helpText)
+
+	return baseStyle.Padding(1, 1, 0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(t.BorderFocus).
+		Width(e.width).
+		Render(lipgloss.JoinVertical(lipgloss.Left, elements...))
+}
+
+func (e *elicitationDialogCmp) Cursor() *tea.Cursor {
+	if len(e.inputs) == 0 {
+		return nil
+	}
+	cursor := e.inputs[e.focused].Cursor()
+	if cursor == nil {
+		return nil
+	}
+	row, col := e.Position()
+	// Each input follows the padding and the label of its field.
+	cursor.Y += row + headerHeight + lipgloss.Height(e.renderMessage()) + e.focused*itemHeight + itemHeight - 1
+	cursor.X += col + paddingHorizontal
+	return cursor
+}
+
+const (
+	headerHeight      = 3
+	itemHeight        = 3
+	paddingHorizontal = 3
+)
+
+func (e *elicitationDialogCmp) renderMessage() string {
+	return styles.CurrentTheme().S().Text.
+		Padding(0, 1).
+		Width(e.width - 4).
+		Render(e.request.Message)
+}
+
+func (e *elicitationDialogCmp) Position() (int, int) {
+	height := lipgloss.Height(e.View())
+	row := (e.wHeight / 2) - (height / 2)
+	col := (e.wWidth / 2) - (e.width / 2)
+	return row, col
+}
+
+// ID implements dialogs.DialogModel. Each request has its own dialog, so
+// concurrent requests stack instead of replacing each other.
+func (e *elicitationDialogCmp) ID() dialogs.DialogID {
+	return ElicitationDialogID + dialogs.DialogID(":"+e.request.ID)
+}
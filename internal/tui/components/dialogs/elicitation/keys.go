@@ -0,0 +1,54 @@
+package elicitation
+
+import (
+	"charm.land/bubbles/v2/key"
+)
+
+// KeyMap defines the keyboard bindings for the elicitation dialog.
+type KeyMap struct {
+	Confirm,
+	Next,
+	Previous,
+	Decline,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Confirm: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "confirm"),
+		),
+		Next: key.NewBinding(
+			key.WithKeys("tab", "down"),
+			key.WithHelp("tab/↓", "next"),
+		),
+		Previous: key.NewBinding(
+			key.WithKeys("shift+tab", "up"),
+			key.WithHelp("shift+tab/↑", "previous"),
+		),
+		Decline: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+			key.WithHelp("ctrl+d", "decline"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "alt+esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		k.Confirm,
+		k.Next,
+		k.Decline,
+		k.Close,
+	}
+}
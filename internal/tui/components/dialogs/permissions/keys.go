@@ -11,6 +11,7 @@ type KeyMap struct {
 	Select,
 	Allow,
 	AllowSession,
+	AllowProject,
 	Deny,
 	ToggleDiffMode,
 	ScrollDown,
@@ -41,6 +42,10 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("s", "S", "ctrl+s"),
 			key.WithHelp("s", "allow session"),
 		),
+		AllowProject: key.NewBinding(
+			key.WithKeys("p", "P"),
+			key.WithHelp("p", "allow project"),
+		),
 		Deny: key.NewBinding(
 			key.WithKeys("d", "D", "esc"),
 			key.WithHelp("d", "deny"),
@@ -81,6 +86,7 @@ func (k KeyMap) KeyBindings() []key.Binding {
 		k.Select,
 		k.Allow,
 		k.AllowSession,
+		k.AllowProject,
 		k.Deny,
 		k.ToggleDiffMode,
 		k.ScrollDown,
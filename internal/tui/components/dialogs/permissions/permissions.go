@@ -26,6 +26,7 @@ type PermissionAction string
 const (
 	PermissionAllow           PermissionAction = "allow"
 	PermissionAllowForSession PermissionAction = "allow_session"
+	PermissionAllowForProject PermissionAction = "allow_project"
 	PermissionDeny            PermissionAction = "deny"
 
 	PermissionsDialogID dialogs.DialogID = "permissions"
@@ -50,7 +51,7 @@ type permissionDialogCmp struct {
 	height          int
 	permission      permission.PermissionRequest
 	contentViewPort viewport.Model
-	selectedOption  int // 0: Allow, 1: Allow for session, 2: Deny
+	selectedOption  int // 0: Allow, 1: Allow for session, 2: Allow for project, 3: Deny
 
 	// Diff view state
 	defaultDiffSplitMode bool  // true for split, false for unified
@@ -92,7 +93,11 @@ func (p *permissionDialogCmp) Init() tea.Cmd {
 }
 
 func (p *permissionDialogCmp) supportsDiffView() bool {
-	return p.permission.ToolName == tools.EditToolName || p.permission.ToolName == tools.WriteToolName || p.permission.ToolName == tools.MultiEditToolName
+	switch p.permission.ToolName {
+	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.RenameToolName, tools.CodeActionToolName:
+		return true
+	}
+	return false
 }
 
 func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
@@ -108,10 +113,10 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, p.keyMap.Right) || key.Matches(msg, p.keyMap.Tab):
-			p.selectedOption = (p.selectedOption + 1) % 3
+			p.selectedOption = (p.selectedOption + 1) % 4
 			return p, nil
 		case key.Matches(msg, p.keyMap.Left):
-			p.selectedOption = (p.selectedOption + 2) % 3
+			p.selectedOption = (p.selectedOption + 3) % 4
 		case key.Matches(msg, p.keyMap.Select):
 			return p, p.selectCurrentOption()
 		case key.Matches(msg, p.keyMap.Allow):
@@ -124,6 +129,11 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 				util.CmdHandler(dialogs.CloseDialogMsg{}),
 				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllowForSession, Permission: p.permission}),
 			)
+		case key.Matches(msg, p.keyMap.AllowProject):
+			return p, tea.Batch(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(PermissionResponseMsg{Action: PermissionAllowForProject, Permission: p.permission}),
+			)
 		case key.Matches(msg, p.keyMap.Deny):
 			return p, tea.Batch(
 				util.CmdHandler(dialogs.CloseDialogMsg{}),
@@ -228,6 +238,8 @@ func (p *permissionDialogCmp) selectCurrentOption() tea.Cmd {
 	case 1:
 		action = PermissionAllowForSession
 	case 2:
+		action = PermissionAllowForProject
+	case 3:
 		action = PermissionDeny
 	}
 
@@ -252,10 +264,15 @@ func (p *permissionDialogCmp) renderButtons() string {
 			UnderlineIndex: 10, // "S" in "Session"
 			Selected:       p.selectedOption == 1,
 		},
+		{
+			Text:           "Allow for Project",
+			UnderlineIndex: 10, // "P" in "Project"
+			Selected:       p.selectedOption == 2,
+		},
 		{
 			Text:           "Deny",
 			UnderlineIndex: 0, // "D"
-			Selected:       p.selectedOption == 2,
+			Selected:       p.selectedOption == 3,
 		},
 	}
 
@@ -297,6 +314,13 @@ func (p *permissionDialogCmp) renderHeader() string {
 			pathValue,
 		),
 	}
+	if p.permission.Reason != "" {
+		ruleKey := t.S().Muted.Render("Rule")
+		ruleValue := t.S().Text.
+			Width(p.width - lipgloss.Width(ruleKey)).
+			Render(fmt.Sprintf(" %s", p.permission.Reason))
+		headerParts = append(headerParts, lipgloss.JoinHorizontal(lipgloss.Left, ruleKey, ruleValue))
+	}
 
 	// Add tool-specific header information
 	switch p.permission.ToolName {
@@ -338,7 +362,7 @@ func (p *permissionDialogCmp) renderHeader() string {
 			),
 			baseStyle.Render(strings.Repeat(" ", p.width)),
 		)
-	case tools.EditToolName:
+	case tools.EditToolName, tools.RenameToolName, tools.CodeActionToolName:
 		params := p.permission.Params.(tools.EditPermissionsParams)
 		fileKey := t.S().Muted.Render("File")
 		filePath := t.S().Text.
@@ -437,7 +461,7 @@ func (p *permissionDialogCmp) getOrGenerateContent() string {
 		content = p.generateBashContent()
 	case tools.DownloadToolName:
 		content = p.generateDownloadContent()
-	case tools.EditToolName:
+	case tools.EditToolName, tools.RenameToolName, tools.CodeActionToolName:
 		content = p.generateEditContent()
 	case tools.WriteToolName:
 		content = p.generateWriteContent()
@@ -814,7 +838,7 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	case tools.DownloadToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.4)
-	case tools.EditToolName:
+	case tools.EditToolName, tools.RenameToolName, tools.CodeActionToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
 	case tools.WriteToolName:
@@ -82,6 +82,16 @@ func RenderMCPList(opts RenderOptions) []string {
 					}
 					extraContent = append(extraContent, t.S().Subtle.Render(fmt.Sprintf("%d %s", count, label)))
 				}
+				if count := state.Counts.Resources; count > 0 {
+					label := "resources"
+					if count == 1 {
+						label = "resource"
+					}
+					extraContent = append(extraContent, t.S().Subtle.Render(fmt.Sprintf("%d %s", count, label)))
+				}
+			case mcp.StateNeedsAuth:
+				icon = t.ItemErrorIcon
+				description = t.S().Subtle.Render("needs auth")
 			case mcp.StateError:
 				icon = t.ItemErrorIcon
 				if state.Error != nil {
@@ -11,6 +11,7 @@ import (
 	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/config"
 	"github.com/charmbracelet/crush/internal/history"
@@ -48,6 +49,10 @@ type (
 		Focused bool
 	}
 	CancelTimerExpiredMsg struct{}
+	UndoTimerExpiredMsg   struct{}
+	sessionRevertedMsg    struct {
+		result app.RevertResult
+	}
 )
 
 type PanelType string
@@ -127,10 +132,21 @@ type chatPage struct {
 	isProjectInit    bool
 	promptQueue      int
 
+	// newSessionWorktree tracks whether the next session should be created
+	// in its own git worktree.
+	newSessionWorktree bool
+
+	// pendingUndoID is the ID of the message the user has asked to undo to
+	// once, waiting for confirmation.
+	pendingUndoID string
+
 	// Pills state
 	pillsExpanded      bool
 	focusedPillSection PillSection
 
+	// retry is the provider retry the session is waiting on, if any.
+	retry *agent.RetryEvent
+
 	// Todo spinner
 	todoSpinner spinner.Model
 }
@@ -262,6 +278,17 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case CancelTimerExpiredMsg:
 		p.isCanceling = false
 		return p, nil
+	case UndoTimerExpiredMsg:
+		p.pendingUndoID = ""
+		return p, nil
+	case messages.UndoMsg:
+		return p, p.undo(msg.MessageID)
+	case sessionRevertedMsg:
+		return p, tea.Batch(
+			p.sidebar.SetSession(p.session),
+			util.CmdHandler(editor.OpenEditorMsg{Text: msg.result.Message.Content().Text}),
+			util.ReportInfo(fmt.Sprintf("Reverted %d file(s)", len(msg.result.Files))),
+		)
 	case editor.OpenEditorMsg:
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
@@ -296,6 +323,21 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		u, cmd := p.editor.Update(msg)
 		p.editor = u.(editor.Editor)
 		return p, cmd
+	case pubsub.Event[agent.RetryEvent]:
+		if p.session.ID == "" || msg.Payload.SessionID != p.session.ID {
+			return p, nil
+		}
+		if msg.Type == pubsub.DeletedEvent {
+			p.retry = nil
+		} else {
+			p.retry = &msg.Payload
+		}
+		return p, p.SetSize(p.width, p.height)
+	case pubsub.Event[agent.FallbackEvent]:
+		if p.session.ID == "" || msg.Payload.SessionID != p.session.ID {
+			return p, nil
+		}
+		return p, util.ReportWarn(fmt.Sprintf("%s failed (%s), switched to %s", msg.Payload.From, msg.Payload.Reason, msg.Payload.To))
 	case pubsub.Event[session.Session]:
 		if msg.Payload.ID == p.session.ID {
 			prevHasIncompleteTodos := hasIncompleteTodos(p.session.Todos)
@@ -425,6 +467,13 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			return p, util.ReportWarn("Agent is busy, please wait before starting a new session...")
 		}
 		return p, p.newSession()
+	case commands.NewWorktreeSessionMsg:
+		if p.app.AgentCoordinator.IsBusy() {
+			return p, util.ReportWarn("Agent is busy, please wait before starting a new session...")
+		}
+		cmd := p.newSession()
+		p.newSessionWorktree = true
+		return p, tea.Batch(cmd, util.ReportInfo("The next session will run in its own git worktree"))
 	case tea.KeyPressMsg:
 		switch {
 		case key.Matches(msg, p.keyMap.NewSession):
@@ -572,6 +621,9 @@ func (p *chatPage) View() string {
 		if hasQueue {
 			pills = append(pills, queuePill(p.promptQueue, queueFocused, p.pillsExpanded, t))
 		}
+		if p.retry != nil {
+			pills = append(pills, retryPill(p.retry, p.pillsExpanded, t))
+		}
 
 		var expandedList string
 		if p.pillsExpanded {
@@ -803,7 +855,7 @@ func (p *chatPage) SetSize(width, height int) tea.Cmd {
 	} else {
 		hasIncompleteTodos := hasIncompleteTodos(p.session.Todos)
 		hasQueue := p.promptQueue > 0
-		hasPills := hasIncompleteTodos || hasQueue
+		hasPills := hasIncompleteTodos || hasQueue || p.retry != nil
 
 		pillsAreaHeight := 0
 		if hasPills {
@@ -834,11 +886,13 @@ func (p *chatPage) SetSize(width, height int) tea.Cmd {
 }
 
 func (p *chatPage) newSession() tea.Cmd {
+	p.newSessionWorktree = false
 	if p.session.ID == "" {
 		return nil
 	}
 
 	p.session = session.Session{}
+	p.retry = nil
 	p.focusedPane = PanelTypeEditor
 	p.editor.Focus()
 	p.chat.Blur()
@@ -849,6 +903,39 @@ func (p *chatPage) newSession() tea.Cmd {
 	)
 }
 
+// undo handles an undo request on a user message. The first request asks for
+// confirmation; the second one (before the timer expires) reverts the
+// session's files to the message's checkpoint and truncates the
+// conversation, putting the message back in the editor.
+func (p *chatPage) undo(messageID string) tea.Cmd {
+	if p.session.ID == "" {
+		return nil
+	}
+	if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy() {
+		return util.ReportWarn("Agent is busy, please wait before undoing...")
+	}
+
+	if p.pendingUndoID != messageID {
+		p.pendingUndoID = messageID
+		return tea.Batch(
+			util.ReportInfo("Press U again to undo this message and everything after it"),
+			tea.Tick(CancelTimerDuration, func(time.Time) tea.Msg {
+				return UndoTimerExpiredMsg{}
+			}),
+		)
+	}
+
+	p.pendingUndoID = ""
+	sessionID := p.session.ID
+	return func() tea.Msg {
+		result, err := app.RevertSession(context.Background(), p.app.Sessions, p.app.Messages, p.app.History, sessionID, messageID)
+		if err != nil {
+			return util.ReportError(err)()
+		}
+		return sessionRevertedMsg{result: result}
+	}
+}
+
 func (p *chatPage) setSession(sess session.Session) tea.Cmd {
 	if p.session.ID == sess.ID {
 		return nil
@@ -856,6 +943,8 @@ func (p *chatPage) setSession(sess session.Session) tea.Cmd {
 
 	var cmds []tea.Cmd
 	p.session = sess
+	p.retry = nil
+	p.app.UseWorktree(sess)
 
 	if p.hasInProgressTodo() {
 		cmds = append(cmds, p.todoSpinner.Tick)
@@ -961,7 +1050,12 @@ func (p *chatPage) sendMessage(text string, attachments []message.Attachment) te
 		// XXX: The second argument here is the session name, which we leave
 		// blank as it will be auto-generated. Ideally, we remove the need for
 		// that argument entirely.
-		newSession, err := p.app.Sessions.Create(context.Background(), "")
+		create := p.app.Sessions.Create
+		if p.newSessionWorktree {
+			create = p.app.NewWorktreeSession
+		}
+		p.newSessionWorktree = false
+		newSession, err := create(context.Background(), "")
 		if err != nil {
 			return util.ReportError(err)
 		}
@@ -1,10 +1,14 @@
 package chat
 
 import (
+	"cmp"
 	"fmt"
 	"strings"
+	"time"
 
+	"charm.land/fantasy"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/tui/components/chat/todos"
 	"github.com/charmbracelet/crush/internal/tui/styles"
@@ -94,6 +98,32 @@ func todoPill(todos []session.Todo, spinnerView string, focused, pillsPanelFocus
 	return style.Render(content)
 }
 
+// retryPill renders the pill shown while a failed provider request waits
+// to be retried.
+func retryPill(retry *agent.RetryEvent, pillsPanelFocused bool, t *styles.Theme) string {
+	if retry == nil {
+		return ""
+	}
+	reason := "request failed"
+	if retry.Error != nil {
+		reason = cmp.Or(retry.Error.Title, fantasy.ErrorTitleForStatusCode(retry.Error.StatusCode), reason)
+	}
+	content := fmt.Sprintf(
+		"%s Retrying %s  %s",
+		t.S().Base.Foreground(t.Warning).Render(styles.WarningIcon),
+		t.S().Base.Foreground(t.FgMuted).Render(fmt.Sprintf("%d/%d in %s", retry.Attempt+1, retry.MaxAttempts, retry.Delay.Round(time.Second))),
+		t.S().Base.Foreground(t.FgSubtle).Render(reason),
+	)
+
+	style := t.S().Base.PaddingLeft(1).PaddingRight(1)
+	if !pillsPanelFocused {
+		style = style.BorderStyle(lipgloss.RoundedBorder()).BorderForeground(t.BgOverlay)
+	} else {
+		style = style.BorderStyle(lipgloss.HiddenBorder())
+	}
+	return style.Render(content)
+}
+
 func todoList(sessionTodos []session.Todo, spinnerView string, t *styles.Theme, width int) string {
 	return todos.FormatTodosList(sessionTodos, spinnerView, t, width)
 }
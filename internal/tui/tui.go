@@ -27,6 +27,7 @@ import (
 	"github.com/charmbracelet/crush/internal/tui/components/core/status"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/commands"
+	"github.com/charmbracelet/crush/internal/tui/components/dialogs/elicitation"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/filepicker"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/models"
 	"github.com/charmbracelet/crush/internal/tui/components/dialogs/permissions"
@@ -166,6 +167,8 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, handleMCPPromptsEvent(context.Background(), msg.Payload.Name)
 		case mcp.EventToolsListChanged:
 			return a, handleMCPToolsEvent(context.Background(), msg.Payload.Name)
+		case mcp.EventResourcesListChanged:
+			return a, handleMCPResourcesEvent(context.Background(), msg.Payload.Name)
 		}
 
 	// Completions messages
@@ -258,7 +261,7 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Compact
 	case commands.CompactMsg:
 		return a, func() tea.Msg {
-			err := a.app.AgentCoordinator.Summarize(context.Background(), msg.SessionID)
+			err := a.app.AgentCoordinator.Summarize(context.Background(), msg.SessionID, msg.Instructions)
 			if err != nil {
 				return util.ReportError(err)()
 			}
@@ -322,12 +325,21 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				DiffMode: config.Get().Options.TUI.DiffMode,
 			}),
 		})
+	case pubsub.Event[mcp.ElicitationRequest]:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: elicitation.NewElicitationDialog(msg.Payload),
+		})
+	case elicitation.ElicitationResponseMsg:
+		mcp.RespondElicitation(msg.ID, msg.Response)
+		return a, nil
 	case permissions.PermissionResponseMsg:
 		switch msg.Action {
 		case permissions.PermissionAllow:
 			a.app.Permissions.Grant(msg.Permission)
 		case permissions.PermissionAllowForSession:
 			a.app.Permissions.GrantPersistent(msg.Permission)
+		case permissions.PermissionAllowForProject:
+			a.app.Permissions.GrantForProject(msg.Permission)
 		case permissions.PermissionDeny:
 			a.app.Permissions.Deny(msg.Permission)
 		}
@@ -687,6 +699,13 @@ func handleMCPToolsEvent(ctx context.Context, name string) tea.Cmd {
 	}
 }
 
+func handleMCPResourcesEvent(ctx context.Context, name string) tea.Cmd {
+	return func() tea.Msg {
+		mcp.RefreshResources(ctx, name)
+		return nil
+	}
+}
+
 // New creates and initializes a new TUI application model.
 func New(app *app.App) *appModel {
 	chatPage := chat.New(app)
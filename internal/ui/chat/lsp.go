@@ -0,0 +1,111 @@
+package chat
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/charmbracelet/crush/internal/agent/tools"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+)
+
+// LSPToolMessageItem is a message item that represents a call of one of the
+// LSP navigation tools: definition, implementation, hover, symbols, rename and
+// code actions.
+type LSPToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*LSPToolMessageItem)(nil)
+
+// NewLSPToolMessageItem creates a new [LSPToolMessageItem].
+func NewLSPToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &LSPToolRenderContext{}, canceled)
+}
+
+// LSPToolRenderContext renders LSP navigation tool messages.
+type LSPToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (l *LSPToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+	name, toolParams := lspToolHeader(opts.ToolCall)
+	if opts.IsPending() {
+		return pendingTool(sty, name, opts.Anim)
+	}
+
+	header := toolHeader(sty, opts.Status, name, cappedWidth, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if opts.HasEmptyResult() {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	body := sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	return joinToolParts(header, body)
+}
+
+// lspToolHeader returns the name and the parameters to show for the call.
+func lspToolHeader(toolCall message.ToolCall) (string, []string) {
+	switch toolCall.Name {
+	case tools.SymbolsToolName:
+		var params tools.SymbolsParams
+		_ = json.Unmarshal([]byte(toolCall.Input), &params)
+		if params.FilePath != "" {
+			return "Symbols", []string{fsext.PrettyPath(params.FilePath)}
+		}
+		return "Symbols", []string{params.Query}
+	case tools.RenameToolName:
+		var params tools.RenameParams
+		_ = json.Unmarshal([]byte(toolCall.Input), &params)
+		rename := params.Symbol + " " + styles.ArrowRightIcon + " " + params.NewName
+		return "Rename", append([]string{rename}, symbolLocationParams(params.FilePath, params.Line)...)
+	case tools.CodeActionToolName:
+		var params tools.CodeActionParams
+		_ = json.Unmarshal([]byte(toolCall.Input), &params)
+		toolParams := []string{fsext.PrettyPath(params.FilePath)}
+		if params.Line > 0 {
+			toolParams = append(toolParams, "line", strconv.Itoa(params.Line))
+		}
+		if params.Apply > 0 {
+			toolParams = append(toolParams, "apply", strconv.Itoa(params.Apply))
+		}
+		return "Code Action", toolParams
+	}
+
+	var params tools.LSPSymbolParams
+	_ = json.Unmarshal([]byte(toolCall.Input), &params)
+	toolParams := append([]string{params.Symbol}, symbolLocationParams(params.FilePath, params.Line)...)
+	switch toolCall.Name {
+	case tools.DefinitionToolName:
+		return "Definition", toolParams
+	case tools.ImplementationToolName:
+		return "Implementations", toolParams
+	default:
+		return "Hover", toolParams
+	}
+}
+
+func symbolLocationParams(filePath string, line int) []string {
+	var params []string
+	if filePath != "" {
+		params = append(params, "path", fsext.PrettyPath(filePath))
+	}
+	if line > 0 {
+		params = append(params, "line", strconv.Itoa(line))
+	}
+	return params
+}
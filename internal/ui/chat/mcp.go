@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/stringext"
 	"github.com/charmbracelet/crush/internal/ui/styles"
@@ -119,3 +120,77 @@ func looksLikeMarkdown(content string) bool {
 	}
 	return false
 }
+
+// MCPResourceToolMessageItem is a message item that represents a call of the
+// tools listing and reading MCP resources.
+type MCPResourceToolMessageItem struct {
+	*baseToolMessageItem
+}
+
+var _ ToolMessageItem = (*MCPResourceToolMessageItem)(nil)
+
+// NewMCPResourceToolMessageItem creates a new [MCPResourceToolMessageItem].
+func NewMCPResourceToolMessageItem(
+	sty *styles.Styles,
+	toolCall message.ToolCall,
+	result *message.ToolResult,
+	canceled bool,
+) ToolMessageItem {
+	return newBaseToolMessageItem(sty, toolCall, result, &MCPResourceToolRenderContext{}, canceled)
+}
+
+// MCPResourceToolRenderContext renders MCP resource tool messages.
+type MCPResourceToolRenderContext struct{}
+
+// RenderTool implements the [ToolRenderer] interface.
+func (m *MCPResourceToolRenderContext) RenderTool(sty *styles.Styles, width int, opts *ToolRenderOpts) string {
+	cappedWidth := cappedMessageWidth(width)
+
+	var params tools.MCPReadResourceParams
+	_ = json.Unmarshal([]byte(opts.ToolCall.Input), &params)
+	name := "MCP Resource"
+	var toolParams []string
+	if params.URI != "" {
+		toolParams = append(toolParams, params.URI)
+	}
+	if opts.ToolCall.Name == tools.MCPListResourcesToolName {
+		name = "MCP Resources"
+	}
+	if params.MCPName != "" {
+		toolParams = append(toolParams, "mcp", params.MCPName)
+	}
+
+	if opts.IsPending() {
+		return pendingTool(sty, name, opts.Anim)
+	}
+
+	header := toolHeader(sty, opts.Status, name, cappedWidth, opts.Compact, toolParams...)
+	if opts.Compact {
+		return header
+	}
+
+	if earlyState, ok := toolEarlyStateContent(sty, opts, cappedWidth); ok {
+		return joinToolParts(header, earlyState)
+	}
+
+	if !opts.HasResult() {
+		return header
+	}
+
+	if opts.Result.Data != "" && strings.HasPrefix(opts.Result.MIMEType, "image/") {
+		body := sty.Tool.Body.Render(toolOutputImageContent(sty, opts.Result.Data, opts.Result.MIMEType))
+		return joinToolParts(header, body)
+	}
+	if opts.Result.Content == "" {
+		return header
+	}
+
+	bodyWidth := cappedWidth - toolBodyLeftPaddingTotal
+	var body string
+	if opts.ToolCall.Name == tools.MCPReadResourceToolName && looksLikeMarkdown(opts.Result.Content) {
+		body = sty.Tool.Body.Render(toolOutputCodeContent(sty, "result.md", opts.Result.Content, 0, bodyWidth, opts.ExpandedContent))
+	} else {
+		body = sty.Tool.Body.Render(toolOutputPlainContent(sty, opts.Result.Content, bodyWidth, opts.ExpandedContent))
+	}
+	return joinToolParts(header, body)
+}
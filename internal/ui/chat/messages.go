@@ -41,6 +41,16 @@ type Expandable interface {
 	ToggleExpanded()
 }
 
+// FullOutputLoader is an interface for tool items that show the full output
+// of their tool when expanded, which is loaded for them outside of the
+// update loop.
+type FullOutputLoader interface {
+	// NeedsFullOutput returns the session and the tool call whose full
+	// output the item needs, if it needs it.
+	NeedsFullOutput() (sessionID, toolCallID string, ok bool)
+	SetFullOutput(output string)
+}
+
 // KeyEventHandler is an interface for items that can handle key events.
 type KeyEventHandler interface {
 	HandleKeyEvent(key tea.KeyMsg) (bool, tea.Cmd)
@@ -275,6 +285,7 @@ func ExtractMessageItems(sty *styles.Styles, msg *message.Message, toolResults m
 			}
 			items = append(items, NewToolMessageItem(
 				sty,
+				msg.SessionID,
 				msg.ID,
 				tc,
 				result,
@@ -47,6 +47,7 @@ type ToolMessageItem interface {
 	SetResult(res *message.ToolResult)
 	MessageID() string
 	SetMessageID(id string)
+	SetSessionID(id string)
 	SetStatus(status ToolStatus)
 	Status() ToolStatus
 }
@@ -141,6 +142,7 @@ type baseToolMessageItem struct {
 	toolCall     message.ToolCall
 	result       *message.ToolResult
 	messageID    string
+	sessionID    string
 	status       ToolStatus
 	// we use this so we can efficiently cache
 	// tools that have a capped width (e.x bash.. and others)
@@ -154,6 +156,9 @@ type baseToolMessageItem struct {
 	sty             *styles.Styles
 	anim            *anim.Anim
 	expandedContent bool
+	// fullOutput is the output of the tool before it was truncated for the
+	// model, loaded when the item is first expanded.
+	fullOutput string
 }
 
 // newBaseToolMessageItem is the internal constructor for base tool message items.
@@ -199,9 +204,10 @@ func newBaseToolMessageItem(
 //
 // It returns a specific tool message item type if implemented, otherwise it
 // returns a generic tool message item. The messageID is the ID of the assistant
-// message containing this tool call.
+// message containing this tool call, and sessionID the ID of its session.
 func NewToolMessageItem(
 	sty *styles.Styles,
+	sessionID string,
 	messageID string,
 	toolCall message.ToolCall,
 	result *message.ToolResult,
@@ -235,6 +241,8 @@ func NewToolMessageItem(
 		item = NewFetchToolMessageItem(sty, toolCall, result, canceled)
 	case tools.SourcegraphToolName:
 		item = NewSourcegraphToolMessageItem(sty, toolCall, result, canceled)
+	case tools.CodeSearchToolName:
+		item = NewCodeSearchToolMessageItem(sty, toolCall, result, canceled)
 	case tools.DiagnosticsToolName:
 		item = NewDiagnosticsToolMessageItem(sty, toolCall, result, canceled)
 	case agent.AgentToolName:
@@ -251,6 +259,10 @@ func NewToolMessageItem(
 		item = NewReferencesToolMessageItem(sty, toolCall, result, canceled)
 	case tools.LSPRestartToolName:
 		item = NewLSPRestartToolMessageItem(sty, toolCall, result, canceled)
+	case tools.DefinitionToolName, tools.ImplementationToolName, tools.HoverToolName, tools.SymbolsToolName, tools.RenameToolName, tools.CodeActionToolName:
+		item = NewLSPToolMessageItem(sty, toolCall, result, canceled)
+	case tools.MCPListResourcesToolName, tools.MCPReadResourceToolName:
+		item = NewMCPResourceToolMessageItem(sty, toolCall, result, canceled)
 	default:
 		if strings.HasPrefix(toolCall.Name, "mcp_") {
 			item = NewMCPToolMessageItem(sty, toolCall, result, canceled)
@@ -259,6 +271,7 @@ func NewToolMessageItem(
 		}
 	}
 	item.SetMessageID(messageID)
+	item.SetSessionID(sessionID)
 	return item
 }
 
@@ -299,9 +312,15 @@ func (t *baseToolMessageItem) RawRender(width int) string {
 	content, height, ok := t.getCachedRender(toolItemWidth)
 	// if we are spinning or there is no cache rerender
 	if !ok || t.isSpinning() {
+		result := t.result
+		if t.expandedContent && t.fullOutput != "" && result != nil {
+			full := *result
+			full.Content = t.fullOutput
+			result = &full
+		}
 		content = t.toolRenderer.RenderTool(t.sty, toolItemWidth, &ToolRenderOpts{
 			ToolCall:        t.toolCall,
-			Result:          t.result,
+			Result:          result,
 			Anim:            t.anim,
 			ExpandedContent: t.expandedContent,
 			Compact:         t.isCompact,
@@ -357,6 +376,11 @@ func (t *baseToolMessageItem) SetMessageID(id string) {
 	t.messageID = id
 }
 
+// SetSessionID sets the ID of the session this tool call belongs to.
+func (t *baseToolMessageItem) SetSessionID(id string) {
+	t.sessionID = id
+}
+
 // SetStatus sets the tool status.
 func (t *baseToolMessageItem) SetStatus(status ToolStatus) {
 	t.status = status
@@ -396,12 +420,28 @@ func (t *baseToolMessageItem) SetSpinningFunc(fn SpinningFunc) {
 	t.spinningFunc = fn
 }
 
-// ToggleExpanded toggles the expanded state of the thinking box.
+// ToggleExpanded toggles the expanded state of the thinking box. Expanded
+// tools whose output was truncated for the model show their full output
+// once it's loaded.
 func (t *baseToolMessageItem) ToggleExpanded() {
 	t.expandedContent = !t.expandedContent
 	t.clearCache()
 }
 
+// NeedsFullOutput implements FullOutputLoader.
+func (t *baseToolMessageItem) NeedsFullOutput() (string, string, bool) {
+	if !t.expandedContent || t.fullOutput != "" || t.result == nil || t.sessionID == "" {
+		return "", "", false
+	}
+	return t.sessionID, t.toolCall.ID, true
+}
+
+// SetFullOutput implements FullOutputLoader.
+func (t *baseToolMessageItem) SetFullOutput(output string) {
+	t.fullOutput = output
+	t.clearCache()
+}
+
 // HandleMouseClick implements MouseClickable.
 func (t *baseToolMessageItem) HandleMouseClick(btn ansi.MouseButton, x, y int) bool {
 	if btn != ansi.MouseLeft {
@@ -969,6 +1009,19 @@ func (t *baseToolMessageItem) formatParametersForCopy() string {
 			}
 			return strings.Join(parts, "\n")
 		}
+	case tools.CodeSearchToolName:
+		var params tools.CodeSearchParams
+		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
+			var parts []string
+			parts = append(parts, fmt.Sprintf("**Query:** %s", params.Query))
+			if params.Path != "" {
+				parts = append(parts, fmt.Sprintf("**Path:** %s", params.Path))
+			}
+			if params.Limit > 0 {
+				parts = append(parts, fmt.Sprintf("**Limit:** %d", params.Limit))
+			}
+			return strings.Join(parts, "\n")
+		}
 	case tools.SourcegraphToolName:
 		var params tools.SourcegraphParams
 		if json.Unmarshal([]byte(t.toolCall.Input), &params) == nil {
@@ -1039,7 +1092,7 @@ func (t *baseToolMessageItem) formatResultForCopy() string {
 		return t.formatWebFetchResultForCopy()
 	case agent.AgentToolName:
 		return t.formatAgentResultForCopy()
-	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.DiagnosticsToolName, tools.TodosToolName:
+	case tools.DownloadToolName, tools.GrepToolName, tools.GlobToolName, tools.LSToolName, tools.SourcegraphToolName, tools.CodeSearchToolName, tools.DiagnosticsToolName, tools.TodosToolName:
 		return fmt.Sprintf("```\n%s\n```", t.result.Content)
 	default:
 		return t.result.Content
@@ -1385,6 +1438,10 @@ func prettifyToolName(name string) string {
 		return "List"
 	case tools.SourcegraphToolName:
 		return "Sourcegraph"
+	case tools.SkillToolName:
+		return "Skill"
+	case tools.CodeSearchToolName:
+		return "Code Search"
 	case tools.TodosToolName:
 		return "To-Do"
 	case tools.ViewToolName:
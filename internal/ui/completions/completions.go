@@ -1,12 +1,14 @@
 package completions
 
 import (
+	"cmp"
 	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/ui/list"
 	"github.com/charmbracelet/x/ansi"
@@ -29,9 +31,11 @@ type SelectionMsg struct {
 // ClosedMsg is sent when the completions are closed.
 type ClosedMsg struct{}
 
-// FilesLoadedMsg is sent when files have been loaded for completions.
+// FilesLoadedMsg is sent when files and MCP resources have been loaded for
+// completions.
 type FilesLoadedMsg struct {
-	Files []string
+	Files     []string
+	Resources []ResourceCompletionValue
 }
 
 // Completions represents the completions popup component.
@@ -92,18 +96,35 @@ func (c *Completions) KeyMap() KeyMap {
 	return c.keyMap
 }
 
-// OpenWithFiles opens the completions with file items from the filesystem.
+// OpenWithFiles opens the completions with file items from the filesystem and
+// the resources of the connected MCP servers.
 func (c *Completions) OpenWithFiles(depth, limit int) tea.Cmd {
 	return func() tea.Msg {
 		files, _, _ := fsext.ListDirectory(".", nil, depth, limit)
 		slices.Sort(files)
-		return FilesLoadedMsg{Files: files}
+
+		var resources []ResourceCompletionValue
+		for name, list := range mcp.Resources() {
+			for _, resource := range list {
+				resources = append(resources, ResourceCompletionValue{
+					MCPName:  name,
+					URI:      resource.URI,
+					Name:     cmp.Or(resource.Title, resource.Name),
+					MIMEType: resource.MIMEType,
+				})
+			}
+		}
+		slices.SortFunc(resources, func(a, b ResourceCompletionValue) int {
+			return cmp.Or(cmp.Compare(a.MCPName, b.MCPName), cmp.Compare(a.URI, b.URI))
+		})
+		return FilesLoadedMsg{Files: files, Resources: resources}
 	}
 }
 
-// SetFiles sets the file items on the completions popup.
-func (c *Completions) SetFiles(files []string) {
-	items := make([]list.FilterableItem, 0, len(files))
+// SetFiles sets the file and MCP resource items on the completions popup.
+func (c *Completions) SetFiles(files []string, resources []ResourceCompletionValue) {
+	items := make([]list.FilterableItem, 0, len(files)+len(resources))
+	texts := make([]string, 0, cap(items))
 	for _, file := range files {
 		file = strings.TrimPrefix(file, "./")
 		item := NewCompletionItem(
@@ -114,6 +135,19 @@ func (c *Completions) SetFiles(files []string) {
 			c.matchStyle,
 		)
 		items = append(items, item)
+		texts = append(texts, file)
+	}
+	for _, resource := range resources {
+		text := resource.Text()
+		item := NewCompletionItem(
+			text,
+			resource,
+			c.normalStyle,
+			c.focusedStyle,
+			c.matchStyle,
+		)
+		items = append(items, item)
+		texts = append(texts, text)
 	}
 
 	c.open = true
@@ -132,8 +166,8 @@ func (c *Completions) SetFiles(files []string) {
 	start, end := c.list.VisibleItemIndices()
 	width := 0
 	if end != 0 {
-		for _, file := range files[start : end+1] {
-			width = max(width, ansi.StringWidth(file))
+		for _, text := range texts[start : end+1] {
+			width = max(width, ansi.StringWidth(text))
 		}
 	}
 	c.width = ordered.Clamp(width+2, int(minWidth), int(maxWidth))
@@ -13,6 +13,22 @@ type FileCompletionValue struct {
 	Path string
 }
 
+// ResourceCompletionValue represents an MCP resource completion value.
+type ResourceCompletionValue struct {
+	MCPName  string
+	URI      string
+	Name     string
+	MIMEType string
+}
+
+// Text returns the text the resource is shown and filtered by.
+func (r ResourceCompletionValue) Text() string {
+	if r.Name == "" {
+		return r.MCPName + ": " + r.URI
+	}
+	return r.MCPName + ": " + r.Name + " (" + r.URI + ")"
+}
+
 // CompletionItem represents an item in the completions list.
 type CompletionItem struct {
 	text    string
@@ -34,6 +34,13 @@ type ActionSelectSession struct {
 	Session session.Session
 }
 
+// ActionSelectMessage is a message indicating a message has been selected
+// in the search dialog.
+type ActionSelectMessage struct {
+	SessionID string
+	MessageID string
+}
+
 // ActionSelectModel is a message indicating a model has been selected.
 type ActionSelectModel struct {
 	Provider  catwalk.Provider
@@ -49,6 +56,9 @@ type (
 	ActionToggleThinking    struct{}
 	ActionExternalEditor    struct{}
 	ActionToggleYoloMode    struct{}
+	// ActionNewWorktreeSession is a message to start a new session in its
+	// own git worktree.
+	ActionNewWorktreeSession struct{}
 	// ActionInitializeProject is a message to initialize a project.
 	ActionInitializeProject struct{}
 	// ActionOpenAgents is a message to open the agents dialog.
@@ -58,6 +68,12 @@ type (
 	ActionSummarize      struct {
 		SessionID string
 	}
+	// ActionCompact is a message to summarize the session focusing on the
+	// instructions given as arguments.
+	ActionCompact struct {
+		SessionID string
+		Args      map[string]string // Actual argument values
+	}
 	// ActionSelectReasoningEffort is a message indicating a reasoning effort has been selected.
 	ActionSelectReasoningEffort struct {
 		Effort string
@@ -217,6 +217,9 @@ func (a *Arguments) HandleMsg(msg tea.Msg) Action {
 				case ActionRunMCPPrompt:
 					action.Args = args
 					return action
+				case ActionCompact:
+					action.Args = args
+					return action
 				}
 			}
 			a.focusInput(a.focused + 1)
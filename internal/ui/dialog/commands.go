@@ -387,13 +387,18 @@ func (c *Commands) setCommandItems(commandType CommandType) {
 func (c *Commands) defaultCommands() []*CommandItem {
 	commands := []*CommandItem{
 		NewCommandItem(c.com.Styles, "new_session", "New Session", "ctrl+n", ActionNewSession{}),
+		NewCommandItem(c.com.Styles, "new_worktree_session", "New Session in Worktree", "", ActionNewWorktreeSession{}),
 		NewCommandItem(c.com.Styles, "switch_session", "Sessions", "ctrl+s", ActionOpenDialog{SessionsID}),
+		NewCommandItem(c.com.Styles, "search_messages", "Search Messages", "", ActionOpenDialog{SearchID}),
 		NewCommandItem(c.com.Styles, "switch_model", "Switch Model", "ctrl+l", ActionOpenDialog{ModelsID}),
 	}
 
 	// Only show compact command if there's an active session
 	if c.sessionID != "" {
-		commands = append(commands, NewCommandItem(c.com.Styles, "summarize", "Summarize Session", "", ActionSummarize{SessionID: c.sessionID}))
+		commands = append(commands,
+			NewCommandItem(c.com.Styles, "summarize", "Summarize Session", "", ActionSummarize{SessionID: c.sessionID}),
+			NewCommandItem(c.com.Styles, "compact", "Compact Session", "", ActionCompact{SessionID: c.sessionID}),
+		)
 	}
 
 	// Add reasoning toggle for models that support it
@@ -445,6 +450,8 @@ func (c *Commands) defaultCommands() []*CommandItem {
 	return append(commands,
 		NewCommandItem(c.com.Styles, "view_agents", "View Agents", "", ActionOpenAgents{}),
 		NewCommandItem(c.com.Styles, "view_mcp_servers", "View MCP Servers", "", ActionOpenMCPServers{}),
+		NewCommandItem(c.com.Styles, "view_skills", "View Skills", "", ActionOpenDialog{SkillsID}),
+		NewCommandItem(c.com.Styles, "manage_permissions", "Manage Permissions", "", ActionOpenDialog{GrantsID}),
 		NewCommandItem(c.com.Styles, "toggle_yolo", "Toggle Yolo Mode", "", ActionToggleYoloMode{}),
 		NewCommandItem(c.com.Styles, "toggle_help", "Toggle Help", "ctrl+g", ActionToggleHelp{}),
 		NewCommandItem(c.com.Styles, "init", "Initialize Project", "", ActionInitializeProject{}),
@@ -0,0 +1,232 @@
+package dialog
+
+import (
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/uiutil"
+	uv "github.com/charmbracelet/ultraviolet"
+)
+
+// ElicitationID is the identifier for the elicitation dialog.
+const ElicitationID = "elicitation"
+
+// ActionElicitationResponse is a message with the answer of the user to an
+// elicitation request.
+type ActionElicitationResponse struct {
+	ID       string
+	Response mcp.ElicitationResponse
+}
+
+// Elicitation represents a dialog with the form an MCP asks the user to fill.
+type Elicitation struct {
+	com     *common.Common
+	request mcp.ElicitationRequest
+	inputs  []textinput.Model
+	focused int
+
+	help   help.Model
+	keyMap struct {
+		Confirm,
+		Next,
+		Previous,
+		Decline,
+		Close key.Binding
+	}
+}
+
+var _ Dialog = (*Elicitation)(nil)
+
+// NewElicitation creates a new elicitation dialog with an input for each
+// field of the request.
+func NewElicitation(com *common.Common, request mcp.ElicitationRequest) *Elicitation {
+	e := &Elicitation{
+		com:     com,
+		request: request,
+	}
+
+	e.help = help.New()
+	e.help.Styles = com.Styles.DialogHelpStyles()
+
+	e.keyMap.Confirm = key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "confirm"),
+	)
+	e.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "tab"),
+		key.WithHelp("↓/tab", "next"),
+	)
+	e.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "shift+tab"),
+		key.WithHelp("↑/shift+tab", "previous"),
+	)
+	e.keyMap.Decline = key.NewBinding(
+		key.WithKeys("ctrl+d"),
+		key.WithHelp("ctrl+d", "decline"),
+	)
+	e.keyMap.Close = key.NewBinding(
+		key.WithKeys("esc", "alt+esc"),
+		key.WithHelp("esc", "cancel"),
+	)
+
+	e.inputs = make([]textinput.Model, len(request.Fields))
+	for i, field := range request.Fields {
+		input := textinput.New()
+		input.SetVirtualCursor(false)
+		input.SetStyles(com.Styles.TextInput)
+		input.Prompt = "> "
+		input.Placeholder = field.Hint()
+		input.SetValue(field.Default)
+		if i == 0 {
+			input.Focus()
+		} else {
+			input.Blur()
+		}
+		e.inputs[i] = input
+	}
+	return e
+}
+
+// ID implements Dialog.
+func (e *Elicitation) ID() string {
+	return ElicitationID
+}
+
+// focusInput changes focus to a new input by index with wrap-around.
+func (e *Elicitation) focusInput(newIndex int) {
+	if len(e.inputs) == 0 {
+		return
+	}
+	e.inputs[e.focused].Blur()
+	n := len(e.inputs)
+	e.focused = ((newIndex % n) + n) % n
+	e.inputs[e.focused].Focus()
+}
+
+// HandleMsg implements Dialog.
+func (e *Elicitation) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, e.keyMap.Close):
+			return e.respond(mcp.ElicitationCancel, nil)
+		case key.Matches(msg, e.keyMap.Decline):
+			return e.respond(mcp.ElicitationDecline, nil)
+		case key.Matches(msg, e.keyMap.Confirm):
+			if e.focused < len(e.inputs)-1 {
+				e.focusInput(e.focused + 1)
+				return nil
+			}
+			values := make(map[string]string, len(e.inputs))
+			for i, field := range e.request.Fields {
+				values[field.Name] = e.inputs[i].Value()
+			}
+			content, err := e.request.Content(values)
+			if err != nil {
+				return ActionCmd{Cmd: uiutil.ReportWarn(err.Error())}
+			}
+			return e.respond(mcp.ElicitationAccept, content)
+		case key.Matches(msg, e.keyMap.Next):
+			e.focusInput(e.focused + 1)
+		case key.Matches(msg, e.keyMap.Previous):
+			e.focusInput(e.focused - 1)
+		default:
+			if len(e.inputs) == 0 {
+				return nil
+			}
+			var cmd tea.Cmd
+			e.inputs[e.focused], cmd = e.inputs[e.focused].Update(msg)
+			return ActionCmd{Cmd: cmd}
+		}
+	case tea.PasteMsg:
+		if len(e.inputs) == 0 {
+			return nil
+		}
+		var cmd tea.Cmd
+		e.inputs[e.focused], cmd = e.inputs[e.focused].Update(msg)
+		return ActionCmd{Cmd: cmd}
+	}
+	return nil
+}
+
+func (e *Elicitation) respond(action string, content map[string]any) Action {
+	return ActionElicitationResponse{
+		ID: e.request.ID,
+		Response: mcp.ElicitationResponse{
+			Action:  action,
+			Content: content,
+		},
+	}
+}
+
+// Draw implements Dialog.
+func (e *Elicitation) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	s := e.com.Styles
+
+	contentStyle := s.Dialog.Arguments.Content
+	possibleWidth := area.Dx() - s.Dialog.View.GetHorizontalFrameSize() - contentStyle.GetHorizontalFrameSize()
+	width := min(possibleWidth, maxInputWidth)
+
+	fields := make([]string, 0, len(e.inputs))
+	for i, field := range e.request.Fields {
+		labelStyle := s.Dialog.Arguments.InputLabelBlurred
+		markRequiredStyle := s.Dialog.Arguments.InputRequiredMarkBlurred
+		if i == e.focused {
+			labelStyle = s.Dialog.Arguments.InputLabelFocused
+			markRequiredStyle = s.Dialog.Arguments.InputRequiredMarkFocused
+		}
+		labelText := field.Label()
+		if field.Required {
+			labelText += markRequiredStyle.String()
+		}
+
+		e.inputs[i].SetWidth(max(minInputWidth, width-lipgloss.Width(e.inputs[i].Prompt)))
+		fields = append(fields, lipgloss.JoinVertical(lipgloss.Left, labelStyle.Render(labelText), e.inputs[i].View(), ""))
+	}
+
+	header := common.DialogTitle(s, e.request.MCPName+" asks for input", width, s.Primary, s.Secondary)
+	description := s.Dialog.Arguments.Description.Width(width).Render(e.request.Message)
+	helpView := s.Dialog.HelpView.Width(width).Render(e.help.View(e))
+
+	contentParts := append([]string{description}, fields...)
+	view := lipgloss.JoinVertical(
+		lipgloss.Left,
+		s.Dialog.Title.Render(header),
+		contentStyle.Render(lipgloss.JoinVertical(lipgloss.Left, contentParts...)),
+		helpView,
+	)
+
+	var cur *tea.Cursor
+	if len(e.inputs) > 0 {
+		cur = InputCursor(s, e.inputs[e.focused].Cursor())
+		if cur != nil {
+			cur.Y += lipgloss.Height(description) + e.focused*argumentsFieldHeight + 1
+		}
+	}
+
+	DrawCenterCursor(scr, area, s.Dialog.View.Render(view), cur)
+	return cur
+}
+
+// ShortHelp implements help.KeyMap.
+func (e *Elicitation) ShortHelp() []key.Binding {
+	return []key.Binding{
+		e.keyMap.Confirm,
+		e.keyMap.Next,
+		e.keyMap.Decline,
+		e.keyMap.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (e *Elicitation) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{e.keyMap.Confirm, e.keyMap.Next, e.keyMap.Previous},
+		{e.keyMap.Decline, e.keyMap.Close},
+	}
+}
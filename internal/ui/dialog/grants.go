@@ -0,0 +1,263 @@
+package dialog
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/fsext"
+	"github.com/charmbracelet/crush/internal/permission"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/list"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/charmbracelet/crush/internal/uiutil"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/dustin/go-humanize"
+	"github.com/sahilm/fuzzy"
+)
+
+// GrantsID is the identifier for the permission grants dialog.
+const GrantsID = "grants"
+
+// Grants is a dialog to review and revoke stored permission grants.
+type Grants struct {
+	com    *common.Common
+	help   help.Model
+	list   *list.FilterableList
+	input  textinput.Model
+	grants []permission.Grant
+
+	keyMap struct {
+		Revoke   key.Binding
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*Grants)(nil)
+
+// NewGrants creates a new permission grants dialog.
+func NewGrants(com *common.Common) (*Grants, error) {
+	grants, err := com.App.PermissionGrants.List(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	g := new(Grants)
+	g.com = com
+	g.grants = grants
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	g.help = help
+
+	g.list = list.NewFilterableList(grantItems(com.Styles, grants...)...)
+	g.list.Focus()
+	g.list.SetSelected(0)
+
+	g.input = textinput.New()
+	g.input.SetVirtualCursor(false)
+	g.input.Placeholder = "Filter permissions"
+	g.input.SetStyles(com.Styles.TextInput)
+	g.input.Focus()
+
+	g.keyMap.Revoke = key.NewBinding(
+		key.WithKeys("ctrl+x"),
+		key.WithHelp("ctrl+x", "revoke"),
+	)
+	g.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "next item"),
+	)
+	g.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "previous item"),
+	)
+	g.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "choose"),
+	)
+	g.keyMap.Close = CloseKey
+
+	return g, nil
+}
+
+// ID implements Dialog.
+func (g *Grants) ID() string {
+	return GrantsID
+}
+
+// HandleMsg implements Dialog.
+func (g *Grants) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, g.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, g.keyMap.Previous):
+			if g.list.IsSelectedFirst() {
+				g.list.SelectLast()
+				g.list.ScrollToBottom()
+				break
+			}
+			g.list.SelectPrev()
+			g.list.ScrollToSelected()
+		case key.Matches(msg, g.keyMap.Next):
+			if g.list.IsSelectedLast() {
+				g.list.SelectFirst()
+				g.list.ScrollToTop()
+				break
+			}
+			g.list.SelectNext()
+			g.list.ScrollToSelected()
+		case key.Matches(msg, g.keyMap.Revoke):
+			if item, ok := g.list.SelectedItem().(*GrantItem); ok {
+				return ActionCmd{g.revoke(item.Grant)}
+			}
+		default:
+			var cmd tea.Cmd
+			g.input, cmd = g.input.Update(msg)
+			g.list.SetFilter(g.input.Value())
+			g.list.ScrollToTop()
+			g.list.SetSelected(0)
+			return ActionCmd{cmd}
+		}
+	}
+	return nil
+}
+
+// revoke removes the grant from the list and the store.
+func (g *Grants) revoke(grant permission.Grant) tea.Cmd {
+	g.grants = slices.DeleteFunc(g.grants, func(other permission.Grant) bool {
+		return other.ID == grant.ID
+	})
+	selected := g.list.Selected()
+	g.list.SetItems(grantItems(g.com.Styles, g.grants...)...)
+	g.list.SetFilter(g.input.Value())
+	g.list.SetSelected(min(selected, g.list.Len()-1))
+	g.list.ScrollToSelected()
+
+	return func() tea.Msg {
+		if err := g.com.App.PermissionGrants.Revoke(context.TODO(), grant); err != nil {
+			return uiutil.NewErrorMsg(err)
+		}
+		return nil
+	}
+}
+
+// Cursor returns the cursor position relative to the dialog.
+func (g *Grants) Cursor() *tea.Cursor {
+	return InputCursor(g.com.Styles, g.input.Cursor())
+}
+
+// Draw implements [Dialog].
+func (g *Grants) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := g.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.InputPrompt.GetVerticalFrameSize() + inputContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+	g.input.SetWidth(max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)) // (1) cursor padding
+	g.list.SetSize(innerWidth, height-heightOffset)
+	g.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Permissions"
+	rc.AddPart(t.Dialog.InputPrompt.Render(g.input.View()))
+	listView := t.Dialog.List.Height(g.list.Height()).Render(g.list.Render())
+	rc.AddPart(listView)
+	rc.Help = g.help.View(g)
+
+	cur := g.Cursor()
+	DrawCenterCursor(scr, area, rc.Render(), cur)
+	return cur
+}
+
+// ShortHelp implements [help.KeyMap].
+func (g *Grants) ShortHelp() []key.Binding {
+	return []key.Binding{
+		g.keyMap.UpDown,
+		g.keyMap.Revoke,
+		g.keyMap.Close,
+	}
+}
+
+// FullHelp implements [help.KeyMap].
+func (g *Grants) FullHelp() [][]key.Binding {
+	return [][]key.Binding{g.ShortHelp()}
+}
+
+// GrantItem wraps a [permission.Grant] to implement the [ListItem]
+// interface.
+type GrantItem struct {
+	permission.Grant
+	t       *styles.Styles
+	m       fuzzy.Match
+	cache   map[int]string
+	focused bool
+}
+
+var _ ListItem = &GrantItem{}
+
+// Filter returns the filterable value of the grant.
+func (g *GrantItem) Filter() string {
+	return g.title()
+}
+
+// ID returns the ID of the grant.
+func (g *GrantItem) ID() string {
+	return g.Grant.ID
+}
+
+// SetMatch sets the fuzzy match for the grant item.
+func (g *GrantItem) SetMatch(m fuzzy.Match) {
+	g.cache = nil
+	g.m = m
+}
+
+// SetFocused sets the focus state of the grant item.
+func (g *GrantItem) SetFocused(focused bool) {
+	if g.focused != focused {
+		g.cache = nil
+	}
+	g.focused = focused
+}
+
+func (g *GrantItem) title() string {
+	return g.ToolName + " " + g.Action + " " + fsext.PrettyPath(g.Path)
+}
+
+// Render returns the string representation of the grant item: the tool,
+// action and path it allows, followed by its scope and age.
+func (g *GrantItem) Render(width int) string {
+	styles := ListIemStyles{
+		ItemBlurred:     g.t.Dialog.NormalItem,
+		ItemFocused:     g.t.Dialog.SelectedItem,
+		InfoTextBlurred: g.t.Subtle,
+		InfoTextFocused: g.t.Base,
+	}
+	scope := "session"
+	if g.Project() {
+		scope = "project"
+	}
+	info := scope + " · " + humanize.Time(time.Unix(g.CreatedAt, 0))
+	return renderItem(styles, g.title(), info, g.focused, width, g.cache, &g.m)
+}
+
+// grantItems converts grants to a slice of [ListItem]s.
+func grantItems(t *styles.Styles, grants ...permission.Grant) []list.FilterableItem {
+	items := make([]list.FilterableItem, len(grants))
+	for i, grant := range grants {
+		items[i] = &GrantItem{Grant: grant, t: t}
+	}
+	return items
+}
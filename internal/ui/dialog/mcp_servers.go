@@ -290,6 +290,8 @@ func (m *MCPServers) drawDetail(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
 		if m.selectedServer.Error != nil {
 			statusText = fmt.Sprintf("Error: %s", m.selectedServer.Error.Error())
 		}
+	case mcp.StateNeedsAuth:
+		statusText = fmt.Sprintf("Needs authorization, run `crush login mcp %s`", m.selectedServer.Name)
 	case mcp.StateDisabled:
 		statusText = "Disabled"
 	}
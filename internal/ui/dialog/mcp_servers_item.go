@@ -2,6 +2,7 @@ package dialog
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/ui/list"
@@ -60,18 +61,18 @@ func (s *MCPServerItem) Render(width int) string {
 	switch s.Server.State {
 	case mcp.StateConnected:
 		statusIcon = s.t.ItemOnlineIcon.String()
-		if s.Server.Counts.Tools > 0 || s.Server.Counts.Prompts > 0 {
-			parts := []string{}
-			if s.Server.Counts.Tools > 0 {
-				parts = append(parts, fmt.Sprintf("%d tools", s.Server.Counts.Tools))
-			}
-			if s.Server.Counts.Prompts > 0 {
-				parts = append(parts, fmt.Sprintf("%d prompts", s.Server.Counts.Prompts))
-			}
-			statusText = fmt.Sprintf(" - %s", parts[0])
-			if len(parts) > 1 {
-				statusText = fmt.Sprintf(" - %s, %s", parts[0], parts[1])
-			}
+		parts := []string{}
+		if s.Server.Counts.Tools > 0 {
+			parts = append(parts, fmt.Sprintf("%d tools", s.Server.Counts.Tools))
+		}
+		if s.Server.Counts.Prompts > 0 {
+			parts = append(parts, fmt.Sprintf("%d prompts", s.Server.Counts.Prompts))
+		}
+		if s.Server.Counts.Resources > 0 {
+			parts = append(parts, fmt.Sprintf("%d resources", s.Server.Counts.Resources))
+		}
+		if len(parts) > 0 {
+			statusText = " - " + strings.Join(parts, ", ")
 		}
 	case mcp.StateStarting:
 		statusIcon = s.t.ItemBusyIcon.String()
@@ -79,6 +80,9 @@ func (s *MCPServerItem) Render(width int) string {
 	case mcp.StateError:
 		statusIcon = s.t.ItemErrorIcon.String()
 		statusText = " - error"
+	case mcp.StateNeedsAuth:
+		statusIcon = s.t.ItemErrorIcon.String()
+		statusText = " - needs auth"
 	case mcp.StateDisabled:
 		statusIcon = s.t.ItemOfflineIcon.String()
 		statusText = " - disabled"
@@ -28,6 +28,7 @@ type PermissionAction string
 const (
 	PermissionAllow           PermissionAction = "allow"
 	PermissionAllowForSession PermissionAction = "allow_session"
+	PermissionAllowForProject PermissionAction = "allow_project"
 	PermissionDeny            PermissionAction = "deny"
 )
 
@@ -61,7 +62,7 @@ type Permissions struct {
 	fullscreen   bool // true when dialog is fullscreen
 
 	permission     permission.PermissionRequest
-	selectedOption int // 0: Allow, 1: Allow for session, 2: Deny
+	selectedOption int // 0: Allow, 1: Allow for session, 2: Allow for project, 3: Deny
 
 	viewport      viewport.Model
 	viewportDirty bool // true when viewport content needs to be re-rendered
@@ -85,6 +86,7 @@ type permissionsKeyMap struct {
 	Select           key.Binding
 	Allow            key.Binding
 	AllowSession     key.Binding
+	AllowProject     key.Binding
 	Deny             key.Binding
 	Close            key.Binding
 	ToggleDiffMode   key.Binding
@@ -123,6 +125,10 @@ func defaultPermissionsKeyMap() permissionsKeyMap {
 			key.WithKeys("s", "S", "ctrl+s"),
 			key.WithHelp("s", "allow session"),
 		),
+		AllowProject: key.NewBinding(
+			key.WithKeys("p", "P"),
+			key.WithHelp("p", "allow project"),
+		),
 		Deny: key.NewBinding(
 			key.WithKeys("d", "D"),
 			key.WithHelp("d", "deny"),
@@ -233,16 +239,18 @@ func (p *Permissions) HandleMsg(msg tea.Msg) Action {
 			// Escape denies the permission request.
 			return p.respond(PermissionDeny)
 		case key.Matches(msg, p.keyMap.Right), key.Matches(msg, p.keyMap.Tab):
-			p.selectedOption = (p.selectedOption + 1) % 3
+			p.selectedOption = (p.selectedOption + 1) % 4
 		case key.Matches(msg, p.keyMap.Left):
-			// Add 2 instead of subtracting 1 to avoid negative modulo.
-			p.selectedOption = (p.selectedOption + 2) % 3
+			// Add 3 instead of subtracting 1 to avoid negative modulo.
+			p.selectedOption = (p.selectedOption + 3) % 4
 		case key.Matches(msg, p.keyMap.Select):
 			return p.selectCurrentOption()
 		case key.Matches(msg, p.keyMap.Allow):
 			return p.respond(PermissionAllow)
 		case key.Matches(msg, p.keyMap.AllowSession):
 			return p.respond(PermissionAllowForSession)
+		case key.Matches(msg, p.keyMap.AllowProject):
+			return p.respond(PermissionAllowForProject)
 		case key.Matches(msg, p.keyMap.Deny):
 			return p.respond(PermissionDeny)
 		case key.Matches(msg, p.keyMap.ToggleDiffMode):
@@ -302,6 +310,8 @@ func (p *Permissions) selectCurrentOption() tea.Msg {
 		return p.respond(PermissionAllow)
 	case 1:
 		return p.respond(PermissionAllowForSession)
+	case 2:
+		return p.respond(PermissionAllowForProject)
 	default:
 		return p.respond(PermissionDeny)
 	}
@@ -316,7 +326,7 @@ func (p *Permissions) respond(action PermissionAction) tea.Msg {
 
 func (p *Permissions) hasDiffView() bool {
 	switch p.permission.ToolName {
-	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName:
+	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.RenameToolName, tools.CodeActionToolName:
 		return true
 	}
 	return false
@@ -450,6 +460,9 @@ func (p *Permissions) renderHeader(contentWidth int) string {
 	pathLine := p.renderKeyValue("Path", fsext.PrettyPath(p.permission.Path), contentWidth)
 
 	lines := []string{title, "", toolLine, pathLine}
+	if p.permission.Reason != "" {
+		lines = append(lines, p.renderKeyValue("Rule", p.permission.Reason, contentWidth))
+	}
 
 	// Add tool-specific header info.
 	switch p.permission.ToolName {
@@ -462,7 +475,7 @@ func (p *Permissions) renderHeader(contentWidth int) string {
 			lines = append(lines, p.renderKeyValue("URL", params.URL, contentWidth))
 			lines = append(lines, p.renderKeyValue("File", fsext.PrettyPath(params.FilePath), contentWidth))
 		}
-	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.ViewToolName:
+	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.ViewToolName, tools.RenameToolName, tools.CodeActionToolName:
 		var filePath string
 		switch params := p.permission.Params.(type) {
 		case tools.EditPermissionsParams:
@@ -524,7 +537,7 @@ func (p *Permissions) renderContent(width int) string {
 	switch p.permission.ToolName {
 	case tools.BashToolName:
 		return p.renderBashContent(width)
-	case tools.EditToolName:
+	case tools.EditToolName, tools.RenameToolName, tools.CodeActionToolName:
 		return p.renderEditContent(width)
 	case tools.WriteToolName:
 		return p.renderWriteContent(width)
@@ -732,7 +745,8 @@ func (p *Permissions) renderButtons(contentWidth int) string {
 	buttons := []common.ButtonOpts{
 		{Text: "Allow", UnderlineIndex: 0, Selected: p.selectedOption == 0},
 		{Text: "Allow for Session", UnderlineIndex: 10, Selected: p.selectedOption == 1},
-		{Text: "Deny", UnderlineIndex: 0, Selected: p.selectedOption == 2},
+		{Text: "Allow for Project", UnderlineIndex: 10, Selected: p.selectedOption == 2},
+		{Text: "Deny", UnderlineIndex: 0, Selected: p.selectedOption == 3},
 	}
 
 	content := common.ButtonGroup(p.com.Styles, buttons, "  ")
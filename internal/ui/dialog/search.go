@@ -0,0 +1,256 @@
+package dialog
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/message"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/list"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/charmbracelet/crush/internal/uiutil"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/dustin/go-humanize"
+	"github.com/sahilm/fuzzy"
+)
+
+// SearchID is the identifier for the message search dialog.
+const SearchID = "search"
+
+// searchLimit is the maximum number of results shown.
+const searchLimit = 50
+
+// Search is a dialog that searches the messages of all sessions.
+type Search struct {
+	com   *common.Common
+	help  help.Model
+	list  *list.FilterableList
+	input textinput.Model
+	query string
+
+	keyMap struct {
+		Select   key.Binding
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*Search)(nil)
+
+// NewSearch creates a new message search dialog.
+func NewSearch(com *common.Common) *Search {
+	s := new(Search)
+	s.com = com
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	s.help = help
+
+	s.list = list.NewFilterableList()
+	s.list.Focus()
+
+	s.input = textinput.New()
+	s.input.SetVirtualCursor(false)
+	s.input.Placeholder = "Search messages"
+	s.input.SetStyles(com.Styles.TextInput)
+	s.input.Focus()
+
+	s.keyMap.Select = key.NewBinding(
+		key.WithKeys("enter", "tab", "ctrl+y"),
+		key.WithHelp("enter", "go to message"),
+	)
+	s.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "next item"),
+	)
+	s.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "previous item"),
+	)
+	s.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "choose"),
+	)
+	s.keyMap.Close = CloseKey
+
+	return s
+}
+
+// ID implements Dialog.
+func (s *Search) ID() string {
+	return SearchID
+}
+
+// HandleMsg implements Dialog.
+func (s *Search) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, s.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, s.keyMap.Previous):
+			if s.list.IsSelectedFirst() {
+				s.list.SelectLast()
+				s.list.ScrollToBottom()
+				break
+			}
+			s.list.SelectPrev()
+			s.list.ScrollToSelected()
+		case key.Matches(msg, s.keyMap.Next):
+			if s.list.IsSelectedLast() {
+				s.list.SelectFirst()
+				s.list.ScrollToTop()
+				break
+			}
+			s.list.SelectNext()
+			s.list.ScrollToSelected()
+		case key.Matches(msg, s.keyMap.Select):
+			if item, ok := s.list.SelectedItem().(*SearchResultItem); ok {
+				return ActionSelectMessage{
+					SessionID: item.SessionID,
+					MessageID: item.MessageID,
+				}
+			}
+		default:
+			var cmd tea.Cmd
+			s.input, cmd = s.input.Update(msg)
+			if query := strings.TrimSpace(s.input.Value()); query != s.query {
+				s.query = query
+				if err := s.search(); err != nil {
+					return ActionCmd{tea.Batch(cmd, uiutil.ReportError(err))}
+				}
+			}
+			return ActionCmd{cmd}
+		}
+	}
+	return nil
+}
+
+// search replaces the results with the messages matching the current query.
+func (s *Search) search() error {
+	var results []message.SearchResult
+	if s.query != "" {
+		var err error
+		results, err = s.com.App.Messages.Search(context.TODO(), s.query, searchLimit)
+		if err != nil {
+			return err
+		}
+	}
+	s.list.SetItems(searchResultItems(s.com.Styles, results...)...)
+	s.list.ScrollToTop()
+	s.list.SetSelected(0)
+	return nil
+}
+
+// Cursor returns the cursor position relative to the dialog.
+func (s *Search) Cursor() *tea.Cursor {
+	return InputCursor(s.com.Styles, s.input.Cursor())
+}
+
+// Draw implements [Dialog].
+func (s *Search) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.InputPrompt.GetVerticalFrameSize() + inputContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+	s.input.SetWidth(max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)) // (1) cursor padding
+	s.list.SetSize(innerWidth, height-heightOffset)
+	s.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Search Messages"
+	rc.AddPart(t.Dialog.InputPrompt.Render(s.input.View()))
+	listView := t.Dialog.List.Height(s.list.Height()).Render(s.list.Render())
+	rc.AddPart(listView)
+	rc.Help = s.help.View(s)
+
+	cur := s.Cursor()
+	DrawCenterCursor(scr, area, rc.Render(), cur)
+	return cur
+}
+
+// ShortHelp implements [help.KeyMap].
+func (s *Search) ShortHelp() []key.Binding {
+	return []key.Binding{
+		s.keyMap.UpDown,
+		s.keyMap.Select,
+		s.keyMap.Close,
+	}
+}
+
+// FullHelp implements [help.KeyMap].
+func (s *Search) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.ShortHelp()}
+}
+
+// SearchResultItem wraps a [message.SearchResult] to implement the
+// [ListItem] interface.
+type SearchResultItem struct {
+	message.SearchResult
+	t       *styles.Styles
+	m       fuzzy.Match
+	cache   map[int]string
+	focused bool
+}
+
+var _ ListItem = &SearchResultItem{}
+
+// Filter returns the filterable value of the result.
+func (s *SearchResultItem) Filter() string {
+	return s.Snippet
+}
+
+// ID returns the ID of the matching message.
+func (s *SearchResultItem) ID() string {
+	return s.MessageID
+}
+
+// SetMatch sets the fuzzy match for the result item.
+func (s *SearchResultItem) SetMatch(m fuzzy.Match) {
+	s.cache = nil
+	s.m = m
+}
+
+// SetFocused sets the focus state of the result item.
+func (s *SearchResultItem) SetFocused(focused bool) {
+	if s.focused != focused {
+		s.cache = nil
+	}
+	s.focused = focused
+}
+
+// Render returns the string representation of the result item: the snippet
+// of the message, followed by its session and age.
+func (s *SearchResultItem) Render(width int) string {
+	styles := ListIemStyles{
+		ItemBlurred:     s.t.Dialog.NormalItem,
+		ItemFocused:     s.t.Dialog.SelectedItem,
+		InfoTextBlurred: s.t.Subtle,
+		InfoTextFocused: s.t.Base,
+	}
+	title := ansi.Truncate(s.SessionTitle, max(0, width/3), "…")
+	info := title + " · " + humanize.Time(time.Unix(s.CreatedAt, 0))
+	snippet := strings.Join(strings.Fields(s.Snippet), " ")
+	return renderItem(styles, snippet, info, s.focused, width, s.cache, &s.m)
+}
+
+// searchResultItems converts search results to a slice of [ListItem]s.
+func searchResultItems(t *styles.Styles, results ...message.SearchResult) []list.FilterableItem {
+	items := make([]list.FilterableItem, len(results))
+	for i, r := range results {
+		items[i] = &SearchResultItem{SearchResult: r, t: t}
+	}
+	return items
+}
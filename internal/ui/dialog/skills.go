@@ -0,0 +1,281 @@
+package dialog
+
+import (
+	"context"
+	"path/filepath"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/crush/internal/skills"
+	"github.com/charmbracelet/crush/internal/ui/common"
+	"github.com/charmbracelet/crush/internal/ui/list"
+	"github.com/charmbracelet/crush/internal/ui/styles"
+	"github.com/charmbracelet/crush/internal/uiutil"
+	uv "github.com/charmbracelet/ultraviolet"
+	"github.com/sahilm/fuzzy"
+)
+
+// SkillsID is the identifier for the skills dialog.
+const SkillsID = "skills"
+
+// Skills is a dialog to review the skills found in the skills paths and
+// enable or disable them.
+type Skills struct {
+	com   *common.Common
+	help  help.Model
+	list  *list.FilterableList
+	input textinput.Model
+	found []skills.Found
+
+	keyMap struct {
+		Toggle   key.Binding
+		Next     key.Binding
+		Previous key.Binding
+		UpDown   key.Binding
+		Close    key.Binding
+	}
+}
+
+var _ Dialog = (*Skills)(nil)
+
+// NewSkills creates a new skills dialog.
+func NewSkills(com *common.Common) (*Skills, error) {
+	s := new(Skills)
+	s.com = com
+	s.found = skills.Scan(com.Config().SkillsDirs())
+
+	help := help.New()
+	help.Styles = com.Styles.DialogHelpStyles()
+	s.help = help
+
+	s.list = list.NewFilterableList(s.items()...)
+	s.list.Focus()
+	s.list.SetSelected(0)
+
+	s.input = textinput.New()
+	s.input.SetVirtualCursor(false)
+	s.input.Placeholder = "Filter skills"
+	s.input.SetStyles(com.Styles.TextInput)
+	s.input.Focus()
+
+	s.keyMap.Toggle = key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl+t", "enable/disable"),
+	)
+	s.keyMap.Next = key.NewBinding(
+		key.WithKeys("down", "ctrl+n"),
+		key.WithHelp("↓", "next item"),
+	)
+	s.keyMap.Previous = key.NewBinding(
+		key.WithKeys("up", "ctrl+p"),
+		key.WithHelp("↑", "previous item"),
+	)
+	s.keyMap.UpDown = key.NewBinding(
+		key.WithKeys("up", "down"),
+		key.WithHelp("↑↓", "choose"),
+	)
+	s.keyMap.Close = CloseKey
+
+	return s, nil
+}
+
+// ID implements Dialog.
+func (s *Skills) ID() string {
+	return SkillsID
+}
+
+// HandleMsg implements Dialog.
+func (s *Skills) HandleMsg(msg tea.Msg) Action {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, s.keyMap.Close):
+			return ActionClose{}
+		case key.Matches(msg, s.keyMap.Previous):
+			if s.list.IsSelectedFirst() {
+				s.list.SelectLast()
+				s.list.ScrollToBottom()
+				break
+			}
+			s.list.SelectPrev()
+			s.list.ScrollToSelected()
+		case key.Matches(msg, s.keyMap.Next):
+			if s.list.IsSelectedLast() {
+				s.list.SelectFirst()
+				s.list.ScrollToTop()
+				break
+			}
+			s.list.SelectNext()
+			s.list.ScrollToSelected()
+		case key.Matches(msg, s.keyMap.Toggle):
+			if item, ok := s.list.SelectedItem().(*SkillItem); ok && item.Skill != nil {
+				return ActionCmd{s.toggle(item.Skill.Name)}
+			}
+		default:
+			var cmd tea.Cmd
+			s.input, cmd = s.input.Update(msg)
+			s.list.SetFilter(s.input.Value())
+			s.list.ScrollToTop()
+			s.list.SetSelected(0)
+			return ActionCmd{cmd}
+		}
+	}
+	return nil
+}
+
+// toggle enables or disables the skill, saves it in the config and updates
+// the system prompt of the agent, which lists the enabled skills.
+func (s *Skills) toggle(name string) tea.Cmd {
+	cfg := s.com.Config()
+	enabled := !cfg.SkillEnabled(name)
+	if err := cfg.SetSkillEnabled(name, enabled); err != nil {
+		return uiutil.ReportError(err)
+	}
+
+	selected := s.list.Selected()
+	s.list.SetItems(s.items()...)
+	s.list.SetFilter(s.input.Value())
+	s.list.SetSelected(selected)
+	s.list.ScrollToSelected()
+
+	return func() tea.Msg {
+		if err := s.com.App.UpdateAgentSystemPrompt(context.TODO()); err != nil {
+			return uiutil.NewErrorMsg(err)
+		}
+		status := "disabled"
+		if enabled {
+			status = "enabled"
+		}
+		return uiutil.NewInfoMsg("Skill " + name + " " + status)
+	}
+}
+
+// Cursor returns the cursor position relative to the dialog.
+func (s *Skills) Cursor() *tea.Cursor {
+	return InputCursor(s.com.Styles, s.input.Cursor())
+}
+
+// Draw implements [Dialog].
+func (s *Skills) Draw(scr uv.Screen, area uv.Rectangle) *tea.Cursor {
+	t := s.com.Styles
+	width := max(0, min(defaultDialogMaxWidth, area.Dx()))
+	height := max(0, min(defaultDialogHeight, area.Dy()))
+	innerWidth := width - t.Dialog.View.GetHorizontalFrameSize() - 2
+	heightOffset := t.Dialog.Title.GetVerticalFrameSize() + titleContentHeight +
+		t.Dialog.InputPrompt.GetVerticalFrameSize() + inputContentHeight +
+		t.Dialog.HelpView.GetVerticalFrameSize() +
+		t.Dialog.View.GetVerticalFrameSize()
+	s.input.SetWidth(max(0, innerWidth-t.Dialog.InputPrompt.GetHorizontalFrameSize()-1)) // (1) cursor padding
+	s.list.SetSize(innerWidth, min(height-heightOffset, s.list.Len()))
+	s.help.SetWidth(innerWidth)
+
+	rc := NewRenderContext(t, width)
+	rc.Title = "Skills"
+	if len(s.found) == 0 {
+		rc.AddPart(t.Dialog.NormalItem.Render("No skills found"))
+		rc.AddPart(t.Subtle.Render("Install skills with `crush skills install`"))
+	} else {
+		rc.AddPart(t.Dialog.InputPrompt.Render(s.input.View()))
+		listView := t.Dialog.List.Height(s.list.Height()).Render(s.list.Render())
+		rc.AddPart(listView)
+	}
+	rc.Help = s.help.View(s)
+
+	cur := s.Cursor()
+	DrawCenterCursor(scr, area, rc.Render(), cur)
+	return cur
+}
+
+// ShortHelp implements [help.KeyMap].
+func (s *Skills) ShortHelp() []key.Binding {
+	return []key.Binding{
+		s.keyMap.UpDown,
+		s.keyMap.Toggle,
+		s.keyMap.Close,
+	}
+}
+
+// FullHelp implements [help.KeyMap].
+func (s *Skills) FullHelp() [][]key.Binding {
+	return [][]key.Binding{s.ShortHelp()}
+}
+
+// items converts the skills found to a slice of [ListItem]s.
+func (s *Skills) items() []list.FilterableItem {
+	cfg := s.com.Config()
+	items := make([]list.FilterableItem, len(s.found))
+	for i, f := range s.found {
+		item := &SkillItem{Found: f, t: s.com.Styles}
+		if f.Skill != nil {
+			item.disabled = !cfg.SkillEnabled(f.Skill.Name)
+		}
+		items[i] = item
+	}
+	return items
+}
+
+// SkillItem wraps a [skills.Found] to implement the [ListItem] interface.
+// Skill is nil when the SKILL.md file couldn't be parsed.
+type SkillItem struct {
+	skills.Found
+	disabled bool
+	t        *styles.Styles
+	m        fuzzy.Match
+	cache    map[int]string
+	focused  bool
+}
+
+var _ ListItem = &SkillItem{}
+
+// Filter returns the filterable value of the skill.
+func (s *SkillItem) Filter() string {
+	return s.title()
+}
+
+// ID returns the path of the SKILL.md file of the skill.
+func (s *SkillItem) ID() string {
+	return s.Path
+}
+
+// SetMatch sets the fuzzy match for the skill item.
+func (s *SkillItem) SetMatch(m fuzzy.Match) {
+	s.cache = nil
+	s.m = m
+}
+
+// SetFocused sets the focus state of the skill item.
+func (s *SkillItem) SetFocused(focused bool) {
+	if s.focused != focused {
+		s.cache = nil
+	}
+	s.focused = focused
+}
+
+func (s *SkillItem) title() string {
+	if s.Skill != nil && s.Skill.Name != "" {
+		return s.Skill.Name
+	}
+	return filepath.Base(filepath.Dir(s.Path))
+}
+
+// Render returns the string representation of the skill item: its name,
+// followed by its status. Run `crush skills validate` for the problems of
+// invalid skills.
+func (s *SkillItem) Render(width int) string {
+	styles := ListIemStyles{
+		ItemBlurred:     s.t.Dialog.NormalItem,
+		ItemFocused:     s.t.Dialog.SelectedItem,
+		InfoTextBlurred: s.t.Subtle,
+		InfoTextFocused: s.t.Base,
+	}
+	var info string
+	switch {
+	case s.Err != nil:
+		info = "invalid"
+	case s.disabled:
+		info = "disabled"
+	}
+	return renderItem(styles, s.title(), info, s.focused, width, s.cache, &s.m)
+}
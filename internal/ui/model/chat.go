@@ -5,6 +5,7 @@ import (
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent/tools"
 	"github.com/charmbracelet/crush/internal/ui/anim"
 	"github.com/charmbracelet/crush/internal/ui/chat"
 	"github.com/charmbracelet/crush/internal/ui/common"
@@ -408,11 +409,65 @@ func (m *Chat) MessageItem(id string) chat.MessageItem {
 	return item
 }
 
-// ToggleExpandedSelectedItem expands the selected message item if it is expandable.
-func (m *Chat) ToggleExpandedSelectedItem() {
+// SelectMessage selects the item with the given message or tool call ID. It
+// reports whether the item was found.
+func (m *Chat) SelectMessage(id string) bool {
+	idx, ok := m.idInxMap[id]
+	if !ok {
+		return false
+	}
+	m.SetSelected(idx)
+	return true
+}
+
+// SelectedUserMessageID returns the ID of the selected message if it's a user
+// message, or an empty string otherwise.
+func (m *Chat) SelectedUserMessageID() string {
+	if item, ok := m.list.SelectedItem().(*chat.UserMessageItem); ok {
+		return item.ID()
+	}
+	return ""
+}
+
+// fullOutputLoadedMsg carries the full output of a tool call loaded for its
+// item.
+type fullOutputLoadedMsg struct {
+	toolCallID string
+	output     string
+}
+
+// ToggleExpandedSelectedItem expands the selected message item if it is
+// expandable, returning a command that loads the full output it needs, if
+// any.
+func (m *Chat) ToggleExpandedSelectedItem() tea.Cmd {
 	if expandable, ok := m.list.SelectedItem().(chat.Expandable); ok {
 		expandable.ToggleExpanded()
 	}
+	return m.LoadSelectedFullOutput()
+}
+
+// LoadSelectedFullOutput returns a command that loads the full output of the
+// selected tool item when it needs it.
+func (m *Chat) LoadSelectedFullOutput() tea.Cmd {
+	loader, ok := m.list.SelectedItem().(chat.FullOutputLoader)
+	if !ok {
+		return nil
+	}
+	sessionID, toolCallID, ok := loader.NeedsFullOutput()
+	if !ok {
+		return nil
+	}
+	return func() tea.Msg {
+		output, _ := tools.GetOutputCache().Get(sessionID, toolCallID)
+		return fullOutputLoadedMsg{toolCallID: toolCallID, output: output}
+	}
+}
+
+// SetFullOutput gives the full output of a tool call to its item.
+func (m *Chat) SetFullOutput(toolCallID, output string) {
+	if loader, ok := m.MessageItem(toolCallID).(chat.FullOutputLoader); ok && output != "" {
+		loader.SetFullOutput(output)
+	}
 }
 
 // HandleKeyMsg handles key events for the chat component.
@@ -45,6 +45,7 @@ type KeyMap struct {
 		Copy           key.Binding
 		ClearHighlight key.Binding
 		Expand         key.Binding
+		Undo           key.Binding
 	}
 
 	Initialize struct {
@@ -235,6 +236,10 @@ func DefaultKeyMap() KeyMap {
 		key.WithKeys("space"),
 		key.WithHelp("space", "expand/collapse"),
 	)
+	km.Chat.Undo = key.NewBinding(
+		key.WithKeys("U"),
+		key.WithHelp("U", "undo to here"),
+	)
 	km.Initialize.Yes = key.NewBinding(
 		key.WithKeys("y", "Y"),
 		key.WithHelp("y", "yes"),
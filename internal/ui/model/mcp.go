@@ -34,7 +34,7 @@ func (m *UI) mcpInfo(width, maxItems int, isSection bool) string {
 	return lipgloss.NewStyle().Width(width).Render(fmt.Sprintf("%s\n\n%s", title, list))
 }
 
-// mcpCounts formats tool and prompt counts for display.
+// mcpCounts formats tool, prompt and resource counts for display.
 func mcpCounts(t *styles.Styles, counts mcp.Counts) string {
 	parts := []string{}
 	if counts.Tools > 0 {
@@ -43,6 +43,9 @@ func mcpCounts(t *styles.Styles, counts mcp.Counts) string {
 	if counts.Prompts > 0 {
 		parts = append(parts, t.Subtle.Render(fmt.Sprintf("%d prompts", counts.Prompts)))
 	}
+	if counts.Resources > 0 {
+		parts = append(parts, t.Subtle.Render(fmt.Sprintf("%d resources", counts.Resources)))
+	}
 	return strings.Join(parts, " ")
 }
 
@@ -73,6 +76,9 @@ func mcpList(t *styles.Styles, mcps []mcp.ClientInfo, width, maxItems int) strin
 			if m.Error != nil {
 				description = t.Subtle.Render(fmt.Sprintf("error: %s", m.Error.Error()))
 			}
+		case mcp.StateNeedsAuth:
+			icon = t.ItemErrorIcon.String()
+			description = t.Subtle.Render("needs auth")
 		case mcp.StateDisabled:
 			icon = t.ItemOfflineIcon.Foreground(t.Muted.GetBackground()).String()
 			description = t.Subtle.Render("disabled")
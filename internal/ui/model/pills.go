@@ -1,11 +1,15 @@
 package model
 
 import (
+	"cmp"
 	"fmt"
 	"strings"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"charm.land/fantasy"
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/ui/chat"
 	"github.com/charmbracelet/crush/internal/ui/styles"
@@ -114,6 +118,26 @@ func todoPill(todos []session.Todo, spinnerView string, focused, panelFocused bo
 	return pillStyle(focused, panelFocused, t).Render(content)
 }
 
+// retryPill renders the pill shown while a failed provider request waits
+// to be retried.
+func retryPill(retry *agent.RetryEvent, panelFocused bool, t *styles.Styles) string {
+	if retry == nil {
+		return ""
+	}
+	reason := "request failed"
+	if retry.Error != nil {
+		reason = cmp.Or(retry.Error.Title, fantasy.ErrorTitleForStatusCode(retry.Error.StatusCode), reason)
+	}
+	content := fmt.Sprintf(
+		"%s %s %s  %s",
+		t.Pills.RetryIcon.String(),
+		t.Base.Render("Retrying"),
+		t.Muted.Render(fmt.Sprintf("%d/%d in %s", retry.Attempt+1, retry.MaxAttempts, retry.Delay.Round(time.Second))),
+		t.Subtle.Render(reason),
+	)
+	return pillStyle(false, panelFocused, t).Render(content)
+}
+
 // todoList renders the expanded todo list.
 func todoList(sessionTodos []session.Todo, spinnerView string, t *styles.Styles, width int) string {
 	return chat.FormatTodosList(t, sessionTodos, spinnerView, width)
@@ -192,7 +216,7 @@ func (m *UI) pillsAreaHeight() int {
 	}
 	hasIncomplete := hasIncompleteTodos(m.session.Todos)
 	hasQueue := m.promptQueue > 0
-	hasPills := hasIncomplete || hasQueue
+	hasPills := hasIncomplete || hasQueue || m.retry != nil
 	if !hasPills {
 		return 0
 	}
@@ -226,7 +250,7 @@ func (m *UI) renderPills() {
 	hasIncomplete := hasIncompleteTodos(m.session.Todos)
 	hasQueue := m.promptQueue > 0
 
-	if !hasIncomplete && !hasQueue {
+	if !hasIncomplete && !hasQueue && m.retry == nil {
 		return
 	}
 
@@ -246,6 +270,9 @@ func (m *UI) renderPills() {
 	if hasQueue {
 		pills = append(pills, queuePill(m.promptQueue, queueFocused, m.pillsExpanded, t))
 	}
+	if m.retry != nil {
+		pills = append(pills, retryPill(m.retry, m.pillsExpanded, t))
+	}
 
 	var expandedList string
 	if m.pillsExpanded {
@@ -12,6 +12,7 @@ import (
 	"github.com/charmbracelet/crush/internal/diff"
 	"github.com/charmbracelet/crush/internal/fsext"
 	"github.com/charmbracelet/crush/internal/history"
+	"github.com/charmbracelet/crush/internal/message"
 	"github.com/charmbracelet/crush/internal/session"
 	"github.com/charmbracelet/crush/internal/ui/common"
 	"github.com/charmbracelet/crush/internal/ui/styles"
@@ -24,6 +25,8 @@ import (
 type loadSessionMsg struct {
 	session *session.Session
 	files   []SessionFile
+	// messageID is the message to select once the session is shown, if any.
+	messageID string
 }
 
 // SessionFile tracks the first and latest versions of a file in a session,
@@ -46,6 +49,7 @@ func (m *UI) loadSession(sessionID string) tea.Cmd {
 			// TODO: better error handling
 			return uiutil.ReportError(err)()
 		}
+		m.com.App.UseWorktree(session)
 
 		files, err := m.com.App.History.ListBySession(context.Background(), sessionID)
 		if err != nil {
@@ -102,6 +106,46 @@ func (m *UI) loadSession(sessionID string) tea.Cmd {
 	}
 }
 
+// loadSessionAt loads the session like [UI.loadSession] and then selects the
+// given message in the chat.
+func (m *UI) loadSessionAt(sessionID, messageID string) tea.Cmd {
+	load := m.loadSession(sessionID)
+	return func() tea.Msg {
+		msg := load()
+		if loaded, ok := msg.(loadSessionMsg); ok {
+			loaded.messageID = messageID
+			return loaded
+		}
+		return msg
+	}
+}
+
+// selectMessage focuses the chat and selects the item showing the message
+// with the given ID. Tool messages are shown as part of the calls they answer,
+// and assistant messages without text only through their tool calls.
+func (m *UI) selectMessage(msgs []message.Message, messageID string) tea.Cmd {
+	idx := slices.IndexFunc(msgs, func(msg message.Message) bool { return msg.ID == messageID })
+	if idx < 0 {
+		return uiutil.ReportWarn("Message not found in session")
+	}
+	ids := []string{messageID}
+	for _, tc := range msgs[idx].ToolCalls() {
+		ids = append(ids, tc.ID)
+	}
+	for _, tr := range msgs[idx].ToolResults() {
+		ids = append(ids, tr.ToolCallID)
+	}
+	for _, id := range ids {
+		if m.chat.SelectMessage(id) {
+			m.setState(uiChat, uiFocusMain)
+			m.textarea.Blur()
+			m.chat.Focus()
+			return m.chat.ScrollToSelectedAndAnimate()
+		}
+	}
+	return nil
+}
+
 // handleFileEvent processes file change events and updates the session file
 // list with new or updated file information.
 func (m *UI) handleFileEvent(file history.File) tea.Cmd {
@@ -24,6 +24,7 @@ import (
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/catwalk/pkg/catwalk"
+	"github.com/charmbracelet/crush/internal/agent"
 	"github.com/charmbracelet/crush/internal/agent/tools/mcp"
 	"github.com/charmbracelet/crush/internal/app"
 	"github.com/charmbracelet/crush/internal/commands"
@@ -90,6 +91,13 @@ type openEditorMsg struct {
 type (
 	// cancelTimerExpiredMsg is sent when the cancel timer expires.
 	cancelTimerExpiredMsg struct{}
+	// undoTimerExpiredMsg is sent when the undo confirmation timer expires.
+	undoTimerExpiredMsg struct{}
+	// sessionRevertedMsg is sent when the session was reverted to an
+	// earlier message.
+	sessionRevertedMsg struct {
+		Result app.RevertResult
+	}
 	// userCommandsLoadedMsg is sent when user commands are loaded.
 	userCommandsLoadedMsg struct {
 		Commands []commands.CustomCommand
@@ -137,6 +145,10 @@ type UI struct {
 	// isCanceling tracks whether the user has pressed escape once to cancel.
 	isCanceling bool
 
+	// pendingUndoID is the ID of the message the user has asked to undo to
+	// once, waiting for confirmation.
+	pendingUndoID string
+
 	// header is the last cached header logo
 	header string
 
@@ -187,6 +199,10 @@ type UI struct {
 	// forceCompactMode tracks whether compact mode is forced by user toggle
 	forceCompactMode bool
 
+	// newSessionWorktree tracks whether the next session should be created
+	// in its own git worktree.
+	newSessionWorktree bool
+
 	// isCompact tracks whether we're currently in compact layout mode (either
 	// by user toggle or auto-switch based on window size)
 	isCompact bool
@@ -200,6 +216,9 @@ type UI struct {
 	promptQueue        int
 	pillsView          string
 
+	// retry is the provider retry the current session is waiting on, if any.
+	retry *agent.RetryEvent
+
 	// Todo spinner
 	todoSpinner    spinner.Model
 	todoIsSpinning bool
@@ -369,6 +388,9 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.sendProgressBar = slices.Contains(msg, "WT_SESSION")
 		}
 		cmds = append(cmds, common.QueryCmd(uv.Environ(msg)))
+	case fullOutputLoadedMsg:
+		m.chat.SetFullOutput(msg.toolCallID, msg.output)
+
 	case loadSessionMsg:
 		if m.forceCompactMode {
 			m.isCompact = true
@@ -376,6 +398,7 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.setState(uiChat, m.focus)
 		m.session = msg.session
 		m.sessionFiles = msg.files
+		m.retry = nil
 		msgs, err := m.com.App.Messages.List(context.Background(), m.session.ID)
 		if err != nil {
 			cmds = append(cmds, uiutil.ReportError(err))
@@ -384,6 +407,11 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cmd := m.setSessionMessages(msgs); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+		if msg.messageID != "" {
+			if cmd := m.selectMessage(msgs, msg.messageID); cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
 		if hasInProgressTodo(m.session.Todos) {
 			// only start spinner if there is an in-progress todo
 			if m.isAgentBusy() {
@@ -481,9 +509,32 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.renderPills()
 	case pubsub.Event[history.File]:
 		cmds = append(cmds, m.handleFileEvent(msg.Payload))
+	case pubsub.Event[agent.RetryEvent]:
+		if m.session == nil || msg.Payload.SessionID != m.session.ID {
+			break
+		}
+		if msg.Type == pubsub.DeletedEvent {
+			m.retry = nil
+		} else {
+			m.retry = &msg.Payload
+		}
+		m.updateLayoutAndSize()
+	case pubsub.Event[agent.FallbackEvent]:
+		if m.session != nil && msg.Payload.SessionID == m.session.ID {
+			cmds = append(cmds, uiutil.ReportWarn(fmt.Sprintf("%s failed (%s), switched to %s", msg.Payload.From, msg.Payload.Reason, msg.Payload.To)))
+		}
 	case pubsub.Event[app.LSPEvent]:
 		m.lspStates = app.GetLSPStates()
 	case pubsub.Event[mcp.Event]:
+		switch msg.Payload.Type {
+		case mcp.EventResourcesListChanged:
+			cmds = append(cmds, func() tea.Msg {
+				mcp.RefreshResources(context.Background(), msg.Payload.Name)
+				return nil
+			})
+		case mcp.EventResourceUpdated:
+			cmds = append(cmds, uiutil.ReportInfo(fmt.Sprintf("MCP resource %s changed", msg.Payload.URI)))
+		}
 		m.mcpStates = mcp.GetStates()
 		// check if all mcps are initialized
 		initialized := true
@@ -502,8 +553,19 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case pubsub.Event[permission.PermissionNotification]:
 		m.handlePermissionNotification(msg.Payload)
+	case pubsub.Event[mcp.ElicitationRequest]:
+		m.dialog.OpenDialog(dialog.NewElicitation(m.com, msg.Payload))
 	case cancelTimerExpiredMsg:
 		m.isCanceling = false
+	case undoTimerExpiredMsg:
+		m.pendingUndoID = ""
+	case sessionRevertedMsg:
+		if m.session != nil {
+			cmds = append(cmds, m.loadSession(m.session.ID))
+		}
+		m.textarea.SetValue(msg.Result.Message.Content().Text)
+		m.textarea.MoveToEnd()
+		cmds = append(cmds, uiutil.ReportInfo(fmt.Sprintf("Reverted %d file(s)", len(msg.Result.Files))))
 	case tea.TerminalVersionMsg:
 		termVersion := strings.ToLower(msg.Name)
 		// Only enable progress bar for the following terminals.
@@ -536,6 +598,9 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			y -= m.layout.main.Min.Y
 			if m.chat.HandleMouseDown(x, y) {
 				m.lastClickTime = time.Now()
+				if cmd := m.chat.LoadSelectedFullOutput(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
 			}
 		}
 
@@ -678,7 +743,7 @@ func (m *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case completions.FilesLoadedMsg:
 		// Handle async file loading for completions.
 		if m.completionsOpen {
-			m.completions.SetFiles(msg.Files)
+			m.completions.SetFiles(msg.Files, msg.Resources)
 		}
 	case uv.KittyGraphicsEvent:
 		if !bytes.HasPrefix(msg.Payload, []byte("OK")) {
@@ -926,7 +991,7 @@ func (m *UI) updateSessionMessage(msg message.Message) tea.Cmd {
 			}
 		}
 		if existingToolItem == nil {
-			items = append(items, chat.NewToolMessageItem(m.com.Styles, msg.ID, tc, nil, false))
+			items = append(items, chat.NewToolMessageItem(m.com.Styles, msg.SessionID, msg.ID, tc, nil, false))
 		}
 	}
 
@@ -1003,7 +1068,7 @@ func (m *UI) handleChildSessionMessage(event pubsub.Event[message.Message]) tea.
 		}
 		if !found {
 			// Create a new nested tool item.
-			nestedItem := chat.NewToolMessageItem(m.com.Styles, event.Payload.ID, tc, nil, false)
+			nestedItem := chat.NewToolMessageItem(m.com.Styles, event.Payload.SessionID, event.Payload.ID, tc, nil, false)
 			if simplifiable, ok := nestedItem.(chat.Compactable); ok {
 				simplifiable.SetCompact(true)
 			}
@@ -1077,6 +1142,9 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 	case dialog.ActionSelectSession:
 		m.dialog.CloseDialog(dialog.SessionsID)
 		cmds = append(cmds, m.loadSession(msg.Session.ID))
+	case dialog.ActionSelectMessage:
+		m.dialog.CloseDialog(dialog.SearchID)
+		cmds = append(cmds, m.loadSessionAt(msg.SessionID, msg.MessageID))
 
 	// Open dialog message
 	case dialog.ActionOpenDialog:
@@ -1100,19 +1168,55 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			cmds = append(cmds, cmd)
 		}
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionNewWorktreeSession:
+		if m.isAgentBusy() {
+			cmds = append(cmds, uiutil.ReportWarn("Agent is busy, please wait before starting a new session..."))
+			break
+		}
+		if cmd := m.newSession(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+		m.newSessionWorktree = true
+		m.dialog.CloseDialog(dialog.CommandsID)
+		cmds = append(cmds, uiutil.ReportInfo("The next session will run in its own git worktree"))
 	case dialog.ActionSummarize:
 		if m.isAgentBusy() {
 			cmds = append(cmds, uiutil.ReportWarn("Agent is busy, please wait before summarizing session..."))
 			break
 		}
 		cmds = append(cmds, func() tea.Msg {
-			err := m.com.App.AgentCoordinator.Summarize(context.Background(), msg.SessionID)
+			err := m.com.App.AgentCoordinator.Summarize(context.Background(), msg.SessionID, "")
 			if err != nil {
 				return uiutil.ReportError(err)()
 			}
 			return nil
 		})
 		m.dialog.CloseDialog(dialog.CommandsID)
+	case dialog.ActionCompact:
+		if m.isAgentBusy() {
+			cmds = append(cmds, uiutil.ReportWarn("Agent is busy, please wait before compacting session..."))
+			break
+		}
+		if msg.Args == nil {
+			m.dialog.CloseFrontDialog()
+			argsDialog := dialog.NewArguments(
+				m.com,
+				"Compact Session",
+				"What should the summary focus on?",
+				[]commands.Argument{{ID: "FOCUS", Title: "Focus", Required: true}},
+				msg, // Pass the action as the result
+			)
+			m.dialog.OpenDialog(argsDialog)
+			break
+		}
+		cmds = append(cmds, func() tea.Msg {
+			err := m.com.App.AgentCoordinator.Summarize(context.Background(), msg.SessionID, msg.Args["FOCUS"])
+			if err != nil {
+				return uiutil.ReportError(err)()
+			}
+			return nil
+		})
+		m.dialog.CloseFrontDialog()
 	case dialog.ActionToggleHelp:
 		m.status.ToggleHelp()
 		m.dialog.CloseDialog(dialog.CommandsID)
@@ -1270,10 +1374,17 @@ func (m *UI) handleDialogMsg(msg tea.Msg) tea.Cmd {
 			m.com.App.Permissions.Grant(msg.Permission)
 		case dialog.PermissionAllowForSession:
 			m.com.App.Permissions.GrantPersistent(msg.Permission)
+		case dialog.PermissionAllowForProject:
+			m.com.App.Permissions.GrantForProject(msg.Permission)
 		case dialog.PermissionDeny:
 			m.com.App.Permissions.Deny(msg.Permission)
 		}
 
+	case dialog.ActionElicitationResponse:
+		// Concurrent requests stack, the answer is from the front one.
+		m.dialog.CloseFrontDialog()
+		mcp.RespondElicitation(msg.ID, msg.Response)
+
 	case dialog.ActionFilePickerSelected:
 		cmds = append(cmds, tea.Sequence(
 			msg.Cmd(),
@@ -1460,9 +1571,12 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 				if msg, ok := m.completions.Update(msg); ok {
 					switch msg := msg.(type) {
 					case completions.SelectionMsg:
-						// Handle file completion selection.
-						if item, ok := msg.Value.(completions.FileCompletionValue); ok {
+						// Handle file and MCP resource completion selection.
+						switch item := msg.Value.(type) {
+						case completions.FileCompletionValue:
 							cmds = append(cmds, m.insertFileCompletion(item.Path))
+						case completions.ResourceCompletionValue:
+							cmds = append(cmds, m.insertResourceCompletion(item))
 						}
 						if !msg.Insert {
 							m.closeCompletions()
@@ -1634,7 +1748,13 @@ func (m *UI) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 					cmds = append(cmds, cmd)
 				}
 			case key.Matches(msg, m.keyMap.Chat.Expand):
-				m.chat.ToggleExpandedSelectedItem()
+				if cmd := m.chat.ToggleExpandedSelectedItem(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+			case key.Matches(msg, m.keyMap.Chat.Undo):
+				if cmd := m.undoToSelected(); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
 			case key.Matches(msg, m.keyMap.Chat.Up):
 				if cmd := m.chat.ScrollByAndAnimate(-1); cmd != nil {
 					cmds = append(cmds, cmd)
@@ -1921,6 +2041,7 @@ func (m *UI) ShortHelp() []key.Binding {
 				k.Chat.PageUp,
 				k.Chat.PageDown,
 				k.Chat.Copy,
+				k.Chat.Undo,
 			)
 			if m.pillsExpanded && hasIncompleteTodos(m.session.Todos) && m.promptQueue > 0 {
 				binds = append(binds, k.Chat.PillLeft)
@@ -2032,6 +2153,7 @@ func (m *UI) FullHelp() [][]key.Binding {
 				[]key.Binding{
 					k.Chat.Copy,
 					k.Chat.ClearHighlight,
+					k.Chat.Undo,
 				},
 			)
 			if m.pillsExpanded && hasIncompleteTodos(m.session.Todos) && m.promptQueue > 0 {
@@ -2411,22 +2533,10 @@ func (m *UI) closeCompletions() {
 // insertFileCompletion inserts the selected file path into the textarea,
 // replacing the @query, and adds the file as an attachment.
 func (m *UI) insertFileCompletion(path string) tea.Cmd {
-	value := m.textarea.Value()
-	word := m.textareaWord()
-
-	// Find the @ and query to replace.
-	if m.completionsStartIndex > len(value) {
+	if !m.replaceCompletionQuery(path) {
 		return nil
 	}
 
-	// Build the new value: everything before @, the path, everything after query.
-	endIdx := min(m.completionsStartIndex+len(word), len(value))
-
-	newValue := value[:m.completionsStartIndex] + path + value[endIdx:]
-	m.textarea.SetValue(newValue)
-	m.textarea.MoveToEnd()
-	m.textarea.InsertRune(' ')
-
 	return func() tea.Msg {
 		absPath, _ := filepath.Abs(path)
 		// Skip attachment if file was already read and hasn't been modified.
@@ -2454,6 +2564,48 @@ func (m *UI) insertFileCompletion(path string) tea.Cmd {
 	}
 }
 
+// insertResourceCompletion inserts the URI of the selected MCP resource into
+// the textarea, replacing the @query, and adds the resource contents as an
+// attachment.
+func (m *UI) insertResourceCompletion(resource completions.ResourceCompletionValue) tea.Cmd {
+	if !m.replaceCompletionQuery(resource.URI) {
+		return nil
+	}
+
+	return func() tea.Msg {
+		contents, err := mcp.ReadResource(context.Background(), resource.MCPName, resource.URI)
+		if err != nil {
+			return uiutil.NewErrorMsg(fmt.Errorf("failed to read MCP resource %s: %w", resource.URI, err))
+		}
+		attachment, ok := mcp.ResourceAttachment(resource.URI, resource.Name, resource.MIMEType, contents)
+		if !ok {
+			return nil
+		}
+		return attachment
+	}
+}
+
+// replaceCompletionQuery replaces the @query in the textarea with the text of
+// the selected completion, followed by a space.
+func (m *UI) replaceCompletionQuery(text string) bool {
+	value := m.textarea.Value()
+	word := m.textareaWord()
+
+	// Find the @ and query to replace.
+	if m.completionsStartIndex > len(value) {
+		return false
+	}
+
+	// Build the new value: everything before @, the text, everything after query.
+	endIdx := min(m.completionsStartIndex+len(word), len(value))
+
+	newValue := value[:m.completionsStartIndex] + text + value[endIdx:]
+	m.textarea.SetValue(newValue)
+	m.textarea.MoveToEnd()
+	m.textarea.InsertRune(' ')
+	return true
+}
+
 // completionsPosition returns the X and Y position for the completions popup.
 func (m *UI) completionsPosition() image.Point {
 	cur := m.textarea.Cursor()
@@ -2556,7 +2708,12 @@ func (m *UI) sendMessage(content string, attachments ...message.Attachment) tea.
 
 	var cmds []tea.Cmd
 	if !m.hasSession() {
-		newSession, err := m.com.App.Sessions.Create(context.Background(), "New Session")
+		create := m.com.App.Sessions.Create
+		if m.newSessionWorktree {
+			create = m.com.App.NewWorktreeSession
+		}
+		m.newSessionWorktree = false
+		newSession, err := create(context.Background(), "New Session")
 		if err != nil {
 			return uiutil.ReportError(err)
 		}
@@ -2633,6 +2790,44 @@ func (m *UI) cancelAgent() tea.Cmd {
 	return cancelTimerCmd()
 }
 
+// undoToSelected handles the undo key press on the selected user message.
+// The first press asks for confirmation; the second press (before the timer
+// expires) reverts the session's files to the message's checkpoint and
+// truncates the conversation, putting the message back in the editor.
+func (m *UI) undoToSelected() tea.Cmd {
+	if !m.hasSession() {
+		return nil
+	}
+	messageID := m.chat.SelectedUserMessageID()
+	if messageID == "" {
+		return uiutil.ReportWarn("Select one of your messages to undo to it")
+	}
+	if m.isAgentBusy() {
+		return uiutil.ReportWarn("Agent is busy, please wait before undoing...")
+	}
+
+	if m.pendingUndoID != messageID {
+		m.pendingUndoID = messageID
+		return tea.Batch(
+			uiutil.ReportInfo("Press U again to undo this message and everything after it"),
+			tea.Tick(cancelTimerDuration, func(time.Time) tea.Msg {
+				return undoTimerExpiredMsg{}
+			}),
+		)
+	}
+
+	m.pendingUndoID = ""
+	sessionID := m.session.ID
+	a := m.com.App
+	return func() tea.Msg {
+		result, err := app.RevertSession(context.Background(), a.Sessions, a.Messages, a.History, sessionID, messageID)
+		if err != nil {
+			return uiutil.ReportError(err)()
+		}
+		return sessionRevertedMsg{Result: result}
+	}
+}
+
 // openDialog opens a dialog by its ID.
 func (m *UI) openDialog(id string) tea.Cmd {
 	var cmds []tea.Cmd
@@ -2641,6 +2836,18 @@ func (m *UI) openDialog(id string) tea.Cmd {
 		if cmd := m.openSessionsDialog(); cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case dialog.SearchID:
+		if cmd := m.openSearchDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.GrantsID:
+		if cmd := m.openGrantsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case dialog.SkillsID:
+		if cmd := m.openSkillsDialog(); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	case dialog.ModelsID:
 		if cmd := m.openModelsDialog(); cmd != nil {
 			cmds = append(cmds, cmd)
@@ -2797,6 +3004,55 @@ func (m *UI) openSessionsDialog() tea.Cmd {
 	return nil
 }
 
+// openSearchDialog opens the message search dialog, or brings it to the front
+// if it's already open.
+func (m *UI) openSearchDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.SearchID) {
+		// Bring to front
+		m.dialog.BringToFront(dialog.SearchID)
+		return nil
+	}
+
+	m.dialog.OpenDialog(dialog.NewSearch(m.com))
+	return nil
+}
+
+// openGrantsDialog opens the permission grants dialog, or brings it to the
+// front if it's already open.
+func (m *UI) openGrantsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.GrantsID) {
+		// Bring to front
+		m.dialog.BringToFront(dialog.GrantsID)
+		return nil
+	}
+
+	dialog, err := dialog.NewGrants(m.com)
+	if err != nil {
+		return uiutil.ReportError(err)
+	}
+
+	m.dialog.OpenDialog(dialog)
+	return nil
+}
+
+// openSkillsDialog opens the skills dialog, or brings it to the front if
+// it's already open.
+func (m *UI) openSkillsDialog() tea.Cmd {
+	if m.dialog.ContainsDialog(dialog.SkillsID) {
+		// Bring to front
+		m.dialog.BringToFront(dialog.SkillsID)
+		return nil
+	}
+
+	dialog, err := dialog.NewSkills(m.com)
+	if err != nil {
+		return uiutil.ReportError(err)
+	}
+
+	m.dialog.OpenDialog(dialog)
+	return nil
+}
+
 // openFilesDialog opens the file picker dialog.
 func (m *UI) openFilesDialog() tea.Cmd {
 	if m.dialog.ContainsDialog(dialog.FilePickerID) {
@@ -2848,6 +3104,7 @@ func (m *UI) handlePermissionNotification(notification permission.PermissionNoti
 // The actual session creation happens when the user sends their first message.
 // Returns a command to reload prompt history.
 func (m *UI) newSession() tea.Cmd {
+	m.newSessionWorktree = false
 	if !m.hasSession() {
 		return nil
 	}
@@ -2861,6 +3118,7 @@ func (m *UI) newSession() tea.Cmd {
 	m.pillsExpanded = false
 	m.promptQueue = 0
 	m.pillsView = ""
+	m.retry = nil
 	m.historyReset()
 	return m.loadPromptHistory()
 }
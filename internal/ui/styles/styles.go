@@ -431,6 +431,7 @@ type Styles struct {
 		HelpText        lipgloss.Style // Help action text style
 		Area            lipgloss.Style // Pills area container
 		TodoSpinner     lipgloss.Style // Todo spinner style
+		RetryIcon       lipgloss.Style // Icon of the provider retry pill
 	}
 }
 
@@ -1338,6 +1339,7 @@ func DefaultStyles() Styles {
 	s.Pills.HelpText = s.Subtle
 	s.Pills.Area = base
 	s.Pills.TodoSpinner = base.Foreground(greenDark)
+	s.Pills.RetryIcon = base.Foreground(warning).SetString(WarningIcon)
 
 	return s
 }
@@ -0,0 +1,227 @@
+// Package worktree manages the git worktrees used to isolate sessions from
+// the main checkout, so several agents can work on the same repository in
+// parallel.
+package worktree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// BranchPrefix is the prefix of the branches created for worktrees.
+const BranchPrefix = "crush/"
+
+// ErrNotRepository is returned when the working directory is not inside a git
+// repository.
+var ErrNotRepository = errors.New("not a git repository")
+
+// Worktree is a git worktree checked out for a session.
+type Worktree struct {
+	// Path is the working directory inside the worktree. When the session was
+	// started from a subdirectory of the repository, this is the matching
+	// subdirectory of the worktree.
+	Path string
+	// Branch is the branch checked out in the worktree.
+	Branch string
+}
+
+// Dir returns the directory worktrees are created in for the given data
+// directory.
+func Dir(dataDir string) string {
+	return filepath.Join(dataDir, "worktrees")
+}
+
+// Create creates a worktree named name under the data directory, checked out
+// on a new branch based on the current HEAD of the repository containing
+// workingDir.
+func Create(ctx context.Context, workingDir, dataDir, name string) (Worktree, error) {
+	root, err := repoRoot(ctx, workingDir)
+	if err != nil {
+		return Worktree{}, err
+	}
+	if resolved, err := filepath.EvalSymlinks(workingDir); err == nil {
+		workingDir = resolved
+	}
+	rel, err := filepath.Rel(root, workingDir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = ""
+	}
+
+	dir, err := filepath.Abs(Dir(dataDir))
+	if err != nil {
+		return Worktree{}, fmt.Errorf("failed to resolve worktrees directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return Worktree{}, fmt.Errorf("failed to create worktrees directory: %w", err)
+	}
+
+	path := filepath.Join(dir, name)
+	branch := BranchPrefix + name
+	if _, err := git(ctx, root, "worktree", "add", "-b", branch, path, "HEAD"); err != nil {
+		return Worktree{}, fmt.Errorf("failed to create worktree: %w", err)
+	}
+
+	return Worktree{
+		Path:   filepath.Join(path, rel),
+		Branch: branch,
+	}, nil
+}
+
+// Diff returns the changes made in the worktree since it branched off,
+// including uncommitted changes and new files. The index of the worktree is
+// left untouched.
+func Diff(ctx context.Context, wt Worktree) (string, error) {
+	base, err := mergeBase(ctx, wt)
+	if err != nil {
+		return "", err
+	}
+	index, err := git(ctx, wt.Path, "rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return "", err
+	}
+	tmp, err := copyIndex(strings.TrimSpace(index))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp)
+
+	// Mark new files with intent-to-add in the copy of the index so they
+	// show up in the diff.
+	env := []string{"GIT_INDEX_FILE=" + tmp}
+	if _, err := gitEnv(ctx, wt.Path, env, "add", "--all", "--intent-to-add"); err != nil {
+		return "", err
+	}
+	return gitEnv(ctx, wt.Path, env, "diff", base)
+}
+
+// copyIndex copies the git index at path to a temporary file and returns
+// its path. A missing index gives an empty file, which git treats as an
+// empty index.
+func copyIndex(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read git index: %w", err)
+	}
+	tmp, err := os.CreateTemp("", "crush-index-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to copy git index: %w", err)
+	}
+	defer tmp.Close()
+	if _, err := tmp.Write(data); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to copy git index: %w", err)
+	}
+	return tmp.Name(), nil
+}
+
+// Merge commits any pending changes in the worktree with the given message
+// and merges its branch into the branch checked out in the main repository.
+// If the merge fails, it is aborted and the repository is left untouched.
+func Merge(ctx context.Context, wt Worktree, message string) error {
+	if _, err := git(ctx, wt.Path, "add", "--all"); err != nil {
+		return err
+	}
+	status, err := git(ctx, wt.Path, "status", "--porcelain")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) != "" {
+		if _, err := git(ctx, wt.Path, "commit", "--quiet", "--message", message); err != nil {
+			return fmt.Errorf("failed to commit worktree changes: %w", err)
+		}
+	}
+
+	root, err := mainRoot(ctx, wt)
+	if err != nil {
+		return err
+	}
+	if _, err := git(ctx, root, "merge", "--no-ff", "--message", message, wt.Branch); err != nil {
+		_, _ = git(ctx, root, "merge", "--abort")
+		return fmt.Errorf("failed to merge %s: %w", wt.Branch, err)
+	}
+	return nil
+}
+
+// Remove removes the worktree, discarding any changes in it, and deletes its
+// branch.
+func Remove(ctx context.Context, wt Worktree) error {
+	root, err := mainRoot(ctx, wt)
+	if err != nil {
+		return err
+	}
+	top, err := repoRoot(ctx, wt.Path)
+	if err != nil {
+		return err
+	}
+	if _, err := git(ctx, root, "worktree", "remove", "--force", top); err != nil {
+		return fmt.Errorf("failed to remove worktree: %w", err)
+	}
+	if _, err := git(ctx, root, "branch", "-D", wt.Branch); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", wt.Branch, err)
+	}
+	return nil
+}
+
+// mergeBase returns the commit the worktree branch shares with the main
+// repository's HEAD.
+func mergeBase(ctx context.Context, wt Worktree) (string, error) {
+	root, err := mainRoot(ctx, wt)
+	if err != nil {
+		return "", err
+	}
+	base, err := git(ctx, root, "merge-base", "HEAD", wt.Branch)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(base), nil
+}
+
+// mainRoot returns the root of the main working tree the worktree belongs
+// to.
+func mainRoot(ctx context.Context, wt Worktree) (string, error) {
+	if _, err := os.Stat(wt.Path); err != nil {
+		return "", fmt.Errorf("worktree %s is missing: %w", wt.Path, err)
+	}
+	commonDir, err := git(ctx, wt.Path, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(strings.TrimSpace(commonDir)), nil
+}
+
+func repoRoot(ctx context.Context, dir string) (string, error) {
+	out, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrNotRepository, dir)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	return gitEnv(ctx, dir, nil, args...)
+}
+
+// gitEnv runs git with env added to the environment.
+func gitEnv(ctx context.Context, dir string, env []string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,119 @@
+package worktree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func initRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	t.Setenv("GIT_AUTHOR_NAME", "Crush")
+	t.Setenv("GIT_AUTHOR_EMAIL", "crush@charm.land")
+	t.Setenv("GIT_COMMITTER_NAME", "Crush")
+	t.Setenv("GIT_COMMITTER_EMAIL", "crush@charm.land")
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch", "main"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		_, err := git(t.Context(), dir, args...)
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(".crush\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("hello\n"), 0o644))
+	_, err = git(t.Context(), dir, "add", "--all")
+	require.NoError(t, err)
+	_, err = git(t.Context(), dir, "commit", "--quiet", "--message", "initial")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestCreateNotRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	_, err := Create(t.Context(), dir, filepath.Join(dir, ".crush"), "abc")
+	require.ErrorIs(t, err, ErrNotRepository)
+}
+
+func TestCreateDiffMerge(t *testing.T) {
+	repo := initRepo(t)
+	dataDir := filepath.Join(repo, ".crush")
+
+	wt, err := Create(t.Context(), repo, dataDir, "abc")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(Dir(dataDir), "abc"), wt.Path)
+	require.Equal(t, "crush/abc", wt.Branch)
+	require.FileExists(t, filepath.Join(wt.Path, "README.md"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Path, "README.md"), []byte("hello, world\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Path, "new.txt"), []byte("new\n"), 0o644))
+
+	diff, err := Diff(t.Context(), wt)
+	require.NoError(t, err)
+	require.Contains(t, diff, "+hello, world")
+	require.Contains(t, diff, "new.txt")
+
+	// The diff doesn't stage anything.
+	status, err := git(t.Context(), wt.Path, "status", "--porcelain")
+	require.NoError(t, err)
+	require.Contains(t, status, "?? new.txt")
+
+	// The main checkout is untouched until the worktree is merged.
+	content, err := os.ReadFile(filepath.Join(repo, "README.md"))
+	require.NoError(t, err)
+	require.Equal(t, "hello\n", string(content))
+
+	require.NoError(t, Merge(t.Context(), wt, "Merge session"))
+	content, err = os.ReadFile(filepath.Join(repo, "README.md"))
+	require.NoError(t, err)
+	require.Equal(t, "hello, world\n", string(content))
+	require.FileExists(t, filepath.Join(repo, "new.txt"))
+
+	require.NoError(t, Remove(t.Context(), wt))
+	require.NoDirExists(t, wt.Path)
+}
+
+func TestCreateFromSubdirectory(t *testing.T) {
+	repo := initRepo(t)
+	sub := filepath.Join(repo, "sub")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, "main.go"), []byte("package main\n"), 0o644))
+	_, err := git(t.Context(), repo, "add", "--all")
+	require.NoError(t, err)
+	_, err = git(t.Context(), repo, "commit", "--quiet", "--message", "sub")
+	require.NoError(t, err)
+
+	wt, err := Create(t.Context(), sub, filepath.Join(repo, ".crush"), "abc")
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(wt.Path, filepath.Join("abc", "sub")))
+	require.FileExists(t, filepath.Join(wt.Path, "main.go"))
+}
+
+func TestRemoveDiscardsChanges(t *testing.T) {
+	repo := initRepo(t)
+
+	wt, err := Create(t.Context(), repo, filepath.Join(repo, ".crush"), "abc")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Path, "scratch.txt"), []byte("x\n"), 0o644))
+
+	require.NoError(t, Remove(t.Context(), wt))
+	require.NoDirExists(t, wt.Path)
+	require.NoFileExists(t, filepath.Join(repo, "scratch.txt"))
+
+	branches, err := git(t.Context(), repo, "branch", "--list", wt.Branch)
+	require.NoError(t, err)
+	require.Empty(t, strings.TrimSpace(branches))
+}
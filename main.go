@@ -7,10 +7,14 @@ import (
 	"os"
 
 	"github.com/charmbracelet/crush/internal/cmd"
+	"github.com/charmbracelet/crush/internal/shell"
 	_ "github.com/joho/godotenv/autoload"
 )
 
 func main() {
+	// The bash tool's sandbox re-executes crush to set itself up.
+	shell.SandboxInit()
+
 	if os.Getenv("CRUSH_PROFILE") != "" {
 		go func() {
 			slog.Info("Serving pprof at localhost:6060")